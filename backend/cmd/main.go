@@ -2,11 +2,16 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/dukunuu/munkhjin-diplom/backend/config"
 	"github.com/dukunuu/munkhjin-diplom/backend/db"
+	"github.com/dukunuu/munkhjin-diplom/backend/logging"
 	"github.com/dukunuu/munkhjin-diplom/backend/server"
+	"github.com/dukunuu/munkhjin-diplom/backend/tracing"
 
 	_ "github.com/dukunuu/munkhjin-diplom/backend/docs"
 )
@@ -27,22 +32,59 @@ import (
 // @BasePath  /
 
 // @schemes   http https
+
+// @securityDefinitions.apikey  UserHeader
+// @in                          header
+// @name                        X-User
+// @description                 Username of the caller (app_user.username). Required for clinic-scoped routes.
+
+// @securityDefinitions.apikey  ClinicHeader
+// @in                          header
+// @name                        X-Clinic-ID
+// @description                 Clinic to act in. Optional when the user is bound to a single clinic.
+
+// @securityDefinitions.apikey  BearerToken
+// @in                          header
+// @name                        Authorization
+// @description                 "Bearer <api token>", an alternative to X-User.
 func main() {
-	ctx := context.Background()
+	// SIGINT/SIGTERM cancel ctx, which starts a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Could not load config: %v", err);
+		slog.Error("Could not load config", "err", err)
+		os.Exit(1)
 	}
 
-	db, err := db.Init(cfg.DB_Url, ctx)
+	logger := logging.New(cfg)
+	slog.SetDefault(logger) // Also routes the standard log package through the redacting handler
+
+	shutdownTracing, err := tracing.Init(ctx, cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize DB: %v", err);
+		logger.Error("Failed to initialize tracing", "err", err)
+		os.Exit(1)
 	}
 
-	srv := server.Init(db, cfg.Model_Url)
+	db, err := db.Init(cfg.DB_Url, ctx, logger, cfg.Environment == "production")
+	if err != nil {
+		logger.Error("Failed to initialize DB", "err", err)
+		os.Exit(1)
+	}
 
-	err = srv.Start(cfg.Port)
+	srv := server.Init(db, cfg, logger)
+
+	err = srv.Start(ctx)
+	// Requests and workers are done with the pool by now
+	db.Close()
+	// Flush spans still buffered for the exporter
+	if err := shutdownTracing(context.Background()); err != nil {
+		logger.Error("Failed to flush traces", "err", err)
+	}
 	if err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		logger.Error("Server stopped with error", "err", err)
+		os.Exit(1)
 	}
+	logger.Info("Server stopped")
 }
@@ -3,6 +3,8 @@ package common
 import (
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func GetString(key, fallback string) string {
@@ -22,3 +24,51 @@ func GetInt(key string, fallback int) int {
 	}
 	return parsedVal
 }
+
+func GetBool(key string, fallback bool) bool {
+	val := os.Getenv(key); if val == "" {
+		return fallback
+	}
+	parsedVal, err := strconv.ParseBool(val);
+	if err != nil {
+		return fallback
+	}
+	return parsedVal
+}
+
+// GetList splits a comma-separated value, dropping empty items.
+func GetList(key string, fallback []string) []string {
+	val := os.Getenv(key); if val == "" {
+		return fallback
+	}
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// GetDuration parses values like "15s" or "2m".
+func GetDuration(key string, fallback time.Duration) time.Duration {
+	val := os.Getenv(key); if val == "" {
+		return fallback
+	}
+	parsedVal, err := time.ParseDuration(val);
+	if err != nil {
+		return fallback
+	}
+	return parsedVal
+}
+
+func GetFloat(key string, fallback float64) float64 {
+	val := os.Getenv(key); if val == "" {
+		return fallback
+	}
+	parsedVal, err := strconv.ParseFloat(val, 64);
+	if err != nil {
+		return fallback
+	}
+	return parsedVal
+}
@@ -2,17 +2,97 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/dukunuu/munkhjin-diplom/backend/common"
 )
 
 type Config struct {
+	Environment string // "development" or "production"
+	Log_Format string // "json" or "text"; defaults to json in production
+	Log_Level string // debug, info, warn or error
 	Port			string
 	DB_Url		string
 	Model_Url string
+	Break_Glass_Minutes int // Length of a break-glass access window
+	Rate_Limit_Store string // "memory" (per replica) or "postgres" (shared by all replicas)
+	Rate_Limit_Default RateLimit // Every route not in a more specific group
+	Rate_Limit_List RateLimit // List endpoints (GET /patients, /symptoms, ...)
+	Rate_Limit_Predict RateLimit // POST /predict, which calls the model service
+	CORS CORS
+	HTTP HTTP
+	TLS_Cert_File string // Serve HTTPS when both TLS files are set
+	TLS_Key_File string
+	Tracing_Exporter string // "none", "otlp" or "stdout"
+	Tracing_Endpoint string // OTLP/HTTP collector URL
+	Tracing_Sample_Ratio float64 // Fraction of new traces to record (0..1)
+	Readiness_Cache_TTL time.Duration // How long a /readyz result is reused
+	Readiness_Check_Timeout time.Duration // Deadline for each readiness check
+	Max_Body_Bytes int64 // Largest accepted request body
+	Idempotency_TTL time.Duration // How long a response to an Idempotency-Key is replayed
+	Patient_Retention time.Duration // How long a deleted patient can be restored before it is purged
+	Patient_Purge_Interval time.Duration // How often the purge job runs
+}
+
+// HTTP holds the http.Server limits.
+type HTTP struct {
+	Read_Timeout        time.Duration
+	Read_Header_Timeout time.Duration
+	Write_Timeout       time.Duration // Must cover the model service call in /predict
+	Idle_Timeout        time.Duration
+	Max_Header_Bytes    int
+	Shutdown_Timeout    time.Duration // How long in-flight requests may take to drain on SIGINT/SIGTERM
+}
+
+// CORS lists what browsers on other origins (the frontend) may do.
+type CORS struct {
+	Allowed_Origins   []string // Exact origins, or "*" for any
+	Allowed_Methods   []string
+	Allowed_Headers   []string
+	Allow_Credentials bool
+	Max_Age           int // Seconds browsers may cache a preflight response
+}
+
+// RateLimit is a token-bucket budget per caller: Per_Minute tokens are
+// refilled each minute, up to Burst. A zero Per_Minute disables the limit.
+type RateLimit struct {
+	Per_Minute int
+	Burst      int
+}
+
+func loadRateLimit(prefix string, perMinute, burst int) (RateLimit, error) {
+	limit := RateLimit{
+		Per_Minute: common.GetInt(prefix+"_PER_MINUTE", perMinute),
+		Burst: common.GetInt(prefix+"_BURST", burst),
+	}
+	if limit.Per_Minute < 0 {
+		return limit, fmt.Errorf("%s_PER_MINUTE must not be negative", prefix);
+	}
+	if limit.Per_Minute > 0 && limit.Burst <= 0 {
+		return limit, fmt.Errorf("%s_BURST must be positive", prefix);
+	}
+	return limit, nil
 }
 
 func Load() (*Config, error){
+	environment := common.GetString("APP_ENV", "development")
+	if environment != "development" && environment != "production" {
+		return nil, fmt.Errorf("APP_ENV must be development or production");
+	}
+	defaultLogFormat := "text"
+	if environment == "production" {
+		defaultLogFormat = "json"
+	}
+	logFormat := common.GetString("LOG_FORMAT", defaultLogFormat)
+	if logFormat != "json" && logFormat != "text" {
+		return nil, fmt.Errorf("LOG_FORMAT must be json or text");
+	}
+	logLevel := common.GetString("LOG_LEVEL", "info")
+	if err := new(slog.Level).UnmarshalText([]byte(logLevel)); err != nil {
+		return nil, fmt.Errorf("invalid LOG_LEVEL: %w", err);
+	}
+
 	port := common.GetString("PORT", ":8080");
 
 	db := common.GetString("DB_URL", "");
@@ -22,9 +102,103 @@ func Load() (*Config, error){
 
 	modelUrl := common.GetString("MODEL_URL", "http://flask_ml_service:5000/predict")
 
+	breakGlassMinutes := common.GetInt("BREAK_GLASS_MINUTES", 60)
+	if breakGlassMinutes <= 0 {
+		return nil, fmt.Errorf("BREAK_GLASS_MINUTES must be positive");
+	}
+
+	rateLimitStore := common.GetString("RATE_LIMIT_STORE", "memory")
+	if rateLimitStore != "memory" && rateLimitStore != "postgres" {
+		return nil, fmt.Errorf("RATE_LIMIT_STORE must be memory or postgres");
+	}
+	defaultLimit, err := loadRateLimit("RATE_LIMIT_DEFAULT", 300, 60)
+	if err != nil {
+		return nil, err
+	}
+	listLimit, err := loadRateLimit("RATE_LIMIT_LIST", 60, 20)
+	if err != nil {
+		return nil, err
+	}
+	predictLimit, err := loadRateLimit("RATE_LIMIT_PREDICT", 10, 5)
+	if err != nil {
+		return nil, err
+	}
+
+	cors := CORS{
+		Allowed_Origins: common.GetList("CORS_ALLOWED_ORIGINS", []string{"http://localhost:5173", "http://localhost:3000"}),
+		Allowed_Methods: common.GetList("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE"}),
+		Allowed_Headers: common.GetList("CORS_ALLOWED_HEADERS", []string{"Accept", "Authorization", "Content-Type", "X-User", "X-Clinic-ID", "If-Match", "If-None-Match", "Idempotency-Key"}),
+		Allow_Credentials: common.GetBool("CORS_ALLOW_CREDENTIALS", false),
+		Max_Age: common.GetInt("CORS_MAX_AGE", 600),
+	}
+	for _, origin := range cors.Allowed_Origins {
+		if origin == "*" && cors.Allow_Credentials {
+			return nil, fmt.Errorf("CORS_ALLOWED_ORIGINS cannot be * when CORS_ALLOW_CREDENTIALS is set");
+		}
+	}
+
+	httpLimits := HTTP{
+		Read_Timeout: common.GetDuration("HTTP_READ_TIMEOUT", 15*time.Second),
+		Read_Header_Timeout: common.GetDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		Write_Timeout: common.GetDuration("HTTP_WRITE_TIMEOUT", 45*time.Second),
+		Idle_Timeout: common.GetDuration("HTTP_IDLE_TIMEOUT", 120*time.Second),
+		Max_Header_Bytes: common.GetInt("HTTP_MAX_HEADER_BYTES", 1<<20),
+		Shutdown_Timeout: common.GetDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+	}
+
+	tlsCert := common.GetString("TLS_CERT_FILE", "")
+	tlsKey := common.GetString("TLS_KEY_FILE", "")
+	if (tlsCert == "") != (tlsKey == "") {
+		return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together");
+	}
+
+	tracingExporter := common.GetString("TRACING_EXPORTER", "none")
+	if tracingExporter != "none" && tracingExporter != "otlp" && tracingExporter != "stdout" {
+		return nil, fmt.Errorf("TRACING_EXPORTER must be none, otlp or stdout");
+	}
+	tracingSampleRatio := common.GetFloat("TRACING_SAMPLE_RATIO", 1)
+	if tracingSampleRatio < 0 || tracingSampleRatio > 1 {
+		return nil, fmt.Errorf("TRACING_SAMPLE_RATIO must be between 0 and 1");
+	}
+
+	idempotencyTTL := common.GetDuration("IDEMPOTENCY_TTL", 24*time.Hour)
+	if idempotencyTTL < time.Second {
+		return nil, fmt.Errorf("IDEMPOTENCY_TTL must be at least 1s");
+	}
+
+	patientRetention := common.GetDuration("PATIENT_RETENTION", 30*24*time.Hour)
+	if patientRetention < time.Hour || patientRetention > 50*365*24*time.Hour {
+		return nil, fmt.Errorf("PATIENT_RETENTION must be between 1h and 50 years");
+	}
+	patientPurgeInterval := common.GetDuration("PATIENT_PURGE_INTERVAL", time.Hour)
+	if patientPurgeInterval < time.Minute {
+		return nil, fmt.Errorf("PATIENT_PURGE_INTERVAL must be at least 1m");
+	}
+
 	return &Config{
+		Environment: environment,
+		Log_Format: logFormat,
+		Log_Level: logLevel,
 		Port: port,
 		DB_Url: db,
 		Model_Url: modelUrl,
+		Break_Glass_Minutes: breakGlassMinutes,
+		Rate_Limit_Store: rateLimitStore,
+		Rate_Limit_Default: defaultLimit,
+		Rate_Limit_List: listLimit,
+		Rate_Limit_Predict: predictLimit,
+		CORS: cors,
+		HTTP: httpLimits,
+		TLS_Cert_File: tlsCert,
+		TLS_Key_File: tlsKey,
+		Tracing_Exporter: tracingExporter,
+		Tracing_Endpoint: common.GetString("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+		Tracing_Sample_Ratio: tracingSampleRatio,
+		Readiness_Cache_TTL: common.GetDuration("READINESS_CACHE_TTL", 5*time.Second),
+		Readiness_Check_Timeout: common.GetDuration("READINESS_CHECK_TIMEOUT", 2*time.Second),
+		Max_Body_Bytes: int64(common.GetInt("MAX_BODY_BYTES", 1<<20)),
+		Idempotency_TTL: idempotencyTTL,
+		Patient_Retention: patientRetention,
+		Patient_Purge_Interval: patientPurgeInterval,
 	}, nil
 }
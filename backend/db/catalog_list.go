@@ -0,0 +1,229 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var symptomSortKeys = map[string]sortKey{
+	"name":  {columns: []string{"s.symptom_name", "s.symptom_id"}, types: []string{"varchar", "int"}},
+	"usage": {columns: []string{"COALESCE(u.usage_count, 0)", "s.symptom_id"}, types: []string{"int", "int"}},
+	"id":    {columns: []string{"s.symptom_id"}, types: []string{"int"}},
+}
+
+var diseaseSortKeys = map[string]sortKey{
+	"name":  {columns: []string{"d.disease_name", "d.disease_id"}, types: []string{"varchar", "int"}},
+	"code":  {columns: []string{"d.disease_code", "d.disease_id"}, types: []string{"varchar", "int"}},
+	"usage": {columns: []string{"COALESCE(u.usage_count, 0)", "d.disease_id"}, types: []string{"int", "int"}},
+	"id":    {columns: []string{"d.disease_id"}, types: []string{"int"}},
+}
+
+// SymptomSortFields lists the sorts ListSymptomsPage accepts.
+func SymptomSortFields() []string {
+	return sortFields(symptomSortKeys)
+}
+
+// DiseaseSortFields lists the sorts ListDiseasesPage accepts.
+func DiseaseSortFields() []string {
+	return sortFields(diseaseSortKeys)
+}
+
+// SymptomWithUsage is a symptom with the number of times patients reported it.
+type SymptomWithUsage struct {
+	Symptom
+	UsageCount int32
+}
+
+// DiseaseWithUsage is a disease with the number of instances recorded of it.
+type DiseaseWithUsage struct {
+	Disease
+	UsageCount int32
+}
+
+// SymptomSortKeyValues returns s's values for the sort's key, in the text form
+// ListSymptomsPage expects back in After.
+func SymptomSortKeyValues(s SymptomWithUsage, sort string) []string {
+	id := strconv.Itoa(int(s.SymptomID))
+	switch sort {
+	case "name":
+		return []string{s.SymptomName, id}
+	case "usage":
+		return []string{strconv.Itoa(int(s.UsageCount)), id}
+	default:
+		return []string{id}
+	}
+}
+
+// DiseaseSortKeyValues returns d's values for the sort's key, in the text form
+// ListDiseasesPage expects back in After.
+func DiseaseSortKeyValues(d DiseaseWithUsage, sort string) []string {
+	id := strconv.Itoa(int(d.DiseaseID))
+	switch sort {
+	case "name":
+		return []string{d.DiseaseName, id}
+	case "code":
+		return []string{d.DiseaseCode, id}
+	case "usage":
+		return []string{strconv.Itoa(int(d.UsageCount)), id}
+	default:
+		return []string{id}
+	}
+}
+
+// CatalogFilter narrows a catalog listing.
+type CatalogFilter struct {
+	// Search matches names, aliases and descriptions containing it,
+	// names and aliases holding a similar word, and disease codes starting
+	// with it; null lists everything.
+	Search pgtype.Text
+	// IncludeDeprecated lists deprecated entries too; pickers leave them out.
+	IncludeDeprecated bool
+}
+
+type ListCatalogPageParams struct {
+	Filter CatalogFilter
+	PageParams
+}
+
+// likeEscaper makes a term match literally inside an ILIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func newSymptomQuery(f CatalogFilter) *listQuery {
+	b := &listQuery{}
+	if !f.IncludeDeprecated {
+		b.add("s.deprecated_at IS NULL")
+	}
+	if search := f.Search; search.Valid {
+		term, contains := b.arg(search.String), b.arg("%"+likeEscaper.Replace(search.String)+"%")
+		b.add("(s.symptom_name ILIKE " + contains +
+			" OR " + term + " <% s.symptom_name" +
+			" OR s.symptom_description ILIKE " + contains +
+			" OR EXISTS (SELECT 1 FROM symptom_alias a WHERE a.symptom_id = s.symptom_id AND (a.alias ILIKE " + contains + " OR " + term + " <% a.alias)))")
+	}
+	return b
+}
+
+func newDiseaseQuery(f CatalogFilter) *listQuery {
+	b := &listQuery{}
+	if !f.IncludeDeprecated {
+		b.add("d.deprecated_at IS NULL")
+	}
+	if search := f.Search; search.Valid {
+		escaped := likeEscaper.Replace(search.String)
+		term, contains, prefix := b.arg(search.String), b.arg("%"+escaped+"%"), b.arg(escaped+"%")
+		b.add("(d.disease_name ILIKE " + contains +
+			" OR " + term + " <% d.disease_name" +
+			" OR d.disease_code ILIKE " + prefix +
+			" OR d.disease_description ILIKE " + contains +
+			" OR EXISTS (SELECT 1 FROM disease_alias a WHERE a.disease_id = d.disease_id AND (a.alias ILIKE " + contains + " OR " + term + " <% a.alias)))")
+	}
+	return b
+}
+
+// ListSymptomsPage lists one page of the symptom catalog with usage counts,
+// by keyset pagination like ListPatientsPage.
+func (q *Queries) ListSymptomsPage(ctx context.Context, arg ListCatalogPageParams) ([]SymptomWithUsage, error) {
+	b := newSymptomQuery(arg.Filter)
+	page, err := b.page(symptomSortKeys, arg.PageParams)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.db.Query(ctx,
+		"SELECT s.symptom_id, s.symptom_name, s.symptom_description, s.created_at, s.updated_at, s.version, s.deprecated_at, s.replaced_by, COALESCE(u.usage_count, 0)"+
+			" FROM symptoms s LEFT JOIN symptom_usage u ON u.symptom_id = s.symptom_id"+b.whereSQL()+page,
+		b.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SymptomWithUsage
+	for rows.Next() {
+		var i SymptomWithUsage
+		if err := rows.Scan(
+			&i.SymptomID,
+			&i.SymptomName,
+			&i.SymptomDescription,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeprecatedAt,
+			&i.ReplacedBy,
+			&i.UsageCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if arg.Backward {
+		slices.Reverse(items)
+	}
+	return items, nil
+}
+
+// ListDiseasesPage lists one page of the disease catalog with usage counts,
+// by keyset pagination like ListPatientsPage.
+func (q *Queries) ListDiseasesPage(ctx context.Context, arg ListCatalogPageParams) ([]DiseaseWithUsage, error) {
+	b := newDiseaseQuery(arg.Filter)
+	page, err := b.page(diseaseSortKeys, arg.PageParams)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.db.Query(ctx,
+		"SELECT d.disease_id, d.disease_name, d.disease_code, d.disease_description, d.disease_treatment, d.created_at, d.updated_at, d.version, d.deprecated_at, d.replaced_by, COALESCE(u.usage_count, 0)"+
+			" FROM disease d LEFT JOIN disease_usage u ON u.disease_id = d.disease_id"+b.whereSQL()+page,
+		b.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DiseaseWithUsage
+	for rows.Next() {
+		var i DiseaseWithUsage
+		if err := rows.Scan(
+			&i.DiseaseID,
+			&i.DiseaseName,
+			&i.DiseaseCode,
+			&i.DiseaseDescription,
+			&i.DiseaseTreatment,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeprecatedAt,
+			&i.ReplacedBy,
+			&i.UsageCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if arg.Backward {
+		slices.Reverse(items)
+	}
+	return items, nil
+}
+
+// CountSymptoms counts the symptoms matching the filter across all pages.
+func (q *Queries) CountSymptoms(ctx context.Context, f CatalogFilter) (int64, error) {
+	b := newSymptomQuery(f)
+	var count int64
+	err := q.db.QueryRow(ctx, "SELECT count(*) FROM symptoms s"+b.whereSQL(), b.args...).Scan(&count)
+	return count, err
+}
+
+// CountDiseases counts the diseases matching the filter across all pages.
+func (q *Queries) CountDiseases(ctx context.Context, f CatalogFilter) (int64, error) {
+	b := newDiseaseQuery(f)
+	var count int64
+	err := q.db.QueryRow(ctx, "SELECT count(*) FROM disease d"+b.whereSQL(), b.args...).Scan(&count)
+	return count, err
+}
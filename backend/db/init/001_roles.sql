@@ -0,0 +1,21 @@
+-- Database roles, created once by the postgres image when it initializes a
+-- fresh volume (mounted at /docker-entrypoint-initdb.d).
+--
+-- app_owner owns the schema and runs the migrations (MIGRATE_DB_URL).
+-- app_user is the role the API connects as (DB_URL): it may read and write
+-- rows but owns nothing. Neither is a superuser or BYPASSRLS, so the
+-- clinic_isolation policies apply to both.
+CREATE ROLE app_owner LOGIN PASSWORD 'owner_password' NOSUPERUSER NOBYPASSRLS NOCREATEDB NOCREATEROLE;
+CREATE ROLE app_user LOGIN PASSWORD 'app_password' NOSUPERUSER NOBYPASSRLS NOCREATEDB NOCREATEROLE;
+
+ALTER DATABASE app_db OWNER TO app_owner;
+ALTER SCHEMA public OWNER TO app_owner;
+
+GRANT CONNECT ON DATABASE app_db TO app_user;
+GRANT USAGE ON SCHEMA public TO app_user;
+
+-- Everything the migrations create is usable by the API
+ALTER DEFAULT PRIVILEGES FOR ROLE app_owner IN SCHEMA public
+    GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO app_user;
+ALTER DEFAULT PRIVILEGES FOR ROLE app_owner IN SCHEMA public
+    GRANT USAGE, SELECT ON SEQUENCES TO app_user;
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrInvalidPageKey is returned for After values that do not fit the sort key,
+// such as those of a tampered cursor.
+var ErrInvalidPageKey = errors.New("page key does not match the sort")
+
+// timestampKeyLayout keeps the full microsecond precision of a TIMESTAMP column.
+const timestampKeyLayout = "2006-01-02T15:04:05.999999"
+
+// PageParams selects one page of a keyset-paginated listing.
+type PageParams struct {
+	Sort string // One of the listing's sort fields
+	Desc bool
+	// After continues from the row with these sort key values (see the
+	// listing's SortKeyValues function); nil starts at the beginning.
+	After []string
+	// Backward pages towards the beginning: the rows just before After. They
+	// are still returned in sort order.
+	Backward bool
+	Limit    int32
+}
+
+// sortKey is an indexed key rows can be listed in. Every key ends in the
+// primary key, so each row has a unique position to continue a page from.
+type sortKey struct {
+	columns []string // SQL expressions
+	types   []string // SQL types of the columns, for casting cursor values
+}
+
+// sortFields lists the names of a listing's sort keys.
+func sortFields(keys map[string]sortKey) []string {
+	fields := make([]string, 0, len(keys))
+	for field := range keys {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// parseKeyValue reads a sort key value written by a SortKeyValues function.
+func parseKeyValue(v, typ string) (any, error) {
+	switch typ {
+	case "int":
+		id, err := strconv.ParseInt(v, 10, 32)
+		return int32(id), err
+	case "date":
+		t, err := time.Parse(time.DateOnly, v)
+		return pgtype.Date{Time: t, Valid: true}, err
+	case "timestamp":
+		t, err := time.Parse(timestampKeyLayout, v)
+		return pgtype.Timestamp{Time: t, Valid: true}, err
+	default:
+		return v, nil
+	}
+}
+
+// listQuery collects the WHERE conditions and arguments of a listing as they
+// are built.
+type listQuery struct {
+	where []string
+	args  []any
+}
+
+// arg adds a query argument and returns its placeholder.
+func (b *listQuery) arg(v any) string {
+	b.args = append(b.args, v)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *listQuery) add(condition string) {
+	b.where = append(b.where, condition)
+}
+
+func (b *listQuery) whereSQL() string {
+	if len(b.where) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.where, " AND ")
+}
+
+// page limits the query to the page's rows: those past After in the sort
+// order (or before it, walking backward). It returns the ORDER BY and LIMIT
+// clauses to finish the query with.
+func (b *listQuery) page(keys map[string]sortKey, p PageParams) (string, error) {
+	key, ok := keys[p.Sort]
+	if !ok {
+		return "", errors.New("unknown sort " + strconv.Quote(p.Sort))
+	}
+
+	// Walking backward reads the key in the opposite order
+	desc := p.Desc != p.Backward
+	if p.After != nil {
+		if len(p.After) != len(key.columns) {
+			return "", ErrInvalidPageKey
+		}
+		values := make([]string, len(p.After))
+		for i, v := range p.After {
+			value, err := parseKeyValue(v, key.types[i])
+			if err != nil {
+				return "", ErrInvalidPageKey
+			}
+			values[i] = b.arg(value) + "::" + key.types[i]
+		}
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		b.add("(" + strings.Join(key.columns, ", ") + ") " + op + " (" + strings.Join(values, ", ") + ")")
+	}
+
+	order := make([]string, len(key.columns))
+	for i, column := range key.columns {
+		order[i] = column
+		if desc {
+			order[i] += " DESC"
+		}
+	}
+	return " ORDER BY " + strings.Join(order, ", ") + " LIMIT " + b.arg(p.Limit), nil
+}
@@ -0,0 +1,151 @@
+package db
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestListQueryPage(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    PageParams
+		wantWhere string
+		wantTail  string
+		wantArgs  []any
+		wantErr   error
+	}{
+		{
+			name:     "first page ascending",
+			params:   PageParams{Sort: "name", Limit: 11},
+			wantTail: " ORDER BY p.lastname, p.firstname, p.patient_id LIMIT $1",
+			wantArgs: []any{int32(11)},
+		},
+		{
+			name:     "first page descending",
+			params:   PageParams{Sort: "id", Desc: true, Limit: 11},
+			wantTail: " ORDER BY p.patient_id DESC LIMIT $1",
+			wantArgs: []any{int32(11)},
+		},
+		{
+			name:      "after a row ascending",
+			params:    PageParams{Sort: "name", After: []string{"Дорж", "Бат", "7"}, Limit: 11},
+			wantWhere: " WHERE (p.lastname, p.firstname, p.patient_id) > ($1::varchar, $2::varchar, $3::int)",
+			wantTail:  " ORDER BY p.lastname, p.firstname, p.patient_id LIMIT $4",
+			wantArgs:  []any{"Дорж", "Бат", int32(7), int32(11)},
+		},
+		{
+			name:      "after a row descending",
+			params:    PageParams{Sort: "birthdate", Desc: true, After: []string{"1999-03-22", "7"}, Limit: 11},
+			wantWhere: " WHERE (p.birthdate, p.patient_id) < ($1::date, $2::int)",
+			wantTail:  " ORDER BY p.birthdate DESC, p.patient_id DESC LIMIT $3",
+			wantArgs:  []any{pgtype.Date{Time: time.Date(1999, 3, 22, 0, 0, 0, 0, time.UTC), Valid: true}, int32(7), int32(11)},
+		},
+		{
+			name:      "backward ascending reads the key descending",
+			params:    PageParams{Sort: "id", After: []string{"7"}, Backward: true, Limit: 11},
+			wantWhere: " WHERE (p.patient_id) < ($1::int)",
+			wantTail:  " ORDER BY p.patient_id DESC LIMIT $2",
+			wantArgs:  []any{int32(7), int32(11)},
+		},
+		{
+			name:      "backward descending reads the key ascending",
+			params:    PageParams{Sort: "created_at", Desc: true, After: []string{"2025-01-02T03:04:05.123456", "7"}, Backward: true, Limit: 11},
+			wantWhere: " WHERE (p.created_at, p.patient_id) > ($1::timestamp, $2::int)",
+			wantTail:  " ORDER BY p.created_at, p.patient_id LIMIT $3",
+			wantArgs:  []any{pgtype.Timestamp{Time: time.Date(2025, 1, 2, 3, 4, 5, 123456000, time.UTC), Valid: true}, int32(7), int32(11)},
+		},
+		{
+			name:    "key of another sort",
+			params:  PageParams{Sort: "name", After: []string{"7"}, Limit: 11},
+			wantErr: ErrInvalidPageKey,
+		},
+		{
+			name:    "ID that is not a number",
+			params:  PageParams{Sort: "id", After: []string{"7; DROP TABLE patient"}, Limit: 11},
+			wantErr: ErrInvalidPageKey,
+		},
+		{
+			name:    "ID out of range",
+			params:  PageParams{Sort: "id", After: []string{"2147483648"}, Limit: 11},
+			wantErr: ErrInvalidPageKey,
+		},
+		{
+			name:    "malformed date",
+			params:  PageParams{Sort: "birthdate", After: []string{"22/03/1999", "7"}, Limit: 11},
+			wantErr: ErrInvalidPageKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b listQuery
+			tail, err := b.page(patientSortKeys, tt.params)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("page: %v", err)
+			}
+			if got := b.whereSQL(); got != tt.wantWhere {
+				t.Errorf("where = %q, want %q", got, tt.wantWhere)
+			}
+			if tail != tt.wantTail {
+				t.Errorf("tail = %q, want %q", tail, tt.wantTail)
+			}
+			if !reflect.DeepEqual(b.args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", b.args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestListQueryPageUnknownSort(t *testing.T) {
+	var b listQuery
+	if _, err := b.page(patientSortKeys, PageParams{Sort: "email", Limit: 11}); err == nil {
+		t.Error("unknown sort accepted")
+	}
+}
+
+// A row's sort key values must read back as the same values, or paging would
+// skip or repeat rows.
+func TestPatientSortKeyValuesRoundTrip(t *testing.T) {
+	p := Patient{
+		PatientID: 7,
+		Lastname:  "Дорж",
+		Firstname: "Бат",
+		Birthdate: pgtype.Date{Time: time.Date(1999, 3, 22, 0, 0, 0, 0, time.UTC), Valid: true},
+		CreatedAt: pgtype.Timestamp{Time: time.Date(2025, 1, 2, 3, 4, 5, 123456000, time.UTC), Valid: true},
+		UpdatedAt: pgtype.Timestamp{Time: time.Date(2025, 6, 7, 8, 9, 10, 0, time.UTC), Valid: true},
+	}
+	want := map[string][]any{
+		"name":       {p.Lastname, p.Firstname, p.PatientID},
+		"birthdate":  {p.Birthdate, p.PatientID},
+		"created_at": {p.CreatedAt, p.PatientID},
+		"updated_at": {p.UpdatedAt, p.PatientID},
+		"id":         {p.PatientID},
+	}
+	for _, sort := range PatientSortFields() {
+		key := patientSortKeys[sort]
+		values := PatientSortKeyValues(p, sort)
+		if len(values) != len(key.columns) {
+			t.Errorf("%s: %d values for %d columns", sort, len(values), len(key.columns))
+			continue
+		}
+		for i, v := range values {
+			got, err := parseKeyValue(v, key.types[i])
+			if err != nil {
+				t.Errorf("%s: value %q: %v", sort, v, err)
+				continue
+			}
+			if !reflect.DeepEqual(got, want[sort][i]) {
+				t.Errorf("%s: value %q reads back as %#v, want %#v", sort, v, got, want[sort][i])
+			}
+		}
+	}
+}
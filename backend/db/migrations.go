@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// schemaFiles are the golang-migrate migrations this build expects to run against.
+//
+//go:embed schema/*.up.sql
+var schemaFiles embed.FS
+
+// ExpectedMigrationVersion is the version of the newest migration shipped
+// with this build (the numeric prefix of its file name).
+func ExpectedMigrationVersion() int64 {
+	entries, err := schemaFiles.ReadDir("schema")
+	if err != nil {
+		return 0
+	}
+	var latest int64
+	for _, entry := range entries {
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		if version, err := strconv.ParseInt(prefix, 10, 64); err == nil && version > latest {
+			latest = version
+		}
+	}
+	return latest
+}
+
+// MigrationVersion reads the version golang-migrate recorded in
+// schema_migrations, and whether a failed migration left it dirty.
+func MigrationVersion(ctx context.Context, pool *pgxpool.Pool) (int64, bool, error) {
+	var version int64
+	var dirty bool
+	err := pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	return version, dirty, err
+}
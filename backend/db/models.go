@@ -8,6 +8,47 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AppUser struct {
+	UserID       int32
+	Username     string
+	DisplayName  pgtype.Text
+	CreatedAt    pgtype.Timestamp
+	UpdatedAt    pgtype.Timestamp
+	Role         string
+	ApiTokenHash pgtype.Text
+}
+
+type BreakGlassAccess struct {
+	AccessID   int32
+	GrantID    int32
+	Method     string
+	Path       string
+	AccessedAt pgtype.Timestamp
+}
+
+type BreakGlassGrant struct {
+	GrantID   int32
+	PatientID int32
+	UserID    int32
+	Reason    string
+	GrantedAt pgtype.Timestamp
+	ExpiresAt pgtype.Timestamp
+}
+
+type CareTeam struct {
+	PatientID  int32
+	UserID     int32
+	AssignedBy string
+	CreatedAt  pgtype.Timestamp
+}
+
+type Clinic struct {
+	ClinicID   int32
+	ClinicName string
+	CreatedAt  pgtype.Timestamp
+	UpdatedAt  pgtype.Timestamp
+}
+
 type Disease struct {
 	DiseaseID          int32
 	DiseaseName        string
@@ -16,19 +57,68 @@ type Disease struct {
 	DiseaseTreatment   []byte
 	CreatedAt          pgtype.Timestamp
 	UpdatedAt          pgtype.Timestamp
+	Version            int32
+	DeprecatedAt       pgtype.Timestamp
+	ReplacedBy         pgtype.Int4
+}
+
+type DiseaseAlias struct {
+	DiseaseAliasID int32
+	DiseaseID      int32
+	Alias          string
+	Language       string
+	Source         string
+	CreatedAt      pgtype.Timestamp
+	UpdatedAt      pgtype.Timestamp
+}
+
+type DiseaseUsage struct {
+	DiseaseID  int32
+	UsageCount int32
+}
+
+type IdempotencyKey struct {
+	Scope           string
+	IdempotencyKey  string
+	Fingerprint     string
+	StatusCode      pgtype.Int4
+	ResponseHeaders []byte
+	ResponseBody    []byte
+	CreatedAt       pgtype.Timestamptz
+	ExpiresAt       pgtype.Timestamptz
 }
 
 type Patient struct {
-	PatientID   int32
-	Firstname   string
-	Lastname    string
-	Register    string
-	Age         int32
-	Gender      string
-	Birthdate   pgtype.Date
-	Address     pgtype.Text
-	Phonenumber string
-	Email       string
+	PatientID      int32
+	Firstname      string
+	Lastname       string
+	Register       string
+	Gender         string
+	Birthdate      pgtype.Date
+	Address        pgtype.Text
+	Phonenumber    string
+	Email          string
+	ClinicID       int32
+	CreatedAt      pgtype.Timestamp
+	UpdatedAt      pgtype.Timestamp
+	Version        int32
+	DeletedAt      pgtype.Timestamp
+	DeletedBy      pgtype.Int4
+	DeletionReason pgtype.Text
+}
+
+type PatientConsent struct {
+	ConsentID        int32
+	PatientID        int32
+	Scope            string
+	GrantedAt        pgtype.Timestamp
+	RevokedAt        pgtype.Timestamp
+	CapturedBy       pgtype.Text
+	RevokedBy        pgtype.Text
+	CreatedAt        pgtype.Timestamp
+	UpdatedAt        pgtype.Timestamp
+	CapturedByUserID pgtype.Int4
+	RevokedByUserID  pgtype.Int4
 }
 
 type PatientDisease struct {
@@ -39,6 +129,19 @@ type PatientDisease struct {
 	Notes            pgtype.Text
 	CreatedAt        pgtype.Timestamp
 	UpdatedAt        pgtype.Timestamp
+	ClinicID         int32
+	Version          int32
+	ClinicalStatus   string
+	StatusChangedAt  pgtype.Timestamp
+}
+
+type PatientDiseaseStatusHistory struct {
+	ID               int32
+	PatientDiseaseID int32
+	FromStatus       pgtype.Text
+	ToStatus         string
+	ChangedBy        string
+	ChangedAt        pgtype.Timestamp
 }
 
 type PatientDiseaseSymptom struct {
@@ -49,6 +152,21 @@ type PatientDiseaseSymptom struct {
 	UpdatedAt        pgtype.Timestamp
 }
 
+type PatientMerge struct {
+	PatientMergeID     int32
+	ClinicID           int32
+	SurvivorID         int32
+	DuplicateID        int32
+	MergedBy           pgtype.Int4
+	Reason             pgtype.Text
+	SymptomsMoved      int32
+	SymptomsCollapsed  int32
+	DiagnosesMoved     int32
+	DiagnosesCollapsed int32
+	CareTeamAdded      int32
+	MergedAt           pgtype.Timestamp
+}
+
 type PatientSymptom struct {
 	ID           int32
 	PatientID    int32
@@ -56,6 +174,15 @@ type PatientSymptom struct {
 	ReportedDate pgtype.Date
 	CreatedAt    pgtype.Timestamp
 	UpdatedAt    pgtype.Timestamp
+	ClinicID     int32
+	Version      int32
+}
+
+type RateLimitBucket struct {
+	BucketKey string
+	Tokens    float64
+	Allowed   bool
+	UpdatedAt pgtype.Timestamptz
 }
 
 type Symptom struct {
@@ -64,4 +191,28 @@ type Symptom struct {
 	SymptomDescription pgtype.Text
 	CreatedAt          pgtype.Timestamp
 	UpdatedAt          pgtype.Timestamp
+	Version            int32
+	DeprecatedAt       pgtype.Timestamp
+	ReplacedBy         pgtype.Int4
+}
+
+type SymptomAlias struct {
+	SymptomAliasID int32
+	SymptomID      int32
+	Alias          string
+	Language       string
+	Source         string
+	CreatedAt      pgtype.Timestamp
+	UpdatedAt      pgtype.Timestamp
+}
+
+type SymptomUsage struct {
+	SymptomID  int32
+	UsageCount int32
+}
+
+type UserClinic struct {
+	UserID    int32
+	ClinicID  int32
+	CreatedAt pgtype.Timestamp
 }
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var patientSortKeys = map[string]sortKey{
+	"name":       {columns: []string{"p.lastname", "p.firstname", "p.patient_id"}, types: []string{"varchar", "varchar", "int"}},
+	"birthdate":  {columns: []string{"p.birthdate", "p.patient_id"}, types: []string{"date", "int"}},
+	"created_at": {columns: []string{"p.created_at", "p.patient_id"}, types: []string{"timestamp", "int"}},
+	"updated_at": {columns: []string{"p.updated_at", "p.patient_id"}, types: []string{"timestamp", "int"}},
+	"id":         {columns: []string{"p.patient_id"}, types: []string{"int"}},
+}
+
+// PatientSortFields lists the sorts ListPatientsPage accepts.
+func PatientSortFields() []string {
+	return sortFields(patientSortKeys)
+}
+
+// PatientSortKeyValues returns p's values for the sort's key, in the text form
+// ListPatientsPage expects back in After.
+func PatientSortKeyValues(p Patient, sort string) []string {
+	id := strconv.Itoa(int(p.PatientID))
+	switch sort {
+	case "name":
+		return []string{p.Lastname, p.Firstname, id}
+	case "birthdate":
+		return []string{p.Birthdate.Time.Format(time.DateOnly), id}
+	case "created_at":
+		return []string{p.CreatedAt.Time.Format(timestampKeyLayout), id}
+	case "updated_at":
+		return []string{p.UpdatedAt.Time.Format(timestampKeyLayout), id}
+	default:
+		return []string{id}
+	}
+}
+
+// PatientFilter narrows a patient listing. Unset fields do not filter, except
+// ReaderID, which always does.
+type PatientFilter struct {
+	ReaderID       int32       // Only patients this user can read: on their care team or holding an open break-glass grant
+	Consent        pgtype.Text // Holding an active consent for this scope
+	WithoutConsent pgtype.Text // Lacking an active consent for this scope
+	Gender         pgtype.Text
+	BirthdateFrom  pgtype.Date // Inclusive
+	BirthdateTo    pgtype.Date // Inclusive
+	DiseaseID      pgtype.Int4 // Has a recorded instance of this disease
+	SymptomID      pgtype.Int4 // Reported this general symptom
+	DiagnosedFrom  pgtype.Date // Has a diagnosis dated on or after (of DiseaseID, when set)
+	DiagnosedTo    pgtype.Date // Has a diagnosis dated on or before (of DiseaseID, when set)
+	IncludeDeleted bool        // Soft-deleted patients too; left out by default
+}
+
+type ListPatientsPageParams struct {
+	Filter PatientFilter
+	PageParams
+}
+
+// newPatientQuery applies the filter. The disease and symptom filters are the
+// EXISTS forms of ListPatientsWithDiseaseInstance and
+// ListPatientsWithGeneralSymptom, so a patient is listed once however many
+// matching rows they have.
+func newPatientQuery(f PatientFilter) *listQuery {
+	b := &listQuery{}
+	b.add("patient_visible_to(p.patient_id, " + b.arg(f.ReaderID) + ")")
+	if !f.IncludeDeleted {
+		b.add("p.deleted_at IS NULL")
+	}
+	if f.Consent.Valid {
+		b.add("patient_has_consent(p.patient_id, " + b.arg(f.Consent.String) + ")")
+	}
+	if f.WithoutConsent.Valid {
+		b.add("NOT patient_has_consent(p.patient_id, " + b.arg(f.WithoutConsent.String) + ")")
+	}
+	if f.Gender.Valid {
+		b.add("p.gender = " + b.arg(f.Gender.String))
+	}
+	if f.BirthdateFrom.Valid {
+		b.add("p.birthdate >= " + b.arg(f.BirthdateFrom))
+	}
+	if f.BirthdateTo.Valid {
+		b.add("p.birthdate <= " + b.arg(f.BirthdateTo))
+	}
+	if f.DiseaseID.Valid || f.DiagnosedFrom.Valid || f.DiagnosedTo.Valid {
+		var diagnosis []string
+		if f.DiseaseID.Valid {
+			diagnosis = append(diagnosis, "pd.disease_id = "+b.arg(f.DiseaseID.Int32))
+		}
+		if f.DiagnosedFrom.Valid {
+			diagnosis = append(diagnosis, "pd.diagnosis_date >= "+b.arg(f.DiagnosedFrom))
+		}
+		if f.DiagnosedTo.Valid {
+			diagnosis = append(diagnosis, "pd.diagnosis_date <= "+b.arg(f.DiagnosedTo))
+		}
+		b.add("EXISTS (SELECT 1 FROM patient_disease pd WHERE pd.patient_id = p.patient_id AND " + strings.Join(diagnosis, " AND ") + ")")
+	}
+	if f.SymptomID.Valid {
+		b.add("EXISTS (SELECT 1 FROM patient_symptoms ps WHERE ps.patient_id = p.patient_id AND ps.symptom_id = " + b.arg(f.SymptomID.Int32) + ")")
+	}
+	return b
+}
+
+const patientColumns = "p.patient_id, p.firstname, p.lastname, p.register, p.gender, p.birthdate, p.address, p.phonenumber, p.email, p.clinic_id, p.created_at, p.updated_at, p.version, p.deleted_at, p.deleted_by, p.deletion_reason"
+
+// ListPatientsPage lists one page of patients by keyset pagination: instead of
+// an offset it continues from the sort key of the last row seen, so pages
+// stay stable while patients are added and deep pages cost no more than the
+// first.
+func (q *Queries) ListPatientsPage(ctx context.Context, arg ListPatientsPageParams) ([]Patient, error) {
+	b := newPatientQuery(arg.Filter)
+	page, err := b.page(patientSortKeys, arg.PageParams)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.db.Query(ctx, "SELECT "+patientColumns+" FROM patient p"+b.whereSQL()+page, b.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Patient
+	for rows.Next() {
+		var i Patient
+		if err := rows.Scan(
+			&i.PatientID,
+			&i.Firstname,
+			&i.Lastname,
+			&i.Register,
+			&i.Gender,
+			&i.Birthdate,
+			&i.Address,
+			&i.Phonenumber,
+			&i.Email,
+			&i.ClinicID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if arg.Backward {
+		slices.Reverse(items)
+	}
+	return items, nil
+}
+
+// CountPatients counts the patients matching the filter across all pages.
+func (q *Queries) CountPatients(ctx context.Context, f PatientFilter) (int64, error) {
+	b := newPatientQuery(f)
+	var count int64
+	err := q.db.QueryRow(ctx, "SELECT count(*) FROM patient p"+b.whereSQL(), b.args...).Scan(&count)
+	return count, err
+}
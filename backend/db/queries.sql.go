@@ -11,6 +11,239 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addCareTeamMember = `-- name: AddCareTeamMember :one
+INSERT INTO care_team (
+    patient_id, user_id, assigned_by
+) VALUES (
+    $1, $2, $3
+)
+RETURNING patient_id, user_id, assigned_by, created_at
+`
+
+type AddCareTeamMemberParams struct {
+	PatientID  int32
+	UserID     int32
+	AssignedBy string
+}
+
+func (q *Queries) AddCareTeamMember(ctx context.Context, arg AddCareTeamMemberParams) (CareTeam, error) {
+	row := q.db.QueryRow(ctx, addCareTeamMember, arg.PatientID, arg.UserID, arg.AssignedBy)
+	var i CareTeam
+	err := row.Scan(
+		&i.PatientID,
+		&i.UserID,
+		&i.AssignedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const addMergedCareTeam = `-- name: AddMergedCareTeam :execrows
+INSERT INTO care_team (patient_id, user_id, assigned_by)
+SELECT $1, ct.user_id, ct.assigned_by
+FROM care_team ct
+WHERE ct.patient_id = $2
+ON CONFLICT (patient_id, user_id) DO NOTHING
+`
+
+type AddMergedCareTeamParams struct {
+	SurvivorID  int32
+	DuplicateID int32
+}
+
+// The duplicate's care team keeps access to the history it looked after
+func (q *Queries) AddMergedCareTeam(ctx context.Context, arg AddMergedCareTeamParams) (int64, error) {
+	result, err := q.db.Exec(ctx, addMergedCareTeam, arg.SurvivorID, arg.DuplicateID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const addMergedDiseaseAlias = `-- name: AddMergedDiseaseAlias :execrows
+INSERT INTO disease_alias (disease_id, alias, source)
+SELECT k.disease_id, d.disease_name, 'merge'
+FROM disease d, disease k
+WHERE d.disease_id = $1
+  AND k.disease_id = $2
+  AND LOWER(d.disease_name) <> LOWER(k.disease_name)
+ON CONFLICT (LOWER(alias)) DO NOTHING
+`
+
+type AddMergedDiseaseAliasParams struct {
+	DuplicateID int32
+	SurvivorID  int32
+}
+
+func (q *Queries) AddMergedDiseaseAlias(ctx context.Context, arg AddMergedDiseaseAliasParams) (int64, error) {
+	result, err := q.db.Exec(ctx, addMergedDiseaseAlias, arg.DuplicateID, arg.SurvivorID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const addMergedSymptomAlias = `-- name: AddMergedSymptomAlias :execrows
+INSERT INTO symptom_alias (symptom_id, alias, source)
+SELECT k.symptom_id, d.symptom_name, 'merge'
+FROM symptoms d, symptoms k
+WHERE d.symptom_id = $1
+  AND k.symptom_id = $2
+  AND LOWER(d.symptom_name) <> LOWER(k.symptom_name)
+ON CONFLICT (LOWER(alias)) DO NOTHING
+`
+
+type AddMergedSymptomAliasParams struct {
+	DuplicateID int32
+	SurvivorID  int32
+}
+
+// Keeps the duplicate's name as an alias of the survivor
+func (q *Queries) AddMergedSymptomAlias(ctx context.Context, arg AddMergedSymptomAliasParams) (int64, error) {
+	result, err := q.db.Exec(ctx, addMergedSymptomAlias, arg.DuplicateID, arg.SurvivorID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const claimIdempotencyKey = `-- name: ClaimIdempotencyKey :execrows
+
+INSERT INTO idempotency_key AS k (
+    scope, idempotency_key, fingerprint, expires_at
+) VALUES (
+    $1, $2, $3,
+    clock_timestamp() + ($4::int * INTERVAL '1 second')
+)
+ON CONFLICT (scope, idempotency_key) DO UPDATE
+SET
+    fingerprint = EXCLUDED.fingerprint,
+    status_code = NULL,
+    response_headers = NULL,
+    response_body = NULL,
+    created_at = clock_timestamp(),
+    expires_at = EXCLUDED.expires_at
+WHERE k.expires_at <= clock_timestamp()
+`
+
+type ClaimIdempotencyKeyParams struct {
+	Scope          string
+	IdempotencyKey string
+	Fingerprint    string
+	TtlSeconds     int32
+}
+
+// === Idempotency Key Queries ===
+// Claims a key for a request about to be handled. An expired entry is taken
+// over; a live one is left alone and 0 rows are affected.
+func (q *Queries) ClaimIdempotencyKey(ctx context.Context, arg ClaimIdempotencyKeyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, claimIdempotencyKey,
+		arg.Scope,
+		arg.IdempotencyKey,
+		arg.Fingerprint,
+		arg.TtlSeconds,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const completeIdempotencyKey = `-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_key
+SET status_code = $3, response_headers = $4, response_body = $5
+WHERE scope = $1 AND idempotency_key = $2
+`
+
+type CompleteIdempotencyKeyParams struct {
+	Scope           string
+	IdempotencyKey  string
+	StatusCode      pgtype.Int4
+	ResponseHeaders []byte
+	ResponseBody    []byte
+}
+
+// Stores the response of a claimed key so retries replay it
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, completeIdempotencyKey,
+		arg.Scope,
+		arg.IdempotencyKey,
+		arg.StatusCode,
+		arg.ResponseHeaders,
+		arg.ResponseBody,
+	)
+	return err
+}
+
+const countDiseaseReferences = `-- name: CountDiseaseReferences :one
+SELECT count_disease_references($1::int)::bigint AS disease_instances
+`
+
+// Disease instances recorded of a disease, across all clinics (000020)
+func (q *Queries) CountDiseaseReferences(ctx context.Context, diseaseID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countDiseaseReferences, diseaseID)
+	var disease_instances int64
+	err := row.Scan(&disease_instances)
+	return disease_instances, err
+}
+
+const countSymptomReferences = `-- name: CountSymptomReferences :one
+SELECT
+    r.patient_symptoms::bigint AS patient_symptoms,
+    r.disease_instance_symptoms::bigint AS disease_instance_symptoms
+FROM count_symptom_references($1::int) r
+`
+
+type CountSymptomReferencesRow struct {
+	PatientSymptoms         int64
+	DiseaseInstanceSymptoms int64
+}
+
+// Patient records using a symptom, across all clinics (000020)
+func (q *Queries) CountSymptomReferences(ctx context.Context, symptomID int32) (CountSymptomReferencesRow, error) {
+	row := q.db.QueryRow(ctx, countSymptomReferences, symptomID)
+	var i CountSymptomReferencesRow
+	err := row.Scan(&i.PatientSymptoms, &i.DiseaseInstanceSymptoms)
+	return i, err
+}
+
+const createBreakGlassGrant = `-- name: CreateBreakGlassGrant :one
+INSERT INTO break_glass_grant (
+    patient_id, user_id, reason, expires_at
+) VALUES (
+    $1, $2, $3,
+    CURRENT_TIMESTAMP + ($4::int * INTERVAL '1 minute')
+)
+RETURNING grant_id, patient_id, user_id, reason, granted_at, expires_at
+`
+
+type CreateBreakGlassGrantParams struct {
+	PatientID       int32
+	UserID          int32
+	Reason          string
+	DurationMinutes int32
+}
+
+// Opens a time-limited emergency access window for a user on one patient
+func (q *Queries) CreateBreakGlassGrant(ctx context.Context, arg CreateBreakGlassGrantParams) (BreakGlassGrant, error) {
+	row := q.db.QueryRow(ctx, createBreakGlassGrant,
+		arg.PatientID,
+		arg.UserID,
+		arg.Reason,
+		arg.DurationMinutes,
+	)
+	var i BreakGlassGrant
+	err := row.Scan(
+		&i.GrantID,
+		&i.PatientID,
+		&i.UserID,
+		&i.Reason,
+		&i.GrantedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
 const createDisease = `-- name: CreateDisease :one
 
 INSERT INTO disease (
@@ -18,7 +251,7 @@ INSERT INTO disease (
 ) VALUES (
     $1, $2, $3, $4 -- $4 should be valid JSON(B) text or compatible type
 )
-RETURNING disease_id, disease_name, disease_code, disease_description, disease_treatment, created_at, updated_at
+RETURNING disease_id, disease_name, disease_code, disease_description, disease_treatment, created_at, updated_at, version, deprecated_at, replaced_by
 `
 
 type CreateDiseaseParams struct {
@@ -45,6 +278,9 @@ func (q *Queries) CreateDisease(ctx context.Context, arg CreateDiseaseParams) (D
 		&i.DiseaseTreatment,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.DeprecatedAt,
+		&i.ReplacedBy,
 	)
 	return i, err
 }
@@ -53,18 +289,17 @@ const createPatient = `-- name: CreatePatient :one
 
 
 INSERT INTO patient (
-    firstname, lastname, register, age, gender, birthdate, address, phonenumber, email
+    firstname, lastname, register, gender, birthdate, address, phonenumber, email
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9
+    $1, $2, $3, $4, $5, $6, $7, $8
 )
-RETURNING patient_id, firstname, lastname, register, age, gender, birthdate, address, phonenumber, email
+RETURNING patient_id, firstname, lastname, register, gender, birthdate, address, phonenumber, email, clinic_id, created_at, updated_at, version, deleted_at, deleted_by, deletion_reason
 `
 
 type CreatePatientParams struct {
 	Firstname   string
 	Lastname    string
 	Register    string
-	Age         int32
 	Gender      string
 	Birthdate   pgtype.Date
 	Address     pgtype.Text
@@ -79,7 +314,6 @@ func (q *Queries) CreatePatient(ctx context.Context, arg CreatePatientParams) (P
 		arg.Firstname,
 		arg.Lastname,
 		arg.Register,
-		arg.Age,
 		arg.Gender,
 		arg.Birthdate,
 		arg.Address,
@@ -92,12 +326,71 @@ func (q *Queries) CreatePatient(ctx context.Context, arg CreatePatientParams) (P
 		&i.Firstname,
 		&i.Lastname,
 		&i.Register,
-		&i.Age,
 		&i.Gender,
 		&i.Birthdate,
 		&i.Address,
 		&i.Phonenumber,
 		&i.Email,
+		&i.ClinicID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+	)
+	return i, err
+}
+
+const createPatientMerge = `-- name: CreatePatientMerge :one
+INSERT INTO patient_merge (
+    survivor_id, duplicate_id, merged_by, reason,
+    symptoms_moved, symptoms_collapsed, diagnoses_moved, diagnoses_collapsed, care_team_added
+) VALUES (
+    $1, $2, $3::int, $4,
+    $5, $6, $7, $8, $9
+)
+RETURNING patient_merge_id, clinic_id, survivor_id, duplicate_id, merged_by, reason, symptoms_moved, symptoms_collapsed, diagnoses_moved, diagnoses_collapsed, care_team_added, merged_at
+`
+
+type CreatePatientMergeParams struct {
+	SurvivorID         int32
+	DuplicateID        int32
+	MergedBy           int32
+	Reason             pgtype.Text
+	SymptomsMoved      int32
+	SymptomsCollapsed  int32
+	DiagnosesMoved     int32
+	DiagnosesCollapsed int32
+	CareTeamAdded      int32
+}
+
+func (q *Queries) CreatePatientMerge(ctx context.Context, arg CreatePatientMergeParams) (PatientMerge, error) {
+	row := q.db.QueryRow(ctx, createPatientMerge,
+		arg.SurvivorID,
+		arg.DuplicateID,
+		arg.MergedBy,
+		arg.Reason,
+		arg.SymptomsMoved,
+		arg.SymptomsCollapsed,
+		arg.DiagnosesMoved,
+		arg.DiagnosesCollapsed,
+		arg.CareTeamAdded,
+	)
+	var i PatientMerge
+	err := row.Scan(
+		&i.PatientMergeID,
+		&i.ClinicID,
+		&i.SurvivorID,
+		&i.DuplicateID,
+		&i.MergedBy,
+		&i.Reason,
+		&i.SymptomsMoved,
+		&i.SymptomsCollapsed,
+		&i.DiagnosesMoved,
+		&i.DiagnosesCollapsed,
+		&i.CareTeamAdded,
+		&i.MergedAt,
 	)
 	return i, err
 }
@@ -109,7 +402,7 @@ INSERT INTO symptoms (
 ) VALUES (
     $1, $2
 )
-RETURNING symptom_id, symptom_name, symptom_description, created_at, updated_at
+RETURNING symptom_id, symptom_name, symptom_description, created_at, updated_at, version, deprecated_at, replaced_by
 `
 
 type CreateSymptomParams struct {
@@ -127,57 +420,395 @@ func (q *Queries) CreateSymptom(ctx context.Context, arg CreateSymptomParams) (S
 		&i.SymptomDescription,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.DeprecatedAt,
+		&i.ReplacedBy,
+	)
+	return i, err
+}
+
+const createSymptomAlias = `-- name: CreateSymptomAlias :one
+
+INSERT INTO symptom_alias (
+    symptom_id, alias, language, source
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING symptom_alias_id, symptom_id, alias, language, source, created_at, updated_at
+`
+
+type CreateSymptomAliasParams struct {
+	SymptomID int32
+	Alias     string
+	Language  string
+	Source    string
+}
+
+// === Symptom Alias Queries ===
+func (q *Queries) CreateSymptomAlias(ctx context.Context, arg CreateSymptomAliasParams) (SymptomAlias, error) {
+	row := q.db.QueryRow(ctx, createSymptomAlias,
+		arg.SymptomID,
+		arg.Alias,
+		arg.Language,
+		arg.Source,
+	)
+	var i SymptomAlias
+	err := row.Scan(
+		&i.SymptomAliasID,
+		&i.SymptomID,
+		&i.Alias,
+		&i.Language,
+		&i.Source,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const deleteDisease = `-- name: DeleteDisease :exec
+const deleteCollidingDiagnoses = `-- name: DeleteCollidingDiagnoses :execrows
+DELETE FROM patient_disease d
+WHERE d.patient_id = $1
+  AND EXISTS (
+    SELECT 1 FROM patient_disease k
+    WHERE k.patient_id = $2
+      AND k.disease_id = d.disease_id
+      AND k.diagnosis_date = d.diagnosis_date
+  )
+`
+
+type DeleteCollidingDiagnosesParams struct {
+	DuplicateID int32
+	SurvivorID  int32
+}
+
+func (q *Queries) DeleteCollidingDiagnoses(ctx context.Context, arg DeleteCollidingDiagnosesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteCollidingDiagnoses, arg.DuplicateID, arg.SurvivorID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteCollidingReportedSymptoms = `-- name: DeleteCollidingReportedSymptoms :execrows
+DELETE FROM patient_symptoms d
+WHERE d.patient_id = $1
+  AND EXISTS (
+    SELECT 1 FROM patient_symptoms k
+    WHERE k.patient_id = $2
+      AND k.symptom_id = d.symptom_id
+      AND k.reported_date = d.reported_date
+  )
+`
+
+type DeleteCollidingReportedSymptomsParams struct {
+	DuplicateID int32
+	SurvivorID  int32
+}
+
+// The survivor already reported the symptom on the same date
+func (q *Queries) DeleteCollidingReportedSymptoms(ctx context.Context, arg DeleteCollidingReportedSymptomsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteCollidingReportedSymptoms, arg.DuplicateID, arg.SurvivorID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteDisease = `-- name: DeleteDisease :execrows
 DELETE FROM disease
-WHERE disease_id = $1
+WHERE disease_id = $1 AND version = $2
 `
 
-// Note: ON DELETE CASCADE will handle related records in junction tables
-func (q *Queries) DeleteDisease(ctx context.Context, diseaseID int32) error {
-	_, err := q.db.Exec(ctx, deleteDisease, diseaseID)
-	return err
+type DeleteDiseaseParams struct {
+	DiseaseID int32
+	Version   int32
 }
 
-const deletePatient = `-- name: DeletePatient :exec
-DELETE FROM patient
-WHERE patient_id = $1
+// Fails (fk_pd_disease) while patient records still use it; deletes nothing
+// once the version has moved on
+func (q *Queries) DeleteDisease(ctx context.Context, arg DeleteDiseaseParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteDisease, arg.DiseaseID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpiredIdempotencyKeys = `-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_key
+WHERE expires_at <= clock_timestamp()
 `
 
-// Note: ON DELETE CASCADE will handle related records in junction tables
-func (q *Queries) DeletePatient(ctx context.Context, patientID int32) error {
-	_, err := q.db.Exec(ctx, deletePatient, patientID)
-	return err
+func (q *Queries) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredIdempotencyKeys)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteIdleRateLimitBuckets = `-- name: DeleteIdleRateLimitBuckets :execrows
+DELETE FROM rate_limit_bucket
+WHERE updated_at < clock_timestamp() - ($1::int * INTERVAL '1 second')
+`
+
+// Buckets unused for this long have refilled completely and can be dropped
+func (q *Queries) DeleteIdleRateLimitBuckets(ctx context.Context, idleSeconds int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteIdleRateLimitBuckets, idleSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const deletePatientDiseaseInstance = `-- name: DeletePatientDiseaseInstance :exec
+const deletePatientDiseaseInstance = `-- name: DeletePatientDiseaseInstance :execrows
 DELETE FROM patient_disease
-WHERE patient_disease_id = $1
+WHERE patient_disease_id = $1 AND version = $2
 `
 
-// Deletes a specific diagnosis instance by its ID
+type DeletePatientDiseaseInstanceParams struct {
+	PatientDiseaseID int32
+	Version          int32
+}
+
+// Deletes a specific diagnosis instance by its ID, if still at the given version
 // Note: ON DELETE CASCADE handles related patient_disease_symptom records
-func (q *Queries) DeletePatientDiseaseInstance(ctx context.Context, patientDiseaseID int32) error {
-	_, err := q.db.Exec(ctx, deletePatientDiseaseInstance, patientDiseaseID)
-	return err
+func (q *Queries) DeletePatientDiseaseInstance(ctx context.Context, arg DeletePatientDiseaseInstanceParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePatientDiseaseInstance, arg.PatientDiseaseID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const deleteSymptom = `-- name: DeleteSymptom :exec
+const deleteSymptom = `-- name: DeleteSymptom :execrows
 DELETE FROM symptoms
-WHERE symptom_id = $1
+WHERE symptom_id = $1 AND version = $2
 `
 
-// Note: ON DELETE CASCADE will handle related records in junction tables
-func (q *Queries) DeleteSymptom(ctx context.Context, symptomID int32) error {
-	_, err := q.db.Exec(ctx, deleteSymptom, symptomID)
-	return err
+type DeleteSymptomParams struct {
+	SymptomID int32
+	Version   int32
+}
+
+// Fails (fk_ps_symptom, fk_pds_symptom) while patient records still use it;
+// deletes nothing once the version has moved on
+func (q *Queries) DeleteSymptom(ctx context.Context, arg DeleteSymptomParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSymptom, arg.SymptomID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSymptomAlias = `-- name: DeleteSymptomAlias :execrows
+DELETE FROM symptom_alias
+WHERE symptom_alias_id = $1 AND symptom_id = $2
+`
+
+type DeleteSymptomAliasParams struct {
+	SymptomAliasID int32
+	SymptomID      int32
+}
+
+func (q *Queries) DeleteSymptomAlias(ctx context.Context, arg DeleteSymptomAliasParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSymptomAlias, arg.SymptomAliasID, arg.SymptomID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deprecateDisease = `-- name: DeprecateDisease :one
+UPDATE disease
+SET
+    deprecated_at = COALESCE(deprecated_at, now()),
+    replaced_by = $1
+WHERE disease_id = $2
+RETURNING disease_id, disease_name, disease_code, disease_description, disease_treatment, created_at, updated_at, version, deprecated_at, replaced_by
+`
+
+type DeprecateDiseaseParams struct {
+	ReplacedBy pgtype.Int4
+	DiseaseID  int32
+}
+
+// Hides a disease from pickers; records keep it. Deprecating again keeps the
+// original deprecated_at and only changes the replacement.
+func (q *Queries) DeprecateDisease(ctx context.Context, arg DeprecateDiseaseParams) (Disease, error) {
+	row := q.db.QueryRow(ctx, deprecateDisease, arg.ReplacedBy, arg.DiseaseID)
+	var i Disease
+	err := row.Scan(
+		&i.DiseaseID,
+		&i.DiseaseName,
+		&i.DiseaseCode,
+		&i.DiseaseDescription,
+		&i.DiseaseTreatment,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeprecatedAt,
+		&i.ReplacedBy,
+	)
+	return i, err
+}
+
+const deprecateSymptom = `-- name: DeprecateSymptom :one
+UPDATE symptoms
+SET
+    deprecated_at = COALESCE(deprecated_at, now()),
+    replaced_by = $1
+WHERE symptom_id = $2
+RETURNING symptom_id, symptom_name, symptom_description, created_at, updated_at, version, deprecated_at, replaced_by
+`
+
+type DeprecateSymptomParams struct {
+	ReplacedBy pgtype.Int4
+	SymptomID  int32
+}
+
+// Hides a symptom from pickers; records keep it. Deprecating again keeps the
+// original deprecated_at and only changes the replacement.
+func (q *Queries) DeprecateSymptom(ctx context.Context, arg DeprecateSymptomParams) (Symptom, error) {
+	row := q.db.QueryRow(ctx, deprecateSymptom, arg.ReplacedBy, arg.SymptomID)
+	var i Symptom
+	err := row.Scan(
+		&i.SymptomID,
+		&i.SymptomName,
+		&i.SymptomDescription,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeprecatedAt,
+		&i.ReplacedBy,
+	)
+	return i, err
+}
+
+const findDuplicatePatients = `-- name: FindDuplicatePatients :many
+
+SELECT
+    p.patient_id, p.firstname, p.lastname, p.register, p.birthdate, p.phonenumber,
+    m.register_match::bool AS register_match,
+    m.phone_match::bool AS phone_match,
+    m.name_similarity::float8 AS name_similarity
+FROM patient p
+CROSS JOIN LATERAL (
+    SELECT
+        UPPER(p.register) = UPPER($1::text) AS register_match,
+        regexp_replace($2::text, '\D', '', 'g') <> ''
+            AND regexp_replace(p.phonenumber, '\D', '', 'g') = regexp_replace($2::text, '\D', '', 'g') AS phone_match,
+        CASE WHEN p.birthdate = $3::date THEN GREATEST(
+            similarity(LOWER(p.firstname || ' ' || p.lastname), $4::text),
+            similarity(LOWER(p.firstname || ' ' || p.lastname), $5::text),
+            similarity(LOWER(p.firstname || ' ' || p.lastname), $6::text)
+        ) ELSE 0 END AS name_similarity
+) m
+WHERE p.deleted_at IS NULL
+  AND p.patient_id <> $7::int
+  AND (m.register_match OR m.phone_match OR m.name_similarity >= $8::float8)
+ORDER BY m.register_match DESC, m.phone_match DESC, m.name_similarity DESC, p.patient_id
+LIMIT $9
+`
+
+type FindDuplicatePatientsParams struct {
+	Register          string
+	Phonenumber       string
+	Birthdate         pgtype.Date
+	Name              string
+	NameCyrillic      string
+	NameLatin         string
+	ExcludeID         int32
+	MinNameSimilarity float64
+	Limit             int32
+}
+
+type FindDuplicatePatientsRow struct {
+	PatientID      int32
+	Firstname      string
+	Lastname       string
+	Register       string
+	Birthdate      pgtype.Date
+	Phonenumber    string
+	RegisterMatch  bool
+	PhoneMatch     bool
+	NameSimilarity float64
+}
+
+// === Patient Duplicate & Merge Queries ===
+// Patients of the caller's clinic who may be the same person: the same
+// register, the same phone number (digits only), or a similar name (any
+// spelling, as with SearchPatients) with the same birthdate. The handler turns
+// the signals into a confidence score.
+func (q *Queries) FindDuplicatePatients(ctx context.Context, arg FindDuplicatePatientsParams) ([]FindDuplicatePatientsRow, error) {
+	rows, err := q.db.Query(ctx, findDuplicatePatients,
+		arg.Register,
+		arg.Phonenumber,
+		arg.Birthdate,
+		arg.Name,
+		arg.NameCyrillic,
+		arg.NameLatin,
+		arg.ExcludeID,
+		arg.MinNameSimilarity,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindDuplicatePatientsRow
+	for rows.Next() {
+		var i FindDuplicatePatientsRow
+		if err := rows.Scan(
+			&i.PatientID,
+			&i.Firstname,
+			&i.Lastname,
+			&i.Register,
+			&i.Birthdate,
+			&i.Phonenumber,
+			&i.RegisterMatch,
+			&i.PhoneMatch,
+			&i.NameSimilarity,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getActiveBreakGlassGrant = `-- name: GetActiveBreakGlassGrant :one
+SELECT grant_id, patient_id, user_id, reason, granted_at, expires_at FROM break_glass_grant
+WHERE user_id = $1 AND patient_id = $2 AND expires_at > CURRENT_TIMESTAMP
+ORDER BY expires_at DESC
+LIMIT 1
+`
+
+type GetActiveBreakGlassGrantParams struct {
+	UserID    int32
+	PatientID int32
+}
+
+// The user's open break-glass window on the patient, if any
+func (q *Queries) GetActiveBreakGlassGrant(ctx context.Context, arg GetActiveBreakGlassGrantParams) (BreakGlassGrant, error) {
+	row := q.db.QueryRow(ctx, getActiveBreakGlassGrant, arg.UserID, arg.PatientID)
+	var i BreakGlassGrant
+	err := row.Scan(
+		&i.GrantID,
+		&i.PatientID,
+		&i.UserID,
+		&i.Reason,
+		&i.GrantedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
 }
 
 const getDiseaseByCode = `-- name: GetDiseaseByCode :one
-SELECT disease_id, disease_name, disease_code, disease_description, disease_treatment, created_at, updated_at FROM disease
+SELECT disease_id, disease_name, disease_code, disease_description, disease_treatment, created_at, updated_at, version, deprecated_at, replaced_by FROM disease
 WHERE disease_code = $1 LIMIT 1
 `
 
@@ -192,12 +823,15 @@ func (q *Queries) GetDiseaseByCode(ctx context.Context, diseaseCode string) (Dis
 		&i.DiseaseTreatment,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.DeprecatedAt,
+		&i.ReplacedBy,
 	)
 	return i, err
 }
 
 const getDiseaseByID = `-- name: GetDiseaseByID :one
-SELECT disease_id, disease_name, disease_code, disease_description, disease_treatment, created_at, updated_at FROM disease
+SELECT disease_id, disease_name, disease_code, disease_description, disease_treatment, created_at, updated_at, version, deprecated_at, replaced_by FROM disease
 WHERE disease_id = $1 LIMIT 1
 `
 
@@ -212,38 +846,147 @@ func (q *Queries) GetDiseaseByID(ctx context.Context, diseaseID int32) (Disease,
 		&i.DiseaseTreatment,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.DeprecatedAt,
+		&i.ReplacedBy,
 	)
 	return i, err
 }
 
-const getPatientByEmail = `-- name: GetPatientByEmail :one
-SELECT patient_id, firstname, lastname, register, age, gender, birthdate, address, phonenumber, email FROM patient
-WHERE email = $1 LIMIT 1
+const getDiseaseForUpdate = `-- name: GetDiseaseForUpdate :one
+SELECT disease_id, disease_name, disease_code, disease_description, disease_treatment, created_at, updated_at, version, deprecated_at, replaced_by FROM disease
+WHERE disease_id = $1
+FOR UPDATE
 `
 
-func (q *Queries) GetPatientByEmail(ctx context.Context, email string) (Patient, error) {
-	row := q.db.QueryRow(ctx, getPatientByEmail, email)
+func (q *Queries) GetDiseaseForUpdate(ctx context.Context, diseaseID int32) (Disease, error) {
+	row := q.db.QueryRow(ctx, getDiseaseForUpdate, diseaseID)
+	var i Disease
+	err := row.Scan(
+		&i.DiseaseID,
+		&i.DiseaseName,
+		&i.DiseaseCode,
+		&i.DiseaseDescription,
+		&i.DiseaseTreatment,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeprecatedAt,
+		&i.ReplacedBy,
+	)
+	return i, err
+}
+
+const getDiseaseInstance = `-- name: GetDiseaseInstance :one
+SELECT
+    pd.patient_disease_id, pd.patient_id, pd.disease_id, pd.diagnosis_date, pd.notes, pd.created_at, pd.updated_at, pd.clinic_id, pd.version, pd.clinical_status, pd.status_changed_at,
+    d.disease_name,
+    d.disease_code
+FROM patient_disease pd
+JOIN disease d ON pd.disease_id = d.disease_id
+WHERE pd.patient_disease_id = $1
+`
+
+type GetDiseaseInstanceRow struct {
+	PatientDiseaseID int32
+	PatientID        int32
+	DiseaseID        int32
+	DiagnosisDate    pgtype.Date
+	Notes            pgtype.Text
+	CreatedAt        pgtype.Timestamp
+	UpdatedAt        pgtype.Timestamp
+	ClinicID         int32
+	Version          int32
+	ClinicalStatus   string
+	StatusChangedAt  pgtype.Timestamp
+	DiseaseName      string
+	DiseaseCode      string
+}
+
+// Gets a diagnosis instance with its disease's name and code
+func (q *Queries) GetDiseaseInstance(ctx context.Context, patientDiseaseID int32) (GetDiseaseInstanceRow, error) {
+	row := q.db.QueryRow(ctx, getDiseaseInstance, patientDiseaseID)
+	var i GetDiseaseInstanceRow
+	err := row.Scan(
+		&i.PatientDiseaseID,
+		&i.PatientID,
+		&i.DiseaseID,
+		&i.DiagnosisDate,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClinicID,
+		&i.Version,
+		&i.ClinicalStatus,
+		&i.StatusChangedAt,
+		&i.DiseaseName,
+		&i.DiseaseCode,
+	)
+	return i, err
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT scope, idempotency_key, fingerprint, status_code, response_headers, response_body, created_at, expires_at FROM idempotency_key
+WHERE scope = $1 AND idempotency_key = $2 AND expires_at > clock_timestamp()
+`
+
+type GetIdempotencyKeyParams struct {
+	Scope          string
+	IdempotencyKey string
+}
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRow(ctx, getIdempotencyKey, arg.Scope, arg.IdempotencyKey)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Scope,
+		&i.IdempotencyKey,
+		&i.Fingerprint,
+		&i.StatusCode,
+		&i.ResponseHeaders,
+		&i.ResponseBody,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getPatientByEmail = `-- name: GetPatientByEmail :one
+SELECT patient_id, firstname, lastname, register, gender, birthdate, address, phonenumber, email, clinic_id, created_at, updated_at, version, deleted_at, deleted_by, deletion_reason FROM patient
+WHERE email = $1 AND deleted_at IS NULL LIMIT 1
+`
+
+func (q *Queries) GetPatientByEmail(ctx context.Context, email string) (Patient, error) {
+	row := q.db.QueryRow(ctx, getPatientByEmail, email)
 	var i Patient
 	err := row.Scan(
 		&i.PatientID,
 		&i.Firstname,
 		&i.Lastname,
 		&i.Register,
-		&i.Age,
 		&i.Gender,
 		&i.Birthdate,
 		&i.Address,
 		&i.Phonenumber,
 		&i.Email,
+		&i.ClinicID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
 	)
 	return i, err
 }
 
 const getPatientByID = `-- name: GetPatientByID :one
-SELECT patient_id, firstname, lastname, register, age, gender, birthdate, address, phonenumber, email FROM patient
-WHERE patient_id = $1 LIMIT 1
+SELECT patient_id, firstname, lastname, register, gender, birthdate, address, phonenumber, email, clinic_id, created_at, updated_at, version, deleted_at, deleted_by, deletion_reason FROM patient
+WHERE patient_id = $1 AND deleted_at IS NULL LIMIT 1
 `
 
+// Soft-deleted patients are left out here and in every other patient query
+// unless noted
 func (q *Queries) GetPatientByID(ctx context.Context, patientID int32) (Patient, error) {
 	row := q.db.QueryRow(ctx, getPatientByID, patientID)
 	var i Patient
@@ -252,16 +995,64 @@ func (q *Queries) GetPatientByID(ctx context.Context, patientID int32) (Patient,
 		&i.Firstname,
 		&i.Lastname,
 		&i.Register,
-		&i.Age,
 		&i.Gender,
 		&i.Birthdate,
 		&i.Address,
 		&i.Phonenumber,
 		&i.Email,
+		&i.ClinicID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+	)
+	return i, err
+}
+
+const getPatientByIDWithDeleted = `-- name: GetPatientByIDWithDeleted :one
+SELECT patient_id, firstname, lastname, register, gender, birthdate, address, phonenumber, email, clinic_id, created_at, updated_at, version, deleted_at, deleted_by, deletion_reason FROM patient
+WHERE patient_id = $1 LIMIT 1
+`
+
+// Also finds a soft-deleted patient, for admins reviewing or restoring it
+func (q *Queries) GetPatientByIDWithDeleted(ctx context.Context, patientID int32) (Patient, error) {
+	row := q.db.QueryRow(ctx, getPatientByIDWithDeleted, patientID)
+	var i Patient
+	err := row.Scan(
+		&i.PatientID,
+		&i.Firstname,
+		&i.Lastname,
+		&i.Register,
+		&i.Gender,
+		&i.Birthdate,
+		&i.Address,
+		&i.Phonenumber,
+		&i.Email,
+		&i.ClinicID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
 	)
 	return i, err
 }
 
+const getPatientDeletedAt = `-- name: GetPatientDeletedAt :one
+SELECT deleted_at FROM patient
+WHERE patient_id = $1
+`
+
+func (q *Queries) GetPatientDeletedAt(ctx context.Context, patientID int32) (pgtype.Timestamp, error) {
+	row := q.db.QueryRow(ctx, getPatientDeletedAt, patientID)
+	var deleted_at pgtype.Timestamp
+	err := row.Scan(&deleted_at)
+	return deleted_at, err
+}
+
 const getPatientDiseaseHistoryWithSymptoms = `-- name: GetPatientDiseaseHistoryWithSymptoms :many
 
 SELECT
@@ -332,7 +1123,7 @@ func (q *Queries) GetPatientDiseaseHistoryWithSymptoms(ctx context.Context, pati
 
 const getPatientDiseaseInstanceByID = `-- name: GetPatientDiseaseInstanceByID :one
 
-SELECT patient_disease_id, patient_id, disease_id, diagnosis_date, notes, created_at, updated_at FROM patient_disease
+SELECT patient_disease_id, patient_id, disease_id, diagnosis_date, notes, created_at, updated_at, clinic_id, version, clinical_status, status_changed_at FROM patient_disease
 WHERE patient_disease_id = $1
 `
 
@@ -349,6 +1140,46 @@ func (q *Queries) GetPatientDiseaseInstanceByID(ctx context.Context, patientDise
 		&i.Notes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ClinicID,
+		&i.Version,
+		&i.ClinicalStatus,
+		&i.StatusChangedAt,
+	)
+	return i, err
+}
+
+const getPatientForUpdate = `-- name: GetPatientForUpdate :one
+
+SELECT patient_id, firstname, lastname, register, gender, birthdate, address, phonenumber, email, clinic_id, created_at, updated_at, version, deleted_at, deleted_by, deletion_reason FROM patient
+WHERE patient_id = $1 AND deleted_at IS NULL
+FOR UPDATE
+`
+
+// A merge moves a duplicate patient's history to the surviving record of the
+// same person. Rows the survivor already has (the same symptom reported on the
+// same date, the same disease diagnosed on the same date) would break a unique
+// key; those are folded into the survivor's, as in a catalog merge. Run inside
+// a transaction, with both patients locked.
+func (q *Queries) GetPatientForUpdate(ctx context.Context, patientID int32) (Patient, error) {
+	row := q.db.QueryRow(ctx, getPatientForUpdate, patientID)
+	var i Patient
+	err := row.Scan(
+		&i.PatientID,
+		&i.Firstname,
+		&i.Lastname,
+		&i.Register,
+		&i.Gender,
+		&i.Birthdate,
+		&i.Address,
+		&i.Phonenumber,
+		&i.Email,
+		&i.ClinicID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
 	)
 	return i, err
 }
@@ -372,7 +1203,7 @@ SELECT
 FROM
     patient p
 WHERE
-    p.patient_id = $1
+    p.patient_id = $1 AND p.deleted_at IS NULL
 GROUP BY
     p.patient_id, p.firstname, p.lastname, p.email
 `
@@ -403,8 +1234,31 @@ func (q *Queries) GetPatientSummary(ctx context.Context, patientID int32) (GetPa
 	return i, err
 }
 
+const getPatientSymptomByID = `-- name: GetPatientSymptomByID :one
+
+SELECT id, patient_id, symptom_id, reported_date, created_at, updated_at, clinic_id, version FROM patient_symptoms
+WHERE id = $1
+`
+
+// More specific deletion
+func (q *Queries) GetPatientSymptomByID(ctx context.Context, id int32) (PatientSymptom, error) {
+	row := q.db.QueryRow(ctx, getPatientSymptomByID, id)
+	var i PatientSymptom
+	err := row.Scan(
+		&i.ID,
+		&i.PatientID,
+		&i.SymptomID,
+		&i.ReportedDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClinicID,
+		&i.Version,
+	)
+	return i, err
+}
+
 const getSymptomByID = `-- name: GetSymptomByID :one
-SELECT symptom_id, symptom_name, symptom_description, created_at, updated_at FROM symptoms
+SELECT symptom_id, symptom_name, symptom_description, created_at, updated_at, version, deprecated_at, replaced_by FROM symptoms
 WHERE symptom_id = $1 LIMIT 1
 `
 
@@ -417,6 +1271,38 @@ func (q *Queries) GetSymptomByID(ctx context.Context, symptomID int32) (Symptom,
 		&i.SymptomDescription,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.DeprecatedAt,
+		&i.ReplacedBy,
+	)
+	return i, err
+}
+
+const getSymptomForUpdate = `-- name: GetSymptomForUpdate :one
+
+SELECT symptom_id, symptom_name, symptom_description, created_at, updated_at, version, deprecated_at, replaced_by FROM symptoms
+WHERE symptom_id = $1
+FOR UPDATE
+`
+
+// === Catalog Merge Queries ===
+// A merge moves every reference from a duplicate entry to the surviving one.
+// References the survivor already has (same patient and date, or same
+// diagnosis) would break a unique key; those are folded into the survivor's.
+// References span clinics, so the patient records are moved by the SECURITY
+// DEFINER functions of 000020; run everything inside one transaction.
+func (q *Queries) GetSymptomForUpdate(ctx context.Context, symptomID int32) (Symptom, error) {
+	row := q.db.QueryRow(ctx, getSymptomForUpdate, symptomID)
+	var i Symptom
+	err := row.Scan(
+		&i.SymptomID,
+		&i.SymptomName,
+		&i.SymptomDescription,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeprecatedAt,
+		&i.ReplacedBy,
 	)
 	return i, err
 }
@@ -459,6 +1345,107 @@ func (q *Queries) GetSymptomsForPatientDiseaseInstance(ctx context.Context, pati
 	return items, nil
 }
 
+const getUserByAPITokenHash = `-- name: GetUserByAPITokenHash :one
+SELECT user_id, username, display_name, created_at, updated_at, role, api_token_hash FROM app_user
+WHERE api_token_hash = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByAPITokenHash(ctx context.Context, apiTokenHash pgtype.Text) (AppUser, error) {
+	row := q.db.QueryRow(ctx, getUserByAPITokenHash, apiTokenHash)
+	var i AppUser
+	err := row.Scan(
+		&i.UserID,
+		&i.Username,
+		&i.DisplayName,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.ApiTokenHash,
+	)
+	return i, err
+}
+
+const getUserByUsername = `-- name: GetUserByUsername :one
+
+SELECT user_id, username, display_name, created_at, updated_at, role, api_token_hash FROM app_user
+WHERE username = $1 LIMIT 1
+`
+
+// === Clinic & User Queries ===
+// Not clinic-scoped: used to resolve the caller and their clinic before a clinic is set
+func (q *Queries) GetUserByUsername(ctx context.Context, username string) (AppUser, error) {
+	row := q.db.QueryRow(ctx, getUserByUsername, username)
+	var i AppUser
+	err := row.Scan(
+		&i.UserID,
+		&i.Username,
+		&i.DisplayName,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.ApiTokenHash,
+	)
+	return i, err
+}
+
+const grantPatientConsent = `-- name: GrantPatientConsent :one
+
+INSERT INTO patient_consent (
+    patient_id, scope, captured_by_user_id
+) VALUES (
+    $1, $2, $3
+)
+RETURNING consent_id, patient_id, scope, granted_at, revoked_at, captured_by, revoked_by, created_at, updated_at, captured_by_user_id, revoked_by_user_id
+`
+
+type GrantPatientConsentParams struct {
+	PatientID        int32
+	Scope            string
+	CapturedByUserID pgtype.Int4
+}
+
+// === Patient Consent Queries ===
+// Records a new active consent; uq_pc_active_scope rejects a second active consent for the same scope
+func (q *Queries) GrantPatientConsent(ctx context.Context, arg GrantPatientConsentParams) (PatientConsent, error) {
+	row := q.db.QueryRow(ctx, grantPatientConsent, arg.PatientID, arg.Scope, arg.CapturedByUserID)
+	var i PatientConsent
+	err := row.Scan(
+		&i.ConsentID,
+		&i.PatientID,
+		&i.Scope,
+		&i.GrantedAt,
+		&i.RevokedAt,
+		&i.CapturedBy,
+		&i.RevokedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CapturedByUserID,
+		&i.RevokedByUserID,
+	)
+	return i, err
+}
+
+const isOnCareTeam = `-- name: IsOnCareTeam :one
+
+SELECT EXISTS (
+    SELECT 1 FROM care_team
+    WHERE patient_id = $1 AND user_id = $2
+) AS on_care_team
+`
+
+type IsOnCareTeamParams struct {
+	PatientID int32
+	UserID    int32
+}
+
+// === Care Team & Break-Glass Queries ===
+func (q *Queries) IsOnCareTeam(ctx context.Context, arg IsOnCareTeamParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isOnCareTeam, arg.PatientID, arg.UserID)
+	var on_care_team bool
+	err := row.Scan(&on_care_team)
+	return on_care_team, err
+}
+
 const linkSymptomToPatientDisease = `-- name: LinkSymptomToPatientDisease :one
 
 INSERT INTO patient_disease_symptom (
@@ -489,52 +1476,30 @@ func (q *Queries) LinkSymptomToPatientDisease(ctx context.Context, arg LinkSympt
 	return i, err
 }
 
-const listDiseaseInstancesForPatient = `-- name: ListDiseaseInstancesForPatient :many
-SELECT
-    pd.patient_disease_id,
-    pd.diagnosis_date,
-    pd.notes,
-    pd.created_at,
-    pd.updated_at,
-    d.disease_id,
-    d.disease_name,
-    d.disease_code
-FROM patient_disease pd
-JOIN disease d ON pd.disease_id = d.disease_id
-WHERE pd.patient_id = $1
-ORDER BY pd.diagnosis_date DESC, d.disease_name
+const listAliasesForSymptoms = `-- name: ListAliasesForSymptoms :many
+SELECT symptom_alias_id, symptom_id, alias, language, source, created_at, updated_at FROM symptom_alias
+WHERE symptom_id = ANY($1::int[])
+ORDER BY symptom_id, alias
 `
 
-type ListDiseaseInstancesForPatientRow struct {
-	PatientDiseaseID int32
-	DiagnosisDate    pgtype.Date
-	Notes            pgtype.Text
-	CreatedAt        pgtype.Timestamp
-	UpdatedAt        pgtype.Timestamp
-	DiseaseID        int32
-	DiseaseName      string
-	DiseaseCode      string
-}
-
-// Lists all recorded disease instances for a specific patient
-func (q *Queries) ListDiseaseInstancesForPatient(ctx context.Context, patientID int32) ([]ListDiseaseInstancesForPatientRow, error) {
-	rows, err := q.db.Query(ctx, listDiseaseInstancesForPatient, patientID)
+// Aliases of a page of symptoms, for their responses
+func (q *Queries) ListAliasesForSymptoms(ctx context.Context, symptomIds []int32) ([]SymptomAlias, error) {
+	rows, err := q.db.Query(ctx, listAliasesForSymptoms, symptomIds)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListDiseaseInstancesForPatientRow
+	var items []SymptomAlias
 	for rows.Next() {
-		var i ListDiseaseInstancesForPatientRow
+		var i SymptomAlias
 		if err := rows.Scan(
-			&i.PatientDiseaseID,
-			&i.DiagnosisDate,
-			&i.Notes,
+			&i.SymptomAliasID,
+			&i.SymptomID,
+			&i.Alias,
+			&i.Language,
+			&i.Source,
 			&i.CreatedAt,
 			&i.UpdatedAt,
-			&i.DiseaseID,
-			&i.DiseaseName,
-			&i.DiseaseCode,
 		); err != nil {
 			return nil, err
 		}
@@ -546,28 +1511,27 @@ func (q *Queries) ListDiseaseInstancesForPatient(ctx context.Context, patientID
 	return items, nil
 }
 
-const listDiseases = `-- name: ListDiseases :many
-SELECT disease_id, disease_name, disease_code, disease_description, disease_treatment, created_at, updated_at FROM disease
-ORDER BY disease_name
+const listBreakGlassAccessesForGrants = `-- name: ListBreakGlassAccessesForGrants :many
+SELECT access_id, grant_id, method, path, accessed_at FROM break_glass_access
+WHERE grant_id = ANY($1::int[])
+ORDER BY accessed_at
 `
 
-func (q *Queries) ListDiseases(ctx context.Context) ([]Disease, error) {
-	rows, err := q.db.Query(ctx, listDiseases)
+func (q *Queries) ListBreakGlassAccessesForGrants(ctx context.Context, grantIds []int32) ([]BreakGlassAccess, error) {
+	rows, err := q.db.Query(ctx, listBreakGlassAccessesForGrants, grantIds)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Disease
+	var items []BreakGlassAccess
 	for rows.Next() {
-		var i Disease
+		var i BreakGlassAccess
 		if err := rows.Scan(
-			&i.DiseaseID,
-			&i.DiseaseName,
-			&i.DiseaseCode,
-			&i.DiseaseDescription,
-			&i.DiseaseTreatment,
-			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.AccessID,
+			&i.GrantID,
+			&i.Method,
+			&i.Path,
+			&i.AccessedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -579,40 +1543,68 @@ func (q *Queries) ListDiseases(ctx context.Context) ([]Disease, error) {
 	return items, nil
 }
 
-const listGeneralSymptomsForPatient = `-- name: ListGeneralSymptomsForPatient :many
-SELECT s.symptom_id, s.symptom_name, s.symptom_description, s.created_at, s.updated_at, ps.reported_date
-FROM symptoms s
-JOIN patient_symptoms ps ON s.symptom_id = ps.symptom_id
-WHERE ps.patient_id = $1
-ORDER BY ps.reported_date DESC, s.symptom_name
+const listBreakGlassEvents = `-- name: ListBreakGlassEvents :many
+SELECT
+    g.grant_id,
+    g.patient_id,
+    p.firstname AS patient_firstname,
+    p.lastname AS patient_lastname,
+    g.user_id,
+    u.username,
+    g.reason,
+    g.granted_at,
+    g.expires_at,
+    (SELECT COUNT(*) FROM break_glass_access a WHERE a.grant_id = g.grant_id) AS access_count,
+    (SELECT MAX(a.accessed_at) FROM break_glass_access a WHERE a.grant_id = g.grant_id)::timestamp AS last_accessed_at
+FROM break_glass_grant g
+JOIN patient p ON g.patient_id = p.patient_id
+JOIN app_user u ON g.user_id = u.user_id
+WHERE ($1::timestamp IS NULL OR g.granted_at >= $1::timestamp)
+  AND ($2::timestamp IS NULL OR g.granted_at < $2::timestamp)
+ORDER BY g.granted_at DESC
 `
 
-type ListGeneralSymptomsForPatientRow struct {
-	SymptomID          int32
-	SymptomName        string
-	SymptomDescription pgtype.Text
-	CreatedAt          pgtype.Timestamp
-	UpdatedAt          pgtype.Timestamp
-	ReportedDate       pgtype.Date
+type ListBreakGlassEventsParams struct {
+	Since pgtype.Timestamp
+	Until pgtype.Timestamp
 }
 
-// Lists general symptoms recorded for a patient via patient_symptoms table
-func (q *Queries) ListGeneralSymptomsForPatient(ctx context.Context, patientID int32) ([]ListGeneralSymptomsForPatientRow, error) {
-	rows, err := q.db.Query(ctx, listGeneralSymptomsForPatient, patientID)
+type ListBreakGlassEventsRow struct {
+	GrantID          int32
+	PatientID        int32
+	PatientFirstname string
+	PatientLastname  string
+	UserID           int32
+	Username         string
+	Reason           string
+	GrantedAt        pgtype.Timestamp
+	ExpiresAt        pgtype.Timestamp
+	AccessCount      int64
+	LastAccessedAt   pgtype.Timestamp
+}
+
+// Review report: break-glass grants in a time range with who, whom, why and how much was accessed
+func (q *Queries) ListBreakGlassEvents(ctx context.Context, arg ListBreakGlassEventsParams) ([]ListBreakGlassEventsRow, error) {
+	rows, err := q.db.Query(ctx, listBreakGlassEvents, arg.Since, arg.Until)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListGeneralSymptomsForPatientRow
+	var items []ListBreakGlassEventsRow
 	for rows.Next() {
-		var i ListGeneralSymptomsForPatientRow
+		var i ListBreakGlassEventsRow
 		if err := rows.Scan(
-			&i.SymptomID,
-			&i.SymptomName,
-			&i.SymptomDescription,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.ReportedDate,
+			&i.GrantID,
+			&i.PatientID,
+			&i.PatientFirstname,
+			&i.PatientLastname,
+			&i.UserID,
+			&i.Username,
+			&i.Reason,
+			&i.GrantedAt,
+			&i.ExpiresAt,
+			&i.AccessCount,
+			&i.LastAccessedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -624,10 +1616,294 @@ func (q *Queries) ListGeneralSymptomsForPatient(ctx context.Context, patientID i
 	return items, nil
 }
 
-const listPatientSummaries = `-- name: ListPatientSummaries :many
+const listCareTeamForPatient = `-- name: ListCareTeamForPatient :many
 SELECT
-    p.patient_id,
-    p.firstname,
+    ct.patient_id,
+    ct.user_id,
+    u.username,
+    u.display_name,
+    ct.assigned_by,
+    ct.created_at
+FROM care_team ct
+JOIN app_user u ON ct.user_id = u.user_id
+WHERE ct.patient_id = $1
+ORDER BY u.username
+`
+
+type ListCareTeamForPatientRow struct {
+	PatientID   int32
+	UserID      int32
+	Username    string
+	DisplayName pgtype.Text
+	AssignedBy  string
+	CreatedAt   pgtype.Timestamp
+}
+
+func (q *Queries) ListCareTeamForPatient(ctx context.Context, patientID int32) ([]ListCareTeamForPatientRow, error) {
+	rows, err := q.db.Query(ctx, listCareTeamForPatient, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCareTeamForPatientRow
+	for rows.Next() {
+		var i ListCareTeamForPatientRow
+		if err := rows.Scan(
+			&i.PatientID,
+			&i.UserID,
+			&i.Username,
+			&i.DisplayName,
+			&i.AssignedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClinicsForUser = `-- name: ListClinicsForUser :many
+SELECT c.clinic_id, c.clinic_name, c.created_at, c.updated_at
+FROM clinic c
+JOIN user_clinic uc ON c.clinic_id = uc.clinic_id
+WHERE uc.user_id = $1
+ORDER BY c.clinic_name
+`
+
+// Lists the clinics a user is bound to
+func (q *Queries) ListClinicsForUser(ctx context.Context, userID int32) ([]Clinic, error) {
+	rows, err := q.db.Query(ctx, listClinicsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Clinic
+	for rows.Next() {
+		var i Clinic
+		if err := rows.Scan(
+			&i.ClinicID,
+			&i.ClinicName,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listConsentsForPatient = `-- name: ListConsentsForPatient :many
+SELECT consent_id, patient_id, scope, granted_at, revoked_at, captured_by, revoked_by, created_at, updated_at, captured_by_user_id, revoked_by_user_id FROM patient_consent
+WHERE patient_id = $1
+ORDER BY granted_at DESC, consent_id DESC
+`
+
+// Lists active and revoked consents for a patient, newest first
+func (q *Queries) ListConsentsForPatient(ctx context.Context, patientID int32) ([]PatientConsent, error) {
+	rows, err := q.db.Query(ctx, listConsentsForPatient, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PatientConsent
+	for rows.Next() {
+		var i PatientConsent
+		if err := rows.Scan(
+			&i.ConsentID,
+			&i.PatientID,
+			&i.Scope,
+			&i.GrantedAt,
+			&i.RevokedAt,
+			&i.CapturedBy,
+			&i.RevokedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CapturedByUserID,
+			&i.RevokedByUserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDiseaseInstanceStatusHistory = `-- name: ListDiseaseInstanceStatusHistory :many
+SELECT id, patient_disease_id, from_status, to_status, changed_by, changed_at FROM patient_disease_status_history
+WHERE patient_disease_id = $1
+ORDER BY changed_at, id
+`
+
+func (q *Queries) ListDiseaseInstanceStatusHistory(ctx context.Context, patientDiseaseID int32) ([]PatientDiseaseStatusHistory, error) {
+	rows, err := q.db.Query(ctx, listDiseaseInstanceStatusHistory, patientDiseaseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PatientDiseaseStatusHistory
+	for rows.Next() {
+		var i PatientDiseaseStatusHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.PatientDiseaseID,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.ChangedBy,
+			&i.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDiseaseInstancesForPatient = `-- name: ListDiseaseInstancesForPatient :many
+SELECT
+    pd.patient_disease_id,
+    pd.diagnosis_date,
+    pd.notes,
+    pd.clinical_status,
+    pd.status_changed_at,
+    pd.created_at,
+    pd.updated_at,
+    pd.version,
+    d.disease_id,
+    d.disease_name,
+    d.disease_code
+FROM patient_disease pd
+JOIN disease d ON pd.disease_id = d.disease_id
+WHERE pd.patient_id = $1
+  AND ($2::varchar[] IS NULL OR pd.clinical_status = ANY($2::varchar[]))
+ORDER BY pd.diagnosis_date DESC, d.disease_name
+`
+
+type ListDiseaseInstancesForPatientParams struct {
+	PatientID int32
+	Statuses  []string
+}
+
+type ListDiseaseInstancesForPatientRow struct {
+	PatientDiseaseID int32
+	DiagnosisDate    pgtype.Date
+	Notes            pgtype.Text
+	ClinicalStatus   string
+	StatusChangedAt  pgtype.Timestamp
+	CreatedAt        pgtype.Timestamp
+	UpdatedAt        pgtype.Timestamp
+	Version          int32
+	DiseaseID        int32
+	DiseaseName      string
+	DiseaseCode      string
+}
+
+// Lists the recorded disease instances for a specific patient, optionally only
+// those in one of the given clinical statuses
+func (q *Queries) ListDiseaseInstancesForPatient(ctx context.Context, arg ListDiseaseInstancesForPatientParams) ([]ListDiseaseInstancesForPatientRow, error) {
+	rows, err := q.db.Query(ctx, listDiseaseInstancesForPatient, arg.PatientID, arg.Statuses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDiseaseInstancesForPatientRow
+	for rows.Next() {
+		var i ListDiseaseInstancesForPatientRow
+		if err := rows.Scan(
+			&i.PatientDiseaseID,
+			&i.DiagnosisDate,
+			&i.Notes,
+			&i.ClinicalStatus,
+			&i.StatusChangedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DiseaseID,
+			&i.DiseaseName,
+			&i.DiseaseCode,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGeneralSymptomsForPatient = `-- name: ListGeneralSymptomsForPatient :many
+SELECT s.symptom_id, s.symptom_name, s.symptom_description, s.created_at, s.updated_at, s.version, s.deprecated_at, s.replaced_by, ps.reported_date, ps.id AS patient_symptom_id, ps.version AS record_version
+FROM symptoms s
+JOIN patient_symptoms ps ON s.symptom_id = ps.symptom_id
+WHERE ps.patient_id = $1
+ORDER BY ps.reported_date DESC, s.symptom_name
+`
+
+type ListGeneralSymptomsForPatientRow struct {
+	SymptomID          int32
+	SymptomName        string
+	SymptomDescription pgtype.Text
+	CreatedAt          pgtype.Timestamp
+	UpdatedAt          pgtype.Timestamp
+	Version            int32
+	DeprecatedAt       pgtype.Timestamp
+	ReplacedBy         pgtype.Int4
+	ReportedDate       pgtype.Date
+	PatientSymptomID   int32
+	RecordVersion      int32
+}
+
+// Lists general symptoms recorded for a patient via patient_symptoms table
+func (q *Queries) ListGeneralSymptomsForPatient(ctx context.Context, patientID int32) ([]ListGeneralSymptomsForPatientRow, error) {
+	rows, err := q.db.Query(ctx, listGeneralSymptomsForPatient, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListGeneralSymptomsForPatientRow
+	for rows.Next() {
+		var i ListGeneralSymptomsForPatientRow
+		if err := rows.Scan(
+			&i.SymptomID,
+			&i.SymptomName,
+			&i.SymptomDescription,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeprecatedAt,
+			&i.ReplacedBy,
+			&i.ReportedDate,
+			&i.PatientSymptomID,
+			&i.RecordVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPatientSummaries = `-- name: ListPatientSummaries :many
+SELECT
+    p.patient_id,
+    p.firstname,
     p.lastname,
     p.email,
     (SELECT STRING_AGG(s_gen.symptom_name, ', ' ORDER BY s_gen.symptom_name)
@@ -640,6 +1916,8 @@ SELECT
      WHERE pd_dis.patient_id = p.patient_id) AS distinct_diseases_list
 FROM
     patient p
+WHERE
+    p.deleted_at IS NULL
 GROUP BY
     p.patient_id, p.firstname, p.lastname, p.email
 ORDER BY
@@ -684,78 +1962,840 @@ func (q *Queries) ListPatientSummaries(ctx context.Context) ([]ListPatientSummar
 	return items, nil
 }
 
-const listPatients = `-- name: ListPatients :many
-SELECT patient_id, firstname, lastname, register, age, gender, birthdate, address, phonenumber, email FROM patient
-ORDER BY lastname, firstname
-LIMIT $1 OFFSET $2
+const listPatientsWithDiseaseInstance = `-- name: ListPatientsWithDiseaseInstance :many
+SELECT p.patient_id, p.firstname, p.lastname, p.register, p.gender, p.birthdate, p.address, p.phonenumber, p.email, p.clinic_id, p.created_at, p.updated_at, p.version, p.deleted_at, p.deleted_by, p.deletion_reason
+FROM patient p
+JOIN patient_disease pd ON p.patient_id = pd.patient_id
+WHERE pd.disease_id = $1 AND p.deleted_at IS NULL
+ORDER BY p.lastname, p.firstname
+`
+
+// Lists patients who have a recorded instance of a specific disease
+func (q *Queries) ListPatientsWithDiseaseInstance(ctx context.Context, diseaseID int32) ([]Patient, error) {
+	rows, err := q.db.Query(ctx, listPatientsWithDiseaseInstance, diseaseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Patient
+	for rows.Next() {
+		var i Patient
+		if err := rows.Scan(
+			&i.PatientID,
+			&i.Firstname,
+			&i.Lastname,
+			&i.Register,
+			&i.Gender,
+			&i.Birthdate,
+			&i.Address,
+			&i.Phonenumber,
+			&i.Email,
+			&i.ClinicID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPatientsWithGeneralSymptom = `-- name: ListPatientsWithGeneralSymptom :many
+SELECT p.patient_id, p.firstname, p.lastname, p.register, p.gender, p.birthdate, p.address, p.phonenumber, p.email, p.clinic_id, p.created_at, p.updated_at, p.version, p.deleted_at, p.deleted_by, p.deletion_reason
+FROM patient p
+JOIN patient_symptoms ps ON p.patient_id = ps.patient_id
+WHERE ps.symptom_id = $1 AND p.deleted_at IS NULL
+ORDER BY p.lastname, p.firstname
+`
+
+// Lists patients who reported a specific general symptom via patient_symptoms table
+func (q *Queries) ListPatientsWithGeneralSymptom(ctx context.Context, symptomID int32) ([]Patient, error) {
+	rows, err := q.db.Query(ctx, listPatientsWithGeneralSymptom, symptomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Patient
+	for rows.Next() {
+		var i Patient
+		if err := rows.Scan(
+			&i.PatientID,
+			&i.Firstname,
+			&i.Lastname,
+			&i.Register,
+			&i.Gender,
+			&i.Birthdate,
+			&i.Address,
+			&i.Phonenumber,
+			&i.Email,
+			&i.ClinicID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSymptomAliases = `-- name: ListSymptomAliases :many
+SELECT symptom_alias_id, symptom_id, alias, language, source, created_at, updated_at FROM symptom_alias
+WHERE symptom_id = $1
+ORDER BY alias
+`
+
+func (q *Queries) ListSymptomAliases(ctx context.Context, symptomID int32) ([]SymptomAlias, error) {
+	rows, err := q.db.Query(ctx, listSymptomAliases, symptomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SymptomAlias
+	for rows.Next() {
+		var i SymptomAlias
+		if err := rows.Scan(
+			&i.SymptomAliasID,
+			&i.SymptomID,
+			&i.Alias,
+			&i.Language,
+			&i.Source,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrainingCases = `-- name: ListTrainingCases :many
+SELECT
+    pd.patient_disease_id,
+    d.disease_name,
+    pd.clinical_status,
+    COALESCE(array_agg(s.symptom_name ORDER BY s.symptom_name) FILTER (WHERE s.symptom_id IS NOT NULL), '{}')::text[] AS symptoms
+FROM patient_disease pd
+JOIN patient p ON p.patient_id = pd.patient_id
+JOIN disease d ON d.disease_id = pd.disease_id
+LEFT JOIN patient_disease_symptom pds ON pds.patient_disease_id = pd.patient_disease_id
+LEFT JOIN symptoms s ON s.symptom_id = pds.symptom_id
+WHERE p.deleted_at IS NULL
+  AND pd.clinical_status IN ('confirmed', 'resolved', 'chronic')
+  AND patient_has_consent(pd.patient_id, 'model_training')
+GROUP BY pd.patient_disease_id, d.disease_name
+ORDER BY pd.patient_disease_id
+`
+
+type ListTrainingCasesRow struct {
+	PatientDiseaseID int32
+	DiseaseName      string
+	ClinicalStatus   string
+	Symptoms         []string
+}
+
+// Model training export: settled diagnoses with their linked symptoms, only for patients with an active model_training consent
+func (q *Queries) ListTrainingCases(ctx context.Context) ([]ListTrainingCasesRow, error) {
+	rows, err := q.db.Query(ctx, listTrainingCases)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTrainingCasesRow
+	for rows.Next() {
+		var i ListTrainingCasesRow
+		if err := rows.Scan(
+			&i.PatientDiseaseID,
+			&i.DiseaseName,
+			&i.ClinicalStatus,
+			&i.Symptoms,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const mergeCollidingDiagnosisNotes = `-- name: MergeCollidingDiagnosisNotes :execrows
+UPDATE patient_disease k
+SET notes = CASE
+    WHEN k.notes IS NULL OR k.notes = '' THEN d.notes
+    ELSE k.notes || E'\n\n' || d.notes
+END
+FROM patient_disease d
+WHERE k.patient_id = $1
+  AND k.disease_id = d.disease_id
+  AND k.diagnosis_date = d.diagnosis_date
+  AND d.patient_id = $2
+  AND d.notes IS NOT NULL AND d.notes <> ''
+  AND d.notes IS DISTINCT FROM k.notes
+`
+
+type MergeCollidingDiagnosisNotesParams struct {
+	SurvivorID  int32
+	DuplicateID int32
+}
+
+// ... and its notes, appended to the survivor's
+func (q *Queries) MergeCollidingDiagnosisNotes(ctx context.Context, arg MergeCollidingDiagnosisNotesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, mergeCollidingDiagnosisNotes, arg.SurvivorID, arg.DuplicateID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const mergeDiseaseReferences = `-- name: MergeDiseaseReferences :one
+SELECT
+    r.instance_symptoms_moved::bigint AS instance_symptoms_moved,
+    r.status_history_moved::bigint AS status_history_moved,
+    r.notes_merged::bigint AS notes_merged,
+    r.instances_collapsed::bigint AS instances_collapsed,
+    r.instances_repointed::bigint AS instances_repointed
+FROM merge_disease_references($1::int, $2::int) r
+`
+
+type MergeDiseaseReferencesParams struct {
+	SurvivorID  int32
+	DuplicateID int32
+}
+
+type MergeDiseaseReferencesRow struct {
+	InstanceSymptomsMoved int64
+	StatusHistoryMoved    int64
+	NotesMerged           int64
+	InstancesCollapsed    int64
+	InstancesRepointed    int64
+}
+
+// Moves every diagnosis of the duplicate to the survivor, across all clinics
+// (000020)
+func (q *Queries) MergeDiseaseReferences(ctx context.Context, arg MergeDiseaseReferencesParams) (MergeDiseaseReferencesRow, error) {
+	row := q.db.QueryRow(ctx, mergeDiseaseReferences, arg.SurvivorID, arg.DuplicateID)
+	var i MergeDiseaseReferencesRow
+	err := row.Scan(
+		&i.InstanceSymptomsMoved,
+		&i.StatusHistoryMoved,
+		&i.NotesMerged,
+		&i.InstancesCollapsed,
+		&i.InstancesRepointed,
+	)
+	return i, err
+}
+
+const mergeSymptomReferences = `-- name: MergeSymptomReferences :one
+SELECT
+    r.patient_symptoms_collapsed::bigint AS patient_symptoms_collapsed,
+    r.patient_symptoms_repointed::bigint AS patient_symptoms_repointed,
+    r.instance_symptoms_collapsed::bigint AS instance_symptoms_collapsed,
+    r.instance_symptoms_repointed::bigint AS instance_symptoms_repointed
+FROM merge_symptom_references($1::int, $2::int) r
+`
+
+type MergeSymptomReferencesParams struct {
+	SurvivorID  int32
+	DuplicateID int32
+}
+
+type MergeSymptomReferencesRow struct {
+	PatientSymptomsCollapsed  int64
+	PatientSymptomsRepointed  int64
+	InstanceSymptomsCollapsed int64
+	InstanceSymptomsRepointed int64
+}
+
+// Moves every patient record of the duplicate to the survivor, across all
+// clinics (000020)
+func (q *Queries) MergeSymptomReferences(ctx context.Context, arg MergeSymptomReferencesParams) (MergeSymptomReferencesRow, error) {
+	row := q.db.QueryRow(ctx, mergeSymptomReferences, arg.SurvivorID, arg.DuplicateID)
+	var i MergeSymptomReferencesRow
+	err := row.Scan(
+		&i.PatientSymptomsCollapsed,
+		&i.PatientSymptomsRepointed,
+		&i.InstanceSymptomsCollapsed,
+		&i.InstanceSymptomsRepointed,
+	)
+	return i, err
+}
+
+const moveCollidingDiagnosisHistory = `-- name: MoveCollidingDiagnosisHistory :execrows
+UPDATE patient_disease_status_history h
+SET patient_disease_id = k.patient_disease_id
+FROM patient_disease d
+JOIN patient_disease k
+  ON k.patient_id = $2
+ AND k.disease_id = d.disease_id
+ AND k.diagnosis_date = d.diagnosis_date
+WHERE h.patient_disease_id = d.patient_disease_id
+  AND d.patient_id = $1
+`
+
+type MoveCollidingDiagnosisHistoryParams struct {
+	DuplicateID int32
+	SurvivorID  int32
+}
+
+// ... and its status history
+func (q *Queries) MoveCollidingDiagnosisHistory(ctx context.Context, arg MoveCollidingDiagnosisHistoryParams) (int64, error) {
+	result, err := q.db.Exec(ctx, moveCollidingDiagnosisHistory, arg.DuplicateID, arg.SurvivorID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const moveCollidingDiagnosisSymptoms = `-- name: MoveCollidingDiagnosisSymptoms :execrows
+UPDATE patient_disease_symptom pds
+SET patient_disease_id = k.patient_disease_id
+FROM patient_disease d
+JOIN patient_disease k
+  ON k.patient_id = $2
+ AND k.disease_id = d.disease_id
+ AND k.diagnosis_date = d.diagnosis_date
+WHERE pds.patient_disease_id = d.patient_disease_id
+  AND d.patient_id = $1
+  AND NOT EXISTS (
+    SELECT 1 FROM patient_disease_symptom x
+    WHERE x.patient_disease_id = k.patient_disease_id
+      AND x.symptom_id = pds.symptom_id
+  )
+`
+
+type MoveCollidingDiagnosisSymptomsParams struct {
+	DuplicateID int32
+	SurvivorID  int32
+}
+
+// A diagnosis of the duplicate that the survivor also has (same disease and
+// date) hands its symptom links to the survivor's diagnosis
+func (q *Queries) MoveCollidingDiagnosisSymptoms(ctx context.Context, arg MoveCollidingDiagnosisSymptomsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, moveCollidingDiagnosisSymptoms, arg.DuplicateID, arg.SurvivorID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const moveDiagnoses = `-- name: MoveDiagnoses :execrows
+UPDATE patient_disease
+SET patient_id = $1
+WHERE patient_id = $2
+`
+
+type MoveDiagnosesParams struct {
+	SurvivorID  int32
+	DuplicateID int32
+}
+
+func (q *Queries) MoveDiagnoses(ctx context.Context, arg MoveDiagnosesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, moveDiagnoses, arg.SurvivorID, arg.DuplicateID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const moveDiseaseAliases = `-- name: MoveDiseaseAliases :execrows
+UPDATE disease_alias a
+SET disease_id = $1
+FROM disease k
+WHERE a.disease_id = $2
+  AND k.disease_id = $1
+  AND LOWER(a.alias) <> LOWER(k.disease_name)
+`
+
+type MoveDiseaseAliasesParams struct {
+	SurvivorID  int32
+	DuplicateID int32
+}
+
+func (q *Queries) MoveDiseaseAliases(ctx context.Context, arg MoveDiseaseAliasesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, moveDiseaseAliases, arg.SurvivorID, arg.DuplicateID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const moveReportedSymptoms = `-- name: MoveReportedSymptoms :execrows
+UPDATE patient_symptoms
+SET patient_id = $1
+WHERE patient_id = $2
+`
+
+type MoveReportedSymptomsParams struct {
+	SurvivorID  int32
+	DuplicateID int32
+}
+
+func (q *Queries) MoveReportedSymptoms(ctx context.Context, arg MoveReportedSymptomsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, moveReportedSymptoms, arg.SurvivorID, arg.DuplicateID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const moveSymptomAliases = `-- name: MoveSymptomAliases :execrows
+UPDATE symptom_alias a
+SET symptom_id = $1
+FROM symptoms k
+WHERE a.symptom_id = $2
+  AND k.symptom_id = $1
+  AND LOWER(a.alias) <> LOWER(k.symptom_name)
+`
+
+type MoveSymptomAliasesParams struct {
+	SurvivorID  int32
+	DuplicateID int32
+}
+
+// An alias spelling the survivor's own name is dropped with the duplicate
+func (q *Queries) MoveSymptomAliases(ctx context.Context, arg MoveSymptomAliasesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, moveSymptomAliases, arg.SurvivorID, arg.DuplicateID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const patientHasConsent = `-- name: PatientHasConsent :one
+SELECT patient_has_consent($1::int, $2::varchar)::boolean AS has_consent
+`
+
+type PatientHasConsentParams struct {
+	PatientID int32
+	Scope     string
+}
+
+func (q *Queries) PatientHasConsent(ctx context.Context, arg PatientHasConsentParams) (bool, error) {
+	row := q.db.QueryRow(ctx, patientHasConsent, arg.PatientID, arg.Scope)
+	var has_consent bool
+	err := row.Scan(&has_consent)
+	return has_consent, err
+}
+
+const purgeDeletedPatients = `-- name: PurgeDeletedPatients :many
+SELECT
+    r.patient_id::int AS patient_id,
+    r.clinic_id::int AS clinic_id,
+    r.deleted_at::timestamp AS deleted_at,
+    COALESCE(r.deleted_by, 0)::int AS deleted_by
+FROM purge_deleted_patients($1::int, $2::int) r
+`
+
+type PurgeDeletedPatientsParams struct {
+	RetentionSeconds int32
+	BatchSize        int32
+}
+
+type PurgeDeletedPatientsRow struct {
+	PatientID int32
+	ClinicID  int32
+	DeletedAt pgtype.Timestamp
+	DeletedBy int32
+}
+
+// Removes patients soft-deleted longer ago than the retention period, with
+// their whole history (ON DELETE CASCADE), across all clinics, a batch at a
+// time (000020).
+// deleted_by is 0 when the deleting user is gone; the reason is not returned,
+// as it may describe the patient.
+func (q *Queries) PurgeDeletedPatients(ctx context.Context, arg PurgeDeletedPatientsParams) ([]PurgeDeletedPatientsRow, error) {
+	rows, err := q.db.Query(ctx, purgeDeletedPatients, arg.RetentionSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PurgeDeletedPatientsRow
+	for rows.Next() {
+		var i PurgeDeletedPatientsRow
+		if err := rows.Scan(
+			&i.PatientID,
+			&i.ClinicID,
+			&i.DeletedAt,
+			&i.DeletedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordBreakGlassAccess = `-- name: RecordBreakGlassAccess :exec
+INSERT INTO break_glass_access (
+    grant_id, method, path
+) VALUES (
+    $1, $2, $3
+)
+`
+
+type RecordBreakGlassAccessParams struct {
+	GrantID int32
+	Method  string
+	Path    string
+}
+
+func (q *Queries) RecordBreakGlassAccess(ctx context.Context, arg RecordBreakGlassAccessParams) error {
+	_, err := q.db.Exec(ctx, recordBreakGlassAccess, arg.GrantID, arg.Method, arg.Path)
+	return err
+}
+
+const recordDiseaseInstanceStatus = `-- name: RecordDiseaseInstanceStatus :exec
+INSERT INTO patient_disease_status_history (
+    patient_disease_id, from_status, to_status, changed_by
+) VALUES (
+    $1, $2, $3, $4
+)
+`
+
+type RecordDiseaseInstanceStatusParams struct {
+	PatientDiseaseID int32
+	FromStatus       pgtype.Text
+	ToStatus         string
+	ChangedBy        string
+}
+
+// Appends a status change (or the initial status, with no from_status) to the history
+func (q *Queries) RecordDiseaseInstanceStatus(ctx context.Context, arg RecordDiseaseInstanceStatusParams) error {
+	_, err := q.db.Exec(ctx, recordDiseaseInstanceStatus,
+		arg.PatientDiseaseID,
+		arg.FromStatus,
+		arg.ToStatus,
+		arg.ChangedBy,
+	)
+	return err
+}
+
+const recordPatientDiseaseInstance = `-- name: RecordPatientDiseaseInstance :one
+
+INSERT INTO patient_disease (
+    patient_id, disease_id, diagnosis_date, notes, clinical_status
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING patient_disease_id, patient_id, disease_id, diagnosis_date, notes, created_at, updated_at, clinic_id, version, clinical_status, status_changed_at
+`
+
+type RecordPatientDiseaseInstanceParams struct {
+	PatientID      int32
+	DiseaseID      int32
+	DiagnosisDate  pgtype.Date
+	Notes          pgtype.Text
+	ClinicalStatus string
+}
+
+// === Patient Disease Instance Queries ===
+// Records a specific diagnosis instance for a patient
+func (q *Queries) RecordPatientDiseaseInstance(ctx context.Context, arg RecordPatientDiseaseInstanceParams) (PatientDisease, error) {
+	row := q.db.QueryRow(ctx, recordPatientDiseaseInstance,
+		arg.PatientID,
+		arg.DiseaseID,
+		arg.DiagnosisDate,
+		arg.Notes,
+		arg.ClinicalStatus,
+	)
+	var i PatientDisease
+	err := row.Scan(
+		&i.PatientDiseaseID,
+		&i.PatientID,
+		&i.DiseaseID,
+		&i.DiagnosisDate,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClinicID,
+		&i.Version,
+		&i.ClinicalStatus,
+		&i.StatusChangedAt,
+	)
+	return i, err
+}
+
+const recordPatientSymptom = `-- name: RecordPatientSymptom :one
+
+INSERT INTO patient_symptoms (
+    patient_id, symptom_id, reported_date
+) VALUES (
+    $1, $2, $3
+)
+RETURNING id, patient_id, symptom_id, reported_date, created_at, updated_at, clinic_id, version
+`
+
+type RecordPatientSymptomParams struct {
+	PatientID    int32
+	SymptomID    int32
+	ReportedDate pgtype.Date
+}
+
+// === Patient Symptom Queries (General - Optional Table) ===
+// Use these if you need to record symptoms reported outside a specific diagnosis
+// Records a general symptom for a patient, optionally with a date
+func (q *Queries) RecordPatientSymptom(ctx context.Context, arg RecordPatientSymptomParams) (PatientSymptom, error) {
+	row := q.db.QueryRow(ctx, recordPatientSymptom, arg.PatientID, arg.SymptomID, arg.ReportedDate)
+	var i PatientSymptom
+	err := row.Scan(
+		&i.ID,
+		&i.PatientID,
+		&i.SymptomID,
+		&i.ReportedDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClinicID,
+		&i.Version,
+	)
+	return i, err
+}
+
+const recordPatientSymptomIfAbsent = `-- name: RecordPatientSymptomIfAbsent :execrows
+INSERT INTO patient_symptoms (
+    patient_id, symptom_id, reported_date
+) VALUES (
+    $1, $2, COALESCE($3::date, CURRENT_DATE)
+)
+ON CONFLICT (patient_id, symptom_id, reported_date) DO NOTHING
+`
+
+type RecordPatientSymptomIfAbsentParams struct {
+	PatientID    int32
+	SymptomID    int32
+	ReportedDate pgtype.Date
+}
+
+// Records a general symptom (dated today when no date is given) unless the
+// patient already reported it on that date
+func (q *Queries) RecordPatientSymptomIfAbsent(ctx context.Context, arg RecordPatientSymptomIfAbsentParams) (int64, error) {
+	result, err := q.db.Exec(ctx, recordPatientSymptomIfAbsent, arg.PatientID, arg.SymptomID, arg.ReportedDate)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const reinstateDisease = `-- name: ReinstateDisease :one
+UPDATE disease
+SET
+    deprecated_at = NULL,
+    replaced_by = NULL
+WHERE disease_id = $1
+RETURNING disease_id, disease_name, disease_code, disease_description, disease_treatment, created_at, updated_at, version, deprecated_at, replaced_by
+`
+
+func (q *Queries) ReinstateDisease(ctx context.Context, diseaseID int32) (Disease, error) {
+	row := q.db.QueryRow(ctx, reinstateDisease, diseaseID)
+	var i Disease
+	err := row.Scan(
+		&i.DiseaseID,
+		&i.DiseaseName,
+		&i.DiseaseCode,
+		&i.DiseaseDescription,
+		&i.DiseaseTreatment,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeprecatedAt,
+		&i.ReplacedBy,
+	)
+	return i, err
+}
+
+const reinstateSymptom = `-- name: ReinstateSymptom :one
+UPDATE symptoms
+SET
+    deprecated_at = NULL,
+    replaced_by = NULL
+WHERE symptom_id = $1
+RETURNING symptom_id, symptom_name, symptom_description, created_at, updated_at, version, deprecated_at, replaced_by
+`
+
+func (q *Queries) ReinstateSymptom(ctx context.Context, symptomID int32) (Symptom, error) {
+	row := q.db.QueryRow(ctx, reinstateSymptom, symptomID)
+	var i Symptom
+	err := row.Scan(
+		&i.SymptomID,
+		&i.SymptomName,
+		&i.SymptomDescription,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeprecatedAt,
+		&i.ReplacedBy,
+	)
+	return i, err
+}
+
+const releaseIdempotencyKey = `-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_key
+WHERE scope = $1 AND idempotency_key = $2 AND status_code IS NULL
+`
+
+type ReleaseIdempotencyKeyParams struct {
+	Scope          string
+	IdempotencyKey string
+}
+
+// Drops a claim whose request failed without a response worth replaying
+func (q *Queries) ReleaseIdempotencyKey(ctx context.Context, arg ReleaseIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, releaseIdempotencyKey, arg.Scope, arg.IdempotencyKey)
+	return err
+}
+
+const removeCareTeamMember = `-- name: RemoveCareTeamMember :exec
+DELETE FROM care_team
+WHERE patient_id = $1 AND user_id = $2
+`
+
+type RemoveCareTeamMemberParams struct {
+	PatientID int32
+	UserID    int32
+}
+
+func (q *Queries) RemoveCareTeamMember(ctx context.Context, arg RemoveCareTeamMemberParams) error {
+	_, err := q.db.Exec(ctx, removeCareTeamMember, arg.PatientID, arg.UserID)
+	return err
+}
+
+const removePatientSymptom = `-- name: RemovePatientSymptom :exec
+DELETE FROM patient_symptoms
+WHERE patient_id = $1 AND symptom_id = $2 AND reported_date = $3
+`
+
+type RemovePatientSymptomParams struct {
+	PatientID    int32
+	SymptomID    int32
+	ReportedDate pgtype.Date
+}
+
+// Removes a specific general symptom record for a patient
+func (q *Queries) RemovePatientSymptom(ctx context.Context, arg RemovePatientSymptomParams) error {
+	_, err := q.db.Exec(ctx, removePatientSymptom, arg.PatientID, arg.SymptomID, arg.ReportedDate)
+	return err
+}
+
+const removePatientSymptomByID = `-- name: RemovePatientSymptomByID :execrows
+DELETE FROM patient_symptoms ps
+WHERE ps.id = $1 AND ps.version = $2
+  AND NOT EXISTS (
+    SELECT 1 FROM patient p
+    WHERE p.patient_id = ps.patient_id AND p.deleted_at IS NOT NULL
+  )
 `
 
-type ListPatientsParams struct {
-	Limit  int32
-	Offset int32
+type RemovePatientSymptomByIDParams struct {
+	ID      int32
+	Version int32
 }
 
-func (q *Queries) ListPatients(ctx context.Context, arg ListPatientsParams) ([]Patient, error) {
-	rows, err := q.db.Query(ctx, listPatients, arg.Limit, arg.Offset)
+// Removes a specific general symptom record by its ID. A soft-deleted
+// patient's records stay as they are until restore or purge, and a record
+// whose version has moved on is not removed.
+func (q *Queries) RemovePatientSymptomByID(ctx context.Context, arg RemovePatientSymptomByIDParams) (int64, error) {
+	result, err := q.db.Exec(ctx, removePatientSymptomByID, arg.ID, arg.Version)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer rows.Close()
-	var items []Patient
-	for rows.Next() {
-		var i Patient
-		if err := rows.Scan(
-			&i.PatientID,
-			&i.Firstname,
-			&i.Lastname,
-			&i.Register,
-			&i.Age,
-			&i.Gender,
-			&i.Birthdate,
-			&i.Address,
-			&i.Phonenumber,
-			&i.Email,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+	return result.RowsAffected(), nil
+}
+
+const repointDiseaseReplacements = `-- name: RepointDiseaseReplacements :execrows
+UPDATE disease
+SET replaced_by = $1::int
+WHERE replaced_by = $2::int
+`
+
+type RepointDiseaseReplacementsParams struct {
+	ReplacedBy int32
+	DiseaseID  int32
+}
+
+// Diseases replaced by one being deprecated follow it to its replacement
+func (q *Queries) RepointDiseaseReplacements(ctx context.Context, arg RepointDiseaseReplacementsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, repointDiseaseReplacements, arg.ReplacedBy, arg.DiseaseID)
+	if err != nil {
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected(), nil
+}
+
+const repointSymptomReplacements = `-- name: RepointSymptomReplacements :execrows
+UPDATE symptoms
+SET replaced_by = $1::int
+WHERE replaced_by = $2::int
+`
+
+type RepointSymptomReplacementsParams struct {
+	ReplacedBy int32
+	SymptomID  int32
+}
+
+// Symptoms replaced by one being deprecated follow it to its replacement
+func (q *Queries) RepointSymptomReplacements(ctx context.Context, arg RepointSymptomReplacementsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, repointSymptomReplacements, arg.ReplacedBy, arg.SymptomID)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
-const listPatientsWithDiseaseInstance = `-- name: ListPatientsWithDiseaseInstance :many
-SELECT p.patient_id, p.firstname, p.lastname, p.register, p.age, p.gender, p.birthdate, p.address, p.phonenumber, p.email
-FROM patient p
-JOIN patient_disease pd ON p.patient_id = pd.patient_id
-WHERE pd.disease_id = $1
-ORDER BY p.lastname, p.firstname
+const resolveSymptomNames = `-- name: ResolveSymptomNames :many
+SELECT DISTINCT ON (n.name)
+    n.name::text AS name,
+    s.symptom_id,
+    s.symptom_name
+FROM unnest($1::text[]) AS n(name)
+JOIN symptoms s ON (LOWER(s.symptom_name) = LOWER(n.name)
+    OR s.symptom_id IN (SELECT a.symptom_id FROM symptom_alias a WHERE LOWER(a.alias) = LOWER(n.name)))
+    AND s.deprecated_at IS NULL
+ORDER BY n.name, (LOWER(s.symptom_name) = LOWER(n.name)) DESC, s.symptom_id
 `
 
-// Lists patients who have a recorded instance of a specific disease
-func (q *Queries) ListPatientsWithDiseaseInstance(ctx context.Context, diseaseID int32) ([]Patient, error) {
-	rows, err := q.db.Query(ctx, listPatientsWithDiseaseInstance, diseaseID)
+type ResolveSymptomNamesRow struct {
+	Name        string
+	SymptomID   int32
+	SymptomName string
+}
+
+// Maps names to canonical symptoms, case-insensitively. A symptom's own name
+// wins over another symptom's alias; names matching nothing are left out.
+// Deprecated symptoms are skipped, so a merged duplicate's name resolves
+// through its alias on the survivor.
+func (q *Queries) ResolveSymptomNames(ctx context.Context, names []string) ([]ResolveSymptomNamesRow, error) {
+	rows, err := q.db.Query(ctx, resolveSymptomNames, names)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Patient
+	var items []ResolveSymptomNamesRow
 	for rows.Next() {
-		var i Patient
-		if err := rows.Scan(
-			&i.PatientID,
-			&i.Firstname,
-			&i.Lastname,
-			&i.Register,
-			&i.Age,
-			&i.Gender,
-			&i.Birthdate,
-			&i.Address,
-			&i.Phonenumber,
-			&i.Email,
-		); err != nil {
+		var i ResolveSymptomNamesRow
+		if err := rows.Scan(&i.Name, &i.SymptomID, &i.SymptomName); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -766,66 +2806,166 @@ func (q *Queries) ListPatientsWithDiseaseInstance(ctx context.Context, diseaseID
 	return items, nil
 }
 
-const listPatientsWithGeneralSymptom = `-- name: ListPatientsWithGeneralSymptom :many
-SELECT p.patient_id, p.firstname, p.lastname, p.register, p.age, p.gender, p.birthdate, p.address, p.phonenumber, p.email
+const restorePatient = `-- name: RestorePatient :one
+UPDATE patient
+SET
+    deleted_at = NULL,
+    deleted_by = NULL,
+    deletion_reason = NULL
+WHERE patient_id = $1 AND deleted_at IS NOT NULL
+RETURNING patient_id, firstname, lastname, register, gender, birthdate, address, phonenumber, email, clinic_id, created_at, updated_at, version, deleted_at, deleted_by, deletion_reason
+`
+
+func (q *Queries) RestorePatient(ctx context.Context, patientID int32) (Patient, error) {
+	row := q.db.QueryRow(ctx, restorePatient, patientID)
+	var i Patient
+	err := row.Scan(
+		&i.PatientID,
+		&i.Firstname,
+		&i.Lastname,
+		&i.Register,
+		&i.Gender,
+		&i.Birthdate,
+		&i.Address,
+		&i.Phonenumber,
+		&i.Email,
+		&i.ClinicID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+	)
+	return i, err
+}
+
+const revokePatientConsent = `-- name: RevokePatientConsent :one
+UPDATE patient_consent
+SET
+    revoked_at = CURRENT_TIMESTAMP,
+    revoked_by_user_id = $3
+WHERE consent_id = $1 AND patient_id = $2 AND revoked_at IS NULL
+RETURNING consent_id, patient_id, scope, granted_at, revoked_at, captured_by, revoked_by, created_at, updated_at, captured_by_user_id, revoked_by_user_id
+`
+
+type RevokePatientConsentParams struct {
+	ConsentID       int32
+	PatientID       int32
+	RevokedByUserID pgtype.Int4
+}
+
+// Revokes an active consent, keeping the row for the audit trail
+func (q *Queries) RevokePatientConsent(ctx context.Context, arg RevokePatientConsentParams) (PatientConsent, error) {
+	row := q.db.QueryRow(ctx, revokePatientConsent, arg.ConsentID, arg.PatientID, arg.RevokedByUserID)
+	var i PatientConsent
+	err := row.Scan(
+		&i.ConsentID,
+		&i.PatientID,
+		&i.Scope,
+		&i.GrantedAt,
+		&i.RevokedAt,
+		&i.CapturedBy,
+		&i.RevokedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CapturedByUserID,
+		&i.RevokedByUserID,
+	)
+	return i, err
+}
+
+const searchPatients = `-- name: SearchPatients :many
+
+SELECT
+    p.patient_id, p.firstname, p.lastname, p.register, p.gender, p.birthdate, p.address,
+    p.phonenumber, p.email, p.clinic_id, p.created_at, p.updated_at, p.version,
+    (GREATEST(
+        word_similarity($1::text, st.txt),
+        word_similarity($2::text, st.txt),
+        word_similarity($3::text, st.txt)
+    ) + ts_rank(to_tsvector('simple', st.txt), to_tsquery('simple', $4::text)))::float8 AS score
 FROM patient p
-JOIN patient_symptoms ps ON p.patient_id = ps.patient_id
-WHERE ps.symptom_id = $1
-ORDER BY p.lastname, p.firstname
+CROSS JOIN LATERAL (
+    SELECT patient_search_text(p.firstname, p.lastname, p.register, p.phonenumber, p.email) AS txt
+) st
+WHERE p.deleted_at IS NULL AND patient_visible_to(p.patient_id, $5::int) AND (
+      patient_search_text(p.firstname, p.lastname, p.register, p.phonenumber, p.email) ILIKE '%' || $1::text || '%'
+   OR patient_search_text(p.firstname, p.lastname, p.register, p.phonenumber, p.email) ILIKE '%' || $2::text || '%'
+   OR patient_search_text(p.firstname, p.lastname, p.register, p.phonenumber, p.email) ILIKE '%' || $3::text || '%'
+   OR $1::text <% patient_search_text(p.firstname, p.lastname, p.register, p.phonenumber, p.email)
+   OR $2::text <% patient_search_text(p.firstname, p.lastname, p.register, p.phonenumber, p.email)
+   OR $3::text <% patient_search_text(p.firstname, p.lastname, p.register, p.phonenumber, p.email)
+   OR to_tsvector('simple', patient_search_text(p.firstname, p.lastname, p.register, p.phonenumber, p.email)) @@ to_tsquery('simple', $4::text)
+)
+ORDER BY score DESC, p.lastname, p.firstname, p.patient_id
+LIMIT $6
 `
 
-// Lists patients who reported a specific general symptom via patient_symptoms table
-func (q *Queries) ListPatientsWithGeneralSymptom(ctx context.Context, symptomID int32) ([]Patient, error) {
-	rows, err := q.db.Query(ctx, listPatientsWithGeneralSymptom, symptomID)
+type SearchPatientsParams struct {
+	Term         string
+	TermCyrillic string
+	TermLatin    string
+	Tsquery      string
+	UserID       int32
+	Limit        int32
+}
+
+type SearchPatientsRow struct {
+	PatientID   int32
+	Firstname   string
+	Lastname    string
+	Register    string
+	Gender      string
+	Birthdate   pgtype.Date
+	Address     pgtype.Text
+	Phonenumber string
+	Email       string
+	ClinicID    int32
+	CreatedAt   pgtype.Timestamp
+	UpdatedAt   pgtype.Timestamp
+	Version     int32
+	Score       float64
+}
+
+// Patient listings are built by ListPatientsPage (db/patient_list.go): their
+// filters and keyset pagination vary too much for a single static query.
+// Ranks patients by how well any spelling of the query (as typed and
+// transliterated, lower-cased) matches their name, register, phone or email:
+// the best word similarity plus the full-text rank. A patient matches on a
+// substring, a similar word (pg_trgm <%) or the prefix tsquery. Only patients
+// the user can read (patient_visible_to) are searched.
+func (q *Queries) SearchPatients(ctx context.Context, arg SearchPatientsParams) ([]SearchPatientsRow, error) {
+	rows, err := q.db.Query(ctx, searchPatients,
+		arg.Term,
+		arg.TermCyrillic,
+		arg.TermLatin,
+		arg.Tsquery,
+		arg.UserID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Patient
+	var items []SearchPatientsRow
 	for rows.Next() {
-		var i Patient
+		var i SearchPatientsRow
 		if err := rows.Scan(
 			&i.PatientID,
 			&i.Firstname,
 			&i.Lastname,
 			&i.Register,
-			&i.Age,
 			&i.Gender,
 			&i.Birthdate,
 			&i.Address,
 			&i.Phonenumber,
 			&i.Email,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const listSymptoms = `-- name: ListSymptoms :many
-SELECT symptom_id, symptom_name, symptom_description, created_at, updated_at FROM symptoms
-ORDER BY symptom_name
-`
-
-func (q *Queries) ListSymptoms(ctx context.Context) ([]Symptom, error) {
-	rows, err := q.db.Query(ctx, listSymptoms)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Symptom
-	for rows.Next() {
-		var i Symptom
-		if err := rows.Scan(
-			&i.SymptomID,
-			&i.SymptomName,
-			&i.SymptomDescription,
+			&i.ClinicID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Version,
+			&i.Score,
 		); err != nil {
 			return nil, err
 		}
@@ -837,106 +2977,90 @@ func (q *Queries) ListSymptoms(ctx context.Context) ([]Symptom, error) {
 	return items, nil
 }
 
-const recordPatientDiseaseInstance = `-- name: RecordPatientDiseaseInstance :one
-
-INSERT INTO patient_disease (
-    patient_id, disease_id, diagnosis_date, notes
-) VALUES (
-    $1, $2, $3, $4
-)
-RETURNING patient_disease_id, patient_id, disease_id, diagnosis_date, notes, created_at, updated_at
+const softDeletePatient = `-- name: SoftDeletePatient :one
+UPDATE patient
+SET
+    deleted_at = CURRENT_TIMESTAMP,
+    deleted_by = $1::int,
+    deletion_reason = $2
+WHERE patient_id = $3 AND deleted_at IS NULL
+  AND ($4::int IS NULL OR version = $4)
+RETURNING patient_id, firstname, lastname, register, gender, birthdate, address, phonenumber, email, clinic_id, created_at, updated_at, version, deleted_at, deleted_by, deletion_reason
 `
 
-type RecordPatientDiseaseInstanceParams struct {
-	PatientID     int32
-	DiseaseID     int32
-	DiagnosisDate pgtype.Date
-	Notes         pgtype.Text
+type SoftDeletePatientParams struct {
+	DeletedBy      int32
+	DeletionReason pgtype.Text
+	PatientID      int32
+	Version        pgtype.Int4
 }
 
-// === Patient Disease Instance Queries ===
-// Records a specific diagnosis instance for a patient
-func (q *Queries) RecordPatientDiseaseInstance(ctx context.Context, arg RecordPatientDiseaseInstanceParams) (PatientDisease, error) {
-	row := q.db.QueryRow(ctx, recordPatientDiseaseInstance,
+// Hides the patient; their history stays until PurgeDeletedPatients. Given a
+// version, only that version is deleted (If-Match).
+func (q *Queries) SoftDeletePatient(ctx context.Context, arg SoftDeletePatientParams) (Patient, error) {
+	row := q.db.QueryRow(ctx, softDeletePatient,
+		arg.DeletedBy,
+		arg.DeletionReason,
 		arg.PatientID,
-		arg.DiseaseID,
-		arg.DiagnosisDate,
-		arg.Notes,
+		arg.Version,
 	)
-	var i PatientDisease
+	var i Patient
 	err := row.Scan(
-		&i.PatientDiseaseID,
 		&i.PatientID,
-		&i.DiseaseID,
-		&i.DiagnosisDate,
-		&i.Notes,
+		&i.Firstname,
+		&i.Lastname,
+		&i.Register,
+		&i.Gender,
+		&i.Birthdate,
+		&i.Address,
+		&i.Phonenumber,
+		&i.Email,
+		&i.ClinicID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
 	)
 	return i, err
 }
 
-const recordPatientSymptom = `-- name: RecordPatientSymptom :one
+const takeRateLimitToken = `-- name: TakeRateLimitToken :one
 
-INSERT INTO patient_symptoms (
-    patient_id, symptom_id, reported_date
+INSERT INTO rate_limit_bucket AS b (
+    bucket_key, tokens, allowed, updated_at
 ) VALUES (
-    $1, $2, $3
+    $1, $2::float8 - 1, TRUE, clock_timestamp()
 )
-RETURNING id, patient_id, symptom_id, reported_date, created_at, updated_at
-`
-
-type RecordPatientSymptomParams struct {
-	PatientID    int32
-	SymptomID    int32
-	ReportedDate pgtype.Date
-}
-
-// === Patient Symptom Queries (General - Optional Table) ===
-// Use these if you need to record symptoms reported outside a specific diagnosis
-// Records a general symptom for a patient, optionally with a date
-func (q *Queries) RecordPatientSymptom(ctx context.Context, arg RecordPatientSymptomParams) (PatientSymptom, error) {
-	row := q.db.QueryRow(ctx, recordPatientSymptom, arg.PatientID, arg.SymptomID, arg.ReportedDate)
-	var i PatientSymptom
-	err := row.Scan(
-		&i.ID,
-		&i.PatientID,
-		&i.SymptomID,
-		&i.ReportedDate,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
-}
-
-const removePatientSymptom = `-- name: RemovePatientSymptom :exec
-DELETE FROM patient_symptoms
-WHERE patient_id = $1 AND symptom_id = $2 AND reported_date = $3
+ON CONFLICT (bucket_key) DO UPDATE
+SET
+    allowed = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at)::float8 * $3::float8) >= 1,
+    tokens = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at)::float8 * $3::float8)
+        - CASE WHEN LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at)::float8 * $3::float8) >= 1 THEN 1 ELSE 0 END,
+    updated_at = clock_timestamp()
+RETURNING allowed, tokens
 `
 
-type RemovePatientSymptomParams struct {
-	PatientID    int32
-	SymptomID    int32
-	ReportedDate pgtype.Date
+type TakeRateLimitTokenParams struct {
+	BucketKey string
+	Burst     float64
+	PerSecond float64
 }
 
-// Removes a specific general symptom record for a patient
-func (q *Queries) RemovePatientSymptom(ctx context.Context, arg RemovePatientSymptomParams) error {
-	_, err := q.db.Exec(ctx, removePatientSymptom, arg.PatientID, arg.SymptomID, arg.ReportedDate)
-	return err
+type TakeRateLimitTokenRow struct {
+	Allowed bool
+	Tokens  float64
 }
 
-const removePatientSymptomByID = `-- name: RemovePatientSymptomByID :exec
-
-DELETE FROM patient_symptoms
-WHERE id = $1
-`
-
-// More specific deletion
-// Removes a specific general symptom record by its ID
-func (q *Queries) RemovePatientSymptomByID(ctx context.Context, id int32) error {
-	_, err := q.db.Exec(ctx, removePatientSymptomByID, id)
-	return err
+// === Rate Limit Queries ===
+// Refills the bucket for the time elapsed since its last use, then takes one token if available.
+// allowed reports whether a token was taken; tokens is what is left.
+func (q *Queries) TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (TakeRateLimitTokenRow, error) {
+	row := q.db.QueryRow(ctx, takeRateLimitToken, arg.BucketKey, arg.Burst, arg.PerSecond)
+	var i TakeRateLimitTokenRow
+	err := row.Scan(&i.Allowed, &i.Tokens)
+	return i, err
 }
 
 const unlinkSymptomFromPatientDisease = `-- name: UnlinkSymptomFromPatientDisease :exec
@@ -956,14 +3080,15 @@ func (q *Queries) UnlinkSymptomFromPatientDisease(ctx context.Context, arg Unlin
 }
 
 const updateDisease = `-- name: UpdateDisease :one
+
 UPDATE disease
 SET
     disease_name = $2,
     disease_code = $3,
     disease_description = $4,
     disease_treatment = $5 -- $5 should be valid JSON(B) text or compatible type
-WHERE disease_id = $1
-RETURNING disease_id, disease_name, disease_code, disease_description, disease_treatment, created_at, updated_at
+WHERE disease_id = $1 AND version = $6
+RETURNING disease_id, disease_name, disease_code, disease_description, disease_treatment, created_at, updated_at, version, deprecated_at, replaced_by
 `
 
 type UpdateDiseaseParams struct {
@@ -972,9 +3097,12 @@ type UpdateDiseaseParams struct {
 	DiseaseCode        string
 	DiseaseDescription pgtype.Text
 	DiseaseTreatment   []byte
+	Version            int32
 }
 
-// updated_at is handled by trigger_set_timestamp
+// The disease listing is built by ListDiseasesPage (db/catalog_list.go).
+// Only applies while the row is still at the version the caller read ($6);
+// updated_at and version are handled by triggers
 func (q *Queries) UpdateDisease(ctx context.Context, arg UpdateDiseaseParams) (Disease, error) {
 	row := q.db.QueryRow(ctx, updateDisease,
 		arg.DiseaseID,
@@ -982,6 +3110,7 @@ func (q *Queries) UpdateDisease(ctx context.Context, arg UpdateDiseaseParams) (D
 		arg.DiseaseCode,
 		arg.DiseaseDescription,
 		arg.DiseaseTreatment,
+		arg.Version,
 	)
 	var i Disease
 	err := row.Scan(
@@ -992,6 +3121,9 @@ func (q *Queries) UpdateDisease(ctx context.Context, arg UpdateDiseaseParams) (D
 		&i.DiseaseTreatment,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.DeprecatedAt,
+		&i.ReplacedBy,
 	)
 	return i, err
 }
@@ -1000,8 +3132,8 @@ const updatePatientAddress = `-- name: UpdatePatientAddress :one
 UPDATE patient
 SET
     address = $2
-WHERE patient_id = $1
-RETURNING patient_id, firstname, lastname, register, age, gender, birthdate, address, phonenumber, email
+WHERE patient_id = $1 AND deleted_at IS NULL
+RETURNING patient_id, firstname, lastname, register, gender, birthdate, address, phonenumber, email, clinic_id, created_at, updated_at, version, deleted_at, deleted_by, deletion_reason
 `
 
 type UpdatePatientAddressParams struct {
@@ -1018,31 +3150,35 @@ func (q *Queries) UpdatePatientAddress(ctx context.Context, arg UpdatePatientAdd
 		&i.Firstname,
 		&i.Lastname,
 		&i.Register,
-		&i.Age,
 		&i.Gender,
 		&i.Birthdate,
 		&i.Address,
 		&i.Phonenumber,
 		&i.Email,
+		&i.ClinicID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
 	)
 	return i, err
 }
 
 const updatePatientDetails = `-- name: UpdatePatientDetails :one
-
 UPDATE patient
 SET
     firstname = $2,
     lastname = $3,
     register = $4,
-    age = $5,
-    gender = $6,
-    birthdate = $7,
-    address = $8,
-    phonenumber = $9,
-    email = $10
-WHERE patient_id = $1
-RETURNING patient_id, firstname, lastname, register, age, gender, birthdate, address, phonenumber, email
+    gender = $5,
+    birthdate = $6,
+    address = $7,
+    phonenumber = $8,
+    email = $9
+WHERE patient_id = $1 AND version = $10 AND deleted_at IS NULL
+RETURNING patient_id, firstname, lastname, register, gender, birthdate, address, phonenumber, email, clinic_id, created_at, updated_at, version, deleted_at, deleted_by, deletion_reason
 `
 
 type UpdatePatientDetailsParams struct {
@@ -1050,28 +3186,28 @@ type UpdatePatientDetailsParams struct {
 	Firstname   string
 	Lastname    string
 	Register    string
-	Age         int32
 	Gender      string
 	Birthdate   pgtype.Date
 	Address     pgtype.Text
 	Phonenumber string
 	Email       string
+	Version     int32
 }
 
-// For pagination
-// Note: patient table in the schema provided doesn't have created_at/updated_at or triggers
+// Only applies while the row is still at the version the caller read ($10);
+// updated_at and version are handled by triggers
 func (q *Queries) UpdatePatientDetails(ctx context.Context, arg UpdatePatientDetailsParams) (Patient, error) {
 	row := q.db.QueryRow(ctx, updatePatientDetails,
 		arg.PatientID,
 		arg.Firstname,
 		arg.Lastname,
 		arg.Register,
-		arg.Age,
 		arg.Gender,
 		arg.Birthdate,
 		arg.Address,
 		arg.Phonenumber,
 		arg.Email,
+		arg.Version,
 	)
 	var i Patient
 	err := row.Scan(
@@ -1079,12 +3215,18 @@ func (q *Queries) UpdatePatientDetails(ctx context.Context, arg UpdatePatientDet
 		&i.Firstname,
 		&i.Lastname,
 		&i.Register,
-		&i.Age,
 		&i.Gender,
 		&i.Birthdate,
 		&i.Address,
 		&i.Phonenumber,
 		&i.Email,
+		&i.ClinicID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
 	)
 	return i, err
 }
@@ -1092,31 +3234,36 @@ func (q *Queries) UpdatePatientDetails(ctx context.Context, arg UpdatePatientDet
 const updatePatientDiseaseInstance = `-- name: UpdatePatientDiseaseInstance :one
 UPDATE patient_disease
 SET
-    patient_id = $2,
-    disease_id = $3,
-    diagnosis_date = $4,
-    notes = $5
-WHERE patient_disease_id = $1
-RETURNING patient_disease_id, patient_id, disease_id, diagnosis_date, notes, created_at, updated_at
+    disease_id = $2,
+    diagnosis_date = $3,
+    notes = $4,
+    clinical_status = $5,
+    status_changed_at = CASE WHEN clinical_status <> $5 THEN CURRENT_TIMESTAMP ELSE status_changed_at END
+WHERE patient_disease_id = $1 AND version = $6
+RETURNING patient_disease_id, patient_id, disease_id, diagnosis_date, notes, created_at, updated_at, clinic_id, version, clinical_status, status_changed_at
 `
 
 type UpdatePatientDiseaseInstanceParams struct {
 	PatientDiseaseID int32
-	PatientID        int32
 	DiseaseID        int32
 	DiagnosisDate    pgtype.Date
 	Notes            pgtype.Text
+	ClinicalStatus   string
+	Version          int32
 }
 
-// Updates details of a specific diagnosis instance
-// updated_at is handled by trigger_set_timestamp
+// Updates details of a specific diagnosis instance; the patient cannot change.
+// status_changed_at moves only when the status does. Only applies while the row
+// is still at the version the caller read ($6); updated_at and version are
+// handled by triggers
 func (q *Queries) UpdatePatientDiseaseInstance(ctx context.Context, arg UpdatePatientDiseaseInstanceParams) (PatientDisease, error) {
 	row := q.db.QueryRow(ctx, updatePatientDiseaseInstance,
 		arg.PatientDiseaseID,
-		arg.PatientID,
 		arg.DiseaseID,
 		arg.DiagnosisDate,
 		arg.Notes,
+		arg.ClinicalStatus,
+		arg.Version,
 	)
 	var i PatientDisease
 	err := row.Scan(
@@ -1127,28 +3274,41 @@ func (q *Queries) UpdatePatientDiseaseInstance(ctx context.Context, arg UpdatePa
 		&i.Notes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ClinicID,
+		&i.Version,
+		&i.ClinicalStatus,
+		&i.StatusChangedAt,
 	)
 	return i, err
 }
 
 const updateSymptom = `-- name: UpdateSymptom :one
+
 UPDATE symptoms
 SET
     symptom_name = $2,
     symptom_description = $3
-WHERE symptom_id = $1
-RETURNING symptom_id, symptom_name, symptom_description, created_at, updated_at
+WHERE symptom_id = $1 AND version = $4
+RETURNING symptom_id, symptom_name, symptom_description, created_at, updated_at, version, deprecated_at, replaced_by
 `
 
 type UpdateSymptomParams struct {
 	SymptomID          int32
 	SymptomName        string
 	SymptomDescription pgtype.Text
+	Version            int32
 }
 
-// updated_at is handled by trigger_set_timestamp
+// The symptom listing is built by ListSymptomsPage (db/catalog_list.go).
+// Only applies while the row is still at the version the caller read ($4);
+// updated_at and version are handled by triggers
 func (q *Queries) UpdateSymptom(ctx context.Context, arg UpdateSymptomParams) (Symptom, error) {
-	row := q.db.QueryRow(ctx, updateSymptom, arg.SymptomID, arg.SymptomName, arg.SymptomDescription)
+	row := q.db.QueryRow(ctx, updateSymptom,
+		arg.SymptomID,
+		arg.SymptomName,
+		arg.SymptomDescription,
+		arg.Version,
+	)
 	var i Symptom
 	err := row.Scan(
 		&i.SymptomID,
@@ -1156,6 +3316,48 @@ func (q *Queries) UpdateSymptom(ctx context.Context, arg UpdateSymptomParams) (S
 		&i.SymptomDescription,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.DeprecatedAt,
+		&i.ReplacedBy,
+	)
+	return i, err
+}
+
+const updateSymptomAlias = `-- name: UpdateSymptomAlias :one
+UPDATE symptom_alias
+SET
+    alias = $3,
+    language = $4,
+    source = $5
+WHERE symptom_alias_id = $1 AND symptom_id = $2
+RETURNING symptom_alias_id, symptom_id, alias, language, source, created_at, updated_at
+`
+
+type UpdateSymptomAliasParams struct {
+	SymptomAliasID int32
+	SymptomID      int32
+	Alias          string
+	Language       string
+	Source         string
+}
+
+func (q *Queries) UpdateSymptomAlias(ctx context.Context, arg UpdateSymptomAliasParams) (SymptomAlias, error) {
+	row := q.db.QueryRow(ctx, updateSymptomAlias,
+		arg.SymptomAliasID,
+		arg.SymptomID,
+		arg.Alias,
+		arg.Language,
+		arg.Source,
+	)
+	var i SymptomAlias
+	err := row.Scan(
+		&i.SymptomAliasID,
+		&i.SymptomID,
+		&i.Alias,
+		&i.Language,
+		&i.Source,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
@@ -4,39 +4,78 @@
 
 -- name: CreatePatient :one
 INSERT INTO patient (
-    firstname, lastname, register, age, gender, birthdate, address, phonenumber, email
+    firstname, lastname, register, gender, birthdate, address, phonenumber, email
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9
+    $1, $2, $3, $4, $5, $6, $7, $8
 )
 RETURNING *;
 
 -- name: GetPatientByID :one
+-- Soft-deleted patients are left out here and in every other patient query
+-- unless noted
+SELECT * FROM patient
+WHERE patient_id = $1 AND deleted_at IS NULL LIMIT 1;
+
+-- name: GetPatientByIDWithDeleted :one
+-- Also finds a soft-deleted patient, for admins reviewing or restoring it
 SELECT * FROM patient
 WHERE patient_id = $1 LIMIT 1;
 
+-- name: GetPatientDeletedAt :one
+SELECT deleted_at FROM patient
+WHERE patient_id = $1;
+
 -- name: GetPatientByEmail :one
 SELECT * FROM patient
-WHERE email = $1 LIMIT 1;
+WHERE email = $1 AND deleted_at IS NULL LIMIT 1;
 
--- name: ListPatients :many
-SELECT * FROM patient
-ORDER BY lastname, firstname
-LIMIT $1 OFFSET $2; -- For pagination
+-- Patient listings are built by ListPatientsPage (db/patient_list.go): their
+-- filters and keyset pagination vary too much for a single static query.
+
+-- name: SearchPatients :many
+-- Ranks patients by how well any spelling of the query (as typed and
+-- transliterated, lower-cased) matches their name, register, phone or email:
+-- the best word similarity plus the full-text rank. A patient matches on a
+-- substring, a similar word (pg_trgm <%) or the prefix tsquery. Only patients
+-- the user can read (patient_visible_to) are searched.
+SELECT
+    p.patient_id, p.firstname, p.lastname, p.register, p.gender, p.birthdate, p.address,
+    p.phonenumber, p.email, p.clinic_id, p.created_at, p.updated_at, p.version,
+    (GREATEST(
+        word_similarity(sqlc.arg('term')::text, st.txt),
+        word_similarity(sqlc.arg('term_cyrillic')::text, st.txt),
+        word_similarity(sqlc.arg('term_latin')::text, st.txt)
+    ) + ts_rank(to_tsvector('simple', st.txt), to_tsquery('simple', sqlc.arg('tsquery')::text)))::float8 AS score
+FROM patient p
+CROSS JOIN LATERAL (
+    SELECT patient_search_text(p.firstname, p.lastname, p.register, p.phonenumber, p.email) AS txt
+) st
+WHERE p.deleted_at IS NULL AND patient_visible_to(p.patient_id, sqlc.arg('user_id')::int) AND (
+      patient_search_text(p.firstname, p.lastname, p.register, p.phonenumber, p.email) ILIKE '%' || sqlc.arg('term')::text || '%'
+   OR patient_search_text(p.firstname, p.lastname, p.register, p.phonenumber, p.email) ILIKE '%' || sqlc.arg('term_cyrillic')::text || '%'
+   OR patient_search_text(p.firstname, p.lastname, p.register, p.phonenumber, p.email) ILIKE '%' || sqlc.arg('term_latin')::text || '%'
+   OR sqlc.arg('term')::text <% patient_search_text(p.firstname, p.lastname, p.register, p.phonenumber, p.email)
+   OR sqlc.arg('term_cyrillic')::text <% patient_search_text(p.firstname, p.lastname, p.register, p.phonenumber, p.email)
+   OR sqlc.arg('term_latin')::text <% patient_search_text(p.firstname, p.lastname, p.register, p.phonenumber, p.email)
+   OR to_tsvector('simple', patient_search_text(p.firstname, p.lastname, p.register, p.phonenumber, p.email)) @@ to_tsquery('simple', sqlc.arg('tsquery')::text)
+)
+ORDER BY score DESC, p.lastname, p.firstname, p.patient_id
+LIMIT sqlc.arg('limit');
 
 -- name: UpdatePatientDetails :one
--- Note: patient table in the schema provided doesn't have created_at/updated_at or triggers
+-- Only applies while the row is still at the version the caller read ($10);
+-- updated_at and version are handled by triggers
 UPDATE patient
 SET
     firstname = $2,
     lastname = $3,
     register = $4,
-    age = $5,
-    gender = $6,
-    birthdate = $7,
-    address = $8,
-    phonenumber = $9,
-    email = $10
-WHERE patient_id = $1
+    gender = $5,
+    birthdate = $6,
+    address = $7,
+    phonenumber = $8,
+    email = $9
+WHERE patient_id = $1 AND version = $10 AND deleted_at IS NULL
 RETURNING *;
 
 -- name: UpdatePatientAddress :one
@@ -44,13 +83,90 @@ RETURNING *;
 UPDATE patient
 SET
     address = $2
-WHERE patient_id = $1
+WHERE patient_id = $1 AND deleted_at IS NULL
 RETURNING *;
 
--- name: DeletePatient :exec
--- Note: ON DELETE CASCADE will handle related records in junction tables
-DELETE FROM patient
-WHERE patient_id = $1;
+-- name: SoftDeletePatient :one
+-- Hides the patient; their history stays until PurgeDeletedPatients. Given a
+-- version, only that version is deleted (If-Match).
+UPDATE patient
+SET
+    deleted_at = CURRENT_TIMESTAMP,
+    deleted_by = sqlc.arg('deleted_by')::int,
+    deletion_reason = sqlc.narg('deletion_reason')
+WHERE patient_id = sqlc.arg('patient_id') AND deleted_at IS NULL
+  AND (sqlc.narg('version')::int IS NULL OR version = sqlc.narg('version'))
+RETURNING *;
+
+-- name: RestorePatient :one
+UPDATE patient
+SET
+    deleted_at = NULL,
+    deleted_by = NULL,
+    deletion_reason = NULL
+WHERE patient_id = $1 AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: PurgeDeletedPatients :many
+-- Removes patients soft-deleted longer ago than the retention period, with
+-- their whole history (ON DELETE CASCADE), across all clinics, a batch at a
+-- time (000020).
+-- deleted_by is 0 when the deleting user is gone; the reason is not returned,
+-- as it may describe the patient.
+SELECT
+    r.patient_id::int AS patient_id,
+    r.clinic_id::int AS clinic_id,
+    r.deleted_at::timestamp AS deleted_at,
+    COALESCE(r.deleted_by, 0)::int AS deleted_by
+FROM purge_deleted_patients(sqlc.arg('retention_seconds')::int, sqlc.arg('batch_size')::int) r;
+
+
+-- === Patient Consent Queries ===
+
+-- name: GrantPatientConsent :one
+-- Records a new active consent; uq_pc_active_scope rejects a second active consent for the same scope
+INSERT INTO patient_consent (
+    patient_id, scope, captured_by_user_id
+) VALUES (
+    $1, $2, $3
+)
+RETURNING *;
+
+-- name: RevokePatientConsent :one
+-- Revokes an active consent, keeping the row for the audit trail
+UPDATE patient_consent
+SET
+    revoked_at = CURRENT_TIMESTAMP,
+    revoked_by_user_id = $3
+WHERE consent_id = $1 AND patient_id = $2 AND revoked_at IS NULL
+RETURNING *;
+
+-- name: ListConsentsForPatient :many
+-- Lists active and revoked consents for a patient, newest first
+SELECT * FROM patient_consent
+WHERE patient_id = $1
+ORDER BY granted_at DESC, consent_id DESC;
+
+-- name: PatientHasConsent :one
+SELECT patient_has_consent(sqlc.arg('patient_id')::int, sqlc.arg('scope')::varchar)::boolean AS has_consent;
+
+-- name: ListTrainingCases :many
+-- Model training export: settled diagnoses with their linked symptoms, only for patients with an active model_training consent
+SELECT
+    pd.patient_disease_id,
+    d.disease_name,
+    pd.clinical_status,
+    COALESCE(array_agg(s.symptom_name ORDER BY s.symptom_name) FILTER (WHERE s.symptom_id IS NOT NULL), '{}')::text[] AS symptoms
+FROM patient_disease pd
+JOIN patient p ON p.patient_id = pd.patient_id
+JOIN disease d ON d.disease_id = pd.disease_id
+LEFT JOIN patient_disease_symptom pds ON pds.patient_disease_id = pd.patient_disease_id
+LEFT JOIN symptoms s ON s.symptom_id = pds.symptom_id
+WHERE p.deleted_at IS NULL
+  AND pd.clinical_status IN ('confirmed', 'resolved', 'chronic')
+  AND patient_has_consent(pd.patient_id, 'model_training')
+GROUP BY pd.patient_disease_id, d.disease_name
+ORDER BY pd.patient_disease_id;
 
 
 -- === Symptom Queries ===
@@ -67,23 +183,103 @@ RETURNING *;
 SELECT * FROM symptoms
 WHERE symptom_id = $1 LIMIT 1;
 
--- name: ListSymptoms :many
-SELECT * FROM symptoms
-ORDER BY symptom_name;
+-- The symptom listing is built by ListSymptomsPage (db/catalog_list.go).
 
 -- name: UpdateSymptom :one
--- updated_at is handled by trigger_set_timestamp
+-- Only applies while the row is still at the version the caller read ($4);
+-- updated_at and version are handled by triggers
 UPDATE symptoms
 SET
     symptom_name = $2,
     symptom_description = $3
-WHERE symptom_id = $1
+WHERE symptom_id = $1 AND version = $4
 RETURNING *;
 
--- name: DeleteSymptom :exec
--- Note: ON DELETE CASCADE will handle related records in junction tables
+-- name: DeleteSymptom :execrows
+-- Fails (fk_ps_symptom, fk_pds_symptom) while patient records still use it;
+-- deletes nothing once the version has moved on
 DELETE FROM symptoms
-WHERE symptom_id = $1;
+WHERE symptom_id = $1 AND version = $2;
+
+-- name: CountSymptomReferences :one
+-- Patient records using a symptom, across all clinics (000020)
+SELECT
+    r.patient_symptoms::bigint AS patient_symptoms,
+    r.disease_instance_symptoms::bigint AS disease_instance_symptoms
+FROM count_symptom_references(@symptom_id::int) r;
+
+-- name: DeprecateSymptom :one
+-- Hides a symptom from pickers; records keep it. Deprecating again keeps the
+-- original deprecated_at and only changes the replacement.
+UPDATE symptoms
+SET
+    deprecated_at = COALESCE(deprecated_at, now()),
+    replaced_by = sqlc.narg('replaced_by')
+WHERE symptom_id = @symptom_id
+RETURNING *;
+
+-- name: RepointSymptomReplacements :execrows
+-- Symptoms replaced by one being deprecated follow it to its replacement
+UPDATE symptoms
+SET replaced_by = @replaced_by::int
+WHERE replaced_by = @symptom_id::int;
+
+-- name: ReinstateSymptom :one
+UPDATE symptoms
+SET
+    deprecated_at = NULL,
+    replaced_by = NULL
+WHERE symptom_id = $1
+RETURNING *;
+
+-- === Symptom Alias Queries ===
+
+-- name: CreateSymptomAlias :one
+INSERT INTO symptom_alias (
+    symptom_id, alias, language, source
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: ListSymptomAliases :many
+SELECT * FROM symptom_alias
+WHERE symptom_id = $1
+ORDER BY alias;
+
+-- name: ListAliasesForSymptoms :many
+-- Aliases of a page of symptoms, for their responses
+SELECT * FROM symptom_alias
+WHERE symptom_id = ANY(@symptom_ids::int[])
+ORDER BY symptom_id, alias;
+
+-- name: UpdateSymptomAlias :one
+UPDATE symptom_alias
+SET
+    alias = $3,
+    language = $4,
+    source = $5
+WHERE symptom_alias_id = $1 AND symptom_id = $2
+RETURNING *;
+
+-- name: DeleteSymptomAlias :execrows
+DELETE FROM symptom_alias
+WHERE symptom_alias_id = $1 AND symptom_id = $2;
+
+-- name: ResolveSymptomNames :many
+-- Maps names to canonical symptoms, case-insensitively. A symptom's own name
+-- wins over another symptom's alias; names matching nothing are left out.
+-- Deprecated symptoms are skipped, so a merged duplicate's name resolves
+-- through its alias on the survivor.
+SELECT DISTINCT ON (n.name)
+    n.name::text AS name,
+    s.symptom_id,
+    s.symptom_name
+FROM unnest(@names::text[]) AS n(name)
+JOIN symptoms s ON (LOWER(s.symptom_name) = LOWER(n.name)
+    OR s.symptom_id IN (SELECT a.symptom_id FROM symptom_alias a WHERE LOWER(a.alias) = LOWER(n.name)))
+    AND s.deprecated_at IS NULL
+ORDER BY n.name, (LOWER(s.symptom_name) = LOWER(n.name)) DESC, s.symptom_id;
 
 
 -- === Disease Queries ===
@@ -104,25 +300,53 @@ WHERE disease_id = $1 LIMIT 1;
 SELECT * FROM disease
 WHERE disease_code = $1 LIMIT 1;
 
--- name: ListDiseases :many
-SELECT * FROM disease
-ORDER BY disease_name;
+-- The disease listing is built by ListDiseasesPage (db/catalog_list.go).
 
 -- name: UpdateDisease :one
--- updated_at is handled by trigger_set_timestamp
+-- Only applies while the row is still at the version the caller read ($6);
+-- updated_at and version are handled by triggers
 UPDATE disease
 SET
     disease_name = $2,
     disease_code = $3,
     disease_description = $4,
     disease_treatment = $5 -- $5 should be valid JSON(B) text or compatible type
-WHERE disease_id = $1
+WHERE disease_id = $1 AND version = $6
 RETURNING *;
 
--- name: DeleteDisease :exec
--- Note: ON DELETE CASCADE will handle related records in junction tables
+-- name: DeleteDisease :execrows
+-- Fails (fk_pd_disease) while patient records still use it; deletes nothing
+-- once the version has moved on
 DELETE FROM disease
-WHERE disease_id = $1;
+WHERE disease_id = $1 AND version = $2;
+
+-- name: CountDiseaseReferences :one
+-- Disease instances recorded of a disease, across all clinics (000020)
+SELECT count_disease_references(@disease_id::int)::bigint AS disease_instances;
+
+-- name: DeprecateDisease :one
+-- Hides a disease from pickers; records keep it. Deprecating again keeps the
+-- original deprecated_at and only changes the replacement.
+UPDATE disease
+SET
+    deprecated_at = COALESCE(deprecated_at, now()),
+    replaced_by = sqlc.narg('replaced_by')
+WHERE disease_id = @disease_id
+RETURNING *;
+
+-- name: RepointDiseaseReplacements :execrows
+-- Diseases replaced by one being deprecated follow it to its replacement
+UPDATE disease
+SET replaced_by = @replaced_by::int
+WHERE replaced_by = @disease_id::int;
+
+-- name: ReinstateDisease :one
+UPDATE disease
+SET
+    deprecated_at = NULL,
+    replaced_by = NULL
+WHERE disease_id = $1
+RETURNING *;
 
 
 -- === Patient Symptom Queries (General - Optional Table) ===
@@ -137,19 +361,39 @@ INSERT INTO patient_symptoms (
 )
 RETURNING *;
 
+-- name: RecordPatientSymptomIfAbsent :execrows
+-- Records a general symptom (dated today when no date is given) unless the
+-- patient already reported it on that date
+INSERT INTO patient_symptoms (
+    patient_id, symptom_id, reported_date
+) VALUES (
+    $1, $2, COALESCE(sqlc.narg('reported_date')::date, CURRENT_DATE)
+)
+ON CONFLICT (patient_id, symptom_id, reported_date) DO NOTHING;
+
 -- name: RemovePatientSymptom :exec
 -- Removes a specific general symptom record for a patient
 DELETE FROM patient_symptoms
 WHERE patient_id = $1 AND symptom_id = $2 AND reported_date = $3; -- More specific deletion
 
--- name: RemovePatientSymptomByID :exec
--- Removes a specific general symptom record by its ID
-DELETE FROM patient_symptoms
+-- name: GetPatientSymptomByID :one
+SELECT * FROM patient_symptoms
 WHERE id = $1;
 
+-- name: RemovePatientSymptomByID :execrows
+-- Removes a specific general symptom record by its ID. A soft-deleted
+-- patient's records stay as they are until restore or purge, and a record
+-- whose version has moved on is not removed.
+DELETE FROM patient_symptoms ps
+WHERE ps.id = $1 AND ps.version = $2
+  AND NOT EXISTS (
+    SELECT 1 FROM patient p
+    WHERE p.patient_id = ps.patient_id AND p.deleted_at IS NOT NULL
+  );
+
 -- name: ListGeneralSymptomsForPatient :many
 -- Lists general symptoms recorded for a patient via patient_symptoms table
-SELECT s.*, ps.reported_date
+SELECT s.*, ps.reported_date, ps.id AS patient_symptom_id, ps.version AS record_version
 FROM symptoms s
 JOIN patient_symptoms ps ON s.symptom_id = ps.symptom_id
 WHERE ps.patient_id = $1
@@ -160,7 +404,7 @@ ORDER BY ps.reported_date DESC, s.symptom_name;
 SELECT p.*
 FROM patient p
 JOIN patient_symptoms ps ON p.patient_id = ps.patient_id
-WHERE ps.symptom_id = $1
+WHERE ps.symptom_id = $1 AND p.deleted_at IS NULL
 ORDER BY p.lastname, p.firstname;
 
 
@@ -169,9 +413,9 @@ ORDER BY p.lastname, p.firstname;
 -- name: RecordPatientDiseaseInstance :one
 -- Records a specific diagnosis instance for a patient
 INSERT INTO patient_disease (
-    patient_id, disease_id, diagnosis_date, notes
+    patient_id, disease_id, diagnosis_date, notes, clinical_status
 ) VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5
 )
 RETURNING *; -- Returns the newly created patient_disease record including patient_disease_id
 
@@ -180,38 +424,69 @@ RETURNING *; -- Returns the newly created patient_disease record including patie
 SELECT * FROM patient_disease
 WHERE patient_disease_id = $1;
 
+-- name: GetDiseaseInstance :one
+-- Gets a diagnosis instance with its disease's name and code
+SELECT
+    pd.*,
+    d.disease_name,
+    d.disease_code
+FROM patient_disease pd
+JOIN disease d ON pd.disease_id = d.disease_id
+WHERE pd.patient_disease_id = $1;
+
 -- name: UpdatePatientDiseaseInstance :one
--- Updates details of a specific diagnosis instance
--- updated_at is handled by trigger_set_timestamp
+-- Updates details of a specific diagnosis instance; the patient cannot change.
+-- status_changed_at moves only when the status does. Only applies while the row
+-- is still at the version the caller read ($6); updated_at and version are
+-- handled by triggers
 UPDATE patient_disease
 SET
-    patient_id = $2,
-    disease_id = $3,
-    diagnosis_date = $4,
-    notes = $5
-WHERE patient_disease_id = $1
+    disease_id = $2,
+    diagnosis_date = $3,
+    notes = $4,
+    clinical_status = $5,
+    status_changed_at = CASE WHEN clinical_status <> $5 THEN CURRENT_TIMESTAMP ELSE status_changed_at END
+WHERE patient_disease_id = $1 AND version = $6
 RETURNING *;
 
--- name: DeletePatientDiseaseInstance :exec
--- Deletes a specific diagnosis instance by its ID
+-- name: RecordDiseaseInstanceStatus :exec
+-- Appends a status change (or the initial status, with no from_status) to the history
+INSERT INTO patient_disease_status_history (
+    patient_disease_id, from_status, to_status, changed_by
+) VALUES (
+    $1, $2, $3, $4
+);
+
+-- name: ListDiseaseInstanceStatusHistory :many
+SELECT * FROM patient_disease_status_history
+WHERE patient_disease_id = $1
+ORDER BY changed_at, id;
+
+-- name: DeletePatientDiseaseInstance :execrows
+-- Deletes a specific diagnosis instance by its ID, if still at the given version
 -- Note: ON DELETE CASCADE handles related patient_disease_symptom records
 DELETE FROM patient_disease
-WHERE patient_disease_id = $1;
+WHERE patient_disease_id = $1 AND version = $2;
 
 -- name: ListDiseaseInstancesForPatient :many
--- Lists all recorded disease instances for a specific patient
+-- Lists the recorded disease instances for a specific patient, optionally only
+-- those in one of the given clinical statuses
 SELECT
     pd.patient_disease_id,
     pd.diagnosis_date,
     pd.notes,
+    pd.clinical_status,
+    pd.status_changed_at,
     pd.created_at,
     pd.updated_at,
+    pd.version,
     d.disease_id,
     d.disease_name,
     d.disease_code
 FROM patient_disease pd
 JOIN disease d ON pd.disease_id = d.disease_id
-WHERE pd.patient_id = $1
+WHERE pd.patient_id = sqlc.arg('patient_id')
+  AND (sqlc.narg('statuses')::varchar[] IS NULL OR pd.clinical_status = ANY(sqlc.narg('statuses')::varchar[]))
 ORDER BY pd.diagnosis_date DESC, d.disease_name;
 
 -- name: ListPatientsWithDiseaseInstance :many
@@ -219,7 +494,7 @@ ORDER BY pd.diagnosis_date DESC, d.disease_name;
 SELECT p.*
 FROM patient p
 JOIN patient_disease pd ON p.patient_id = pd.patient_id
-WHERE pd.disease_id = $1
+WHERE pd.disease_id = $1 AND p.deleted_at IS NULL
 ORDER BY p.lastname, p.firstname;
 
 
@@ -297,7 +572,7 @@ SELECT
 FROM
     patient p
 WHERE
-    p.patient_id = $1
+    p.patient_id = $1 AND p.deleted_at IS NULL
 GROUP BY
     p.patient_id, p.firstname, p.lastname, p.email;
 
@@ -320,7 +595,387 @@ SELECT
      WHERE pd_dis.patient_id = p.patient_id) AS distinct_diseases_list
 FROM
     patient p
+WHERE
+    p.deleted_at IS NULL
 GROUP BY
     p.patient_id, p.firstname, p.lastname, p.email
 ORDER BY
     p.lastname, p.firstname;
+
+
+-- === Catalog Merge Queries ===
+-- A merge moves every reference from a duplicate entry to the surviving one.
+-- References the survivor already has (same patient and date, or same
+-- diagnosis) would break a unique key; those are folded into the survivor's.
+-- References span clinics, so the patient records are moved by the SECURITY
+-- DEFINER functions of 000020; run everything inside one transaction.
+
+-- name: GetSymptomForUpdate :one
+SELECT * FROM symptoms
+WHERE symptom_id = $1
+FOR UPDATE;
+
+-- name: MoveSymptomAliases :execrows
+-- An alias spelling the survivor's own name is dropped with the duplicate
+UPDATE symptom_alias a
+SET symptom_id = @survivor_id
+FROM symptoms k
+WHERE a.symptom_id = @duplicate_id
+  AND k.symptom_id = @survivor_id
+  AND LOWER(a.alias) <> LOWER(k.symptom_name);
+
+-- name: AddMergedSymptomAlias :execrows
+-- Keeps the duplicate's name as an alias of the survivor
+INSERT INTO symptom_alias (symptom_id, alias, source)
+SELECT k.symptom_id, d.symptom_name, 'merge'
+FROM symptoms d, symptoms k
+WHERE d.symptom_id = @duplicate_id
+  AND k.symptom_id = @survivor_id
+  AND LOWER(d.symptom_name) <> LOWER(k.symptom_name)
+ON CONFLICT (LOWER(alias)) DO NOTHING;
+
+-- name: MergeSymptomReferences :one
+-- Moves every patient record of the duplicate to the survivor, across all
+-- clinics (000020)
+SELECT
+    r.patient_symptoms_collapsed::bigint AS patient_symptoms_collapsed,
+    r.patient_symptoms_repointed::bigint AS patient_symptoms_repointed,
+    r.instance_symptoms_collapsed::bigint AS instance_symptoms_collapsed,
+    r.instance_symptoms_repointed::bigint AS instance_symptoms_repointed
+FROM merge_symptom_references(@survivor_id::int, @duplicate_id::int) r;
+
+-- name: GetDiseaseForUpdate :one
+SELECT * FROM disease
+WHERE disease_id = $1
+FOR UPDATE;
+
+-- name: MergeDiseaseReferences :one
+-- Moves every diagnosis of the duplicate to the survivor, across all clinics
+-- (000020)
+SELECT
+    r.instance_symptoms_moved::bigint AS instance_symptoms_moved,
+    r.status_history_moved::bigint AS status_history_moved,
+    r.notes_merged::bigint AS notes_merged,
+    r.instances_collapsed::bigint AS instances_collapsed,
+    r.instances_repointed::bigint AS instances_repointed
+FROM merge_disease_references(@survivor_id::int, @duplicate_id::int) r;
+
+-- name: MoveDiseaseAliases :execrows
+UPDATE disease_alias a
+SET disease_id = @survivor_id
+FROM disease k
+WHERE a.disease_id = @duplicate_id
+  AND k.disease_id = @survivor_id
+  AND LOWER(a.alias) <> LOWER(k.disease_name);
+
+-- name: AddMergedDiseaseAlias :execrows
+INSERT INTO disease_alias (disease_id, alias, source)
+SELECT k.disease_id, d.disease_name, 'merge'
+FROM disease d, disease k
+WHERE d.disease_id = @duplicate_id
+  AND k.disease_id = @survivor_id
+  AND LOWER(d.disease_name) <> LOWER(k.disease_name)
+ON CONFLICT (LOWER(alias)) DO NOTHING;
+
+-- === Patient Duplicate & Merge Queries ===
+
+-- name: FindDuplicatePatients :many
+-- Patients of the caller's clinic who may be the same person: the same
+-- register, the same phone number (digits only), or a similar name (any
+-- spelling, as with SearchPatients) with the same birthdate. The handler turns
+-- the signals into a confidence score.
+SELECT
+    p.patient_id, p.firstname, p.lastname, p.register, p.birthdate, p.phonenumber,
+    m.register_match::bool AS register_match,
+    m.phone_match::bool AS phone_match,
+    m.name_similarity::float8 AS name_similarity
+FROM patient p
+CROSS JOIN LATERAL (
+    SELECT
+        UPPER(p.register) = UPPER(sqlc.arg('register')::text) AS register_match,
+        regexp_replace(sqlc.arg('phonenumber')::text, '\D', '', 'g') <> ''
+            AND regexp_replace(p.phonenumber, '\D', '', 'g') = regexp_replace(sqlc.arg('phonenumber')::text, '\D', '', 'g') AS phone_match,
+        CASE WHEN p.birthdate = sqlc.narg('birthdate')::date THEN GREATEST(
+            similarity(LOWER(p.firstname || ' ' || p.lastname), sqlc.arg('name')::text),
+            similarity(LOWER(p.firstname || ' ' || p.lastname), sqlc.arg('name_cyrillic')::text),
+            similarity(LOWER(p.firstname || ' ' || p.lastname), sqlc.arg('name_latin')::text)
+        ) ELSE 0 END AS name_similarity
+) m
+WHERE p.deleted_at IS NULL
+  AND p.patient_id <> sqlc.arg('exclude_id')::int
+  AND (m.register_match OR m.phone_match OR m.name_similarity >= sqlc.arg('min_name_similarity')::float8)
+ORDER BY m.register_match DESC, m.phone_match DESC, m.name_similarity DESC, p.patient_id
+LIMIT sqlc.arg('limit');
+
+-- A merge moves a duplicate patient's history to the surviving record of the
+-- same person. Rows the survivor already has (the same symptom reported on the
+-- same date, the same disease diagnosed on the same date) would break a unique
+-- key; those are folded into the survivor's, as in a catalog merge. Run inside
+-- a transaction, with both patients locked.
+
+-- name: GetPatientForUpdate :one
+SELECT * FROM patient
+WHERE patient_id = $1 AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: DeleteCollidingReportedSymptoms :execrows
+-- The survivor already reported the symptom on the same date
+DELETE FROM patient_symptoms d
+WHERE d.patient_id = @duplicate_id
+  AND EXISTS (
+    SELECT 1 FROM patient_symptoms k
+    WHERE k.patient_id = @survivor_id
+      AND k.symptom_id = d.symptom_id
+      AND k.reported_date = d.reported_date
+  );
+
+-- name: MoveReportedSymptoms :execrows
+UPDATE patient_symptoms
+SET patient_id = @survivor_id
+WHERE patient_id = @duplicate_id;
+
+-- name: MoveCollidingDiagnosisSymptoms :execrows
+-- A diagnosis of the duplicate that the survivor also has (same disease and
+-- date) hands its symptom links to the survivor's diagnosis
+UPDATE patient_disease_symptom pds
+SET patient_disease_id = k.patient_disease_id
+FROM patient_disease d
+JOIN patient_disease k
+  ON k.patient_id = @survivor_id
+ AND k.disease_id = d.disease_id
+ AND k.diagnosis_date = d.diagnosis_date
+WHERE pds.patient_disease_id = d.patient_disease_id
+  AND d.patient_id = @duplicate_id
+  AND NOT EXISTS (
+    SELECT 1 FROM patient_disease_symptom x
+    WHERE x.patient_disease_id = k.patient_disease_id
+      AND x.symptom_id = pds.symptom_id
+  );
+
+-- name: MoveCollidingDiagnosisHistory :execrows
+-- ... and its status history
+UPDATE patient_disease_status_history h
+SET patient_disease_id = k.patient_disease_id
+FROM patient_disease d
+JOIN patient_disease k
+  ON k.patient_id = @survivor_id
+ AND k.disease_id = d.disease_id
+ AND k.diagnosis_date = d.diagnosis_date
+WHERE h.patient_disease_id = d.patient_disease_id
+  AND d.patient_id = @duplicate_id;
+
+-- name: MergeCollidingDiagnosisNotes :execrows
+-- ... and its notes, appended to the survivor's
+UPDATE patient_disease k
+SET notes = CASE
+    WHEN k.notes IS NULL OR k.notes = '' THEN d.notes
+    ELSE k.notes || E'\n\n' || d.notes
+END
+FROM patient_disease d
+WHERE k.patient_id = @survivor_id
+  AND k.disease_id = d.disease_id
+  AND k.diagnosis_date = d.diagnosis_date
+  AND d.patient_id = @duplicate_id
+  AND d.notes IS NOT NULL AND d.notes <> ''
+  AND d.notes IS DISTINCT FROM k.notes;
+
+-- name: DeleteCollidingDiagnoses :execrows
+DELETE FROM patient_disease d
+WHERE d.patient_id = @duplicate_id
+  AND EXISTS (
+    SELECT 1 FROM patient_disease k
+    WHERE k.patient_id = @survivor_id
+      AND k.disease_id = d.disease_id
+      AND k.diagnosis_date = d.diagnosis_date
+  );
+
+-- name: MoveDiagnoses :execrows
+UPDATE patient_disease
+SET patient_id = @survivor_id
+WHERE patient_id = @duplicate_id;
+
+-- name: AddMergedCareTeam :execrows
+-- The duplicate's care team keeps access to the history it looked after
+INSERT INTO care_team (patient_id, user_id, assigned_by)
+SELECT @survivor_id, ct.user_id, ct.assigned_by
+FROM care_team ct
+WHERE ct.patient_id = @duplicate_id
+ON CONFLICT (patient_id, user_id) DO NOTHING;
+
+-- name: CreatePatientMerge :one
+INSERT INTO patient_merge (
+    survivor_id, duplicate_id, merged_by, reason,
+    symptoms_moved, symptoms_collapsed, diagnoses_moved, diagnoses_collapsed, care_team_added
+) VALUES (
+    @survivor_id, @duplicate_id, sqlc.arg('merged_by')::int, sqlc.narg('reason'),
+    @symptoms_moved, @symptoms_collapsed, @diagnoses_moved, @diagnoses_collapsed, @care_team_added
+)
+RETURNING *;
+
+-- === Clinic & User Queries ===
+-- Not clinic-scoped: used to resolve the caller and their clinic before a clinic is set
+
+-- name: GetUserByUsername :one
+SELECT * FROM app_user
+WHERE username = $1 LIMIT 1;
+
+-- name: GetUserByAPITokenHash :one
+SELECT * FROM app_user
+WHERE api_token_hash = $1 LIMIT 1;
+
+-- name: ListClinicsForUser :many
+-- Lists the clinics a user is bound to
+SELECT c.*
+FROM clinic c
+JOIN user_clinic uc ON c.clinic_id = uc.clinic_id
+WHERE uc.user_id = $1
+ORDER BY c.clinic_name;
+
+
+-- === Care Team & Break-Glass Queries ===
+
+-- name: IsOnCareTeam :one
+SELECT EXISTS (
+    SELECT 1 FROM care_team
+    WHERE patient_id = $1 AND user_id = $2
+) AS on_care_team;
+
+-- name: AddCareTeamMember :one
+INSERT INTO care_team (
+    patient_id, user_id, assigned_by
+) VALUES (
+    $1, $2, $3
+)
+RETURNING *;
+
+-- name: RemoveCareTeamMember :exec
+DELETE FROM care_team
+WHERE patient_id = $1 AND user_id = $2;
+
+-- name: ListCareTeamForPatient :many
+SELECT
+    ct.patient_id,
+    ct.user_id,
+    u.username,
+    u.display_name,
+    ct.assigned_by,
+    ct.created_at
+FROM care_team ct
+JOIN app_user u ON ct.user_id = u.user_id
+WHERE ct.patient_id = $1
+ORDER BY u.username;
+
+-- name: CreateBreakGlassGrant :one
+-- Opens a time-limited emergency access window for a user on one patient
+INSERT INTO break_glass_grant (
+    patient_id, user_id, reason, expires_at
+) VALUES (
+    sqlc.arg('patient_id'), sqlc.arg('user_id'), sqlc.arg('reason'),
+    CURRENT_TIMESTAMP + (sqlc.arg('duration_minutes')::int * INTERVAL '1 minute')
+)
+RETURNING *;
+
+-- name: GetActiveBreakGlassGrant :one
+-- The user's open break-glass window on the patient, if any
+SELECT * FROM break_glass_grant
+WHERE user_id = $1 AND patient_id = $2 AND expires_at > CURRENT_TIMESTAMP
+ORDER BY expires_at DESC
+LIMIT 1;
+
+-- name: RecordBreakGlassAccess :exec
+INSERT INTO break_glass_access (
+    grant_id, method, path
+) VALUES (
+    $1, $2, $3
+);
+
+-- name: ListBreakGlassEvents :many
+-- Review report: break-glass grants in a time range with who, whom, why and how much was accessed
+SELECT
+    g.grant_id,
+    g.patient_id,
+    p.firstname AS patient_firstname,
+    p.lastname AS patient_lastname,
+    g.user_id,
+    u.username,
+    g.reason,
+    g.granted_at,
+    g.expires_at,
+    (SELECT COUNT(*) FROM break_glass_access a WHERE a.grant_id = g.grant_id) AS access_count,
+    (SELECT MAX(a.accessed_at) FROM break_glass_access a WHERE a.grant_id = g.grant_id)::timestamp AS last_accessed_at
+FROM break_glass_grant g
+JOIN patient p ON g.patient_id = p.patient_id
+JOIN app_user u ON g.user_id = u.user_id
+WHERE (sqlc.narg('since')::timestamp IS NULL OR g.granted_at >= sqlc.narg('since')::timestamp)
+  AND (sqlc.narg('until')::timestamp IS NULL OR g.granted_at < sqlc.narg('until')::timestamp)
+ORDER BY g.granted_at DESC;
+
+-- name: ListBreakGlassAccessesForGrants :many
+SELECT * FROM break_glass_access
+WHERE grant_id = ANY(sqlc.arg('grant_ids')::int[])
+ORDER BY accessed_at;
+
+
+-- === Rate Limit Queries ===
+
+-- name: TakeRateLimitToken :one
+-- Refills the bucket for the time elapsed since its last use, then takes one token if available.
+-- allowed reports whether a token was taken; tokens is what is left.
+INSERT INTO rate_limit_bucket AS b (
+    bucket_key, tokens, allowed, updated_at
+) VALUES (
+    sqlc.arg('bucket_key'), sqlc.arg('burst')::float8 - 1, TRUE, clock_timestamp()
+)
+ON CONFLICT (bucket_key) DO UPDATE
+SET
+    allowed = LEAST(sqlc.arg('burst')::float8, b.tokens + EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at)::float8 * sqlc.arg('per_second')::float8) >= 1,
+    tokens = LEAST(sqlc.arg('burst')::float8, b.tokens + EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at)::float8 * sqlc.arg('per_second')::float8)
+        - CASE WHEN LEAST(sqlc.arg('burst')::float8, b.tokens + EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at)::float8 * sqlc.arg('per_second')::float8) >= 1 THEN 1 ELSE 0 END,
+    updated_at = clock_timestamp()
+RETURNING allowed, tokens;
+
+-- name: DeleteIdleRateLimitBuckets :execrows
+-- Buckets unused for this long have refilled completely and can be dropped
+DELETE FROM rate_limit_bucket
+WHERE updated_at < clock_timestamp() - (sqlc.arg('idle_seconds')::int * INTERVAL '1 second');
+
+
+-- === Idempotency Key Queries ===
+
+-- name: ClaimIdempotencyKey :execrows
+-- Claims a key for a request about to be handled. An expired entry is taken
+-- over; a live one is left alone and 0 rows are affected.
+INSERT INTO idempotency_key AS k (
+    scope, idempotency_key, fingerprint, expires_at
+) VALUES (
+    sqlc.arg('scope'), sqlc.arg('idempotency_key'), sqlc.arg('fingerprint'),
+    clock_timestamp() + (sqlc.arg('ttl_seconds')::int * INTERVAL '1 second')
+)
+ON CONFLICT (scope, idempotency_key) DO UPDATE
+SET
+    fingerprint = EXCLUDED.fingerprint,
+    status_code = NULL,
+    response_headers = NULL,
+    response_body = NULL,
+    created_at = clock_timestamp(),
+    expires_at = EXCLUDED.expires_at
+WHERE k.expires_at <= clock_timestamp();
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_key
+WHERE scope = $1 AND idempotency_key = $2 AND expires_at > clock_timestamp();
+
+-- name: CompleteIdempotencyKey :exec
+-- Stores the response of a claimed key so retries replay it
+UPDATE idempotency_key
+SET status_code = $3, response_headers = $4, response_body = $5
+WHERE scope = $1 AND idempotency_key = $2;
+
+-- name: ReleaseIdempotencyKey :exec
+-- Drops a claim whose request failed without a response worth replaying
+DELETE FROM idempotency_key
+WHERE scope = $1 AND idempotency_key = $2 AND status_code IS NULL;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_key
+WHERE expires_at <= clock_timestamp();
@@ -0,0 +1,2 @@
+DROP FUNCTION IF EXISTS patient_has_consent(INT, VARCHAR);
+DROP TABLE IF EXISTS patient_consent;
@@ -0,0 +1,44 @@
+-- Table: patient_consent (Consents captured per patient and scope)
+-- name: PatientConsentTable
+CREATE TABLE patient_consent (
+    consent_id SERIAL PRIMARY KEY,
+    patient_id INT NOT NULL,
+    scope VARCHAR(50) NOT NULL,
+    granted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP,
+    captured_by VARCHAR(255) NOT NULL,
+    revoked_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_pc_patient
+        FOREIGN KEY (patient_id)
+        REFERENCES patient(patient_id)
+        ON DELETE CASCADE,
+    CONSTRAINT chk_pc_scope
+        CHECK (scope IN ('data_processing', 'model_training', 'contact_sms', 'contact_email'))
+);
+
+-- Only one active (non-revoked) consent per patient and scope
+CREATE UNIQUE INDEX uq_pc_active_scope
+    ON patient_consent (patient_id, scope)
+    WHERE revoked_at IS NULL;
+
+-- Trigger for patient_consent
+-- name: SetPatientConsentTimestampTrigger
+CREATE TRIGGER set_patient_consent_timestamp
+BEFORE UPDATE ON patient_consent
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+-- Reusable consent scope: TRUE when the patient holds an active consent for the scope.
+-- Use in WHERE clauses to exclude non-consenting patients for a given purpose.
+CREATE OR REPLACE FUNCTION patient_has_consent(p_patient_id INT, p_scope VARCHAR)
+RETURNS BOOLEAN AS $$
+  SELECT EXISTS (
+    SELECT 1
+    FROM patient_consent pc
+    WHERE pc.patient_id = p_patient_id
+      AND pc.scope = p_scope
+      AND pc.revoked_at IS NULL
+  );
+$$ LANGUAGE sql STABLE;
@@ -0,0 +1,37 @@
+DROP POLICY IF EXISTS clinic_isolation ON patient_consent;
+ALTER TABLE patient_consent NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE patient_consent DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS clinic_isolation ON patient_disease_symptom;
+ALTER TABLE patient_disease_symptom NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE patient_disease_symptom DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS clinic_isolation ON patient_symptoms;
+ALTER TABLE patient_symptoms NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE patient_symptoms DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS clinic_isolation ON patient_disease;
+ALTER TABLE patient_disease NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE patient_disease DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS clinic_isolation ON patient;
+ALTER TABLE patient NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE patient DISABLE ROW LEVEL SECURITY;
+
+ALTER TABLE patient_symptoms DROP CONSTRAINT IF EXISTS fk_ps_patient_clinic;
+ALTER TABLE patient_symptoms DROP COLUMN IF EXISTS clinic_id;
+
+ALTER TABLE patient_disease DROP CONSTRAINT IF EXISTS fk_pd_patient_clinic;
+ALTER TABLE patient_disease DROP COLUMN IF EXISTS clinic_id;
+
+ALTER TABLE patient DROP CONSTRAINT IF EXISTS uq_patient_clinic_email;
+ALTER TABLE patient ADD CONSTRAINT patient_email_key UNIQUE (email);
+ALTER TABLE patient DROP CONSTRAINT IF EXISTS uq_patient_clinic;
+ALTER TABLE patient DROP CONSTRAINT IF EXISTS fk_patient_clinic;
+ALTER TABLE patient DROP COLUMN IF EXISTS clinic_id;
+
+DROP FUNCTION IF EXISTS current_clinic_id();
+
+DROP TABLE IF EXISTS user_clinic;
+DROP TABLE IF EXISTS app_user;
+DROP TABLE IF EXISTS clinic;
@@ -0,0 +1,142 @@
+-- Multi-tenant clinics. Patient data (patient, patient_disease, patient_symptoms and the
+-- tables hanging off them) is isolated per clinic with row-level security; the
+-- symptoms/disease catalog stays global.
+
+-- Table: clinic
+-- name: ClinicTable
+CREATE TABLE clinic (
+    clinic_id SERIAL PRIMARY KEY,
+    clinic_name VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Trigger for clinic
+-- name: SetClinicTimestampTrigger
+CREATE TRIGGER set_clinic_timestamp
+BEFORE UPDATE ON clinic
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+-- Table: app_user (Staff calling the API)
+-- name: AppUserTable
+CREATE TABLE app_user (
+    user_id SERIAL PRIMARY KEY,
+    username VARCHAR(100) NOT NULL UNIQUE,
+    display_name VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Trigger for app_user
+-- name: SetAppUserTimestampTrigger
+CREATE TRIGGER set_app_user_timestamp
+BEFORE UPDATE ON app_user
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+-- Table: user_clinic (Binds users to one or more clinics)
+-- name: UserClinicTable
+CREATE TABLE user_clinic (
+    user_id INT NOT NULL,
+    clinic_id INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, clinic_id),
+    CONSTRAINT fk_uc_user
+        FOREIGN KEY (user_id)
+        REFERENCES app_user(user_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_uc_clinic
+        FOREIGN KEY (clinic_id)
+        REFERENCES clinic(clinic_id)
+        ON DELETE CASCADE
+);
+
+-- Existing data moves into a default clinic, with an admin user bound to it
+INSERT INTO clinic (clinic_name) VALUES ('Default clinic');
+INSERT INTO app_user (username, display_name) VALUES ('admin', 'Administrator');
+INSERT INTO user_clinic (user_id, clinic_id)
+SELECT u.user_id, c.clinic_id
+FROM app_user u, clinic c
+WHERE u.username = 'admin' AND c.clinic_name = 'Default clinic';
+
+-- The caller's clinic, set per connection by the backend (app.clinic_id).
+-- NULL when no clinic is set, which makes every clinic-scoped row invisible.
+CREATE OR REPLACE FUNCTION current_clinic_id()
+RETURNS INT AS $$
+  SELECT NULLIF(current_setting('app.clinic_id', true), '')::INT;
+$$ LANGUAGE sql STABLE;
+
+-- patient.clinic_id
+ALTER TABLE patient ADD COLUMN clinic_id INT;
+UPDATE patient SET clinic_id = (SELECT clinic_id FROM clinic WHERE clinic_name = 'Default clinic');
+ALTER TABLE patient
+    ALTER COLUMN clinic_id SET NOT NULL,
+    ALTER COLUMN clinic_id SET DEFAULT current_clinic_id(),
+    ADD CONSTRAINT fk_patient_clinic
+        FOREIGN KEY (clinic_id)
+        REFERENCES clinic(clinic_id),
+    -- Target for the composite FKs below, so child rows always share the patient's clinic
+    ADD CONSTRAINT uq_patient_clinic UNIQUE (patient_id, clinic_id),
+    -- Email is unique per clinic instead of across the deployment
+    DROP CONSTRAINT patient_email_key,
+    ADD CONSTRAINT uq_patient_clinic_email UNIQUE (clinic_id, email);
+
+-- patient_disease.clinic_id
+ALTER TABLE patient_disease ADD COLUMN clinic_id INT;
+UPDATE patient_disease pd SET clinic_id = p.clinic_id FROM patient p WHERE p.patient_id = pd.patient_id;
+ALTER TABLE patient_disease
+    ALTER COLUMN clinic_id SET NOT NULL,
+    ALTER COLUMN clinic_id SET DEFAULT current_clinic_id(),
+    ADD CONSTRAINT fk_pd_patient_clinic
+        FOREIGN KEY (patient_id, clinic_id)
+        REFERENCES patient(patient_id, clinic_id)
+        ON DELETE CASCADE;
+
+-- patient_symptoms.clinic_id
+ALTER TABLE patient_symptoms ADD COLUMN clinic_id INT;
+UPDATE patient_symptoms ps SET clinic_id = p.clinic_id FROM patient p WHERE p.patient_id = ps.patient_id;
+ALTER TABLE patient_symptoms
+    ALTER COLUMN clinic_id SET NOT NULL,
+    ALTER COLUMN clinic_id SET DEFAULT current_clinic_id(),
+    ADD CONSTRAINT fk_ps_patient_clinic
+        FOREIGN KEY (patient_id, clinic_id)
+        REFERENCES patient(patient_id, clinic_id)
+        ON DELETE CASCADE;
+
+-- Row-level security. FORCE makes the policies apply to the table owner too;
+-- superusers and BYPASSRLS roles are still exempt, so the backend must not connect as one.
+ALTER TABLE patient ENABLE ROW LEVEL SECURITY;
+ALTER TABLE patient FORCE ROW LEVEL SECURITY;
+CREATE POLICY clinic_isolation ON patient
+    USING (clinic_id = current_clinic_id())
+    WITH CHECK (clinic_id = current_clinic_id());
+
+ALTER TABLE patient_disease ENABLE ROW LEVEL SECURITY;
+ALTER TABLE patient_disease FORCE ROW LEVEL SECURITY;
+CREATE POLICY clinic_isolation ON patient_disease
+    USING (clinic_id = current_clinic_id())
+    WITH CHECK (clinic_id = current_clinic_id());
+
+ALTER TABLE patient_symptoms ENABLE ROW LEVEL SECURITY;
+ALTER TABLE patient_symptoms FORCE ROW LEVEL SECURITY;
+CREATE POLICY clinic_isolation ON patient_symptoms
+    USING (clinic_id = current_clinic_id())
+    WITH CHECK (clinic_id = current_clinic_id());
+
+-- Tables without their own clinic_id follow the visibility of their parent row
+ALTER TABLE patient_disease_symptom ENABLE ROW LEVEL SECURITY;
+ALTER TABLE patient_disease_symptom FORCE ROW LEVEL SECURITY;
+CREATE POLICY clinic_isolation ON patient_disease_symptom
+    USING (EXISTS (
+        SELECT 1 FROM patient_disease pd
+        WHERE pd.patient_disease_id = patient_disease_symptom.patient_disease_id
+    ));
+
+ALTER TABLE patient_consent ENABLE ROW LEVEL SECURITY;
+ALTER TABLE patient_consent FORCE ROW LEVEL SECURITY;
+CREATE POLICY clinic_isolation ON patient_consent
+    USING (EXISTS (
+        SELECT 1 FROM patient p
+        WHERE p.patient_id = patient_consent.patient_id
+    ));
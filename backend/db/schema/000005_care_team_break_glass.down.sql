@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS break_glass_access;
+DROP TABLE IF EXISTS break_glass_grant;
+DROP TABLE IF EXISTS care_team;
+
+ALTER TABLE app_user
+    DROP CONSTRAINT IF EXISTS chk_app_user_role,
+    DROP COLUMN IF EXISTS api_token_hash,
+    DROP COLUMN IF EXISTS role;
@@ -0,0 +1,101 @@
+-- Care teams and break-glass emergency access. Patient records are only readable by
+-- the patient's care team; anyone else has to break the glass with a stated reason.
+
+-- app_user: role and optional API token (sha256 hex of the bearer token)
+ALTER TABLE app_user
+    ADD COLUMN role VARCHAR(50) NOT NULL DEFAULT 'clinician',
+    ADD COLUMN api_token_hash VARCHAR(64) UNIQUE,
+    ADD CONSTRAINT chk_app_user_role CHECK (role IN ('clinician', 'admin'));
+
+UPDATE app_user SET role = 'admin' WHERE username = 'admin';
+
+-- Table: care_team (Users assigned to a patient's care)
+-- name: CareTeamTable
+CREATE TABLE care_team (
+    patient_id INT NOT NULL,
+    user_id INT NOT NULL,
+    assigned_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (patient_id, user_id),
+    CONSTRAINT fk_ct_patient
+        FOREIGN KEY (patient_id)
+        REFERENCES patient(patient_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_ct_user
+        FOREIGN KEY (user_id)
+        REFERENCES app_user(user_id)
+        ON DELETE CASCADE
+);
+
+-- Existing patients keep their current readers: every user of the patient's clinic.
+-- Backfill across every clinic: lift FORCE for the owner running the migration
+ALTER TABLE patient NO FORCE ROW LEVEL SECURITY;
+
+INSERT INTO care_team (patient_id, user_id, assigned_by)
+SELECT p.patient_id, uc.user_id, 'migration'
+FROM patient p
+JOIN user_clinic uc ON uc.clinic_id = p.clinic_id;
+
+ALTER TABLE patient FORCE ROW LEVEL SECURITY;
+
+-- Table: break_glass_grant (Time-limited emergency access to one patient)
+-- name: BreakGlassGrantTable
+CREATE TABLE break_glass_grant (
+    grant_id SERIAL PRIMARY KEY,
+    patient_id INT NOT NULL,
+    user_id INT NOT NULL,
+    reason TEXT NOT NULL,
+    granted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    CONSTRAINT fk_bgg_patient
+        FOREIGN KEY (patient_id)
+        REFERENCES patient(patient_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_bgg_user
+        FOREIGN KEY (user_id)
+        REFERENCES app_user(user_id)
+        ON DELETE CASCADE,
+    CONSTRAINT chk_bgg_reason CHECK (length(trim(reason)) > 0),
+    CONSTRAINT chk_bgg_window CHECK (expires_at > granted_at)
+);
+
+CREATE INDEX idx_bgg_user_patient ON break_glass_grant (user_id, patient_id, expires_at);
+
+-- Table: break_glass_access (Every request made under a break-glass grant)
+-- name: BreakGlassAccessTable
+CREATE TABLE break_glass_access (
+    access_id SERIAL PRIMARY KEY,
+    grant_id INT NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    accessed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_bga_grant
+        FOREIGN KEY (grant_id)
+        REFERENCES break_glass_grant(grant_id)
+        ON DELETE CASCADE
+);
+
+-- Clinic isolation follows the patient
+ALTER TABLE care_team ENABLE ROW LEVEL SECURITY;
+ALTER TABLE care_team FORCE ROW LEVEL SECURITY;
+CREATE POLICY clinic_isolation ON care_team
+    USING (EXISTS (
+        SELECT 1 FROM patient p
+        WHERE p.patient_id = care_team.patient_id
+    ));
+
+ALTER TABLE break_glass_grant ENABLE ROW LEVEL SECURITY;
+ALTER TABLE break_glass_grant FORCE ROW LEVEL SECURITY;
+CREATE POLICY clinic_isolation ON break_glass_grant
+    USING (EXISTS (
+        SELECT 1 FROM patient p
+        WHERE p.patient_id = break_glass_grant.patient_id
+    ));
+
+ALTER TABLE break_glass_access ENABLE ROW LEVEL SECURITY;
+ALTER TABLE break_glass_access FORCE ROW LEVEL SECURITY;
+CREATE POLICY clinic_isolation ON break_glass_access
+    USING (EXISTS (
+        SELECT 1 FROM break_glass_grant g
+        WHERE g.grant_id = break_glass_access.grant_id
+    ));
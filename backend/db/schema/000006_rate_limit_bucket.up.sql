@@ -0,0 +1,10 @@
+-- Table: rate_limit_bucket (Token buckets shared by all backend replicas)
+-- name: RateLimitBucketTable
+CREATE TABLE rate_limit_bucket (
+    bucket_key VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_rlb_updated_at ON rate_limit_bucket (updated_at);
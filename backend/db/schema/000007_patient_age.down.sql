@@ -0,0 +1,10 @@
+ALTER TABLE patient ADD COLUMN age INT;
+
+-- Backfill across every clinic: lift FORCE for the owner running the migration
+ALTER TABLE patient NO FORCE ROW LEVEL SECURITY;
+
+UPDATE patient SET age = DATE_PART('year', AGE(birthdate))::INT;
+
+ALTER TABLE patient FORCE ROW LEVEL SECURITY;
+
+ALTER TABLE patient ALTER COLUMN age SET NOT NULL;
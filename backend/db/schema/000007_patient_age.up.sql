@@ -0,0 +1,10 @@
+-- Age is derived from birthdate when a patient is read, so a stored age can no
+-- longer go stale. Registers are stored upper-cased, as the backend now does.
+ALTER TABLE patient DROP COLUMN age;
+
+-- Normalise across every clinic: lift FORCE for the owner running the migration
+ALTER TABLE patient NO FORCE ROW LEVEL SECURITY;
+
+UPDATE patient SET register = UPPER(TRIM(register)) WHERE register <> UPPER(TRIM(register));
+
+ALTER TABLE patient FORCE ROW LEVEL SECURITY;
@@ -0,0 +1,15 @@
+DROP TRIGGER IF EXISTS bump_patient_disease_version ON patient_disease;
+DROP TRIGGER IF EXISTS bump_disease_version ON disease;
+DROP TRIGGER IF EXISTS bump_symptoms_version ON symptoms;
+DROP TRIGGER IF EXISTS bump_patient_version ON patient;
+DROP TRIGGER IF EXISTS set_patient_timestamp ON patient;
+
+ALTER TABLE patient_disease DROP COLUMN IF EXISTS version;
+ALTER TABLE disease DROP COLUMN IF EXISTS version;
+ALTER TABLE symptoms DROP COLUMN IF EXISTS version;
+ALTER TABLE patient
+    DROP COLUMN IF EXISTS version,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at;
+
+DROP FUNCTION IF EXISTS trigger_bump_version();
@@ -0,0 +1,31 @@
+-- Row versions for optimistic concurrency. Every update bumps version, which the
+-- API exposes as the ETag; writes must send it back in If-Match.
+
+-- Function to bump 'version' on every update
+CREATE OR REPLACE FUNCTION trigger_bump_version()
+RETURNS TRIGGER AS $$
+BEGIN
+  NEW.version = OLD.version + 1;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- patient had no timestamps at all
+ALTER TABLE patient
+    ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+CREATE TRIGGER set_patient_timestamp
+BEFORE UPDATE ON patient
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+ALTER TABLE symptoms ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE disease ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE patient_disease ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+CREATE TRIGGER bump_patient_version BEFORE UPDATE ON patient FOR EACH ROW EXECUTE FUNCTION trigger_bump_version();
+CREATE TRIGGER bump_symptoms_version BEFORE UPDATE ON symptoms FOR EACH ROW EXECUTE FUNCTION trigger_bump_version();
+CREATE TRIGGER bump_disease_version BEFORE UPDATE ON disease FOR EACH ROW EXECUTE FUNCTION trigger_bump_version();
+CREATE TRIGGER bump_patient_disease_version BEFORE UPDATE ON patient_disease FOR EACH ROW EXECUTE FUNCTION trigger_bump_version();
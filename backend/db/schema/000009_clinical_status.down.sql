@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS patient_disease_status_history;
+
+DROP INDEX IF EXISTS idx_pd_patient_status;
+
+ALTER TABLE patient_disease
+    DROP CONSTRAINT IF EXISTS chk_pd_clinical_status,
+    DROP COLUMN IF EXISTS status_changed_at,
+    DROP COLUMN IF EXISTS clinical_status;
@@ -0,0 +1,48 @@
+-- Clinical status of a recorded diagnosis. Existing instances were recorded as
+-- diagnoses, so they start out confirmed.
+ALTER TABLE patient_disease
+    ADD COLUMN clinical_status VARCHAR(20) NOT NULL DEFAULT 'confirmed',
+    ADD COLUMN status_changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD CONSTRAINT chk_pd_clinical_status
+        CHECK (clinical_status IN ('suspected', 'confirmed', 'refuted', 'resolved', 'chronic'));
+
+CREATE INDEX idx_pd_patient_status ON patient_disease (patient_id, clinical_status);
+
+-- Table: patient_disease_status_history (Every status a diagnosis has moved through)
+-- name: PatientDiseaseStatusHistoryTable
+CREATE TABLE patient_disease_status_history (
+    id SERIAL PRIMARY KEY,
+    patient_disease_id INT NOT NULL,
+    from_status VARCHAR(20), -- NULL for the status the instance was recorded with
+    to_status VARCHAR(20) NOT NULL,
+    changed_by VARCHAR(100) NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_pdsh_patient_disease
+        FOREIGN KEY (patient_disease_id)
+        REFERENCES patient_disease(patient_disease_id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_pdsh_instance ON patient_disease_status_history (patient_disease_id, changed_at);
+
+-- Backfill across every clinic: lift FORCE for the owner running the migration.
+-- Existing instances got their status when they were recorded, and the history
+-- starts with it.
+ALTER TABLE patient_disease NO FORCE ROW LEVEL SECURITY;
+
+UPDATE patient_disease SET status_changed_at = created_at;
+
+INSERT INTO patient_disease_status_history (patient_disease_id, from_status, to_status, changed_by, changed_at)
+SELECT patient_disease_id, NULL, clinical_status, 'migration', created_at
+FROM patient_disease;
+
+ALTER TABLE patient_disease FORCE ROW LEVEL SECURITY;
+
+-- Follows the visibility of its diagnosis, like patient_disease_symptom
+ALTER TABLE patient_disease_status_history ENABLE ROW LEVEL SECURITY;
+ALTER TABLE patient_disease_status_history FORCE ROW LEVEL SECURITY;
+CREATE POLICY clinic_isolation ON patient_disease_status_history
+    USING (EXISTS (
+        SELECT 1 FROM patient_disease pd
+        WHERE pd.patient_disease_id = patient_disease_status_history.patient_disease_id
+    ));
@@ -0,0 +1,15 @@
+-- Table: idempotency_key (Responses to POSTs sent with an Idempotency-Key, replayed on retry)
+-- name: IdempotencyKeyTable
+CREATE TABLE idempotency_key (
+    scope VARCHAR(255) NOT NULL, -- The caller the key belongs to (user:<id> or ip:<address>)
+    idempotency_key VARCHAR(255) NOT NULL,
+    fingerprint CHAR(64) NOT NULL, -- SHA-256 of method, path and body
+    status_code INT, -- NULL while the first request is still being handled
+    response_headers JSONB,
+    response_body BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (scope, idempotency_key)
+);
+
+CREATE INDEX idx_ik_expires_at ON idempotency_key (expires_at);
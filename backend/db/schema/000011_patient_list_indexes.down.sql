@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_ps_symptom;
+DROP INDEX IF EXISTS idx_pd_diagnosis_date;
+DROP INDEX IF EXISTS idx_pd_disease_date;
+DROP INDEX IF EXISTS idx_patient_updated_at;
+DROP INDEX IF EXISTS idx_patient_created_at;
+DROP INDEX IF EXISTS idx_patient_birthdate;
+DROP INDEX IF EXISTS idx_patient_name;
@@ -0,0 +1,11 @@
+-- Indexes behind the patient listing's keyset pagination and filters. Each
+-- sort key ends in patient_id so every row has a unique position.
+CREATE INDEX idx_patient_name ON patient (lastname, firstname, patient_id);
+CREATE INDEX idx_patient_birthdate ON patient (birthdate, patient_id);
+CREATE INDEX idx_patient_created_at ON patient (created_at, patient_id);
+CREATE INDEX idx_patient_updated_at ON patient (updated_at, patient_id);
+
+-- Has-disease, diagnosis date and has-symptom filters
+CREATE INDEX idx_pd_disease_date ON patient_disease (disease_id, diagnosis_date);
+CREATE INDEX idx_pd_diagnosis_date ON patient_disease (diagnosis_date);
+CREATE INDEX idx_ps_symptom ON patient_symptoms (symptom_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_patient_search_fts;
+DROP INDEX IF EXISTS idx_patient_search_trgm;
+DROP FUNCTION IF EXISTS patient_search_text(TEXT, TEXT, TEXT, TEXT, TEXT);
+-- pg_trgm is left installed: other objects may depend on it
@@ -0,0 +1,18 @@
+-- Fuzzy and full-text patient search (GET /patients/search)
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- The text a patient is found by. Queries must use this same expression so
+-- the indexes below apply.
+CREATE OR REPLACE FUNCTION patient_search_text(
+    firstname TEXT, lastname TEXT, register TEXT, phonenumber TEXT, email TEXT
+) RETURNS TEXT AS $$
+  SELECT lower(firstname || ' ' || lastname || ' ' || register || ' ' || phonenumber || ' ' || email);
+$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE;
+
+-- Similarity and substring (ILIKE) matches, e.g. misspelled names or part of a phone number
+CREATE INDEX idx_patient_search_trgm ON patient
+    USING gin (patient_search_text(firstname, lastname, register, phonenumber, email) gin_trgm_ops);
+
+-- Whole-word and prefix matches. 'simple' does no stemming, which suits names in any script.
+CREATE INDEX idx_patient_search_fts ON patient
+    USING gin (to_tsvector('simple', patient_search_text(firstname, lastname, register, phonenumber, email)));
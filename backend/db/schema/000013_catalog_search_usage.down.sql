@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_disease_code;
+DROP INDEX IF EXISTS idx_disease_description_trgm;
+DROP INDEX IF EXISTS idx_disease_code_trgm;
+DROP INDEX IF EXISTS idx_disease_name_trgm;
+DROP INDEX IF EXISTS idx_symptoms_description_trgm;
+DROP INDEX IF EXISTS idx_symptoms_name_trgm;
+
+DROP TRIGGER IF EXISTS count_disease_usage ON patient_disease;
+DROP TRIGGER IF EXISTS count_symptom_usage ON patient_symptoms;
+DROP FUNCTION IF EXISTS trigger_count_disease_usage();
+DROP FUNCTION IF EXISTS trigger_count_symptom_usage();
+
+DROP TABLE IF EXISTS disease_usage;
+DROP TABLE IF EXISTS symptom_usage;
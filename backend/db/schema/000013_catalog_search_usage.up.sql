@@ -0,0 +1,77 @@
+-- Searchable, usage-sorted symptom and disease catalogs.
+
+-- How often each catalog entry is used, across all clinics. Kept by triggers
+-- rather than counted per request: the referencing tables are clinic-scoped
+-- by row-level security, and the catalog is not.
+-- name: SymptomUsageTable
+CREATE TABLE symptom_usage (
+    symptom_id INT PRIMARY KEY REFERENCES symptoms(symptom_id) ON DELETE CASCADE,
+    usage_count INT NOT NULL DEFAULT 0 -- patient_symptoms rows
+);
+
+-- name: DiseaseUsageTable
+CREATE TABLE disease_usage (
+    disease_id INT PRIMARY KEY REFERENCES disease(disease_id) ON DELETE CASCADE,
+    usage_count INT NOT NULL DEFAULT 0 -- patient_disease rows
+);
+
+CREATE INDEX idx_symptom_usage_count ON symptom_usage (usage_count, symptom_id);
+CREATE INDEX idx_disease_usage_count ON disease_usage (usage_count, disease_id);
+
+CREATE OR REPLACE FUNCTION trigger_count_symptom_usage()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP IN ('DELETE', 'UPDATE') THEN
+    UPDATE symptom_usage SET usage_count = usage_count - 1 WHERE symptom_id = OLD.symptom_id;
+  END IF;
+  IF TG_OP IN ('INSERT', 'UPDATE') THEN
+    INSERT INTO symptom_usage (symptom_id, usage_count) VALUES (NEW.symptom_id, 1)
+    ON CONFLICT (symptom_id) DO UPDATE SET usage_count = symptom_usage.usage_count + 1;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION trigger_count_disease_usage()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP IN ('DELETE', 'UPDATE') THEN
+    UPDATE disease_usage SET usage_count = usage_count - 1 WHERE disease_id = OLD.disease_id;
+  END IF;
+  IF TG_OP IN ('INSERT', 'UPDATE') THEN
+    INSERT INTO disease_usage (disease_id, usage_count) VALUES (NEW.disease_id, 1)
+    ON CONFLICT (disease_id) DO UPDATE SET usage_count = disease_usage.usage_count + 1;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER count_symptom_usage
+AFTER INSERT OR DELETE OR UPDATE OF symptom_id ON patient_symptoms
+FOR EACH ROW EXECUTE FUNCTION trigger_count_symptom_usage();
+
+CREATE TRIGGER count_disease_usage
+AFTER INSERT OR DELETE OR UPDATE OF disease_id ON patient_disease
+FOR EACH ROW EXECUTE FUNCTION trigger_count_disease_usage();
+
+-- Backfill across every clinic: lift FORCE for the owner running the migration
+ALTER TABLE patient_symptoms NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE patient_disease NO FORCE ROW LEVEL SECURITY;
+
+INSERT INTO symptom_usage (symptom_id, usage_count)
+SELECT symptom_id, count(*) FROM patient_symptoms GROUP BY symptom_id;
+INSERT INTO disease_usage (disease_id, usage_count)
+SELECT disease_id, count(*) FROM patient_disease GROUP BY disease_id;
+
+ALTER TABLE patient_symptoms FORCE ROW LEVEL SECURITY;
+ALTER TABLE patient_disease FORCE ROW LEVEL SECURITY;
+
+-- ?q= matches names, descriptions and codes by substring or similar words
+CREATE INDEX idx_symptoms_name_trgm ON symptoms USING gin (symptom_name gin_trgm_ops);
+CREATE INDEX idx_symptoms_description_trgm ON symptoms USING gin (symptom_description gin_trgm_ops);
+CREATE INDEX idx_disease_name_trgm ON disease USING gin (disease_name gin_trgm_ops);
+CREATE INDEX idx_disease_code_trgm ON disease USING gin (disease_code gin_trgm_ops);
+CREATE INDEX idx_disease_description_trgm ON disease USING gin (disease_description gin_trgm_ops);
+
+-- Sorting by code
+CREATE INDEX idx_disease_code ON disease (disease_code, disease_id);
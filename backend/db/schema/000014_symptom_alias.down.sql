@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS touch_aliased_symptom ON symptom_alias;
+DROP FUNCTION IF EXISTS trigger_touch_aliased_symptom();
+DROP TABLE IF EXISTS symptom_alias;
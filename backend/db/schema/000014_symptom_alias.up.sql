@@ -0,0 +1,46 @@
+-- Other names a symptom goes by: spellings in the seed data, synonyms
+-- clinicians type, translations. Each resolves to one canonical symptom.
+-- name: SymptomAliasTable
+CREATE TABLE symptom_alias (
+    symptom_alias_id SERIAL PRIMARY KEY,
+    symptom_id INT NOT NULL,
+    alias VARCHAR(255) NOT NULL,
+    language VARCHAR(35) NOT NULL DEFAULT 'mn', -- BCP 47 tag
+    source VARCHAR(50) NOT NULL DEFAULT 'manual', -- Where the alias came from, e.g. seed, clinician
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_sa_symptom
+        FOREIGN KEY (symptom_id)
+        REFERENCES symptoms(symptom_id)
+        ON DELETE CASCADE
+);
+
+-- An alias names one symptom, whatever its case
+CREATE UNIQUE INDEX uq_symptom_alias ON symptom_alias (LOWER(alias));
+CREATE INDEX idx_symptom_alias_symptom ON symptom_alias (symptom_id, alias);
+CREATE INDEX idx_symptom_alias_trgm ON symptom_alias USING gin (alias gin_trgm_ops);
+
+CREATE TRIGGER set_symptom_alias_timestamp
+BEFORE UPDATE ON symptom_alias
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+-- Aliases are part of the symptom's representation, so changing them bumps
+-- its version (and ETag) through bump_symptoms_version.
+CREATE OR REPLACE FUNCTION trigger_touch_aliased_symptom()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP IN ('UPDATE', 'DELETE') THEN
+    UPDATE symptoms SET updated_at = CURRENT_TIMESTAMP WHERE symptom_id = OLD.symptom_id;
+  END IF;
+  IF TG_OP IN ('INSERT', 'UPDATE') AND (TG_OP = 'INSERT' OR NEW.symptom_id <> OLD.symptom_id) THEN
+    UPDATE symptoms SET updated_at = CURRENT_TIMESTAMP WHERE symptom_id = NEW.symptom_id;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER touch_aliased_symptom
+AFTER INSERT OR UPDATE OR DELETE ON symptom_alias
+FOR EACH ROW
+EXECUTE FUNCTION trigger_touch_aliased_symptom();
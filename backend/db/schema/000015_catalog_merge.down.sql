@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS disease_alias;
+
+ALTER POLICY clinic_isolation ON patient_symptoms
+    USING (clinic_id = current_clinic_id())
+    WITH CHECK (clinic_id = current_clinic_id());
+
+ALTER POLICY clinic_isolation ON patient_disease
+    USING (clinic_id = current_clinic_id())
+    WITH CHECK (clinic_id = current_clinic_id());
+
+ALTER POLICY clinic_isolation ON patient
+    USING (clinic_id = current_clinic_id())
+    WITH CHECK (clinic_id = current_clinic_id());
+
+DROP FUNCTION IF EXISTS system_scope();
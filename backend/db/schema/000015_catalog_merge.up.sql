@@ -0,0 +1,47 @@
+-- Merging duplicate catalog entries, which repoints patient records across
+-- every clinic.
+
+-- System scope: maintenance that spans clinics (a catalog merge) lifts clinic
+-- isolation for one transaction with set_config('app.system_scope', 'on', true).
+-- Only the backend sets it, and only inside such a transaction.
+CREATE OR REPLACE FUNCTION system_scope()
+RETURNS BOOLEAN AS $$
+  SELECT COALESCE(current_setting('app.system_scope', true), '') = 'on';
+$$ LANGUAGE sql STABLE;
+
+ALTER POLICY clinic_isolation ON patient
+    USING (clinic_id = current_clinic_id() OR system_scope())
+    WITH CHECK (clinic_id = current_clinic_id() OR system_scope());
+
+ALTER POLICY clinic_isolation ON patient_disease
+    USING (clinic_id = current_clinic_id() OR system_scope())
+    WITH CHECK (clinic_id = current_clinic_id() OR system_scope());
+
+ALTER POLICY clinic_isolation ON patient_symptoms
+    USING (clinic_id = current_clinic_id() OR system_scope())
+    WITH CHECK (clinic_id = current_clinic_id() OR system_scope());
+
+-- Other names a disease goes by; a merge keeps the absorbed disease's name here.
+-- name: DiseaseAliasTable
+CREATE TABLE disease_alias (
+    disease_alias_id SERIAL PRIMARY KEY,
+    disease_id INT NOT NULL,
+    alias VARCHAR(255) NOT NULL,
+    language VARCHAR(35) NOT NULL DEFAULT 'mn', -- BCP 47 tag
+    source VARCHAR(50) NOT NULL DEFAULT 'manual', -- Where the alias came from, e.g. merge
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_da_disease
+        FOREIGN KEY (disease_id)
+        REFERENCES disease(disease_id)
+        ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX uq_disease_alias ON disease_alias (LOWER(alias));
+CREATE INDEX idx_disease_alias_disease ON disease_alias (disease_id, alias);
+CREATE INDEX idx_disease_alias_trgm ON disease_alias USING gin (alias gin_trgm_ops);
+
+CREATE TRIGGER set_disease_alias_timestamp
+BEFORE UPDATE ON disease_alias
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
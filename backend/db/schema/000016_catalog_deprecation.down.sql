@@ -0,0 +1,32 @@
+ALTER TABLE patient_symptoms
+    DROP CONSTRAINT fk_ps_symptom,
+    ADD CONSTRAINT fk_ps_symptom
+        FOREIGN KEY (symptom_id)
+        REFERENCES symptoms(symptom_id)
+        ON DELETE CASCADE;
+
+ALTER TABLE patient_disease_symptom
+    DROP CONSTRAINT fk_pds_symptom,
+    ADD CONSTRAINT fk_pds_symptom
+        FOREIGN KEY (symptom_id)
+        REFERENCES symptoms(symptom_id)
+        ON DELETE CASCADE;
+
+ALTER TABLE patient_disease
+    DROP CONSTRAINT fk_pd_disease,
+    ADD CONSTRAINT fk_pd_disease
+        FOREIGN KEY (disease_id)
+        REFERENCES disease(disease_id)
+        ON DELETE CASCADE;
+
+ALTER TABLE disease
+    DROP CONSTRAINT chk_disease_replaced_by,
+    DROP CONSTRAINT fk_disease_replaced_by,
+    DROP COLUMN replaced_by,
+    DROP COLUMN deprecated_at;
+
+ALTER TABLE symptoms
+    DROP CONSTRAINT chk_symptoms_replaced_by,
+    DROP CONSTRAINT fk_symptoms_replaced_by,
+    DROP COLUMN replaced_by,
+    DROP COLUMN deprecated_at;
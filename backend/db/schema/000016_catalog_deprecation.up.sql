@@ -0,0 +1,43 @@
+-- Catalog entries are deprecated rather than deleted: patient records keep
+-- pointing at them, while pickers stop offering them.
+ALTER TABLE symptoms
+    ADD COLUMN deprecated_at TIMESTAMP,
+    ADD COLUMN replaced_by INT,
+    ADD CONSTRAINT fk_symptoms_replaced_by
+        FOREIGN KEY (replaced_by)
+        REFERENCES symptoms(symptom_id)
+        ON DELETE SET NULL,
+    ADD CONSTRAINT chk_symptoms_replaced_by
+        CHECK (replaced_by IS NULL OR (replaced_by <> symptom_id AND deprecated_at IS NOT NULL));
+
+ALTER TABLE disease
+    ADD COLUMN deprecated_at TIMESTAMP,
+    ADD COLUMN replaced_by INT,
+    ADD CONSTRAINT fk_disease_replaced_by
+        FOREIGN KEY (replaced_by)
+        REFERENCES disease(disease_id)
+        ON DELETE SET NULL,
+    ADD CONSTRAINT chk_disease_replaced_by
+        CHECK (replaced_by IS NULL OR (replaced_by <> disease_id AND deprecated_at IS NOT NULL));
+
+-- Deleting a catalog entry no longer takes patient records with it
+ALTER TABLE patient_disease
+    DROP CONSTRAINT fk_pd_disease,
+    ADD CONSTRAINT fk_pd_disease
+        FOREIGN KEY (disease_id)
+        REFERENCES disease(disease_id)
+        ON DELETE RESTRICT;
+
+ALTER TABLE patient_disease_symptom
+    DROP CONSTRAINT fk_pds_symptom,
+    ADD CONSTRAINT fk_pds_symptom
+        FOREIGN KEY (symptom_id)
+        REFERENCES symptoms(symptom_id)
+        ON DELETE RESTRICT;
+
+ALTER TABLE patient_symptoms
+    DROP CONSTRAINT fk_ps_symptom,
+    ADD CONSTRAINT fk_ps_symptom
+        FOREIGN KEY (symptom_id)
+        REFERENCES symptoms(symptom_id)
+        ON DELETE RESTRICT;
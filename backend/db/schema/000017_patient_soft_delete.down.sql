@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_patient_deleted_at;
+
+ALTER TABLE patient
+    DROP CONSTRAINT chk_patient_deletion,
+    DROP CONSTRAINT fk_patient_deleted_by,
+    DROP COLUMN deletion_reason,
+    DROP COLUMN deleted_by,
+    DROP COLUMN deleted_at;
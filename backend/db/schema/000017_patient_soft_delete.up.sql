@@ -0,0 +1,15 @@
+-- Deleting a patient only marks the record; the purge job removes it (and,
+-- by cascade, its history) once the retention period has passed.
+ALTER TABLE patient
+    ADD COLUMN deleted_at TIMESTAMP,
+    ADD COLUMN deleted_by INT,
+    ADD COLUMN deletion_reason TEXT,
+    ADD CONSTRAINT fk_patient_deleted_by
+        FOREIGN KEY (deleted_by)
+        REFERENCES app_user(user_id)
+        ON DELETE SET NULL,
+    ADD CONSTRAINT chk_patient_deletion
+        CHECK (deleted_at IS NOT NULL OR (deleted_by IS NULL AND deletion_reason IS NULL));
+
+-- The purge job scans only deleted patients
+CREATE INDEX idx_patient_deleted_at ON patient (deleted_at) WHERE deleted_at IS NOT NULL;
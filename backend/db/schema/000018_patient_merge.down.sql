@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS patient_merge;
+
+DROP INDEX IF EXISTS idx_patient_live_birthdate;
+DROP INDEX IF EXISTS idx_patient_phone_digits;
+DROP INDEX IF EXISTS idx_patient_register_upper;
@@ -0,0 +1,44 @@
+-- Duplicate patient detection and merging two records of the same person.
+
+-- Lookups for the duplicate check on create and update: the register as the
+-- civil registry writes it, and the phone number as digits only
+CREATE INDEX idx_patient_register_upper ON patient (UPPER(register)) WHERE deleted_at IS NULL;
+CREATE INDEX idx_patient_phone_digits ON patient (regexp_replace(phonenumber, '\D', '', 'g')) WHERE deleted_at IS NULL;
+CREATE INDEX idx_patient_live_birthdate ON patient (birthdate) WHERE deleted_at IS NULL;
+
+-- Table: patient_merge (Audit record of every patient merge)
+-- The patient IDs are kept without foreign keys, so the record outlives the
+-- purge of the (soft-deleted) duplicate.
+-- name: PatientMergeTable
+CREATE TABLE patient_merge (
+    patient_merge_id SERIAL PRIMARY KEY,
+    clinic_id INT NOT NULL DEFAULT current_clinic_id(),
+    survivor_id INT NOT NULL,
+    duplicate_id INT NOT NULL,
+    merged_by INT,
+    reason TEXT,
+    symptoms_moved INT NOT NULL DEFAULT 0,
+    symptoms_collapsed INT NOT NULL DEFAULT 0, -- Already reported for the survivor on the same date
+    diagnoses_moved INT NOT NULL DEFAULT 0,
+    diagnoses_collapsed INT NOT NULL DEFAULT 0, -- Folded into the survivor's diagnosis of the same disease and date
+    care_team_added INT NOT NULL DEFAULT 0,
+    merged_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_pm_clinic
+        FOREIGN KEY (clinic_id)
+        REFERENCES clinic(clinic_id),
+    CONSTRAINT fk_pm_merged_by
+        FOREIGN KEY (merged_by)
+        REFERENCES app_user(user_id)
+        ON DELETE SET NULL,
+    CONSTRAINT chk_pm_distinct
+        CHECK (survivor_id <> duplicate_id)
+);
+
+CREATE INDEX idx_patient_merge_survivor ON patient_merge (survivor_id);
+CREATE INDEX idx_patient_merge_duplicate ON patient_merge (duplicate_id);
+
+ALTER TABLE patient_merge ENABLE ROW LEVEL SECURITY;
+ALTER TABLE patient_merge FORCE ROW LEVEL SECURITY;
+CREATE POLICY clinic_isolation ON patient_merge
+    USING (clinic_id = current_clinic_id() OR system_scope())
+    WITH CHECK (clinic_id = current_clinic_id() OR system_scope());
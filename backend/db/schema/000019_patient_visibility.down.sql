@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_ct_user;
+DROP FUNCTION IF EXISTS patient_visible_to(INT, INT);
@@ -0,0 +1,21 @@
+-- Reusable read scope: TRUE when the user may read the patient, being on the care team
+-- or holding an open break-glass grant. Listings and search filter on it, so reads of
+-- other patients' records stay denied by default.
+CREATE OR REPLACE FUNCTION patient_visible_to(p_patient_id INT, p_user_id INT)
+RETURNS BOOLEAN AS $$
+  SELECT EXISTS (
+    SELECT 1
+    FROM care_team ct
+    WHERE ct.patient_id = p_patient_id
+      AND ct.user_id = p_user_id
+  ) OR EXISTS (
+    SELECT 1
+    FROM break_glass_grant g
+    WHERE g.patient_id = p_patient_id
+      AND g.user_id = p_user_id
+      AND g.expires_at > CURRENT_TIMESTAMP
+  );
+$$ LANGUAGE sql STABLE;
+
+-- Listings look the care team up by user
+CREATE INDEX idx_ct_user ON care_team (user_id, patient_id);
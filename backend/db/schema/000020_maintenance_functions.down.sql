@@ -0,0 +1,30 @@
+DROP FUNCTION IF EXISTS purge_deleted_patients(INT, INT);
+DROP FUNCTION IF EXISTS merge_disease_references(INT, INT);
+DROP FUNCTION IF EXISTS merge_symptom_references(INT, INT);
+DROP FUNCTION IF EXISTS count_disease_references(INT);
+DROP FUNCTION IF EXISTS count_symptom_references(INT);
+
+DROP POLICY IF EXISTS system_maintenance ON patient_symptoms;
+DROP POLICY IF EXISTS system_maintenance ON patient_disease;
+DROP POLICY IF EXISTS system_maintenance ON patient;
+
+CREATE OR REPLACE FUNCTION system_scope()
+RETURNS BOOLEAN AS $$
+  SELECT COALESCE(current_setting('app.system_scope', true), '') = 'on';
+$$ LANGUAGE sql STABLE;
+
+ALTER POLICY clinic_isolation ON patient_merge
+    USING (clinic_id = current_clinic_id() OR system_scope())
+    WITH CHECK (clinic_id = current_clinic_id() OR system_scope());
+
+ALTER POLICY clinic_isolation ON patient_symptoms
+    USING (clinic_id = current_clinic_id() OR system_scope())
+    WITH CHECK (clinic_id = current_clinic_id() OR system_scope());
+
+ALTER POLICY clinic_isolation ON patient_disease
+    USING (clinic_id = current_clinic_id() OR system_scope())
+    WITH CHECK (clinic_id = current_clinic_id() OR system_scope());
+
+ALTER POLICY clinic_isolation ON patient
+    USING (clinic_id = current_clinic_id() OR system_scope())
+    WITH CHECK (clinic_id = current_clinic_id() OR system_scope());
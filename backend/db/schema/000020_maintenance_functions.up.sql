@@ -0,0 +1,174 @@
+-- Cross-clinic maintenance without a switch the API role can flip. 000015 let
+-- any session lift clinic isolation with app.system_scope; instead, catalog
+-- merges, reference counts and the patient purge now run through SECURITY
+-- DEFINER functions owned by the role running the migrations. Only that role
+-- gets the maintenance policies below, so the API role (which must not be it,
+-- nor a member of it) can do exactly what the functions do and nothing more.
+
+ALTER POLICY clinic_isolation ON patient
+    USING (clinic_id = current_clinic_id())
+    WITH CHECK (clinic_id = current_clinic_id());
+
+ALTER POLICY clinic_isolation ON patient_disease
+    USING (clinic_id = current_clinic_id())
+    WITH CHECK (clinic_id = current_clinic_id());
+
+ALTER POLICY clinic_isolation ON patient_symptoms
+    USING (clinic_id = current_clinic_id())
+    WITH CHECK (clinic_id = current_clinic_id());
+
+ALTER POLICY clinic_isolation ON patient_merge
+    USING (clinic_id = current_clinic_id())
+    WITH CHECK (clinic_id = current_clinic_id());
+
+DROP FUNCTION IF EXISTS system_scope();
+
+-- The owner sees every clinic. Child tables (patient_disease_symptom, status
+-- history, ...) follow their parent row, so they open up with it.
+CREATE POLICY system_maintenance ON patient TO CURRENT_USER
+    USING (true) WITH CHECK (true);
+CREATE POLICY system_maintenance ON patient_disease TO CURRENT_USER
+    USING (true) WITH CHECK (true);
+CREATE POLICY system_maintenance ON patient_symptoms TO CURRENT_USER
+    USING (true) WITH CHECK (true);
+
+-- Patient records using a symptom, across all clinics
+CREATE OR REPLACE FUNCTION count_symptom_references(p_symptom_id INT)
+RETURNS TABLE (patient_symptoms BIGINT, disease_instance_symptoms BIGINT) AS $$
+  SELECT
+    (SELECT count(*) FROM patient_symptoms ps WHERE ps.symptom_id = p_symptom_id),
+    (SELECT count(*) FROM patient_disease_symptom pds WHERE pds.symptom_id = p_symptom_id);
+$$ LANGUAGE sql STABLE SECURITY DEFINER SET search_path = public, pg_temp;
+
+-- Disease instances recorded of a disease, across all clinics
+CREATE OR REPLACE FUNCTION count_disease_references(p_disease_id INT)
+RETURNS BIGINT AS $$
+  SELECT count(*) FROM patient_disease WHERE disease_id = p_disease_id;
+$$ LANGUAGE sql STABLE SECURITY DEFINER SET search_path = public, pg_temp;
+
+-- Moves every patient record of the duplicate symptom to the survivor, across
+-- all clinics. A record the survivor already has (same patient and date, or
+-- same diagnosis) is dropped first, so the repointing cannot break a unique key.
+CREATE OR REPLACE FUNCTION merge_symptom_references(p_survivor_id INT, p_duplicate_id INT)
+RETURNS TABLE (
+    patient_symptoms_collapsed BIGINT,
+    patient_symptoms_repointed BIGINT,
+    instance_symptoms_collapsed BIGINT,
+    instance_symptoms_repointed BIGINT
+) AS $$
+BEGIN
+  DELETE FROM patient_symptoms d
+  WHERE d.symptom_id = p_duplicate_id
+    AND EXISTS (
+      SELECT 1 FROM patient_symptoms k
+      WHERE k.patient_id = d.patient_id
+        AND k.symptom_id = p_survivor_id
+        AND k.reported_date = d.reported_date
+    );
+  GET DIAGNOSTICS patient_symptoms_collapsed = ROW_COUNT;
+
+  UPDATE patient_symptoms SET symptom_id = p_survivor_id
+  WHERE symptom_id = p_duplicate_id;
+  GET DIAGNOSTICS patient_symptoms_repointed = ROW_COUNT;
+
+  DELETE FROM patient_disease_symptom d
+  WHERE d.symptom_id = p_duplicate_id
+    AND EXISTS (
+      SELECT 1 FROM patient_disease_symptom k
+      WHERE k.patient_disease_id = d.patient_disease_id
+        AND k.symptom_id = p_survivor_id
+    );
+  GET DIAGNOSTICS instance_symptoms_collapsed = ROW_COUNT;
+
+  UPDATE patient_disease_symptom SET symptom_id = p_survivor_id
+  WHERE symptom_id = p_duplicate_id;
+  GET DIAGNOSTICS instance_symptoms_repointed = ROW_COUNT;
+
+  RETURN NEXT;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER SET search_path = public, pg_temp;
+
+-- Moves every diagnosis of the duplicate disease to the survivor, across all
+-- clinics. A diagnosis the patient also has of the survivor on the same date
+-- hands over its symptom links, status history and notes, then is dropped.
+CREATE OR REPLACE FUNCTION merge_disease_references(p_survivor_id INT, p_duplicate_id INT)
+RETURNS TABLE (
+    instance_symptoms_moved BIGINT,
+    status_history_moved BIGINT,
+    notes_merged BIGINT,
+    instances_collapsed BIGINT,
+    instances_repointed BIGINT
+) AS $$
+BEGIN
+  UPDATE patient_disease_symptom pds
+  SET patient_disease_id = k.patient_disease_id
+  FROM patient_disease d
+  JOIN patient_disease k
+    ON k.patient_id = d.patient_id
+   AND k.disease_id = p_survivor_id
+   AND k.diagnosis_date = d.diagnosis_date
+  WHERE pds.patient_disease_id = d.patient_disease_id
+    AND d.disease_id = p_duplicate_id
+    AND NOT EXISTS (
+      SELECT 1 FROM patient_disease_symptom x
+      WHERE x.patient_disease_id = k.patient_disease_id
+        AND x.symptom_id = pds.symptom_id
+    );
+  GET DIAGNOSTICS instance_symptoms_moved = ROW_COUNT;
+
+  UPDATE patient_disease_status_history h
+  SET patient_disease_id = k.patient_disease_id
+  FROM patient_disease d
+  JOIN patient_disease k
+    ON k.patient_id = d.patient_id
+   AND k.disease_id = p_survivor_id
+   AND k.diagnosis_date = d.diagnosis_date
+  WHERE h.patient_disease_id = d.patient_disease_id
+    AND d.disease_id = p_duplicate_id;
+  GET DIAGNOSTICS status_history_moved = ROW_COUNT;
+
+  UPDATE patient_disease k
+  SET notes = CASE
+      WHEN k.notes IS NULL OR k.notes = '' THEN d.notes
+      ELSE k.notes || E'\n\n' || d.notes
+  END
+  FROM patient_disease d
+  WHERE k.patient_id = d.patient_id
+    AND k.disease_id = p_survivor_id
+    AND k.diagnosis_date = d.diagnosis_date
+    AND d.disease_id = p_duplicate_id
+    AND d.notes IS NOT NULL AND d.notes <> ''
+    AND d.notes IS DISTINCT FROM k.notes;
+  GET DIAGNOSTICS notes_merged = ROW_COUNT;
+
+  DELETE FROM patient_disease d
+  WHERE d.disease_id = p_duplicate_id
+    AND EXISTS (
+      SELECT 1 FROM patient_disease k
+      WHERE k.patient_id = d.patient_id
+        AND k.disease_id = p_survivor_id
+        AND k.diagnosis_date = d.diagnosis_date
+    );
+  GET DIAGNOSTICS instances_collapsed = ROW_COUNT;
+
+  UPDATE patient_disease SET disease_id = p_survivor_id
+  WHERE disease_id = p_duplicate_id;
+  GET DIAGNOSTICS instances_repointed = ROW_COUNT;
+
+  RETURN NEXT;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER SET search_path = public, pg_temp;
+
+-- Removes patients soft-deleted longer ago than the retention period, with
+-- their whole history (ON DELETE CASCADE), across all clinics, a batch at a time.
+CREATE OR REPLACE FUNCTION purge_deleted_patients(p_retention_seconds INT, p_batch_size INT)
+RETURNS TABLE (patient_id INT, clinic_id INT, deleted_at TIMESTAMP, deleted_by INT, deletion_reason TEXT) AS $$
+  DELETE FROM patient
+  WHERE patient.patient_id IN (
+      SELECT p.patient_id FROM patient p
+      WHERE p.deleted_at < CURRENT_TIMESTAMP - (p_retention_seconds * INTERVAL '1 second')
+      ORDER BY p.deleted_at
+      LIMIT p_batch_size
+  )
+  RETURNING patient.patient_id, patient.clinic_id, patient.deleted_at, patient.deleted_by, patient.deletion_reason;
+$$ LANGUAGE sql SECURITY DEFINER SET search_path = public, pg_temp;
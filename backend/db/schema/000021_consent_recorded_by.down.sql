@@ -0,0 +1,18 @@
+UPDATE patient_consent pc
+SET captured_by = u.username
+FROM app_user u
+WHERE pc.captured_by IS NULL AND u.user_id = pc.captured_by_user_id;
+
+UPDATE patient_consent pc
+SET revoked_by = u.username
+FROM app_user u
+WHERE pc.revoked_by IS NULL AND u.user_id = pc.revoked_by_user_id;
+
+UPDATE patient_consent SET captured_by = 'unknown' WHERE captured_by IS NULL;
+
+ALTER TABLE patient_consent
+    ALTER COLUMN captured_by SET NOT NULL,
+    DROP CONSTRAINT IF EXISTS fk_pc_revoked_by,
+    DROP CONSTRAINT IF EXISTS fk_pc_captured_by,
+    DROP COLUMN IF EXISTS revoked_by_user_id,
+    DROP COLUMN IF EXISTS captured_by_user_id;
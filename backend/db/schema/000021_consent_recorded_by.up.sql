@@ -0,0 +1,27 @@
+-- Consents record who captured and revoked them as the authenticated user,
+-- never a name sent by the client. The free-text columns stay for consents
+-- recorded before; they are no longer written.
+ALTER TABLE patient_consent
+    ADD COLUMN captured_by_user_id INT,
+    ADD COLUMN revoked_by_user_id INT,
+    ADD CONSTRAINT fk_pc_captured_by
+        FOREIGN KEY (captured_by_user_id)
+        REFERENCES app_user(user_id)
+        ON DELETE SET NULL,
+    ADD CONSTRAINT fk_pc_revoked_by
+        FOREIGN KEY (revoked_by_user_id)
+        REFERENCES app_user(user_id)
+        ON DELETE SET NULL,
+    ALTER COLUMN captured_by DROP NOT NULL;
+
+-- Backfill across every clinic: the owner running the migration sees every
+-- patient (system_maintenance, 000020), and consents follow their patient
+UPDATE patient_consent pc
+SET captured_by_user_id = u.user_id
+FROM app_user u
+WHERE u.username = pc.captured_by;
+
+UPDATE patient_consent pc
+SET revoked_by_user_id = u.user_id
+FROM app_user u
+WHERE u.username = pc.revoked_by;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS bump_patient_symptoms_version ON patient_symptoms;
+
+ALTER TABLE patient_symptoms DROP COLUMN IF EXISTS version;
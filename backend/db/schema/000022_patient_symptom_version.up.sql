@@ -0,0 +1,5 @@
+-- General symptom records get a row version like the other patient records, so
+-- deleting one can be checked against If-Match (a symptom merge repoints them).
+ALTER TABLE patient_symptoms ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+CREATE TRIGGER bump_patient_symptoms_version BEFORE UPDATE ON patient_symptoms FOR EACH ROW EXECUTE FUNCTION trigger_bump_version();
@@ -3,15 +3,26 @@ package db
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func Init(dbUrl string, ctx context.Context) (*pgxpool.Pool, error) {
-	log.Printf("Connecting to Database: %s", dbUrl);
+// Init connects the pool. With requireRLS (production) it refuses a role that
+// bypasses row-level security, since clinic isolation depends on it.
+func Init(dbUrl string, ctx context.Context, logger *slog.Logger, requireRLS bool) (*pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(dbUrl)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse database url: %w", err)
+	}
+	// Never log the URL itself: it carries the password
+	logger.Info("Connecting to database", "host", config.ConnConfig.Host, "port", config.ConnConfig.Port, "database", config.ConnConfig.Database, "user", config.ConnConfig.User)
+	// Scope every acquired connection to the caller's clinic (row-level security)
+	config.BeforeAcquire = setClinicOnAcquire
+	// Record a span per query (OpenTelemetry)
+	config.ConnConfig.Tracer = newQueryTracer()
 
-	pool, err := pgxpool.New(ctx, dbUrl)
+	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create connection pool: %w", err)
 	}
@@ -22,5 +33,24 @@ func Init(dbUrl string, ctx context.Context) (*pgxpool.Pool, error) {
 		return nil, fmt.Errorf("database ping failed: %w", err)
 	}
 
+	// Superusers and BYPASSRLS roles ignore the clinic isolation policies, and
+	// the schema owner (or a member of it) gets the system_maintenance ones
+	var bypassesRLS bool
+	err = pool.QueryRow(ctx, `SELECT rolsuper OR rolbypassrls OR COALESCE(pg_has_role(current_user,
+		(SELECT tableowner FROM pg_tables WHERE schemaname = 'public' AND tablename = 'patient'), 'MEMBER'), false)
+		FROM pg_roles WHERE rolname = current_user`).Scan(&bypassesRLS)
+	switch {
+	case err != nil && requireRLS:
+		pool.Close()
+		return nil, fmt.Errorf("could not check database role attributes: %w", err)
+	case err != nil:
+		logger.Warn("Could not check database role attributes", "err", err)
+	case bypassesRLS && requireRLS:
+		pool.Close()
+		return nil, fmt.Errorf("database role %q is a superuser, has BYPASSRLS or owns the schema, so clinic isolation would not be enforced; connect as a regular role", config.ConnConfig.User)
+	case bypassesRLS:
+		logger.Warn("Database role bypasses row-level security; clinic isolation is NOT enforced. Connect as a non-superuser role that does not own the schema.")
+	}
+
 	return pool, nil
 }
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type clinicIDKey struct{}
+
+// WithClinicID returns a context scoped to a clinic. Connections acquired from the
+// pool with this context have app.clinic_id set, which the row-level security
+// policies on patient data compare against.
+func WithClinicID(ctx context.Context, clinicID int32) context.Context {
+	return context.WithValue(ctx, clinicIDKey{}, clinicID)
+}
+
+// ClinicIDFromContext returns the clinic set with WithClinicID, if any.
+func ClinicIDFromContext(ctx context.Context) (int32, bool) {
+	clinicID, ok := ctx.Value(clinicIDKey{}).(int32)
+	return clinicID, ok
+}
+
+// setClinicOnAcquire applies the context's clinic to a pooled connection. Without a
+// clinic the setting is cleared, so a connection never carries a previous caller's scope.
+func setClinicOnAcquire(ctx context.Context, conn *pgx.Conn) bool {
+	setting := ""
+	if clinicID, ok := ClinicIDFromContext(ctx); ok {
+		setting = strconv.Itoa(int(clinicID))
+	}
+	_, err := conn.Exec(ctx, "SELECT set_config('app.clinic_id', $1, false)", setting)
+	return err == nil // false destroys the connection and acquires another
+}
@@ -24,9 +24,17 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/disease-instances/{instanceID}": {
-            "delete": {
-                "description": "Remove a specific patient_disease entry by its unique ID (patient_disease_id). This also removes associated symptom links via ON DELETE CASCADE.",
+        "/admin/break-glass-events": {
+            "get": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Administrator report of every break-glass grant in the clinic, with reason, window and each access made under it. Filter by grant time with since/until (RFC 3339).",
                 "consumes": [
                     "application/json"
                 ],
@@ -34,44 +42,71 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Patient Relationships"
+                    "Admin"
                 ],
-                "summary": "Delete a specific disease instance record",
+                "summary": "Break-glass review report",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "format": "int32",
-                        "description": "Patient Disease Instance ID",
-                        "name": "instanceID",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "Only grants at or after this time (RFC 3339)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only grants before this time (RFC 3339)",
+                        "name": "until",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content (Successful removal)",
+                    "200": {
+                        "description": "Break-glass events, newest first",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.BreakGlassEventResponse"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid ID format",
+                        "description": "Invalid time filter",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an administrator",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             }
         },
-        "/disease-instances/{instanceID}/symptoms": {
+        "/admin/training-cases": {
             "get": {
-                "description": "Get all symptoms linked to a specific patient_disease record (diagnosis instance).",
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Administrator export of settled diagnoses (confirmed, resolved or chronic) with their linked symptoms, for training the prediction model. Only patients with an active model_training consent are included, and no patient identifiers are returned.",
                 "consumes": [
                     "application/json"
                 ],
@@ -79,61 +114,58 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Patient Relationships"
-                ],
-                "summary": "List symptoms for a specific disease instance",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "format": "int32",
-                        "description": "Patient Disease Instance ID",
-                        "name": "instanceID",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Admin"
                 ],
+                "summary": "Model training export",
                 "responses": {
                     "200": {
-                        "description": "Successfully retrieved symptoms for the instance",
+                        "description": "Training cases, oldest diagnosis first",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/db.GetSymptomsForPatientDiseaseInstanceRow"
+                                "$ref": "#/definitions/server.TrainingCaseResponse"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Invalid Instance ID format",
+                    "403": {
+                        "description": "Caller is not an administrator",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Disease instance not found",
+                    "429": {
+                        "description": "Rate limit exceeded (see Retry-After)",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Create an association between an existing symptom and a specific patient disease instance.",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/disease-instances/{instanceID}": {
+            "get": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
                 ],
+                "description": "Retrieve one recorded diagnosis with its disease's name and code.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Patient Relationships"
                 ],
-                "summary": "Link a symptom to a disease instance",
+                "summary": "Get a disease instance",
                 "parameters": [
                     {
                         "type": "integer",
@@ -142,54 +174,57 @@ const docTemplate = `{
                         "name": "instanceID",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Symptom ID to link",
-                        "name": "link",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/server.LinkSymptomToDiseaseInstanceRequest"
-                        }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Symptom linked successfully",
+                    "200": {
+                        "description": "Successfully retrieved disease instance",
                         "schema": {
-                            "$ref": "#/definitions/db.PatientDiseaseSymptom"
+                            "$ref": "#/definitions/server.PatientDiseaseInstanceResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the disease instance"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Invalid Instance ID or request payload",
+                    "304": {
+                        "description": "Not Modified (If-None-Match lists the current version)",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Disease instance or Symptom not found (FK constraint)",
+                    "400": {
+                        "description": "Invalid ID format",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
-                    "409": {
-                        "description": "Symptom already linked to this instance (unique constraint)",
+                    "404": {
+                        "description": "Disease instance not found",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/disease-instances/{instanceID}/symptoms/{symptomID}": {
+            },
             "delete": {
-                "description": "Remove the association between a symptom and a specific patient disease instance.",
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Remove a specific patient_disease entry by its unique ID (patient_disease_id). This also removes associated symptom links via ON DELETE CASCADE.",
                 "consumes": [
                     "application/json"
                 ],
@@ -199,7 +234,7 @@ const docTemplate = `{
                 "tags": [
                     "Patient Relationships"
                 ],
-                "summary": "Unlink a symptom from a disease instance",
+                "summary": "Delete a specific disease instance record",
                 "parameters": [
                     {
                         "type": "integer",
@@ -210,11 +245,10 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "format": "int32",
-                        "description": "Symptom ID to unlink",
-                        "name": "symptomID",
-                        "in": "path",
+                        "type": "string",
+                        "description": "ETag of the version being removed",
+                        "name": "If-Match",
+                        "in": "header",
                         "required": true
                     }
                 ],
@@ -226,148 +260,217 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid Instance ID or Symptom ID format",
+                        "description": "Invalid ID format",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "404": {
+                        "description": "Disease instance not found",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
-                    }
-                }
-            }
-        },
-        "/diseases": {
-            "get": {
-                "description": "Get a list of all diseases",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Diseases"
-                ],
-                "summary": "List diseases",
-                "responses": {
-                    "200": {
-                        "description": "Successfully retrieved list of diseases",
+                    },
+                    "412": {
+                        "description": "The instance changed since the ETag in If-Match was read",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/server.DiseaseResponse"
-                            }
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match is missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             },
-            "post": {
-                "description": "Add a new disease record",
+            "patch": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Apply a JSON Merge Patch (RFC 7396) to a recorded diagnosis to correct its disease, diagnosis date or notes, or move its clinical_status; null clears the date or notes. The patient cannot be changed. Status changes must follow the lifecycle (suspected -\u003e confirmed|refuted, confirmed -\u003e resolved|chronic|refuted, chronic -\u003e resolved, resolved -\u003e confirmed), are timestamped in status_changed_at and recorded in the status history. If-Match must carry the ETag from a previous read.",
                 "consumes": [
-                    "application/json"
+                    "application/merge-patch+json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Diseases"
+                    "Patient Relationships"
                 ],
-                "summary": "Create a new disease",
+                "summary": "Partially update a disease instance",
                 "parameters": [
                     {
-                        "description": "Disease data to create",
-                        "name": "disease",
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient Disease Instance ID",
+                        "name": "instanceID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being changed",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Members to change",
+                        "name": "patch",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/server.CreateDiseaseRequest"
+                            "$ref": "#/definitions/server.UpdateDiseaseInstanceRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Disease created successfully",
+                    "200": {
+                        "description": "Disease instance updated successfully",
                         "schema": {
-                            "$ref": "#/definitions/server.DiseaseResponse"
+                            "$ref": "#/definitions/server.PatientDiseaseInstanceResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the updated disease instance"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid request payload or validation error",
+                        "description": "Malformed JSON, wrong type or unknown field",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Disease instance or disease not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Duplicate instance for this patient/disease/date (code disease_instance_exists)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "The instance changed since the ETag in If-Match was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Body is not application/merge-patch+json",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Patched instance failed validation, or the status change is not allowed (code invalid_status_transition)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match is missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             }
         },
-        "/diseases/{diseaseID}": {
+        "/disease-instances/{instanceID}/status-history": {
             "get": {
-                "description": "Retrieve details of a specific disease by its ID",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
                 ],
+                "description": "Every clinical status the diagnosis has had, oldest first, with who changed it and when. The first entry is the status it was recorded with.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Diseases"
+                    "Patient Relationships"
                 ],
-                "summary": "Get disease by ID",
+                "summary": "Status history of a disease instance",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int32",
-                        "description": "Disease ID",
-                        "name": "diseaseID",
+                        "description": "Patient Disease Instance ID",
+                        "name": "instanceID",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully retrieved disease",
+                        "description": "Status changes",
                         "schema": {
-                            "$ref": "#/definitions/server.DiseaseResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.DiseaseStatusChangeResponse"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid Disease ID format",
+                        "description": "Invalid ID format",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "404": {
-                        "description": "Disease not found",
+                        "description": "Disease instance not found",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update details for an existing disease",
+            }
+        },
+        "/disease-instances/{instanceID}/symptoms": {
+            "get": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Get all symptoms linked to a specific patient_disease record (diagnosis instance).",
                 "consumes": [
                     "application/json"
                 ],
@@ -375,57 +478,59 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Diseases"
+                    "Patient Relationships"
                 ],
-                "summary": "Update disease details",
+                "summary": "List symptoms for a specific disease instance",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int32",
-                        "description": "Disease ID",
-                        "name": "diseaseID",
+                        "description": "Patient Disease Instance ID",
+                        "name": "instanceID",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Disease data to update",
-                        "name": "disease",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/server.UpdateDiseaseRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Disease updated successfully",
+                        "description": "Successfully retrieved symptoms for the instance",
                         "schema": {
-                            "$ref": "#/definitions/server.DiseaseResponse"
-                        }
-                    },
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/db.GetSymptomsForPatientDiseaseInstanceRow"
+                            }
+                        }
+                    },
                     "400": {
-                        "description": "Invalid request payload or validation error",
+                        "description": "Invalid Instance ID format",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "404": {
-                        "description": "Disease not found",
+                        "description": "Disease instance not found",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             },
-            "delete": {
-                "description": "Delete a disease record by ID",
+            "post": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Create an association between an existing symptom and a specific patient disease instance.",
                 "consumes": [
                     "application/json"
                 ],
@@ -433,44 +538,85 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Diseases"
+                    "Patient Relationships"
                 ],
-                "summary": "Delete disease",
+                "summary": "Link a symptom to a disease instance",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int32",
-                        "description": "Disease ID",
-                        "name": "diseaseID",
+                        "description": "Patient Disease Instance ID",
+                        "name": "instanceID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Symptom ID to link",
+                        "name": "link",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.LinkSymptomToDiseaseInstanceRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key for this request; a retry with the same key replays the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content (Successful deletion)",
+                    "201": {
+                        "description": "Symptom linked successfully",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/db.PatientDiseaseSymptom"
                         }
                     },
                     "400": {
-                        "description": "Invalid Disease ID format",
+                        "description": "Invalid Instance ID or request payload",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Disease instance or Symptom not found (FK constraint)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Symptom already linked to this instance (unique constraint)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             }
         },
-        "/patient-symptoms/{id}": {
+        "/disease-instances/{instanceID}/symptoms/{symptomID}": {
             "delete": {
-                "description": "Remove a specific patient_symptoms entry by its unique ID.",
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Remove the association between a symptom and a specific patient disease instance.",
                 "consumes": [
                     "application/json"
                 ],
@@ -480,13 +626,21 @@ const docTemplate = `{
                 "tags": [
                     "Patient Relationships"
                 ],
-                "summary": "Delete a specific general symptom record",
+                "summary": "Unlink a symptom from a disease instance",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int32",
-                        "description": "Patient Symptom Record ID",
-                        "name": "id",
+                        "description": "Patient Disease Instance ID",
+                        "name": "instanceID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Symptom ID to unlink",
+                        "name": "symptomID",
                         "in": "path",
                         "required": true
                     }
@@ -499,23 +653,23 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid ID format",
+                        "description": "Invalid Instance ID or Symptom ID format",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             }
         },
-        "/patients": {
+        "/diseases": {
             "get": {
-                "description": "Get a paginated list of patients",
+                "description": "Get a page of the disease catalog, each with how many instances of it were recorded. Deprecated diseases are left out unless include_deprecated is set. q finds diseases whose name or description contains the text, whose code starts with it, or whose name holds a similar word (tolerating misspellings). Pages are cursor-based: pass page.next_cursor or page.prev_cursor back as cursor.",
                 "consumes": [
                     "application/json"
                 ],
@@ -523,45 +677,94 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Patients"
+                    "Diseases"
                 ],
-                "summary": "List patients",
+                "summary": "List diseases",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search text (at most 100 characters)",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "-name",
+                            "code",
+                            "-code",
+                            "usage",
+                            "-usage",
+                            "id",
+                            "-id"
+                        ],
+                        "type": "string",
+                        "default": "name",
+                        "description": "Sort field, prefixed with - for descending; -usage lists the most common first",
+                        "name": "sort",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "default": 10,
-                        "description": "Pagination limit",
+                        "description": "Page size (at most 100)",
                         "name": "limit",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "default": 0,
-                        "description": "Pagination offset",
-                        "name": "offset",
+                        "type": "string",
+                        "description": "Cursor from a previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include the total number of matching diseases",
+                        "name": "count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also list deprecated diseases",
+                        "name": "include_deprecated",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully retrieved list of patients",
+                        "description": "A page of diseases",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/server.PatientResponse"
+                            "$ref": "#/definitions/server.DiseasePage"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "first, prev and next page URLs (RFC 8288)"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Invalid query, sort, limit or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Add a new patient record to the database",
+                "description": "Add a new disease record",
                 "consumes": [
                     "application/json"
                 ],
@@ -569,45 +772,57 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Patients"
+                    "Diseases"
                 ],
-                "summary": "Create a new patient",
+                "summary": "Create a new disease",
                 "parameters": [
                     {
-                        "description": "Patient data to create",
-                        "name": "patient",
+                        "description": "Disease data to create",
+                        "name": "disease",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/server.CreatePatientRequest"
+                            "$ref": "#/definitions/server.CreateDiseaseRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Patient created successfully",
+                        "description": "Disease created successfully",
                         "schema": {
-                            "$ref": "#/definitions/server.PatientResponse"
+                            "$ref": "#/definitions/server.DiseaseResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version to send in If-Match when changing it"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid request payload or validation error",
+                        "description": "Malformed JSON, wrong type or unknown field",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
-                        "description": "Internal server error (e.g., DB error)",
+                        "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             }
         },
-        "/patients/{patientID}": {
+        "/diseases/{diseaseID}": {
             "get": {
-                "description": "Retrieve details of a specific patient by their ID",
+                "description": "Retrieve details of a specific disease by its ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -615,48 +830,60 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Patients"
+                    "Diseases"
                 ],
-                "summary": "Get patient by ID",
+                "summary": "Get disease by ID",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int32",
-                        "description": "Patient ID",
-                        "name": "patientID",
+                        "description": "Disease ID",
+                        "name": "diseaseID",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully retrieved patient",
+                        "description": "Successfully retrieved disease",
                         "schema": {
-                            "$ref": "#/definitions/server.PatientResponse"
+                            "$ref": "#/definitions/server.DiseaseResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version to send in If-Match when changing it"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified (If-None-Match lists the current version)",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Invalid Patient ID format",
+                        "description": "Invalid Disease ID format",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "404": {
-                        "description": "Patient not found",
+                        "description": "Disease not found",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             },
             "put": {
-                "description": "Update details for an existing patient",
+                "description": "Replace the details of an existing disease. If-Match must carry the ETag from a previous read.",
                 "consumes": [
                     "application/json"
                 ],
@@ -664,57 +891,88 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Patients"
+                    "Diseases"
                 ],
-                "summary": "Update patient details",
+                "summary": "Update disease details",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int32",
-                        "description": "Patient ID",
-                        "name": "patientID",
+                        "description": "Disease ID",
+                        "name": "diseaseID",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Patient data to update",
-                        "name": "patient",
+                        "type": "string",
+                        "description": "ETag of the version being replaced",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Disease data to update",
+                        "name": "disease",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/server.UpdatePatientRequest"
+                            "$ref": "#/definitions/server.UpdateDiseaseRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Patient updated successfully",
+                        "description": "Disease updated successfully",
                         "schema": {
-                            "$ref": "#/definitions/server.PatientResponse"
+                            "$ref": "#/definitions/server.DiseaseResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the updated disease"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid request payload or validation error",
+                        "description": "Malformed JSON, wrong type or unknown field",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "404": {
-                        "description": "Patient not found",
+                        "description": "Disease not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "The disease changed since the ETag in If-Match was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match is missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Delete a patient record by ID. Associated records in junction tables (patient_symptoms, patient_disease) should be removed via ON DELETE CASCADE.",
+                "description": "Delete a disease that no patient record uses, such as one created by mistake. While any disease instance (in any clinic) still records it, the delete is refused with their number in references; deprecate it instead.",
                 "consumes": [
                     "application/json"
                 ],
@@ -722,17 +980,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Patients"
+                    "Diseases"
                 ],
-                "summary": "Delete patient",
+                "summary": "Delete disease",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int32",
-                        "description": "Patient ID",
-                        "name": "patientID",
+                        "description": "Disease ID",
+                        "name": "diseaseID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being deleted",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -743,120 +1008,150 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid Patient ID format",
+                        "description": "Invalid Disease ID format",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Disease not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Patient records still use the disease; counted in references",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "The disease changed since the ETag in If-Match was read",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match is missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/patients/{patientID}/details": {
-            "get": {
-                "description": "Retrieve patient details along with aggregated lists of their general symptoms and distinct diseases recorded. Uses the GetPatientSummary query.",
+            },
+            "patch": {
+                "description": "Apply a JSON Merge Patch (RFC 7396) to a disease. disease_treatment is merged member by member; null clears a member, or the description or whole treatment. If-Match must carry the ETag from a previous read.",
                 "consumes": [
-                    "application/json"
+                    "application/merge-patch+json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Patients"
+                    "Diseases"
                 ],
-                "summary": "Get patient summary details",
+                "summary": "Partially update a disease",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int32",
-                        "description": "Patient ID",
-                        "name": "patientID",
+                        "description": "Disease ID",
+                        "name": "diseaseID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being changed",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Members to change",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.UpdateDiseaseRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully retrieved patient summary details",
+                        "description": "Disease updated successfully",
                         "schema": {
-                            "$ref": "#/definitions/server.PatientDetailsResponse"
+                            "$ref": "#/definitions/server.DiseaseResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the updated disease"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid Patient ID format",
+                        "description": "Malformed JSON, wrong type or unknown field",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "404": {
-                        "description": "Patient not found",
+                        "description": "Disease not found",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "412": {
+                        "description": "The disease changed since the ETag in If-Match was read",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
-                    }
-                }
-            }
-        },
-        "/patients/{patientID}/disease-instances": {
-            "get": {
-                "description": "Get all recorded disease instances (diagnoses) for a given patient ID.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Patient Relationships"
-                ],
-                "summary": "List disease instances for a specific patient",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "format": "int32",
-                        "description": "Patient ID",
-                        "name": "patientID",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Successfully retrieved disease instances",
+                    },
+                    "415": {
+                        "description": "Body is not application/merge-patch+json",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/server.PatientDiseaseInstanceResponse"
-                            }
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
-                    "400": {
-                        "description": "Invalid Patient ID format",
+                    "422": {
+                        "description": "Patched disease failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match is missing",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/diseases/{diseaseID}/deprecate": {
             "post": {
-                "description": "Create a record of a specific disease diagnosis for a patient, with optional date and notes.",
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Retire a disease from the catalog without touching patient records: it is left out of listings, but still returned by ID so history keeps resolving. replaced_by names the disease to use instead; diseases already replaced by this one follow it there. Deprecating again only changes the replacement. Admins only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -864,111 +1159,97 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Patient Relationships"
+                    "Diseases"
                 ],
-                "summary": "Record a disease instance for a patient",
+                "summary": "Deprecate a disease",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int32",
-                        "description": "Patient ID",
-                        "name": "patientID",
+                        "description": "Disease ID",
+                        "name": "diseaseID",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Disease ID, optional diagnosis date and notes",
-                        "name": "instance",
+                        "description": "Replacement, if any",
+                        "name": "deprecation",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/server.RecordPatientDiseaseInstanceRequest"
+                            "$ref": "#/definitions/server.DeprecateCatalogRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Disease instance recorded successfully",
+                    "200": {
+                        "description": "Disease deprecated",
                         "schema": {
-                            "$ref": "#/definitions/db.PatientDisease"
+                            "$ref": "#/definitions/server.DiseaseResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the deprecated disease"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid Patient ID or request payload",
+                        "description": "Invalid ID, malformed JSON, or a disease replacing itself",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Patient or Disease not found (FK constraint)",
+                    "401": {
+                        "description": "Missing or invalid credentials",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
-                    "409": {
-                        "description": "Duplicate instance for this patient/disease/date (unique constraint)",
+                    "403": {
+                        "description": "Caller is not an admin",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "404": {
+                        "description": "Disease or replacement not found",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
-                    }
-                }
-            }
-        },
-        "/patients/{patientID}/general-symptoms": {
-            "get": {
-                "description": "Get all symptoms associated with a given patient ID via the patient_symptoms table (not linked to specific diagnoses).",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Patient Relationships"
-                ],
-                "summary": "List general symptoms reported by a patient",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "format": "int32",
-                        "description": "Patient ID",
-                        "name": "patientID",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Successfully retrieved general symptoms for patient",
+                    },
+                    "409": {
+                        "description": "The replacement is deprecated itself",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/db.ListGeneralSymptomsForPatientRow"
-                            }
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
-                    "400": {
-                        "description": "Invalid Patient ID format",
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/diseases/{diseaseID}/merge": {
             "post": {
-                "description": "Associate an existing symptom with a patient (not tied to a specific diagnosis instance). Optionally include the date it was reported.",
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Absorb duplicate_id into the disease in the path, in one transaction. Every recorded diagnosis is moved to the survivor, across all clinics. A patient may already have the survivor diagnosed on the same date. The duplicate diagnosis then hands its symptom links, status history and notes to that one and is dropped. The duplicate's aliases move to the survivor, and its name becomes one. The duplicate is then deprecated with the survivor as its replacement, so it still resolves for history. A deprecated disease cannot survive a merge. With dry_run the merge is carried out and rolled back, so the counts are exactly what a real merge would change. Admins only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -976,147 +1257,191 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Patient Relationships"
+                    "Diseases"
                 ],
-                "summary": "Record a general symptom for a patient",
+                "summary": "Merge a duplicate disease",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int32",
-                        "description": "Patient ID",
-                        "name": "patientID",
+                        "description": "Surviving disease ID",
+                        "name": "diseaseID",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Symptom ID and optional reported date",
-                        "name": "symptom",
+                        "description": "Duplicate to absorb",
+                        "name": "merge",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/server.RecordPatientSymptomRequest"
+                            "$ref": "#/definitions/server.MergeCatalogRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key for this request; a retry with the same key replays the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Symptom recorded successfully",
+                    "200": {
+                        "description": "What the merge changed (or would change, with dry_run)",
                         "schema": {
-                            "$ref": "#/definitions/db.PatientSymptom"
+                            "$ref": "#/definitions/server.DiseaseMergeResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid Patient ID or request payload",
+                        "description": "Invalid ID, malformed JSON, or a disease merged into itself",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "404": {
-                        "description": "Patient or Symptom not found (FK constraint)",
+                        "description": "Either disease not found",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "409": {
-                        "description": "Relationship already exists for this date (unique constraint)",
+                        "description": "The surviving disease is deprecated",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             }
         },
-        "/predict": {
+        "/diseases/{diseaseID}/reinstate": {
             "post": {
-                "description": "Accepts feature data in JSON format, forwards it to the configured Flask ML service, and returns the prediction result. The 'features' field in the request body can be a single JSON object or an array of JSON objects.",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
                 ],
+                "description": "Undo a deprecation: the disease is listed again, and loses its replacement. Admins only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "predictions"
+                    "Diseases"
                 ],
-                "summary": "Proxy Prediction Request",
+                "summary": "Reinstate a deprecated disease",
                 "parameters": [
                     {
-                        "description": "Prediction Request Features (single object or array of objects)",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/server.PredictRequest"
-                        }
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Disease ID",
+                        "name": "diseaseID",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successful prediction response (forwarded from Flask)",
+                        "description": "Disease reinstated",
                         "schema": {
-                            "$ref": "#/definitions/server.PredictResponse"
+                            "$ref": "#/definitions/server.DiseaseResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the reinstated disease"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Bad Request - Invalid JSON format or missing 'features' key",
+                        "description": "Invalid Disease ID format",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error - Error during proxy processing or creating request",
+                    "401": {
+                        "description": "Missing or invalid credentials",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
-                    "502": {
-                        "description": "Bad Gateway - Failed to contact or get a valid response from the backend Flask service",
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Disease not found",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             }
         },
-        "/symptoms": {
+        "/livez": {
             "get": {
-                "description": "Get a list of all symptoms",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Reports that the process is up and serving. It checks no dependencies, so a failing database does not get the backend restarted.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Symptoms"
+                    "Health"
                 ],
-                "summary": "List symptoms",
+                "summary": "Liveness probe",
                 "responses": {
                     "200": {
-                        "description": "Successfully retrieved list of symptoms",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/server.SymptomResponse"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
+                        "description": "Process is alive",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.LivenessResponse"
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Add a new symptom record. Symptom name is required.",
+            }
+        },
+        "/patient-symptoms/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Remove a specific patient_symptoms entry by its unique ID. Limited to the patient's care team or an open break-glass grant, like the patient's other records. Records of a soft-deleted patient cannot be removed (404) until the patient is restored.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1124,51 +1449,83 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Symptoms"
+                    "Patient Relationships"
                 ],
-                "summary": "Create a new symptom",
+                "summary": "Delete a specific general symptom record",
                 "parameters": [
                     {
-                        "description": "Symptom data to create",
-                        "name": "symptom",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/server.CreateSymptomRequest"
-                        }
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient Symptom Record ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being removed (record_version in the patient's general symptoms)",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Symptom created successfully",
+                    "204": {
+                        "description": "No Content (Successful removal)",
                         "schema": {
-                            "$ref": "#/definitions/server.SymptomResponse"
+                            "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Invalid request payload or missing symptom name",
+                        "description": "Invalid ID format",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
-                    "409": {
-                        "description": "Symptom name already exists (unique constraint)",
+                    "403": {
+                        "description": "Not on the patient's care team and no open break-glass grant",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient symptom record not found, or its patient is deleted",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "The record changed since the ETag in If-Match was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match is missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             }
         },
-        "/symptoms/{symptomID}": {
+        "/patients": {
             "get": {
-                "description": "Retrieve details of a specific symptom by its ID",
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Get a page of patients. Pages are keyset-paginated: follow page.next_cursor / page.prev_cursor (or the Link header) instead of computing offsets, so pages stay stable while patients are added. Only patients on the caller's care team or under an open break-glass grant are listed. Filters combine with AND.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1176,48 +1533,187 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Symptoms"
+                    "Patients"
                 ],
-                "summary": "Get symptom by ID",
+                "summary": "List patients",
                 "parameters": [
                     {
                         "type": "integer",
-                        "format": "int32",
-                        "description": "Symptom ID",
-                        "name": "symptomID",
-                        "in": "path",
-                        "required": true
+                        "default": 10,
+                        "description": "Page size (at most 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from a previous page's next_cursor or prev_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "-name",
+                            "birthdate",
+                            "-birthdate",
+                            "created_at",
+                            "-created_at",
+                            "updated_at",
+                            "-updated_at",
+                            "id",
+                            "-id"
+                        ],
+                        "type": "string",
+                        "default": "name",
+                        "description": "Sort field, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also return the total number of matching patients in page.total",
+                        "name": "count",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "Male",
+                            "Female",
+                            "Other"
+                        ],
+                        "type": "string",
+                        "description": "Only this gender",
+                        "name": "gender",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only patients at least this old (years)",
+                        "name": "min_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only patients at most this old (years)",
+                        "name": "max_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only patients born on or after (YYYY-MM-DD)",
+                        "name": "birthdate_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only patients born on or before (YYYY-MM-DD)",
+                        "name": "birthdate_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only patients with a recorded instance of this disease",
+                        "name": "disease_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only patients who reported this general symptom",
+                        "name": "symptom_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only patients with a diagnosis (of disease_id, when given) dated on or after (YYYY-MM-DD)",
+                        "name": "diagnosed_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only patients with a diagnosis (of disease_id, when given) dated on or before (YYYY-MM-DD)",
+                        "name": "diagnosed_to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "data_processing",
+                            "model_training",
+                            "contact_sms",
+                            "contact_email"
+                        ],
+                        "type": "string",
+                        "description": "Only patients with an active consent for this scope",
+                        "name": "consent",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "data_processing",
+                            "model_training",
+                            "contact_sms",
+                            "contact_email"
+                        ],
+                        "type": "string",
+                        "description": "Only patients without an active consent for this scope",
+                        "name": "without_consent",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also list soft-deleted patients (admins only)",
+                        "name": "include_deleted",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully retrieved symptom",
+                        "description": "A page of patients",
                         "schema": {
-                            "$ref": "#/definitions/server.SymptomResponse"
+                            "$ref": "#/definitions/server.PatientPage"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "first, prev and next page URLs (RFC 8288)"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid Symptom ID format",
+                        "description": "Invalid filter, sort, limit or cursor (offset is no longer supported)",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Symptom not found",
+                    "403": {
+                        "description": "include_deleted asked for by a non-admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded (see Retry-After)",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
             },
-            "put": {
-                "description": "Update details for an existing symptom. Symptom name is required.",
+            "post": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Add a new patient record to the database. The caller joins the patient's care team. A patient of the clinic with the same register or phone number, or a similar name and the same birthdate, may be the same person: the request is then refused with code possible_duplicate and the candidates, each with a confidence score. Merge into an existing record instead, or repeat with allow_duplicate=true.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1225,63 +1721,148 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Symptoms"
+                    "Patients"
                 ],
-                "summary": "Update symptom details",
+                "summary": "Create a new patient",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "format": "int32",
-                        "description": "Symptom ID",
-                        "name": "symptomID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Symptom data to update",
-                        "name": "symptom",
+                        "description": "Patient data to create",
+                        "name": "patient",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/server.UpdateSymptomRequest"
+                            "$ref": "#/definitions/server.CreatePatientRequest"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Create the patient even if it may already be registered",
+                        "name": "allow_duplicate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key for this request; a retry with the same key replays the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Symptom updated successfully",
+                    "201": {
+                        "description": "Patient created successfully",
                         "schema": {
-                            "$ref": "#/definitions/server.SymptomResponse"
+                            "$ref": "#/definitions/server.PatientResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version to send in If-Match when changing it"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid request payload or missing symptom name",
+                        "description": "Malformed JSON, wrong type or unknown field",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Symptom not found",
+                    "409": {
+                        "description": "A patient with this email already exists (code patient_email_taken), or the patient may already be registered (code possible_duplicate, candidates in duplicates)",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
-                    "409": {
-                        "description": "Symptom name already exists (unique constraint)",
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (e.g., DB error)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/search": {
+            "get": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Find patients by part of their name, register number, phone or email, tolerating misspellings. Latin input also finds Cyrillic names and the other way round (\"Bat\" finds \"Бат\"). Results are ranked by similarity, best first, and say which fields matched. Only patients on the caller's care team or under an open break-glass grant are searched.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Search patients",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search text (2-100 characters)",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Maximum results (at most 50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Matching patients, best match first",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.PatientSearchResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Missing, too short or too long query, or invalid limit",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a symptom record by ID. Associated records in junction tables (patient_symptoms, patient_disease_symptom) should be removed via ON DELETE CASCADE.",
+            }
+        },
+        "/patients/{patientID}": {
+            "get": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Retrieve details of a specific patient by their ID. A soft-deleted patient is not found unless an admin sets include_deleted.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1289,235 +1870,3924 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Symptoms"
+                    "Patients"
                 ],
-                "summary": "Delete symptom",
+                "summary": "Get patient by ID",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int32",
-                        "description": "Symptom ID",
-                        "name": "symptomID",
+                        "description": "Patient ID",
+                        "name": "patientID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also find a soft-deleted patient (admins only)",
+                        "name": "include_deleted",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content (Successful deletion)",
+                    "200": {
+                        "description": "Successfully retrieved patient",
+                        "schema": {
+                            "$ref": "#/definitions/server.PatientResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version to send in If-Match when changing it"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified (If-None-Match lists the current version)",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Invalid Symptom ID format",
+                        "description": "Invalid Patient ID format",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "include_deleted asked for by a non-admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/server.HTTPError"
+                            "$ref": "#/definitions/server.Problem"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "db.GetSymptomsForPatientDiseaseInstanceRow": {
-            "type": "object",
-            "properties": {
-                "symptomDescription": {
-                    "$ref": "#/definitions/pgtype.Text"
-                },
-                "symptomID": {
-                    "type": "integer"
-                },
-                "symptomName": {
-                    "type": "string"
-                }
-            }
-        },
-        "db.ListGeneralSymptomsForPatientRow": {
-            "type": "object",
-            "properties": {
-                "createdAt": {
-                    "$ref": "#/definitions/pgtype.Timestamp"
-                },
-                "reportedDate": {
-                    "$ref": "#/definitions/pgtype.Date"
+            },
+            "put": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Replace the details of an existing patient. If-Match must carry the ETag from a previous read; a patient changed since then is not overwritten. A change to the name, register, phone number or birthdate is checked for duplicates as on create (override with allow_duplicate=true).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Update patient details",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being replaced",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Patient data to update",
+                        "name": "patient",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.UpdatePatientRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Save the change even if the patient may then duplicate another",
+                        "name": "allow_duplicate",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Patient updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/server.PatientResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the updated patient"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed JSON, wrong type or unknown field",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "A patient with this email already exists (code patient_email_taken), or the changed name, register, phone or birthdate match another patient (code possible_duplicate)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "The patient changed since the ETag in If-Match was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match is missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Soft-delete a patient: the record and its history are hidden from every listing and lookup, but kept until the purge job removes them after the retention period (PATIENT_RETENTION). Until then an admin can restore it. The caller is recorded as deleted_by.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Delete patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Why the patient is deleted (at most 1000 characters)",
+                        "name": "reason",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being deleted",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content (Successful deletion)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Patient ID format or reason too long",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "The patient changed since the ETag in If-Match was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match is missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Apply a JSON Merge Patch (RFC 7396) to a patient: members present in the body replace the stored values and null clears optional ones (a cleared birthdate or gender is derived from the register again). The merged patient is validated and checked for duplicates like a PUT. If-Match must carry the ETag from a previous read.",
+                "consumes": [
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Partially update a patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being changed",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Members to change",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.UpdatePatientRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Save the change even if the patient may then duplicate another",
+                        "name": "allow_duplicate",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Patient updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/server.PatientResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the updated patient"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed JSON, wrong type or unknown field",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "A patient with this email already exists (code patient_email_taken), or the changed name, register, phone or birthdate match another patient (code possible_duplicate)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "The patient changed since the ETag in If-Match was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Body is not application/merge-patch+json",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Patched patient failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match is missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/break-glass": {
+            "post": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Grant the caller time-limited emergency access to a patient outside their care team. A reason is mandatory. Every request made during the window is recorded and flagged with the X-Break-Glass-Grant response header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Break the glass on a patient record",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Justification",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.BreakGlassRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key for this request; a retry with the same key replays the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Emergency access granted",
+                        "schema": {
+                            "$ref": "#/definitions/server.BreakGlassGrantResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Patient ID or request payload",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/care-team": {
+            "get": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Get the users assigned to the patient's care team.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "List a patient's care team",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved care team",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.CareTeamMemberResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Patient ID format",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Add a user of the same clinic to the patient's care team. Requires the admin role and access through the care team; a break-glass grant does not allow it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Assign a user to a patient's care team",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User to assign",
+                        "name": "member",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.AddCareTeamMemberRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key for this request; a retry with the same key replays the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User assigned",
+                        "schema": {
+                            "$ref": "#/definitions/db.CareTeam"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Patient ID or request payload",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an administrator on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found in this clinic",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "User already on the care team",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/care-team/{userID}": {
+            "delete": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Requires the admin role and access through the care team; a break-glass grant does not allow it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Remove a user from a patient's care team",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content (Successful removal)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an administrator on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/consents": {
+            "get": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Get all active and revoked consents recorded for a patient, newest first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Consents"
+                ],
+                "summary": "List consents for a patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved consents",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.ConsentResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Patient ID format",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Record that the patient consented to a scope (data_processing, model_training, contact_sms, contact_email), The caller is recorded as the user who captured it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Consents"
+                ],
+                "summary": "Grant a consent",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Consent scope",
+                        "name": "consent",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.GrantConsentRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key for this request; a retry with the same key replays the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Consent recorded successfully",
+                        "schema": {
+                            "$ref": "#/definitions/server.ConsentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Patient ID or request payload",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found (FK constraint)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "An active consent for this scope already exists",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/consents/{consentID}/revoke": {
+            "post": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Mark an active consent as revoked. The record is kept so the consent history stays auditable, and the caller is recorded as the user who revoked it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Consents"
+                ],
+                "summary": "Revoke a consent",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Consent ID",
+                        "name": "consentID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key for this request; a retry with the same key replays the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Consent revoked successfully",
+                        "schema": {
+                            "$ref": "#/definitions/server.ConsentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "No active consent with this ID for the patient",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/details": {
+            "get": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Retrieve patient details along with aggregated lists of their general symptoms and distinct diseases recorded. Uses the GetPatientSummary query.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Get patient summary details",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved patient summary details",
+                        "schema": {
+                            "$ref": "#/definitions/server.PatientDetailsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Patient ID format",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/diagnoses": {
+            "post": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Create a disease instance and link all of its symptoms in one transaction, so a failure leaves nothing half-recorded. With record_general_symptoms the symptoms are also recorded as reported by the patient on the diagnosis date (or today); ones already recorded for that date are kept as they are. Returns the composed instance with its linked symptoms.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Relationships"
+                ],
+                "summary": "Record a diagnosis with its symptoms",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Disease, symptoms and optional date, notes and status",
+                        "name": "diagnosis",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.RecordDiagnosisRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key for this request; a retry with the same key replays the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Diagnosis recorded",
+                        "schema": {
+                            "$ref": "#/definitions/server.DiagnosisResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the disease instance"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Patient ID or request payload",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient, disease or a symptom not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Duplicate instance for this patient/disease/date (code disease_instance_exists)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/disease-instances": {
+            "get": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Get the recorded disease instances (diagnoses) for a given patient ID, optionally only those in the given clinical statuses.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Relationships"
+                ],
+                "summary": "List disease instances for a specific patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated clinical statuses to include, e.g. confirmed,chronic",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved disease instances",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.PatientDiseaseInstanceResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Patient ID format or unknown status",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Create a record of a specific disease diagnosis for a patient, with optional date and notes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Relationships"
+                ],
+                "summary": "Record a disease instance for a patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Disease ID, optional diagnosis date and notes",
+                        "name": "instance",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.RecordPatientDiseaseInstanceRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key for this request; a retry with the same key replays the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Disease instance recorded successfully",
+                        "schema": {
+                            "$ref": "#/definitions/db.PatientDisease"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Patient ID or request payload",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient or Disease not found (FK constraint)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Duplicate instance for this patient/disease/date (unique constraint)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/general-symptoms": {
+            "get": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Get all symptoms associated with a given patient ID via the patient_symptoms table (not linked to specific diagnoses).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Relationships"
+                ],
+                "summary": "List general symptoms reported by a patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved general symptoms for patient",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/db.ListGeneralSymptomsForPatientRow"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Patient ID format",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Associate an existing symptom with a patient (not tied to a specific diagnosis instance). Optionally include the date it was reported.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Relationships"
+                ],
+                "summary": "Record a general symptom for a patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Symptom ID and optional reported date",
+                        "name": "symptom",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.RecordPatientSymptomRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key for this request; a retry with the same key replays the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Symptom recorded successfully",
+                        "schema": {
+                            "$ref": "#/definitions/db.PatientSymptom"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version to send in If-Match when removing it"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Patient ID or request payload",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient or Symptom not found (FK constraint)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Relationship already exists for this date (unique constraint)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/merge": {
+            "post": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Absorb duplicate_id, a second record of the same person, into the patient in the path, in one transaction. Every reported symptom and disease instance of the duplicate moves to the survivor. A symptom the survivor reported on the same date is dropped instead. A diagnosis the survivor has of the same disease and date hands its symptom links, status history and notes to that one and is dropped. The duplicate's care team joins the survivor's, and the emptied duplicate is soft-deleted. Consents are not moved. An audit record of the merge, with its counts, is kept. The caller needs access to both patients. With dry_run the merge is carried out and rolled back, so the counts are exactly what a real merge would change.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Merge a duplicate patient record",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Surviving patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Duplicate to absorb",
+                        "name": "merge",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.MergePatientRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key for this request; a retry with the same key replays the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "What the merge changed (or would change, with dry_run)",
+                        "schema": {
+                            "$ref": "#/definitions/server.PatientMergeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID, malformed JSON, or a patient merged into itself",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller has no access to one of the patients",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Either patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Undo a soft delete before the purge job removes the patient. The record and its history become visible again. Admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Restore a deleted patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key for this request; a retry with the same key replays the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Patient restored",
+                        "schema": {
+                            "$ref": "#/definitions/server.PatientResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the restored patient"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Patient ID format",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "No deleted patient with this ID (never deleted, or already purged)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/predict": {
+            "post": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Accepts feature data in JSON format, forwards it to the configured Flask ML service, and returns the prediction result. The 'features' field in the request body can be a single JSON object or an array of JSON objects. When known_symptoms is an object keyed by symptom name, keys that are symptom aliases are renamed to the symptom's catalog name first. patient_id names the patient the prediction is for: the caller must be on their care team (or hold a break-glass grant) and the patient must have an active data_processing consent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "predictions"
+                ],
+                "summary": "Proxy Prediction Request",
+                "parameters": [
+                    {
+                        "description": "Prediction Request Features (single object or array of objects)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.PredictRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key for this request; a retry with the same key replays the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful prediction response (forwarded from Flask)",
+                        "schema": {
+                            "$ref": "#/definitions/server.PredictResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid JSON format or unknown field",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - not on the patient's care team, or the patient has no active data_processing consent",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - patient does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Payload Too Large - body exceeds MAX_BODY_BYTES",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity - known_symptoms or patient_id missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests - predict budget exhausted (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Error during proxy processing or creating request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway - Failed to contact or get a valid response from the backend Flask service",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Checks the database connection, that the schema is at the migration version this build expects, and that the model service is reachable and has loaded its model. Each check reports its duration; results are cached for a few seconds.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "All checks passed",
+                        "schema": {
+                            "$ref": "#/definitions/server.ReadinessResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "At least one check failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.ReadinessResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/symptoms": {
+            "get": {
+                "description": "Get a page of the symptom catalog, each with how often patients reported it. Deprecated symptoms are left out unless include_deprecated is set. q finds symptoms whose name or description contains the text, or whose name holds a similar word (tolerating misspellings). Pages are cursor-based: pass page.next_cursor or page.prev_cursor back as cursor.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Symptoms"
+                ],
+                "summary": "List symptoms",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search text (at most 100 characters)",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "-name",
+                            "usage",
+                            "-usage",
+                            "id",
+                            "-id"
+                        ],
+                        "type": "string",
+                        "default": "name",
+                        "description": "Sort field, prefixed with - for descending; -usage lists the most common first",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Page size (at most 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from a previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include the total number of matching symptoms",
+                        "name": "count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also list deprecated symptoms",
+                        "name": "include_deprecated",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "A page of symptoms",
+                        "schema": {
+                            "$ref": "#/definitions/server.SymptomPage"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "first, prev and next page URLs (RFC 8288)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query, sort, limit or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a new symptom record. Symptom name is required.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Symptoms"
+                ],
+                "summary": "Create a new symptom",
+                "parameters": [
+                    {
+                        "description": "Symptom data to create",
+                        "name": "symptom",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateSymptomRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Symptom created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/server.SymptomResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version to send in If-Match when changing it"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed JSON, wrong type or unknown field",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Symptom name already exists (unique constraint)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/symptoms/{symptomID}": {
+            "get": {
+                "description": "Retrieve details of a specific symptom by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Symptoms"
+                ],
+                "summary": "Get symptom by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Symptom ID",
+                        "name": "symptomID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved symptom",
+                        "schema": {
+                            "$ref": "#/definitions/server.SymptomResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version to send in If-Match when changing it"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified (If-None-Match lists the current version)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Symptom ID format",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Symptom not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace the details of an existing symptom. Symptom name is required. If-Match must carry the ETag from a previous read.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Symptoms"
+                ],
+                "summary": "Update symptom details",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Symptom ID",
+                        "name": "symptomID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being replaced",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Symptom data to update",
+                        "name": "symptom",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.UpdateSymptomRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Symptom updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/server.SymptomResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the updated symptom"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed JSON, wrong type or unknown field",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Symptom not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Symptom name already exists (unique constraint)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "The symptom changed since the ETag in If-Match was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match is missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a symptom that no patient record uses, such as one created by mistake. While any reported symptom or diagnosis link (in any clinic) still uses it, the delete is refused with the number of each in references; deprecate it instead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Symptoms"
+                ],
+                "summary": "Delete symptom",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Symptom ID",
+                        "name": "symptomID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being deleted",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content (Successful deletion)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Symptom ID format",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Symptom not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Patient records still use the symptom; counted in references",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "The symptom changed since the ETag in If-Match was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match is missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Apply a JSON Merge Patch (RFC 7396) to a symptom; null clears the description. If-Match must carry the ETag from a previous read.",
+                "consumes": [
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Symptoms"
+                ],
+                "summary": "Partially update a symptom",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Symptom ID",
+                        "name": "symptomID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being changed",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Members to change",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.UpdateSymptomRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Symptom updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/server.SymptomResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the updated symptom"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed JSON, wrong type or unknown field",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Symptom not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Symptom name already exists (unique constraint)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "The symptom changed since the ETag in If-Match was read",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Body is not application/merge-patch+json",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Patched symptom failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match is missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/symptoms/{symptomID}/aliases": {
+            "get": {
+                "description": "Get the other names that resolve to a symptom, alphabetically.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Symptoms"
+                ],
+                "summary": "List aliases of a symptom",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Symptom ID",
+                        "name": "symptomID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved aliases",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.SymptomAliasResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Symptom ID format",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Record another name for a symptom, such as a spelling variant, synonym or translation. Only admins can change aliases. Catalog search and lookups by name then resolve it to this symptom. An alias can name only one symptom, and cannot be another symptom's name.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Symptoms"
+                ],
+                "summary": "Add an alias to a symptom",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Symptom ID",
+                        "name": "symptomID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Alias to add",
+                        "name": "alias",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.SymptomAliasRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key for this request; a retry with the same key replays the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Alias created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/server.SymptomAliasResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Symptom ID or malformed JSON",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Symptom not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "The alias already names a symptom",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/symptoms/{symptomID}/aliases/{aliasID}": {
+            "put": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Replace an alias's text, language and source.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Symptoms"
+                ],
+                "summary": "Update a symptom alias",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Symptom ID",
+                        "name": "symptomID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Alias ID",
+                        "name": "aliasID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New alias data",
+                        "name": "alias",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.SymptomAliasRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Alias updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/server.SymptomAliasResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID or malformed JSON",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "No alias with this ID for the symptom",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "The alias already names a symptom",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Remove an alias; the name no longer resolves to the symptom.",
+                "tags": [
+                    "Symptoms"
+                ],
+                "summary": "Delete a symptom alias",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Symptom ID",
+                        "name": "symptomID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Alias ID",
+                        "name": "aliasID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content (Successful deletion)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "No alias with this ID for the symptom",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/symptoms/{symptomID}/deprecate": {
+            "post": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Retire a symptom from the catalog without touching patient records: it is left out of listings and name lookups, but still returned by ID so history keeps resolving. replaced_by names the symptom to use instead; symptoms already replaced by this one follow it there. Deprecating again only changes the replacement. Admins only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Symptoms"
+                ],
+                "summary": "Deprecate a symptom",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Symptom ID",
+                        "name": "symptomID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Replacement, if any",
+                        "name": "deprecation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.DeprecateCatalogRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Symptom deprecated",
+                        "schema": {
+                            "$ref": "#/definitions/server.SymptomResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the deprecated symptom"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID, malformed JSON, or a symptom replacing itself",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Symptom or replacement not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "The replacement is deprecated itself",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/symptoms/{symptomID}/merge": {
+            "post": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Absorb duplicate_id into the symptom in the path, in one transaction. Every reported symptom and diagnosis link is moved to the survivor, across all clinics. A reference the survivor already has (same patient and date, or same diagnosis) is dropped instead. The duplicate's aliases move to the survivor, and its name becomes one. The duplicate is then deprecated with the survivor as its replacement, so it still resolves for history. A deprecated symptom cannot survive a merge. With dry_run the merge is carried out and rolled back, so the counts are exactly what a real merge would change. Admins only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Symptoms"
+                ],
+                "summary": "Merge a duplicate symptom",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Surviving symptom ID",
+                        "name": "symptomID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Duplicate to absorb",
+                        "name": "merge",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.MergeCatalogRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key for this request; a retry with the same key replays the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "What the merge changed (or would change, with dry_run)",
+                        "schema": {
+                            "$ref": "#/definitions/server.SymptomMergeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID, malformed JSON, or a symptom merged into itself",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Either symptom not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "The surviving symptom is deprecated",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Body failed validation; every violation is listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/symptoms/{symptomID}/reinstate": {
+            "post": {
+                "security": [
+                    {
+                        "UserHeader": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Undo a deprecation: the symptom is listed and looked up by name again, and loses its replacement. Admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Symptoms"
+                ],
+                "summary": "Reinstate a deprecated symptom",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "Symptom ID",
+                        "name": "symptomID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Symptom reinstated",
+                        "schema": {
+                            "$ref": "#/definitions/server.SymptomResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the reinstated symptom"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Symptom ID format",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Symptom not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "db.CareTeam": {
+            "type": "object",
+            "properties": {
+                "assignedBy": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "patientID": {
+                    "type": "integer"
+                },
+                "userID": {
+                    "type": "integer"
+                }
+            }
+        },
+        "db.GetSymptomsForPatientDiseaseInstanceRow": {
+            "type": "object",
+            "properties": {
+                "symptomDescription": {
+                    "$ref": "#/definitions/pgtype.Text"
+                },
+                "symptomID": {
+                    "type": "integer"
+                },
+                "symptomName": {
+                    "type": "string"
+                }
+            }
+        },
+        "db.ListGeneralSymptomsForPatientRow": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "deprecatedAt": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "patientSymptomID": {
+                    "type": "integer"
+                },
+                "recordVersion": {
+                    "type": "integer"
+                },
+                "replacedBy": {
+                    "$ref": "#/definitions/pgtype.Int4"
+                },
+                "reportedDate": {
+                    "$ref": "#/definitions/pgtype.Date"
+                },
+                "symptomDescription": {
+                    "$ref": "#/definitions/pgtype.Text"
+                },
+                "symptomID": {
+                    "type": "integer"
+                },
+                "symptomName": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "db.PatientDisease": {
+            "type": "object",
+            "properties": {
+                "clinicID": {
+                    "type": "integer"
+                },
+                "clinicalStatus": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "diagnosisDate": {
+                    "$ref": "#/definitions/pgtype.Date"
+                },
+                "diseaseID": {
+                    "type": "integer"
+                },
+                "notes": {
+                    "$ref": "#/definitions/pgtype.Text"
+                },
+                "patientDiseaseID": {
+                    "type": "integer"
+                },
+                "patientID": {
+                    "type": "integer"
+                },
+                "statusChangedAt": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "updatedAt": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "db.PatientDiseaseSymptom": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "patientDiseaseID": {
+                    "type": "integer"
+                },
+                "symptomID": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                }
+            }
+        },
+        "db.PatientSymptom": {
+            "type": "object",
+            "properties": {
+                "clinicID": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "patientID": {
+                    "type": "integer"
+                },
+                "reportedDate": {
+                    "$ref": "#/definitions/pgtype.Date"
+                },
+                "symptomID": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "pgtype.Date": {
+            "type": "object",
+            "properties": {
+                "infinityModifier": {
+                    "$ref": "#/definitions/pgtype.InfinityModifier"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "valid": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "pgtype.InfinityModifier": {
+            "type": "integer",
+            "enum": [
+                1,
+                0,
+                -1
+            ],
+            "x-enum-varnames": [
+                "Infinity",
+                "Finite",
+                "NegativeInfinity"
+            ]
+        },
+        "pgtype.Int4": {
+            "type": "object",
+            "properties": {
+                "int32": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "pgtype.Text": {
+            "type": "object",
+            "properties": {
+                "string": {
+                    "type": "string"
+                },
+                "valid": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "pgtype.Timestamp": {
+            "type": "object",
+            "properties": {
+                "infinityModifier": {
+                    "$ref": "#/definitions/pgtype.InfinityModifier"
+                },
+                "time": {
+                    "description": "Time zone will be ignored when encoding to PostgreSQL.",
+                    "type": "string"
+                },
+                "valid": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "server.AddCareTeamMemberRequest": {
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.BreakGlassAccessResponse": {
+            "type": "object",
+            "properties": {
+                "accessed_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.BreakGlassEventResponse": {
+            "type": "object",
+            "properties": {
+                "access_count": {
+                    "type": "integer"
+                },
+                "accesses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.BreakGlassAccessResponse"
+                    }
+                },
+                "expires_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "grant_id": {
+                    "type": "integer"
+                },
+                "granted_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "last_accessed_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "patient_firstname": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "integer"
+                },
+                "patient_lastname": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.BreakGlassGrantResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "grant_id": {
+                    "type": "integer"
+                },
+                "granted_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "patient_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.BreakGlassRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "description": "Mandatory justification",
+                    "type": "string",
+                    "maxLength": 1000,
+                    "example": "Unconscious patient admitted to ER, on-call physician"
+                }
+            }
+        },
+        "server.CareTeamMemberResponse": {
+            "type": "object",
+            "properties": {
+                "assigned_by": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.ConsentResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "captured_by": {
+                    "description": "User who recorded the consent; null for consents recorded before users were tracked",
+                    "type": "integer"
+                },
+                "consent_id": {
+                    "type": "integer"
+                },
+                "granted_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "patient_id": {
+                    "type": "integer"
+                },
+                "revoked_at": {
+                    "description": "null while the consent is active",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/pgtype.Timestamp"
+                        }
+                    ]
+                },
+                "revoked_by": {
+                    "description": "User who recorded the revocation",
+                    "type": "integer"
+                },
+                "scope": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.CreateDiseaseRequest": {
+            "type": "object",
+            "required": [
+                "disease_code",
+                "disease_name"
+            ],
+            "properties": {
+                "disease_code": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "disease_description": {
+                    "type": "string",
+                    "maxLength": 10000
+                },
+                "disease_name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "disease_treatment": {
+                    "description": "Accept raw JSON",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "server.CreatePatientRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "firstname",
+                "lastname",
+                "phonenumber",
+                "register"
+            ],
+            "properties": {
+                "address": {
+                    "description": "Use pointer for optional field",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "birthdate": {
+                    "description": "YYYY-MM-DD; derived from the register when omitted",
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "firstname": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "gender": {
+                    "description": "Derived from the register when omitted",
+                    "type": "string",
+                    "enum": [
+                        "Male",
+                        "Female",
+                        "Other"
+                    ]
+                },
+                "lastname": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "phonenumber": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "register": {
+                    "description": "Mongolian civil register number",
+                    "type": "string",
+                    "example": "УБ99032215"
+                }
+            }
+        },
+        "server.CreateSymptomRequest": {
+            "type": "object",
+            "required": [
+                "symptom_name"
+            ],
+            "properties": {
+                "symptom_description": {
+                    "type": "string",
+                    "maxLength": 10000
+                },
+                "symptom_name": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "server.DeprecateCatalogRequest": {
+            "type": "object",
+            "properties": {
+                "replaced_by": {
+                    "description": "Entry to use instead; must not be deprecated itself",
+                    "type": "integer",
+                    "example": 12
+                }
+            }
+        },
+        "server.DiagnosisResponse": {
+            "type": "object",
+            "properties": {
+                "clinical_status": {
+                    "description": "suspected, confirmed, refuted, resolved or chronic",
+                    "type": "string",
+                    "example": "confirmed"
+                },
+                "created_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "diagnosis_date": {
+                    "$ref": "#/definitions/pgtype.Date"
+                },
+                "disease_code": {
+                    "description": "Added from join",
+                    "type": "string"
+                },
+                "disease_id": {
+                    "type": "integer"
+                },
+                "disease_name": {
+                    "description": "Added from join",
+                    "type": "string"
+                },
+                "notes": {
+                    "description": "Keep as pointer",
+                    "type": "string"
+                },
+                "patient_disease_id": {
+                    "type": "integer"
+                },
+                "patient_id": {
+                    "description": "Added for context",
+                    "type": "integer"
+                },
+                "status_changed_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "symptoms": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.LinkedSymptomResponse"
+                    }
+                },
+                "updated_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "version": {
+                    "description": "Also sent as the ETag; echo it in If-Match",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "server.DiseaseListItem": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Or format as string",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/pgtype.Timestamp"
+                        }
+                    ]
+                },
+                "deprecated_at": {
+                    "description": "null unless retired from pickers",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/pgtype.Timestamp"
+                        }
+                    ]
+                },
+                "disease_code": {
+                    "type": "string"
+                },
+                "disease_description": {
+                    "type": "string"
+                },
+                "disease_id": {
+                    "type": "integer"
+                },
+                "disease_name": {
+                    "type": "string"
+                },
+                "disease_treatment": {
+                    "description": "Send raw JSON back",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "replaced_by": {
+                    "description": "Disease to use instead, if any",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "description": "Or format as string",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/pgtype.Timestamp"
+                        }
+                    ]
+                },
+                "usage_count": {
+                    "description": "Instances recorded of it, across all clinics",
+                    "type": "integer",
+                    "example": 17
+                },
+                "version": {
+                    "description": "Also sent as the ETag; echo it in If-Match",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "server.DiseaseMergeResponse": {
+            "type": "object",
+            "properties": {
+                "alias_added": {
+                    "description": "The duplicate's name was kept as an alias",
+                    "type": "boolean"
+                },
+                "aliases_moved": {
+                    "description": "The duplicate's aliases, now the survivor's",
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "duplicate_id": {
+                    "type": "integer",
+                    "example": 41
+                },
+                "instance_symptoms_moved": {
+                    "description": "Symptom links the folded diagnoses handed over",
+                    "type": "integer"
+                },
+                "instances_collapsed": {
+                    "description": "Folded into the patient's diagnosis of the survivor on the same date",
+                    "type": "integer"
+                },
+                "instances_repointed": {
+                    "description": "Diagnoses moved to the survivor",
+                    "type": "integer"
+                },
+                "notes_merged": {
+                    "description": "Survivor diagnoses the folded notes were appended to",
+                    "type": "integer"
+                },
+                "status_history_moved": {
+                    "description": "Status history rows the folded diagnoses handed over",
+                    "type": "integer"
+                },
+                "survivor_id": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "server.DiseasePage": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.DiseaseListItem"
+                    }
+                },
+                "page": {
+                    "$ref": "#/definitions/server.PageInfo"
+                }
+            }
+        },
+        "server.DiseaseResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Or format as string",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/pgtype.Timestamp"
+                        }
+                    ]
+                },
+                "deprecated_at": {
+                    "description": "null unless retired from pickers",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/pgtype.Timestamp"
+                        }
+                    ]
+                },
+                "disease_code": {
+                    "type": "string"
+                },
+                "disease_description": {
+                    "type": "string"
+                },
+                "disease_id": {
+                    "type": "integer"
+                },
+                "disease_name": {
+                    "type": "string"
+                },
+                "disease_treatment": {
+                    "description": "Send raw JSON back",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "replaced_by": {
+                    "description": "Disease to use instead, if any",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "description": "Or format as string",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/pgtype.Timestamp"
+                        }
+                    ]
+                },
+                "version": {
+                    "description": "Also sent as the ETag; echo it in If-Match",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "server.DiseaseStatusChangeResponse": {
+            "type": "object",
+            "properties": {
+                "changed_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "changed_by": {
+                    "type": "string",
+                    "example": "dr.bat"
+                },
+                "from_status": {
+                    "description": "null for the status the instance was recorded with",
+                    "type": "string",
+                    "example": "suspected"
+                },
+                "to_status": {
+                    "type": "string",
+                    "example": "confirmed"
+                }
+            }
+        },
+        "server.DuplicateCandidate": {
+            "type": "object",
+            "properties": {
+                "birthdate": {
+                    "description": "YYYY-MM-DD or null",
+                    "type": "string"
+                },
+                "confidence": {
+                    "description": "0 to 1 that this is the same person",
+                    "type": "number",
+                    "example": 0.98
+                },
+                "firstname": {
+                    "type": "string"
+                },
+                "lastname": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "integer",
+                    "example": 87
+                },
+                "phonenumber": {
+                    "type": "string"
+                },
+                "reasons": {
+                    "description": "Signals that matched: register, phonenumber, name_birthdate",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "register",
+                        "phonenumber"
+                    ]
+                },
+                "register": {
+                    "type": "string",
+                    "example": "УБ99032215"
+                }
+            }
+        },
+        "server.FieldViolation": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "description": "JSON path of the offending value",
+                    "type": "string",
+                    "example": "email"
+                },
+                "message": {
+                    "description": "Human-readable explanation",
+                    "type": "string",
+                    "example": "must be a valid email address"
+                },
+                "rule": {
+                    "description": "Rule that failed: required, max, email, oneof, ...",
+                    "type": "string",
+                    "example": "email"
+                }
+            }
+        },
+        "server.GrantConsentRequest": {
+            "type": "object",
+            "required": [
+                "scope"
+            ],
+            "properties": {
+                "scope": {
+                    "description": "data_processing, model_training, contact_sms or contact_email",
+                    "type": "string",
+                    "enum": [
+                        "data_processing",
+                        "model_training",
+                        "contact_sms",
+                        "contact_email"
+                    ],
+                    "example": "data_processing"
+                }
+            }
+        },
+        "server.HealthCheck": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "description": "Check-specific facts, e.g. the migration version"
+                },
+                "duration_ms": {
+                    "type": "number",
+                    "example": 1.7
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "ok or fail",
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "server.LinkSymptomToDiseaseInstanceRequest": {
+            "type": "object",
+            "required": [
+                "symptom_id"
+            ],
+            "properties": {
+                "symptom_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.LinkedSymptomResponse": {
+            "type": "object",
+            "properties": {
+                "symptom_description": {
+                    "type": "string"
+                },
+                "symptom_id": {
+                    "type": "integer"
+                },
+                "symptom_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.LivenessResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "server.MergeCatalogRequest": {
+            "type": "object",
+            "required": [
+                "duplicate_id"
+            ],
+            "properties": {
+                "dry_run": {
+                    "description": "Report what the merge would change, changing nothing",
+                    "type": "boolean"
+                },
+                "duplicate_id": {
+                    "description": "Entry absorbed into the one in the path",
+                    "type": "integer",
+                    "example": 57
+                }
+            }
+        },
+        "server.MergePatientRequest": {
+            "type": "object",
+            "required": [
+                "duplicate_id"
+            ],
+            "properties": {
+                "dry_run": {
+                    "description": "Report what the merge would change, changing nothing",
+                    "type": "boolean"
+                },
+                "duplicate_id": {
+                    "description": "Record absorbed into the patient in the path",
+                    "type": "integer",
+                    "example": 88
+                },
+                "reason": {
+                    "description": "Kept in the merge's audit record",
+                    "type": "string",
+                    "maxLength": 1000
+                }
+            }
+        },
+        "server.PageInfo": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "next_cursor": {
+                    "description": "null on the last page",
+                    "type": "string"
+                },
+                "prev_cursor": {
+                    "description": "null on the first page",
+                    "type": "string"
+                },
+                "sort": {
+                    "description": "The sort in effect, \"-\" prefixed when descending",
+                    "type": "string",
+                    "example": "name"
+                },
+                "total": {
+                    "description": "Only with count=true",
+                    "type": "integer",
+                    "example": 137
+                }
+            }
+        },
+        "server.PatientDetailsResponse": {
+            "type": "object",
+            "properties": {
+                "distinct_diseases_list": {
+                    "description": "Parsed list from patient_disease",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "email": {
+                    "type": "string"
                 },
-                "symptomDescription": {
-                    "$ref": "#/definitions/pgtype.Text"
+                "firstname": {
+                    "type": "string"
                 },
-                "symptomID": {
-                    "type": "integer"
+                "general_symptoms_list": {
+                    "description": "Parsed list from patient_symptoms",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "symptomName": {
+                "lastname": {
                     "type": "string"
                 },
-                "updatedAt": {
-                    "$ref": "#/definitions/pgtype.Timestamp"
+                "patient_id": {
+                    "type": "integer"
                 }
             }
         },
-        "db.PatientDisease": {
+        "server.PatientDiseaseInstanceResponse": {
             "type": "object",
             "properties": {
-                "createdAt": {
+                "clinical_status": {
+                    "description": "suspected, confirmed, refuted, resolved or chronic",
+                    "type": "string",
+                    "example": "confirmed"
+                },
+                "created_at": {
                     "$ref": "#/definitions/pgtype.Timestamp"
                 },
-                "diagnosisDate": {
+                "diagnosis_date": {
                     "$ref": "#/definitions/pgtype.Date"
                 },
-                "diseaseID": {
+                "disease_code": {
+                    "description": "Added from join",
+                    "type": "string"
+                },
+                "disease_id": {
                     "type": "integer"
                 },
+                "disease_name": {
+                    "description": "Added from join",
+                    "type": "string"
+                },
                 "notes": {
-                    "$ref": "#/definitions/pgtype.Text"
+                    "description": "Keep as pointer",
+                    "type": "string"
                 },
-                "patientDiseaseID": {
+                "patient_disease_id": {
                     "type": "integer"
                 },
-                "patientID": {
+                "patient_id": {
+                    "description": "Added for context",
                     "type": "integer"
                 },
-                "updatedAt": {
+                "status_changed_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "updated_at": {
                     "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "version": {
+                    "description": "Also sent as the ETag; echo it in If-Match",
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "db.PatientDiseaseSymptom": {
+        "server.PatientMergeResponse": {
             "type": "object",
             "properties": {
-                "createdAt": {
-                    "$ref": "#/definitions/pgtype.Timestamp"
+                "care_team_added": {
+                    "description": "Members of the duplicate's care team who joined the survivor's",
+                    "type": "integer"
                 },
-                "id": {
+                "diagnoses_collapsed": {
+                    "description": "Folded into the survivor's diagnosis of the same disease and date",
                     "type": "integer"
                 },
-                "patientDiseaseID": {
+                "diagnoses_moved": {
+                    "description": "Disease instances moved to the survivor",
                     "type": "integer"
                 },
-                "symptomID": {
+                "diagnosis_symptoms_moved": {
+                    "description": "Symptom links the folded diagnoses handed over",
                     "type": "integer"
                 },
-                "updatedAt": {
-                    "$ref": "#/definitions/pgtype.Timestamp"
-                }
-            }
-        },
-        "db.PatientSymptom": {
-            "type": "object",
-            "properties": {
-                "createdAt": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "duplicate_id": {
+                    "type": "integer",
+                    "example": 88
+                },
+                "merge_id": {
+                    "description": "Audit record of the merge; absent on a dry run",
+                    "type": "integer",
+                    "example": 5
+                },
+                "merged_at": {
                     "$ref": "#/definitions/pgtype.Timestamp"
                 },
-                "id": {
+                "notes_merged": {
+                    "description": "Survivor diagnoses the folded notes were appended to",
                     "type": "integer"
                 },
-                "patientID": {
+                "status_history_moved": {
+                    "description": "Status history rows the folded diagnoses handed over",
                     "type": "integer"
                 },
-                "reportedDate": {
-                    "$ref": "#/definitions/pgtype.Date"
+                "survivor_id": {
+                    "type": "integer",
+                    "example": 87
                 },
-                "symptomID": {
+                "symptoms_collapsed": {
+                    "description": "Dropped: the survivor reported the same symptom that day",
                     "type": "integer"
                 },
-                "updatedAt": {
-                    "$ref": "#/definitions/pgtype.Timestamp"
+                "symptoms_moved": {
+                    "description": "Reported symptoms moved to the survivor",
+                    "type": "integer"
                 }
             }
         },
-        "pgtype.Date": {
+        "server.PatientPage": {
             "type": "object",
             "properties": {
-                "infinityModifier": {
-                    "$ref": "#/definitions/pgtype.InfinityModifier"
-                },
-                "time": {
-                    "type": "string"
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.PatientResponse"
+                    }
                 },
-                "valid": {
-                    "type": "boolean"
+                "page": {
+                    "$ref": "#/definitions/server.PageInfo"
                 }
             }
         },
-        "pgtype.InfinityModifier": {
-            "type": "integer",
-            "enum": [
-                1,
-                0,
-                -1
-            ],
-            "x-enum-varnames": [
-                "Infinity",
-                "Finite",
-                "NegativeInfinity"
-            ]
-        },
-        "pgtype.Text": {
+        "server.PatientResponse": {
             "type": "object",
             "properties": {
-                "string": {
+                "address": {
+                    "description": "string or null",
                     "type": "string"
                 },
-                "valid": {
-                    "type": "boolean"
-                }
-            }
-        },
-        "pgtype.Timestamp": {
-            "type": "object",
-            "properties": {
-                "infinityModifier": {
-                    "$ref": "#/definitions/pgtype.InfinityModifier"
+                "age": {
+                    "description": "Computed from birthdate",
+                    "type": "integer"
                 },
-                "time": {
-                    "description": "Time zone will be ignored when encoding to PostgreSQL.",
+                "birthdate": {
+                    "description": "YYYY-MM-DD or null",
                     "type": "string"
                 },
-                "valid": {
-                    "type": "boolean"
-                }
-            }
-        },
-        "server.CreateDiseaseRequest": {
-            "type": "object",
-            "properties": {
-                "disease_code": {
+                "deleted_at": {
+                    "description": "Set only on soft-deleted patients, which admins see with include_deleted",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/pgtype.Timestamp"
+                        }
+                    ]
+                },
+                "deleted_by": {
+                    "type": "integer"
+                },
+                "deletion_reason": {
                     "type": "string"
                 },
-                "disease_description": {
+                "email": {
                     "type": "string"
                 },
-                "disease_name": {
+                "firstname": {
                     "type": "string"
                 },
-                "disease_treatment": {
-                    "description": "Accept raw JSON",
+                "gender": {
+                    "type": "string"
+                },
+                "lastname": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "integer"
+                },
+                "phonenumber": {
+                    "type": "string"
+                },
+                "register": {
+                    "type": "string"
+                },
+                "register_issues": {
+                    "description": "Disagreements between the register and the record: invalid_register, birthdate_mismatch, gender_mismatch",
                     "type": "array",
                     "items": {
-                        "type": "integer"
+                        "type": "string"
                     }
+                },
+                "updated_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "version": {
+                    "description": "Also sent as the ETag; echo it in If-Match",
+                    "type": "integer",
+                    "example": 3
                 }
             }
         },
-        "server.CreatePatientRequest": {
+        "server.PatientSearchResult": {
             "type": "object",
             "properties": {
                 "address": {
-                    "description": "Use pointer for optional field",
+                    "description": "string or null",
                     "type": "string"
                 },
                 "age": {
+                    "description": "Computed from birthdate",
                     "type": "integer"
                 },
                 "birthdate": {
-                    "description": "Expect YYYY-MM-DD string",
+                    "description": "YYYY-MM-DD or null",
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Set only on soft-deleted patients, which admins see with include_deleted",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/pgtype.Timestamp"
+                        }
+                    ]
+                },
+                "deleted_by": {
+                    "type": "integer"
+                },
+                "deletion_reason": {
                     "type": "string"
                 },
                 "email": {
@@ -1529,269 +5799,514 @@ const docTemplate = `{
                 "gender": {
                     "type": "string"
                 },
+                "highlights": {
+                    "description": "Matching fields (firstname, lastname, register, phonenumber, email) with\nthe matching parts wrapped in \u003cmark\u003e; the rest of the value is HTML-escaped",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "lastname": {
                     "type": "string"
                 },
+                "patient_id": {
+                    "type": "integer"
+                },
                 "phonenumber": {
                     "type": "string"
                 },
                 "register": {
                     "type": "string"
+                },
+                "register_issues": {
+                    "description": "Disagreements between the register and the record: invalid_register, birthdate_mismatch, gender_mismatch",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "score": {
+                    "description": "Higher is a better match",
+                    "type": "number",
+                    "example": 1.35
+                },
+                "updated_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "version": {
+                    "description": "Also sent as the ETag; echo it in If-Match",
+                    "type": "integer",
+                    "example": 3
                 }
             }
         },
-        "server.CreateSymptomRequest": {
+        "server.PredictRequest": {
+            "type": "object"
+        },
+        "server.PredictResponse": {
             "type": "object",
             "properties": {
-                "symptom_description": {
-                    "type": "string"
-                },
-                "symptom_name": {
-                    "description": "Changed to string (required)",
-                    "type": "string"
+                "predictions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "[\"ClassA\"",
+                        " \"ClassB\"]"
+                    ]
                 }
             }
         },
-        "server.DiseaseResponse": {
+        "server.Problem": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "description": "Or format as string",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/pgtype.Timestamp"
-                        }
-                    ]
+                "code": {
+                    "description": "Stable, machine-readable error code",
+                    "type": "string",
+                    "example": "patient_email_taken"
                 },
-                "disease_code": {
+                "detail": {
+                    "type": "string",
+                    "example": "A patient with this email already exists"
+                },
+                "duplicates": {
+                    "description": "Patients who may be the same person, best match first, for possible_duplicate",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.DuplicateCandidate"
+                    }
+                },
+                "errors": {
+                    "description": "Every violation, for validation_failed",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.FieldViolation"
+                    }
+                },
+                "field": {
+                    "description": "Offending request field, when there is one",
+                    "type": "string",
+                    "example": "email"
+                },
+                "instance": {
+                    "description": "Request path",
+                    "type": "string",
+                    "example": "/patients"
+                },
+                "references": {
+                    "description": "Records still using the resource, by kind, for still_referenced",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    },
+                    "example": {
+                        "patient_symptoms": 12
+                    }
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "host/abc123-000042"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 409
+                },
+                "title": {
+                    "description": "Summary of the status",
+                    "type": "string",
+                    "example": "Conflict"
+                },
+                "type": {
+                    "description": "URI identifying the problem type",
+                    "type": "string",
+                    "example": "https://patient-api.local/problems/patient_email_taken"
+                }
+            }
+        },
+        "server.ReadinessResponse": {
+            "type": "object",
+            "properties": {
+                "checked_at": {
                     "type": "string"
                 },
-                "disease_description": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/server.HealthCheck"
+                    }
+                },
+                "status": {
+                    "description": "ok only when every check is ok",
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "server.RecordDiagnosisRequest": {
+            "type": "object",
+            "required": [
+                "disease_id",
+                "symptom_ids"
+            ],
+            "properties": {
+                "clinical_status": {
+                    "description": "Defaults to confirmed",
+                    "type": "string",
+                    "enum": [
+                        "suspected",
+                        "confirmed",
+                        "chronic"
+                    ],
+                    "example": "suspected"
+                },
+                "diagnosis_date": {
                     "type": "string"
                 },
                 "disease_id": {
                     "type": "integer"
                 },
-                "disease_name": {
-                    "type": "string"
+                "notes": {
+                    "type": "string",
+                    "maxLength": 10000
                 },
-                "disease_treatment": {
-                    "description": "Send raw JSON back",
+                "record_general_symptoms": {
+                    "description": "Also record the symptoms as reported by the patient (patient_symptoms) on the diagnosis date, or today",
+                    "type": "boolean"
+                },
+                "symptom_ids": {
+                    "description": "Symptoms to link to the instance",
                     "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "uniqueItems": true,
                     "items": {
                         "type": "integer"
-                    }
-                },
-                "updated_at": {
-                    "description": "Or format as string",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/pgtype.Timestamp"
-                        }
+                    },
+                    "example": [
+                        1,
+                        4,
+                        7
                     ]
                 }
             }
         },
-        "server.HTTPError": {
+        "server.RecordPatientDiseaseInstanceRequest": {
             "type": "object",
+            "required": [
+                "disease_id"
+            ],
             "properties": {
-                "error": {
-                    "description": "Error message",
+                "clinical_status": {
+                    "description": "Defaults to confirmed; a diagnosis cannot be recorded as already refuted or resolved",
                     "type": "string",
-                    "example": "Resource not found"
+                    "enum": [
+                        "suspected",
+                        "confirmed",
+                        "chronic"
+                    ],
+                    "example": "suspected"
+                },
+                "diagnosis_date": {
+                    "description": "Use pointer for optional date",
+                    "type": "string"
+                },
+                "disease_id": {
+                    "type": "integer"
+                },
+                "notes": {
+                    "description": "Use pointer for optional notes",
+                    "type": "string",
+                    "maxLength": 10000
                 }
             }
         },
-        "server.LinkSymptomToDiseaseInstanceRequest": {
+        "server.RecordPatientSymptomRequest": {
             "type": "object",
+            "required": [
+                "symptom_id"
+            ],
             "properties": {
+                "reported_date": {
+                    "description": "Use pointer for optional date",
+                    "type": "string"
+                },
                 "symptom_id": {
                     "type": "integer"
                 }
             }
         },
-        "server.PatientDetailsResponse": {
+        "server.SymptomAliasRequest": {
             "type": "object",
+            "required": [
+                "alias"
+            ],
             "properties": {
-                "distinct_diseases_list": {
-                    "description": "Parsed list from patient_disease",
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "alias": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Халуурах"
                 },
-                "email": {
-                    "type": "string"
+                "language": {
+                    "description": "BCP 47 tag; defaults to mn",
+                    "type": "string",
+                    "maxLength": 35,
+                    "example": "mn"
                 },
-                "firstname": {
+                "source": {
+                    "description": "Where the alias came from, e.g. seed or clinician; defaults to manual",
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "clinician"
+                }
+            }
+        },
+        "server.SymptomAliasResponse": {
+            "type": "object",
+            "properties": {
+                "alias": {
                     "type": "string"
                 },
-                "general_symptoms_list": {
-                    "description": "Parsed list from patient_symptoms",
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "created_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
                 },
-                "lastname": {
+                "language": {
                     "type": "string"
                 },
-                "patient_id": {
+                "source": {
+                    "type": "string"
+                },
+                "symptom_alias_id": {
+                    "type": "integer"
+                },
+                "symptom_id": {
                     "type": "integer"
+                },
+                "updated_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
                 }
             }
         },
-        "server.PatientDiseaseInstanceResponse": {
+        "server.SymptomListItem": {
             "type": "object",
             "properties": {
+                "aliases": {
+                    "description": "Other names that resolve to this symptom",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.SymptomAliasResponse"
+                    }
+                },
                 "created_at": {
                     "$ref": "#/definitions/pgtype.Timestamp"
                 },
-                "diagnosis_date": {
-                    "$ref": "#/definitions/pgtype.Date"
-                },
-                "disease_code": {
-                    "description": "Added from join",
-                    "type": "string"
+                "deprecated_at": {
+                    "description": "null unless retired from pickers",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/pgtype.Timestamp"
+                        }
+                    ]
                 },
-                "disease_id": {
+                "replaced_by": {
+                    "description": "Symptom to use instead, if any",
                     "type": "integer"
                 },
-                "disease_name": {
-                    "description": "Added from join",
-                    "type": "string"
-                },
-                "notes": {
-                    "description": "Keep as pointer",
+                "symptom_description": {
                     "type": "string"
                 },
-                "patient_disease_id": {
+                "symptom_id": {
                     "type": "integer"
                 },
-                "patient_id": {
-                    "description": "Added for context",
-                    "type": "integer"
+                "symptom_name": {
+                    "description": "Changed to string (cannot be null)",
+                    "type": "string"
                 },
                 "updated_at": {
                     "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "usage_count": {
+                    "description": "Times patients reported it, across all clinics",
+                    "type": "integer",
+                    "example": 42
+                },
+                "version": {
+                    "description": "Also sent as the ETag; echo it in If-Match",
+                    "type": "integer",
+                    "example": 3
                 }
             }
         },
-        "server.PatientResponse": {
+        "server.SymptomMergeResponse": {
             "type": "object",
             "properties": {
-                "address": {
-                    "description": "string or null",
-                    "type": "string"
+                "alias_added": {
+                    "description": "The duplicate's name was kept as an alias",
+                    "type": "boolean"
                 },
-                "age": {
+                "aliases_moved": {
+                    "description": "The duplicate's aliases, now the survivor's",
                     "type": "integer"
                 },
-                "birthdate": {
-                    "description": "YYYY-MM-DD or null",
-                    "type": "string"
-                },
-                "email": {
-                    "type": "string"
+                "dry_run": {
+                    "type": "boolean"
                 },
-                "firstname": {
-                    "type": "string"
+                "duplicate_id": {
+                    "type": "integer",
+                    "example": 57
                 },
-                "gender": {
-                    "type": "string"
+                "instance_symptoms_collapsed": {
+                    "description": "Dropped: the survivor was already linked to the diagnosis",
+                    "type": "integer"
                 },
-                "lastname": {
-                    "type": "string"
+                "instance_symptoms_repointed": {
+                    "description": "Diagnosis symptom links moved to the survivor",
+                    "type": "integer"
                 },
-                "patient_id": {
+                "patient_symptoms_collapsed": {
+                    "description": "Dropped: the survivor was already reported by the patient that day",
                     "type": "integer"
                 },
-                "phonenumber": {
-                    "type": "string"
+                "patient_symptoms_repointed": {
+                    "description": "Reported symptoms moved to the survivor",
+                    "type": "integer"
                 },
-                "register": {
-                    "type": "string"
+                "survivor_id": {
+                    "type": "integer",
+                    "example": 12
                 }
             }
         },
-        "server.PredictRequest": {
-            "type": "object"
-        },
-        "server.PredictResponse": {
+        "server.SymptomPage": {
             "type": "object",
             "properties": {
-                "predictions": {
+                "data": {
                     "type": "array",
                     "items": {
-                        "type": "string"
-                    },
-                    "example": [
-                        "[\"ClassA\"",
-                        " \"ClassB\"]"
-                    ]
+                        "$ref": "#/definitions/server.SymptomListItem"
+                    }
+                },
+                "page": {
+                    "$ref": "#/definitions/server.PageInfo"
                 }
             }
         },
-        "server.RecordPatientDiseaseInstanceRequest": {
+        "server.SymptomResponse": {
             "type": "object",
             "properties": {
-                "diagnosis_date": {
-                    "description": "Use pointer for optional date",
+                "aliases": {
+                    "description": "Other names that resolve to this symptom",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.SymptomAliasResponse"
+                    }
+                },
+                "created_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "deprecated_at": {
+                    "description": "null unless retired from pickers",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/pgtype.Timestamp"
+                        }
+                    ]
+                },
+                "replaced_by": {
+                    "description": "Symptom to use instead, if any",
+                    "type": "integer"
+                },
+                "symptom_description": {
                     "type": "string"
                 },
-                "disease_id": {
+                "symptom_id": {
                     "type": "integer"
                 },
-                "notes": {
-                    "description": "Use pointer for optional notes",
+                "symptom_name": {
+                    "description": "Changed to string (cannot be null)",
                     "type": "string"
+                },
+                "updated_at": {
+                    "$ref": "#/definitions/pgtype.Timestamp"
+                },
+                "version": {
+                    "description": "Also sent as the ETag; echo it in If-Match",
+                    "type": "integer",
+                    "example": 3
                 }
             }
         },
-        "server.RecordPatientSymptomRequest": {
+        "server.TrainingCaseResponse": {
             "type": "object",
             "properties": {
-                "reported_date": {
-                    "description": "Use pointer for optional date",
+                "clinical_status": {
                     "type": "string"
                 },
-                "symptom_id": {
+                "disease_name": {
+                    "type": "string"
+                },
+                "patient_disease_id": {
                     "type": "integer"
+                },
+                "symptoms": {
+                    "description": "Catalog names of the symptoms linked to the diagnosis",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "server.SymptomResponse": {
+        "server.UpdateDiseaseInstanceRequest": {
             "type": "object",
+            "required": [
+                "clinical_status",
+                "disease_id"
+            ],
             "properties": {
-                "created_at": {
-                    "$ref": "#/definitions/pgtype.Timestamp"
+                "clinical_status": {
+                    "description": "Changes must follow the lifecycle: suspected -\u003e confirmed|refuted, confirmed -\u003e resolved|chronic|refuted, chronic -\u003e resolved, resolved -\u003e confirmed",
+                    "type": "string",
+                    "enum": [
+                        "suspected",
+                        "confirmed",
+                        "refuted",
+                        "resolved",
+                        "chronic"
+                    ],
+                    "example": "confirmed"
                 },
-                "symptom_description": {
+                "diagnosis_date": {
+                    "description": "YYYY-MM-DD",
                     "type": "string"
                 },
-                "symptom_id": {
+                "disease_id": {
                     "type": "integer"
                 },
-                "symptom_name": {
-                    "description": "Changed to string (cannot be null)",
-                    "type": "string"
-                },
-                "updated_at": {
-                    "$ref": "#/definitions/pgtype.Timestamp"
+                "notes": {
+                    "type": "string",
+                    "maxLength": 10000
                 }
             }
         },
         "server.UpdateDiseaseRequest": {
             "type": "object",
+            "required": [
+                "disease_code",
+                "disease_name"
+            ],
             "properties": {
                 "disease_code": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 255
                 },
                 "disease_description": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 10000
                 },
                 "disease_name": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 255
                 },
                 "disease_treatment": {
                     "description": "Accept raw JSON",
@@ -1804,49 +6319,90 @@ const docTemplate = `{
         },
         "server.UpdatePatientRequest": {
             "type": "object",
+            "required": [
+                "email",
+                "firstname",
+                "lastname",
+                "phonenumber",
+                "register"
+            ],
             "properties": {
                 "address": {
-                    "type": "string"
-                },
-                "age": {
-                    "type": "integer"
+                    "type": "string",
+                    "maxLength": 255
                 },
                 "birthdate": {
-                    "description": "Expect YYYY-MM-DD string",
+                    "description": "YYYY-MM-DD; derived from the register when omitted",
                     "type": "string"
                 },
                 "email": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 255
                 },
                 "firstname": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 255
                 },
                 "gender": {
-                    "type": "string"
+                    "description": "Derived from the register when omitted",
+                    "type": "string",
+                    "enum": [
+                        "Male",
+                        "Female",
+                        "Other"
+                    ]
                 },
                 "lastname": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 255
                 },
                 "phonenumber": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 255
                 },
                 "register": {
-                    "type": "string"
+                    "description": "Mongolian civil register number",
+                    "type": "string",
+                    "example": "УБ99032215"
                 }
             }
         },
         "server.UpdateSymptomRequest": {
             "type": "object",
+            "required": [
+                "symptom_name"
+            ],
             "properties": {
                 "symptom_description": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 10000
                 },
                 "symptom_name": {
-                    "description": "Changed to string (required)",
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 255
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "BearerToken": {
+            "description": "\"Bearer \u003capi token\u003e\", an alternative to X-User.",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
+        "ClinicHeader": {
+            "description": "Clinic to act in. Optional when the user is bound to a single clinic.",
+            "type": "apiKey",
+            "name": "X-Clinic-ID",
+            "in": "header"
+        },
+        "UserHeader": {
+            "description": "Username of the caller (app_user.username). Required for clinic-scoped routes.",
+            "type": "apiKey",
+            "name": "X-User",
+            "in": "header"
+        }
     }
 }`
 
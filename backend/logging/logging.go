@@ -0,0 +1,60 @@
+// Package logging builds the application's structured logger and enforces
+// the redaction policy: patient PII and prediction payloads never reach logs.
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/config"
+)
+
+// Redacted replaces the value of any attribute whose key is in redactedKeys.
+const Redacted = "[REDACTED]"
+
+// redactedKeys are attribute keys whose values must never be logged, at any
+// nesting level. Log IDs (patient_id, ...) instead of the values themselves.
+var redactedKeys = map[string]bool{
+	// Patient PII
+	"firstname":    true,
+	"lastname":     true,
+	"email":        true,
+	"phone_number": true,
+	"register":     true,
+	"address":      true,
+	"birthdate":    true,
+	// Clinical and prediction payloads
+	"known_symptoms": true,
+	"predictions":    true,
+	"body":           true,
+	"payload":        true,
+	// Credentials
+	"authorization": true,
+	"token":         true,
+	"password":      true,
+	"db_url":        true,
+}
+
+// New returns the logger for cfg: JSON lines in production, human-readable
+// text otherwise, both passing through the redaction policy.
+func New(cfg *config.Config) *slog.Logger {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(cfg.Log_Level)) // Validated by config.Load
+
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	}
+	if cfg.Log_Format == "json" {
+		return slog.New(slog.NewJSONHandler(os.Stdout, opts))
+	}
+	return slog.New(slog.NewTextHandler(os.Stdout, opts))
+}
+
+func redact(_ []string, a slog.Attr) slog.Attr {
+	if redactedKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, Redacted)
+	}
+	return a
+}
@@ -0,0 +1,130 @@
+// server/auth.go
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Headers identifying the caller. X-User is expected to be set by a trusted
+// gateway in front of the API; callers can instead present their API token.
+const (
+	headerUser   = "X-User"      // app_user.username of the caller
+	headerClinic = "X-Clinic-ID" // Clinic to act in; optional when the user has exactly one
+)
+
+// User roles (app_user.role)
+const (
+	RoleClinician = "clinician"
+	RoleAdmin     = "admin"
+)
+
+// Principal is the authenticated caller and the clinic the request is scoped to.
+type Principal struct {
+	UserID   int32
+	Username string
+	Role     string
+	ClinicID int32
+}
+
+type principalKey struct{}
+
+// principalFromContext returns the caller set by the authenticate middleware.
+func principalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// authenticate resolves the caller and their clinic, and scopes the request
+// context to that clinic so every query only sees the clinic's patient data.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var user db.AppUser
+		var err error
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && token != "" {
+			// API tokens are stored as the hex sha256 of the token
+			sum := sha256.Sum256([]byte(token))
+			user, err = s.queries.GetUserByAPITokenHash(r.Context(), pgtype.Text{String: hex.EncodeToString(sum[:]), Valid: true})
+		} else if username := r.Header.Get(headerUser); username != "" {
+			user, err = s.queries.GetUserByUsername(r.Context(), username)
+		} else {
+			respondWithError(w, r, http.StatusUnauthorized, "Missing "+headerUser+" header or bearer token")
+			return
+		}
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, r, http.StatusUnauthorized, "Unknown user or token")
+			} else {
+				s.respondWithDBError(w, r, err, "Failed to authenticate user")
+			}
+			return
+		}
+
+		clinics, err := s.queries.ListClinicsForUser(r.Context(), user.UserID)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to authenticate user", "user_id", user.UserID)
+			return
+		}
+		if len(clinics) == 0 {
+			respondWithError(w, r, http.StatusForbidden, "User is not bound to any clinic")
+			return
+		}
+
+		var clinicID int32
+		if clinicStr := r.Header.Get(headerClinic); clinicStr != "" {
+			requested, err := strconv.ParseInt(clinicStr, 10, 32)
+			if err != nil {
+				respondWithError(w, r, http.StatusBadRequest, "Invalid "+headerClinic+" header")
+				return
+			}
+			for _, c := range clinics {
+				if c.ClinicID == int32(requested) {
+					clinicID = c.ClinicID
+				}
+			}
+			if clinicID == 0 {
+				respondWithError(w, r, http.StatusForbidden, "User is not bound to clinic "+clinicStr)
+				return
+			}
+		} else if len(clinics) == 1 {
+			clinicID = clinics[0].ClinicID
+		} else {
+			respondWithError(w, r, http.StatusBadRequest, "User is bound to several clinics; set the "+headerClinic+" header")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), principalKey{}, Principal{
+			UserID:   user.UserID,
+			Username: user.Username,
+			Role:     user.Role,
+			ClinicID: clinicID,
+		})
+		ctx = db.WithClinicID(ctx, clinicID)
+		notePrincipal(ctx, user.Username)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requireRole rejects callers without the given role with 403.
+func requireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			caller, ok := principalFromContext(r.Context())
+			if !ok || caller.Role != role {
+				respondWithError(w, r, http.StatusForbidden, "Requires the "+role+" role")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
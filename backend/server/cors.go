@@ -0,0 +1,98 @@
+// server/cors.go
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/config"
+)
+
+// exposedHeaders are response headers the frontend is allowed to read.
+var exposedHeaders = []string{"Retry-After", "ETag", headerIdempotentReplayed, headerBreakGlass}
+
+// cors answers preflight requests for every route and adds the CORS headers
+// to actual requests from allowed origins. Requests from other origins are
+// served without CORS headers, so the browser blocks the response.
+func cors(cfg config.CORS) func(http.Handler) http.Handler {
+	anyOrigin := false
+	origins := make(map[string]bool, len(cfg.Allowed_Origins))
+	for _, origin := range cfg.Allowed_Origins {
+		if origin == "*" {
+			anyOrigin = true
+		}
+		origins[origin] = true
+	}
+	methods := strings.Join(cfg.Allowed_Methods, ", ")
+	headers := strings.Join(cfg.Allowed_Headers, ", ")
+	exposed := strings.Join(exposedHeaders, ", ")
+	maxAge := strconv.Itoa(cfg.Max_Age)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			w.Header().Add("Vary", "Origin")
+			if origin == "" || !(anyOrigin || origins[origin]) {
+				if preflight {
+					w.WriteHeader(http.StatusNoContent) // No CORS headers: the browser refuses the request
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if anyOrigin {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.Allow_Credentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if preflight {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			w.Header().Set("Access-Control-Expose-Headers", exposed)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Content-Security-Policy values. The API only returns JSON; the Swagger UI
+// page needs its bundled scripts and the inline bootstrap script and styles.
+const (
+	cspAPI     = "default-src 'none'; frame-ancestors 'none'"
+	cspSwagger = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; frame-ancestors 'none'"
+)
+
+// securityHeaders sets the standard hardening headers on every response.
+// HSTS is only sent over TLS (directly or via a proxy setting X-Forwarded-Proto),
+// since browsers ignore it on plain HTTP.
+func securityHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("Referrer-Policy", "no-referrer")
+		if strings.HasPrefix(r.URL.Path, "/swagger/") {
+			h.Set("Content-Security-Policy", cspSwagger)
+		} else {
+			h.Set("Content-Security-Policy", cspAPI)
+		}
+		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+			h.Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,122 @@
+// server/disease_status.go
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Clinical statuses of a disease instance (patient_disease.clinical_status).
+const (
+	StatusSuspected = "suspected"
+	StatusConfirmed = "confirmed"
+	StatusRefuted   = "refuted"
+	StatusResolved  = "resolved"
+	StatusChronic   = "chronic"
+)
+
+// CodeInvalidStatusTransition is the problem code for a status change the
+// lifecycle does not allow.
+const CodeInvalidStatusTransition = "invalid_status_transition"
+
+// statusTransitions lists the statuses each status may move to. A suspicion is
+// confirmed or refuted; a confirmed disease resolves, becomes chronic or turns
+// out to be wrong; a resolved one can come back. Refuted is final: record a new
+// instance if the disease is suspected again.
+var statusTransitions = map[string][]string{
+	StatusSuspected: {StatusConfirmed, StatusRefuted},
+	StatusConfirmed: {StatusResolved, StatusChronic, StatusRefuted},
+	StatusChronic:   {StatusResolved},
+	StatusResolved:  {StatusConfirmed},
+	StatusRefuted:   {},
+}
+
+// swagger:model DiseaseStatusChangeResponse
+type DiseaseStatusChangeResponse struct {
+	FromStatus *string          `json:"from_status" example:"suspected"` // null for the status the instance was recorded with
+	ToStatus   string           `json:"to_status" example:"confirmed"`
+	ChangedBy  string           `json:"changed_by" example:"dr.bat"`
+	ChangedAt  pgtype.Timestamp `json:"changed_at"`
+}
+
+// statusTransitionError reports a status change the lifecycle does not allow,
+// or nil when from and to are the same or the move is allowed.
+func statusTransitionError(from, to string) *APIError {
+	if from == to {
+		return nil
+	}
+	allowed := statusTransitions[from]
+	for _, next := range allowed {
+		if next == to {
+			return nil
+		}
+	}
+	detail := "A " + from + " diagnosis cannot become " + to
+	if len(allowed) > 0 {
+		detail += "; it can become " + strings.Join(allowed, ", ")
+	} else {
+		detail += "; record a new disease instance instead"
+	}
+	return &APIError{Status: http.StatusUnprocessableEntity, Code: CodeInvalidStatusTransition, Field: "clinical_status", Detail: detail}
+}
+
+// parseStatusFilter reads a comma-separated ?status= list. It returns nil for
+// no filter, or false after writing a 400 for an unknown status.
+func parseStatusFilter(w http.ResponseWriter, r *http.Request) ([]string, bool) {
+	raw := r.URL.Query().Get("status")
+	if raw == "" {
+		return nil, true
+	}
+	var statuses []string
+	for _, status := range strings.Split(raw, ",") {
+		status = strings.TrimSpace(status)
+		if _, ok := statusTransitions[status]; !ok {
+			respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidValue, Field: "status", Detail: "Unknown clinical status: " + status})
+			return nil, false
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, true
+}
+
+// handleListDiseaseInstanceStatusHistory godoc
+// @Summary      Status history of a disease instance
+// @Description  Every clinical status the diagnosis has had, oldest first, with who changed it and when. The first entry is the status it was recorded with.
+// @Tags         Patient Relationships
+// @Produce      json
+// @Param        instanceID path      int true "Patient Disease Instance ID" Format(int32)
+// @Success      200       {array}   DiseaseStatusChangeResponse "Status changes"
+// @Failure      400       {object}  Problem "Invalid ID format"
+// @Failure      404       {object}  Problem "Disease instance not found"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /disease-instances/{instanceID}/status-history [get]
+func (s *Server) handleListDiseaseInstanceStatusHistory() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		instanceID, err := parseInt32Param(r, "instanceID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid disease instance ID: "+err.Error())
+			return
+		}
+
+		changes, err := s.queries.ListDiseaseInstanceStatusHistory(r.Context(), instanceID)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to list status history", "instance_id", instanceID)
+			return
+		}
+
+		response := make([]DiseaseStatusChangeResponse, len(changes))
+		for i, c := range changes {
+			response[i] = DiseaseStatusChangeResponse{
+				FromStatus: stringPtrFromPgtypeText(c.FromStatus),
+				ToStatus:   c.ToStatus,
+				ChangedBy:  c.ChangedBy,
+				ChangedAt:  c.ChangedAt,
+			}
+		}
+		respondWithJSON(w, http.StatusOK, response)
+	}
+}
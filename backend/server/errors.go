@@ -0,0 +1,215 @@
+// server/errors.go
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// problemTypeBase prefixes the stable error code to form the problem "type" URI.
+const problemTypeBase = "https://patient-api.local/problems/"
+
+// Stable error codes for failures that are not tied to a constraint.
+const (
+	CodeBadRequest       = "bad_request"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeTooManyRequests  = "too_many_requests"
+	CodeInternal         = "internal"
+	CodeBadGateway       = "bad_gateway"
+	CodeInvalidValue     = "invalid_value"
+	CodeStillReferenced  = "still_referenced"
+)
+
+// codeForStatus is the code used when a handler only gives a status.
+var codeForStatus = map[int]string{
+	http.StatusBadRequest:          CodeBadRequest,
+	http.StatusUnauthorized:        CodeUnauthorized,
+	http.StatusForbidden:           CodeForbidden,
+	http.StatusNotFound:            CodeNotFound,
+	http.StatusMethodNotAllowed:    CodeMethodNotAllowed,
+	http.StatusConflict:            CodeConflict,
+	http.StatusTooManyRequests:     CodeTooManyRequests,
+	http.StatusInternalServerError: CodeInternal,
+	http.StatusBadGateway:          CodeBadGateway,
+}
+
+// Problem is an RFC 7807 problem details body (application/problem+json).
+// swagger:model Problem
+type Problem struct {
+	Type      string           `json:"type" example:"https://patient-api.local/problems/patient_email_taken"` // URI identifying the problem type
+	Title     string           `json:"title" example:"Conflict"`                                              // Summary of the status
+	Status    int              `json:"status" example:"409"`
+	Detail    string           `json:"detail,omitempty" example:"A patient with this email already exists"`
+	Instance  string           `json:"instance,omitempty" example:"/patients"` // Request path
+	Code      string           `json:"code" example:"patient_email_taken"`     // Stable, machine-readable error code
+	Field     string           `json:"field,omitempty" example:"email"`        // Offending request field, when there is one
+	RequestID string           `json:"request_id,omitempty" example:"host/abc123-000042"`
+	Errors    []FieldViolation `json:"errors,omitempty"` // Every violation, for validation_failed
+	// Records still using the resource, by kind, for still_referenced
+	References map[string]int64 `json:"references,omitempty" swaggertype:"object,integer" example:"patient_symptoms:12"`
+	// Patients who may be the same person, best match first, for possible_duplicate
+	Duplicates []DuplicateCandidate `json:"duplicates,omitempty"`
+}
+
+// APIError is the central error type handlers respond with. Err is the
+// underlying cause; it is logged for 5xx responses and never sent to clients.
+type APIError struct {
+	Status int
+	Code   string
+	Field  string
+	Detail string
+	Err    error
+	// Violations lists every invalid field of a request body
+	Violations []FieldViolation
+	// References counts the records that block a delete
+	References map[string]int64
+	// Duplicates lists the patients a new or changed record may duplicate
+	Duplicates []DuplicateCandidate
+}
+
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return e.Code + ": " + e.Detail + ": " + e.Err.Error()
+	}
+	return e.Code + ": " + e.Detail
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// constraintError describes how a violation of a named constraint is reported.
+type constraintError struct {
+	status int
+	code   string
+	field  string
+	detail string
+}
+
+// constraintErrors maps constraint names (see db/schema) to problems. Unique
+// violations become 409 and foreign keys on insert/update become 404, since
+// the referenced row does not exist (or is not visible to the clinic).
+var constraintErrors = map[string]constraintError{
+	// Unique constraints
+	"uq_patient_clinic_email":                                   {http.StatusConflict, "patient_email_taken", "email", "A patient with this email already exists"},
+	"symptoms_symptom_name_key":                                 {http.StatusConflict, "symptom_name_taken", "symptom_name", "A symptom with this name already exists"},
+	"patient_disease_patient_id_disease_id_diagnosis_date_key":  {http.StatusConflict, "disease_instance_exists", "diagnosis_date", "This disease is already recorded for the patient on this diagnosis date"},
+	"patient_symptoms_patient_id_symptom_id_reported_date_key":  {http.StatusConflict, "patient_symptom_exists", "reported_date", "This symptom is already recorded for the patient on this date"},
+	"patient_disease_symptom_patient_disease_id_symptom_id_key": {http.StatusConflict, "symptom_already_linked", "symptom_id", "This symptom is already linked to the disease instance"},
+	"uq_pc_active_scope":                                        {http.StatusConflict, "consent_already_active", "scope", "The patient already has an active consent for this scope"},
+	"uq_symptom_alias":                                          {http.StatusConflict, "symptom_alias_taken", "alias", "This alias already names a symptom"},
+	"care_team_pkey":                                            {http.StatusConflict, "care_team_member_exists", "user_id", "The user is already on the patient's care team"},
+	// Foreign keys
+	"fk_pd_disease":           {http.StatusNotFound, "disease_not_found", "disease_id", "Disease not found"},
+	"fk_pds_symptom":          {http.StatusNotFound, "symptom_not_found", "symptom_id", "Symptom not found"},
+	"fk_ps_symptom":           {http.StatusNotFound, "symptom_not_found", "symptom_id", "Symptom not found"},
+	"fk_pds_patient_disease":  {http.StatusNotFound, "disease_instance_not_found", "", "Disease instance not found"},
+	"fk_pd_patient":           {http.StatusNotFound, "patient_not_found", "", "Patient not found"},
+	"fk_pd_patient_clinic":    {http.StatusNotFound, "patient_not_found", "", "Patient not found"},
+	"fk_ps_patient":           {http.StatusNotFound, "patient_not_found", "", "Patient not found"},
+	"fk_ps_patient_clinic":    {http.StatusNotFound, "patient_not_found", "", "Patient not found"},
+	"fk_pc_patient":           {http.StatusNotFound, "patient_not_found", "", "Patient not found"},
+	"fk_ct_patient":           {http.StatusNotFound, "patient_not_found", "", "Patient not found"},
+	"fk_ct_user":              {http.StatusNotFound, "user_not_found", "user_id", "User not found"},
+	"fk_pdsh_patient_disease": {http.StatusNotFound, "disease_instance_not_found", "", "Disease instance not found"},
+	"fk_symptoms_replaced_by": {http.StatusNotFound, "symptom_not_found", "replaced_by", "Replacement symptom not found"},
+	"fk_disease_replaced_by":  {http.StatusNotFound, "disease_not_found", "replaced_by", "Replacement disease not found"},
+	// Check constraints
+	"chk_pc_scope":             {http.StatusBadRequest, CodeInvalidValue, "scope", "Invalid consent scope"},
+	"chk_bgg_reason":           {http.StatusBadRequest, CodeInvalidValue, "reason", "A reason is required"},
+	"chk_pd_clinical_status":   {http.StatusBadRequest, CodeInvalidValue, "clinical_status", "Invalid clinical status"},
+	"chk_symptoms_replaced_by": {http.StatusBadRequest, CodeInvalidValue, "replaced_by", "A symptom cannot replace itself"},
+	"chk_disease_replaced_by":  {http.StatusBadRequest, CodeInvalidValue, "replaced_by", "A disease cannot replace itself"},
+}
+
+// translateDBError turns a query error into an APIError: missing rows become
+// 404, known constraint violations their mapped problem, other integrity
+// and data errors 4xx, and anything else 500 with the message given.
+func translateDBError(err error, message string) *APIError {
+	if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+		return &APIError{Status: http.StatusNotFound, Code: CodeNotFound, Detail: "Resource not found", Err: err}
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return &APIError{Status: http.StatusInternalServerError, Code: CodeInternal, Detail: message, Err: err}
+	}
+	if ce, ok := constraintErrors[pgErr.ConstraintName]; ok {
+		// A foreign key fails on delete when the row is still referenced; that is a conflict
+		if pgErr.Code == "23503" && strings.Contains(pgErr.Message, "still referenced") {
+			return &APIError{Status: http.StatusConflict, Code: CodeStillReferenced, Detail: "The resource is still referenced by other records", Err: err}
+		}
+		return &APIError{Status: ce.status, Code: ce.code, Field: ce.field, Detail: ce.detail, Err: err}
+	}
+
+	switch pgErr.Code {
+	case "23505": // unique_violation
+		return &APIError{Status: http.StatusConflict, Code: CodeConflict, Field: pgErr.ColumnName, Detail: "The resource already exists", Err: err}
+	case "23503": // foreign_key_violation
+		if strings.Contains(pgErr.Message, "still referenced") {
+			return &APIError{Status: http.StatusConflict, Code: CodeStillReferenced, Detail: "The resource is still referenced by other records", Err: err}
+		}
+		return &APIError{Status: http.StatusNotFound, Code: CodeNotFound, Detail: "A referenced resource does not exist", Err: err}
+	case "23502", "23514", "22001", "22007", "22008", "22P02": // not_null, check, too long, bad datetime/format, bad text representation
+		return &APIError{Status: http.StatusBadRequest, Code: CodeInvalidValue, Field: pgErr.ColumnName, Detail: "Invalid value", Err: err}
+	}
+	return &APIError{Status: http.StatusInternalServerError, Code: CodeInternal, Detail: message, Err: err}
+}
+
+// respondWithProblem writes apiErr as application/problem+json.
+func respondWithProblem(w http.ResponseWriter, r *http.Request, apiErr *APIError) {
+	problem := Problem{
+		Type:       "about:blank",
+		Title:      http.StatusText(apiErr.Status),
+		Status:     apiErr.Status,
+		Detail:     apiErr.Detail,
+		Instance:   r.URL.Path,
+		Code:       apiErr.Code,
+		Field:      apiErr.Field,
+		RequestID:  middleware.GetReqID(r.Context()),
+		Errors:     apiErr.Violations,
+		References: apiErr.References,
+		Duplicates: apiErr.Duplicates,
+	}
+	if problem.Code == "" {
+		problem.Code = codeForStatus[apiErr.Status]
+	}
+	if problem.Code != "" {
+		problem.Type = problemTypeBase + problem.Code
+	}
+
+	// Reported on the request's access log line (see requestLog)
+	if rec := findStatusRecorder(w); rec != nil {
+		rec.errMessage = problem.Code + ": " + problem.Detail
+	}
+
+	body, err := json.Marshal(problem)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(apiErr.Status)
+	w.Write(body)
+}
+
+// respondWithDBError answers a failed query through translateDBError. Errors
+// that end up as 500 are logged with the given message and log attributes.
+func (s *Server) respondWithDBError(w http.ResponseWriter, r *http.Request, err error, message string, attrs ...any) {
+	apiErr := translateDBError(err, message)
+	if apiErr.Status >= 500 {
+		s.log(r).Error(message, append(attrs, "err", err)...)
+	}
+	respondWithProblem(w, r, apiErr)
+}
@@ -0,0 +1,153 @@
+// server/etag.go
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// mergePatchContentType is the media type of RFC 7396 JSON Merge Patch bodies.
+const mergePatchContentType = "application/merge-patch+json"
+
+// Problem codes for conditional requests.
+const (
+	CodePreconditionFailed   = "precondition_failed"
+	CodePreconditionRequired = "precondition_required"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+)
+
+// etag is the strong entity tag for a row version, e.g. "3".
+func etag(version int32) string {
+	return strconv.Quote(strconv.Itoa(int(version)))
+}
+
+func setETag(w http.ResponseWriter, version int32) {
+	w.Header().Set("ETag", etag(version))
+}
+
+// etagListMatches reports whether an If-Match/If-None-Match value lists the
+// version's tag or is "*". Weak tags never match: versions are strong.
+func etagListMatches(header string, version int32) bool {
+	want := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == want {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified answers a GET whose If-None-Match lists the current version with
+// 304 and returns true; otherwise it sets the ETag and returns false.
+func notModified(w http.ResponseWriter, r *http.Request, version int32) bool {
+	setETag(w, version)
+	if inm := r.Header.Get("If-None-Match"); inm != "" && etagListMatches(inm, version) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// checkIfMatch enforces optimistic concurrency on a write: If-Match must be
+// present (428) and list the row's current version (412). The 412 carries the
+// current ETag so the client knows it has to refetch.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, version int32) bool {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		respondWithProblem(w, r, &APIError{Status: http.StatusPreconditionRequired, Code: CodePreconditionRequired, Detail: "If-Match is required; send the ETag of the version you are changing"})
+		return false
+	}
+	if !etagListMatches(ifMatch, version) {
+		respondWithStaleVersion(w, r, version)
+		return false
+	}
+	return true
+}
+
+// respondWithStaleVersion answers 412 for a write against an outdated version,
+// including one that lost a race after its If-Match was checked.
+func respondWithStaleVersion(w http.ResponseWriter, r *http.Request, current int32) {
+	if current > 0 {
+		setETag(w, current)
+	}
+	respondWithProblem(w, r, &APIError{Status: http.StatusPreconditionFailed, Code: CodePreconditionFailed, Detail: "The resource was changed by someone else; fetch it again and reapply your change"})
+}
+
+// applyMergePatch merges patch into target as RFC 7396 describes: objects are
+// merged member by member, null removes a member, anything else replaces.
+func applyMergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for name, value := range patchObj {
+		if value == nil {
+			delete(targetObj, name)
+		} else {
+			targetObj[name] = applyMergePatch(targetObj[name], value)
+		}
+	}
+	return targetObj
+}
+
+// decodeMergePatch applies the request's merge patch to current (the
+// resource's writable fields, in the shape of its update request) and decodes
+// the result strictly into dst, then validates it. On failure it writes the
+// problem response and returns false.
+func (s *Server) decodeMergePatch(w http.ResponseWriter, r *http.Request, current, dst any) bool {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != mergePatchContentType && mediaType != "application/json" {
+		respondWithProblem(w, r, &APIError{Status: http.StatusUnsupportedMediaType, Code: CodeUnsupportedMediaType, Detail: "PATCH bodies must be " + mergePatchContentType})
+		return false
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.Max_Body_Bytes)
+	defer r.Body.Close()
+	var patch any
+	if err := decodeStrict(r.Body, &patch); err != nil {
+		respondWithProblem(w, r, decodeError(err))
+		return false
+	}
+	if _, ok := patch.(map[string]any); !ok {
+		respondWithError(w, r, http.StatusBadRequest, "A merge patch must be a JSON object")
+		return false
+	}
+
+	currentJSON, err := json.Marshal(current)
+	if err != nil {
+		s.log(r).Error("Error encoding resource for merge patch", "err", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to apply patch")
+		return false
+	}
+	var target any
+	if err := json.Unmarshal(currentJSON, &target); err != nil {
+		s.log(r).Error("Error encoding resource for merge patch", "err", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to apply patch")
+		return false
+	}
+	merged, err := json.Marshal(applyMergePatch(target, patch))
+	if err != nil {
+		s.log(r).Error("Error encoding merged resource", "err", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to apply patch")
+		return false
+	}
+
+	// Unknown or mistyped members in the patch are reported like in any other body
+	if err := decodeStrict(bytes.NewReader(merged), dst); err != nil {
+		respondWithProblem(w, r, decodeError(err))
+		return false
+	}
+	if apiErr := validationError(dst); apiErr != nil {
+		respondWithProblem(w, r, apiErr)
+		return false
+	}
+	return true
+}
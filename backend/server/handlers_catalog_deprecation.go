@@ -0,0 +1,312 @@
+// server/handlers_catalog_deprecation.go
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CodeReplacementDeprecated is the problem code for deprecating an entry in
+// favour of one that is itself deprecated.
+const CodeReplacementDeprecated = "replacement_deprecated"
+
+// swagger:model DeprecateCatalogRequest
+type DeprecateCatalogRequest struct {
+	ReplacedBy *int32 `json:"replaced_by,omitempty" validate:"omitempty,gt=0" example:"12"` // Entry to use instead; must not be deprecated itself
+}
+
+// replacementFor validates req.ReplacedBy for the entry id: it must differ
+// from id, exist and not be deprecated. lookup locks the replacement and
+// reports its deprecated_at. It writes the error response and returns false
+// when the replacement is unusable.
+func replacementFor(w http.ResponseWriter, r *http.Request, req DeprecateCatalogRequest, id int32, kind string, lookup func(int32) (pgtype.Timestamp, error), onErr func(error)) (pgtype.Int4, bool) {
+	if req.ReplacedBy == nil {
+		return pgtype.Int4{}, true
+	}
+	replacement := *req.ReplacedBy
+	if replacement == id {
+		respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidValue, Field: "replaced_by", Detail: "A " + kind + " cannot replace itself"})
+		return pgtype.Int4{}, false
+	}
+	deprecatedAt, err := lookup(replacement)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+			respondWithProblem(w, r, &APIError{Status: http.StatusNotFound, Code: kind + "_not_found", Field: "replaced_by", Detail: "Replacement " + kind + " not found"})
+		} else {
+			onErr(err)
+		}
+		return pgtype.Int4{}, false
+	}
+	if deprecatedAt.Valid {
+		respondWithProblem(w, r, &APIError{Status: http.StatusConflict, Code: CodeReplacementDeprecated, Field: "replaced_by", Detail: "The replacement " + kind + " is deprecated itself"})
+		return pgtype.Int4{}, false
+	}
+	return pgtype.Int4{Int32: replacement, Valid: true}, true
+}
+
+// handleDeprecateSymptom godoc
+// @Summary      Deprecate a symptom
+// @Description  Retire a symptom from the catalog without touching patient records: it is left out of listings and name lookups, but still returned by ID so history keeps resolving. replaced_by names the symptom to use instead; symptoms already replaced by this one follow it there. Deprecating again only changes the replacement. Admins only.
+// @Tags         Symptoms
+// @Accept       json
+// @Produce      json
+// @Param        symptomID   path      int                     true "Symptom ID" Format(int32)
+// @Param        deprecation body      DeprecateCatalogRequest true "Replacement, if any"
+// @Success      200         {object}  SymptomResponse "Symptom deprecated"
+// @Header       200         {string}  ETag "Version of the deprecated symptom"
+// @Failure      400         {object}  Problem "Invalid ID, malformed JSON, or a symptom replacing itself"
+// @Failure      401         {object}  Problem "Missing or invalid credentials"
+// @Failure      403         {object}  Problem "Caller is not an admin"
+// @Failure      404         {object}  Problem "Symptom or replacement not found"
+// @Failure      409         {object}  Problem "The replacement is deprecated itself"
+// @Failure      422         {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      500         {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /symptoms/{symptomID}/deprecate [post]
+func (s *Server) handleDeprecateSymptom() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		symptomID, err := parseInt32Param(r, "symptomID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid symptom ID: "+err.Error())
+			return
+		}
+		var req DeprecateCatalogRequest
+		if !s.decodeAndValidate(w, r, &req) {
+			return
+		}
+		fail := func(err error) {
+			s.respondWithDBError(w, r, err, "Failed to deprecate symptom", "symptom_id", symptomID)
+		}
+
+		tx, err := s.pool.Begin(r.Context())
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+		qtx := s.queries.WithTx(tx)
+
+		// Locked so the replacement cannot be deprecated in favour of this one meanwhile
+		if _, err := qtx.GetSymptomForUpdate(r.Context(), symptomID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, r, http.StatusNotFound, "Symptom not found")
+			} else {
+				fail(err)
+			}
+			return
+		}
+		replacement, ok := replacementFor(w, r, req, symptomID, "symptom", func(id int32) (pgtype.Timestamp, error) {
+			sym, err := qtx.GetSymptomForUpdate(r.Context(), id)
+			return sym.DeprecatedAt, err
+		}, fail)
+		if !ok {
+			return
+		}
+
+		symptom, err := qtx.DeprecateSymptom(r.Context(), db.DeprecateSymptomParams{SymptomID: symptomID, ReplacedBy: replacement})
+		if err != nil {
+			fail(err)
+			return
+		}
+		if replacement.Valid {
+			if _, err := qtx.RepointSymptomReplacements(r.Context(), db.RepointSymptomReplacementsParams{SymptomID: symptomID, ReplacedBy: replacement.Int32}); err != nil {
+				fail(err)
+				return
+			}
+		}
+		aliases, err := qtx.ListSymptomAliases(r.Context(), symptomID)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if err := tx.Commit(r.Context()); err != nil {
+			fail(err)
+			return
+		}
+
+		attrs := []any{"symptom_id", symptomID}
+		if replacement.Valid {
+			attrs = append(attrs, "replaced_by", replacement.Int32)
+		}
+		s.log(r).Info("Deprecated symptom", attrs...)
+		setETag(w, symptom.Version)
+		respondWithJSON(w, http.StatusOK, newSymptomResponse(symptom, aliases))
+	}
+}
+
+// handleReinstateSymptom godoc
+// @Summary      Reinstate a deprecated symptom
+// @Description  Undo a deprecation: the symptom is listed and looked up by name again, and loses its replacement. Admins only.
+// @Tags         Symptoms
+// @Produce      json
+// @Param        symptomID path      int true "Symptom ID" Format(int32)
+// @Success      200       {object}  SymptomResponse "Symptom reinstated"
+// @Header       200       {string}  ETag "Version of the reinstated symptom"
+// @Failure      400       {object}  Problem "Invalid Symptom ID format"
+// @Failure      401       {object}  Problem "Missing or invalid credentials"
+// @Failure      403       {object}  Problem "Caller is not an admin"
+// @Failure      404       {object}  Problem "Symptom not found"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /symptoms/{symptomID}/reinstate [post]
+func (s *Server) handleReinstateSymptom() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		symptomID, err := parseInt32Param(r, "symptomID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid symptom ID: "+err.Error())
+			return
+		}
+
+		symptom, err := s.queries.ReinstateSymptom(r.Context(), symptomID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, r, http.StatusNotFound, "Symptom not found")
+			} else {
+				s.respondWithDBError(w, r, err, "Failed to reinstate symptom", "symptom_id", symptomID)
+			}
+			return
+		}
+		aliases, err := s.queries.ListSymptomAliases(r.Context(), symptomID)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to retrieve symptom aliases", "symptom_id", symptomID)
+			return
+		}
+
+		s.log(r).Info("Reinstated symptom", "symptom_id", symptomID)
+		setETag(w, symptom.Version)
+		respondWithJSON(w, http.StatusOK, newSymptomResponse(symptom, aliases))
+	}
+}
+
+// handleDeprecateDisease godoc
+// @Summary      Deprecate a disease
+// @Description  Retire a disease from the catalog without touching patient records: it is left out of listings, but still returned by ID so history keeps resolving. replaced_by names the disease to use instead; diseases already replaced by this one follow it there. Deprecating again only changes the replacement. Admins only.
+// @Tags         Diseases
+// @Accept       json
+// @Produce      json
+// @Param        diseaseID   path      int                     true "Disease ID" Format(int32)
+// @Param        deprecation body      DeprecateCatalogRequest true "Replacement, if any"
+// @Success      200         {object}  DiseaseResponse "Disease deprecated"
+// @Header       200         {string}  ETag "Version of the deprecated disease"
+// @Failure      400         {object}  Problem "Invalid ID, malformed JSON, or a disease replacing itself"
+// @Failure      401         {object}  Problem "Missing or invalid credentials"
+// @Failure      403         {object}  Problem "Caller is not an admin"
+// @Failure      404         {object}  Problem "Disease or replacement not found"
+// @Failure      409         {object}  Problem "The replacement is deprecated itself"
+// @Failure      422         {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      500         {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /diseases/{diseaseID}/deprecate [post]
+func (s *Server) handleDeprecateDisease() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		diseaseID, err := parseInt32Param(r, "diseaseID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid disease ID: "+err.Error())
+			return
+		}
+		var req DeprecateCatalogRequest
+		if !s.decodeAndValidate(w, r, &req) {
+			return
+		}
+		fail := func(err error) {
+			s.respondWithDBError(w, r, err, "Failed to deprecate disease", "disease_id", diseaseID)
+		}
+
+		tx, err := s.pool.Begin(r.Context())
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+		qtx := s.queries.WithTx(tx)
+
+		// Locked so the replacement cannot be deprecated in favour of this one meanwhile
+		if _, err := qtx.GetDiseaseForUpdate(r.Context(), diseaseID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, r, http.StatusNotFound, "Disease not found")
+			} else {
+				fail(err)
+			}
+			return
+		}
+		replacement, ok := replacementFor(w, r, req, diseaseID, "disease", func(id int32) (pgtype.Timestamp, error) {
+			d, err := qtx.GetDiseaseForUpdate(r.Context(), id)
+			return d.DeprecatedAt, err
+		}, fail)
+		if !ok {
+			return
+		}
+
+		disease, err := qtx.DeprecateDisease(r.Context(), db.DeprecateDiseaseParams{DiseaseID: diseaseID, ReplacedBy: replacement})
+		if err != nil {
+			fail(err)
+			return
+		}
+		if replacement.Valid {
+			if _, err := qtx.RepointDiseaseReplacements(r.Context(), db.RepointDiseaseReplacementsParams{DiseaseID: diseaseID, ReplacedBy: replacement.Int32}); err != nil {
+				fail(err)
+				return
+			}
+		}
+		if err := tx.Commit(r.Context()); err != nil {
+			fail(err)
+			return
+		}
+
+		attrs := []any{"disease_id", diseaseID}
+		if replacement.Valid {
+			attrs = append(attrs, "replaced_by", replacement.Int32)
+		}
+		s.log(r).Info("Deprecated disease", attrs...)
+		setETag(w, disease.Version)
+		respondWithJSON(w, http.StatusOK, newDiseaseResponse(disease))
+	}
+}
+
+// handleReinstateDisease godoc
+// @Summary      Reinstate a deprecated disease
+// @Description  Undo a deprecation: the disease is listed again, and loses its replacement. Admins only.
+// @Tags         Diseases
+// @Produce      json
+// @Param        diseaseID path      int true "Disease ID" Format(int32)
+// @Success      200       {object}  DiseaseResponse "Disease reinstated"
+// @Header       200       {string}  ETag "Version of the reinstated disease"
+// @Failure      400       {object}  Problem "Invalid Disease ID format"
+// @Failure      401       {object}  Problem "Missing or invalid credentials"
+// @Failure      403       {object}  Problem "Caller is not an admin"
+// @Failure      404       {object}  Problem "Disease not found"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /diseases/{diseaseID}/reinstate [post]
+func (s *Server) handleReinstateDisease() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		diseaseID, err := parseInt32Param(r, "diseaseID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid disease ID: "+err.Error())
+			return
+		}
+
+		disease, err := s.queries.ReinstateDisease(r.Context(), diseaseID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, r, http.StatusNotFound, "Disease not found")
+			} else {
+				s.respondWithDBError(w, r, err, "Failed to reinstate disease", "disease_id", diseaseID)
+			}
+			return
+		}
+
+		s.log(r).Info("Reinstated disease", "disease_id", diseaseID)
+		setETag(w, disease.Version)
+		respondWithJSON(w, http.StatusOK, newDiseaseResponse(disease))
+	}
+}
@@ -0,0 +1,260 @@
+// server/handlers_catalog_merge.go
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// swagger:model MergeCatalogRequest
+type MergeCatalogRequest struct {
+	DuplicateID int32 `json:"duplicate_id" validate:"required,gt=0" example:"57"` // Entry absorbed into the one in the path
+	DryRun      bool  `json:"dry_run,omitempty"`                                  // Report what the merge would change, changing nothing
+}
+
+// swagger:model SymptomMergeResponse
+type SymptomMergeResponse struct {
+	SurvivorID                int32 `json:"survivor_id" example:"12"`
+	DuplicateID               int32 `json:"duplicate_id" example:"57"`
+	DryRun                    bool  `json:"dry_run"`
+	PatientSymptomsRepointed  int64 `json:"patient_symptoms_repointed"`  // Reported symptoms moved to the survivor
+	PatientSymptomsCollapsed  int64 `json:"patient_symptoms_collapsed"`  // Dropped: the survivor was already reported by the patient that day
+	InstanceSymptomsRepointed int64 `json:"instance_symptoms_repointed"` // Diagnosis symptom links moved to the survivor
+	InstanceSymptomsCollapsed int64 `json:"instance_symptoms_collapsed"` // Dropped: the survivor was already linked to the diagnosis
+	AliasesMoved              int64 `json:"aliases_moved"`               // The duplicate's aliases, now the survivor's
+	AliasAdded                bool  `json:"alias_added"`                 // The duplicate's name was kept as an alias
+}
+
+// swagger:model DiseaseMergeResponse
+type DiseaseMergeResponse struct {
+	SurvivorID            int32 `json:"survivor_id" example:"3"`
+	DuplicateID           int32 `json:"duplicate_id" example:"41"`
+	DryRun                bool  `json:"dry_run"`
+	InstancesRepointed    int64 `json:"instances_repointed"`     // Diagnoses moved to the survivor
+	InstancesCollapsed    int64 `json:"instances_collapsed"`     // Folded into the patient's diagnosis of the survivor on the same date
+	InstanceSymptomsMoved int64 `json:"instance_symptoms_moved"` // Symptom links the folded diagnoses handed over
+	StatusHistoryMoved    int64 `json:"status_history_moved"`    // Status history rows the folded diagnoses handed over
+	NotesMerged           int64 `json:"notes_merged"`            // Survivor diagnoses the folded notes were appended to
+	AliasesMoved          int64 `json:"aliases_moved"`           // The duplicate's aliases, now the survivor's
+	AliasAdded            bool  `json:"alias_added"`             // The duplicate's name was kept as an alias
+}
+
+// mergeSymptoms moves every reference from duplicate to survivor and
+// deprecates duplicate in its favour. qtx must be in a transaction; the
+// patient records of every clinic are moved by merge_symptom_references.
+func mergeSymptoms(ctx context.Context, qtx *db.Queries, survivor, duplicate int32) (SymptomMergeResponse, error) {
+	res := SymptomMergeResponse{SurvivorID: survivor, DuplicateID: duplicate}
+	refs, err := qtx.MergeSymptomReferences(ctx, db.MergeSymptomReferencesParams{SurvivorID: survivor, DuplicateID: duplicate})
+	if err != nil {
+		return res, err
+	}
+	res.PatientSymptomsCollapsed = refs.PatientSymptomsCollapsed
+	res.PatientSymptomsRepointed = refs.PatientSymptomsRepointed
+	res.InstanceSymptomsCollapsed = refs.InstanceSymptomsCollapsed
+	res.InstanceSymptomsRepointed = refs.InstanceSymptomsRepointed
+	if res.AliasesMoved, err = qtx.MoveSymptomAliases(ctx, db.MoveSymptomAliasesParams{SurvivorID: survivor, DuplicateID: duplicate}); err != nil {
+		return res, err
+	}
+	added, err := qtx.AddMergedSymptomAlias(ctx, db.AddMergedSymptomAliasParams{SurvivorID: survivor, DuplicateID: duplicate})
+	if err != nil {
+		return res, err
+	}
+	res.AliasAdded = added > 0
+	if _, err := qtx.DeprecateSymptom(ctx, db.DeprecateSymptomParams{SymptomID: duplicate, ReplacedBy: pgtype.Int4{Int32: survivor, Valid: true}}); err != nil {
+		return res, err
+	}
+	_, err = qtx.RepointSymptomReplacements(ctx, db.RepointSymptomReplacementsParams{SymptomID: duplicate, ReplacedBy: survivor})
+	return res, err
+}
+
+// mergeDiseases moves every reference from duplicate to survivor and
+// deprecates duplicate in its favour. qtx must be in a transaction; the
+// diagnoses of every clinic are moved by merge_disease_references.
+func mergeDiseases(ctx context.Context, qtx *db.Queries, survivor, duplicate int32) (DiseaseMergeResponse, error) {
+	res := DiseaseMergeResponse{SurvivorID: survivor, DuplicateID: duplicate}
+	refs, err := qtx.MergeDiseaseReferences(ctx, db.MergeDiseaseReferencesParams{SurvivorID: survivor, DuplicateID: duplicate})
+	if err != nil {
+		return res, err
+	}
+	res.InstanceSymptomsMoved = refs.InstanceSymptomsMoved
+	res.StatusHistoryMoved = refs.StatusHistoryMoved
+	res.NotesMerged = refs.NotesMerged
+	res.InstancesCollapsed = refs.InstancesCollapsed
+	res.InstancesRepointed = refs.InstancesRepointed
+	if res.AliasesMoved, err = qtx.MoveDiseaseAliases(ctx, db.MoveDiseaseAliasesParams{SurvivorID: survivor, DuplicateID: duplicate}); err != nil {
+		return res, err
+	}
+	added, err := qtx.AddMergedDiseaseAlias(ctx, db.AddMergedDiseaseAliasParams{SurvivorID: survivor, DuplicateID: duplicate})
+	if err != nil {
+		return res, err
+	}
+	res.AliasAdded = added > 0
+	if _, err := qtx.DeprecateDisease(ctx, db.DeprecateDiseaseParams{DiseaseID: duplicate, ReplacedBy: pgtype.Int4{Int32: survivor, Valid: true}}); err != nil {
+		return res, err
+	}
+	_, err = qtx.RepointDiseaseReplacements(ctx, db.RepointDiseaseReplacementsParams{DiseaseID: duplicate, ReplacedBy: survivor})
+	return res, err
+}
+
+// beginMerge reads the survivor from the path and the duplicate from the body
+// and opens the merge transaction. It writes the error
+// response and returns false on failure; otherwise the caller must roll back
+// or commit tx.
+func (s *Server) beginMerge(w http.ResponseWriter, r *http.Request, pathParam string) (int32, MergeCatalogRequest, pgx.Tx, bool) {
+	survivor, err := parseInt32Param(r, pathParam)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid ID: "+err.Error())
+		return 0, MergeCatalogRequest{}, nil, false
+	}
+	var req MergeCatalogRequest
+	if !s.decodeAndValidate(w, r, &req) {
+		return 0, req, nil, false
+	}
+	if req.DuplicateID == survivor {
+		respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidValue, Field: "duplicate_id", Detail: "An entry cannot be merged into itself"})
+		return 0, req, nil, false
+	}
+
+	tx, err := s.pool.Begin(r.Context())
+	if err != nil {
+		s.respondWithDBError(w, r, err, "Failed to start merge")
+		return 0, req, nil, false
+	}
+	return survivor, req, tx, true
+}
+
+// handleMergeSymptoms godoc
+// @Summary      Merge a duplicate symptom
+// @Description  Absorb duplicate_id into the symptom in the path, in one transaction. Every reported symptom and diagnosis link is moved to the survivor, across all clinics. A reference the survivor already has (same patient and date, or same diagnosis) is dropped instead. The duplicate's aliases move to the survivor, and its name becomes one. The duplicate is then deprecated with the survivor as its replacement, so it still resolves for history. A deprecated symptom cannot survive a merge. With dry_run the merge is carried out and rolled back, so the counts are exactly what a real merge would change. Admins only.
+// @Tags         Symptoms
+// @Accept       json
+// @Produce      json
+// @Param        symptomID path      int                 true "Surviving symptom ID" Format(int32)
+// @Param        merge     body      MergeCatalogRequest true "Duplicate to absorb"
+// @Param        Idempotency-Key header string false "Unique key for this request; a retry with the same key replays the first response"
+// @Success      200       {object}  SymptomMergeResponse "What the merge changed (or would change, with dry_run)"
+// @Failure      400       {object}  Problem "Invalid ID, malformed JSON, or a symptom merged into itself"
+// @Failure      401       {object}  Problem "Missing or invalid credentials"
+// @Failure      403       {object}  Problem "Caller is not an admin"
+// @Failure      404       {object}  Problem "Either symptom not found"
+// @Failure      409       {object}  Problem "The surviving symptom is deprecated"
+// @Failure      422       {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /symptoms/{symptomID}/merge [post]
+func (s *Server) handleMergeSymptoms() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		survivor, req, tx, ok := s.beginMerge(w, r, "symptomID")
+		if !ok {
+			return
+		}
+		defer tx.Rollback(r.Context()) // Undoes a dry run, or everything on any failure below
+		qtx := s.queries.WithTx(tx)
+
+		// Locked so neither is renamed or merged elsewhere meanwhile
+		for _, id := range []int32{survivor, req.DuplicateID} {
+			sym, err := qtx.GetSymptomForUpdate(r.Context(), id)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+					respondWithError(w, r, http.StatusNotFound, "Symptom "+strconv.Itoa(int(id))+" not found")
+				} else {
+					s.respondWithDBError(w, r, err, "Failed to merge symptoms", "symptom_id", id)
+				}
+				return
+			}
+			if id == survivor && sym.DeprecatedAt.Valid {
+				respondWithProblem(w, r, &APIError{Status: http.StatusConflict, Code: CodeReplacementDeprecated, Detail: "A deprecated symptom cannot absorb another"})
+				return
+			}
+		}
+
+		res, err := mergeSymptoms(r.Context(), qtx, survivor, req.DuplicateID)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to merge symptoms", "symptom_id", survivor, "duplicate_id", req.DuplicateID)
+			return
+		}
+		res.DryRun = req.DryRun
+		if !req.DryRun {
+			if err := tx.Commit(r.Context()); err != nil {
+				s.respondWithDBError(w, r, err, "Failed to merge symptoms", "symptom_id", survivor, "duplicate_id", req.DuplicateID)
+				return
+			}
+			s.log(r).Info("Merged symptoms", "symptom_id", survivor, "duplicate_id", req.DuplicateID,
+				"patient_symptoms_repointed", res.PatientSymptomsRepointed, "patient_symptoms_collapsed", res.PatientSymptomsCollapsed,
+				"instance_symptoms_repointed", res.InstanceSymptomsRepointed, "instance_symptoms_collapsed", res.InstanceSymptomsCollapsed)
+		}
+		respondWithJSON(w, http.StatusOK, res)
+	}
+}
+
+// handleMergeDiseases godoc
+// @Summary      Merge a duplicate disease
+// @Description  Absorb duplicate_id into the disease in the path, in one transaction. Every recorded diagnosis is moved to the survivor, across all clinics. A patient may already have the survivor diagnosed on the same date. The duplicate diagnosis then hands its symptom links, status history and notes to that one and is dropped. The duplicate's aliases move to the survivor, and its name becomes one. The duplicate is then deprecated with the survivor as its replacement, so it still resolves for history. A deprecated disease cannot survive a merge. With dry_run the merge is carried out and rolled back, so the counts are exactly what a real merge would change. Admins only.
+// @Tags         Diseases
+// @Accept       json
+// @Produce      json
+// @Param        diseaseID path      int                 true "Surviving disease ID" Format(int32)
+// @Param        merge     body      MergeCatalogRequest true "Duplicate to absorb"
+// @Param        Idempotency-Key header string false "Unique key for this request; a retry with the same key replays the first response"
+// @Success      200       {object}  DiseaseMergeResponse "What the merge changed (or would change, with dry_run)"
+// @Failure      400       {object}  Problem "Invalid ID, malformed JSON, or a disease merged into itself"
+// @Failure      401       {object}  Problem "Missing or invalid credentials"
+// @Failure      403       {object}  Problem "Caller is not an admin"
+// @Failure      404       {object}  Problem "Either disease not found"
+// @Failure      409       {object}  Problem "The surviving disease is deprecated"
+// @Failure      422       {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /diseases/{diseaseID}/merge [post]
+func (s *Server) handleMergeDiseases() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		survivor, req, tx, ok := s.beginMerge(w, r, "diseaseID")
+		if !ok {
+			return
+		}
+		defer tx.Rollback(r.Context()) // Undoes a dry run, or everything on any failure below
+		qtx := s.queries.WithTx(tx)
+
+		// Locked so neither is renamed or merged elsewhere meanwhile
+		for _, id := range []int32{survivor, req.DuplicateID} {
+			d, err := qtx.GetDiseaseForUpdate(r.Context(), id)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+					respondWithError(w, r, http.StatusNotFound, "Disease "+strconv.Itoa(int(id))+" not found")
+				} else {
+					s.respondWithDBError(w, r, err, "Failed to merge diseases", "disease_id", id)
+				}
+				return
+			}
+			if id == survivor && d.DeprecatedAt.Valid {
+				respondWithProblem(w, r, &APIError{Status: http.StatusConflict, Code: CodeReplacementDeprecated, Detail: "A deprecated disease cannot absorb another"})
+				return
+			}
+		}
+
+		res, err := mergeDiseases(r.Context(), qtx, survivor, req.DuplicateID)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to merge diseases", "disease_id", survivor, "duplicate_id", req.DuplicateID)
+			return
+		}
+		res.DryRun = req.DryRun
+		if !req.DryRun {
+			if err := tx.Commit(r.Context()); err != nil {
+				s.respondWithDBError(w, r, err, "Failed to merge diseases", "disease_id", survivor, "duplicate_id", req.DuplicateID)
+				return
+			}
+			s.log(r).Info("Merged diseases", "disease_id", survivor, "duplicate_id", req.DuplicateID,
+				"instances_repointed", res.InstancesRepointed, "instances_collapsed", res.InstancesCollapsed)
+		}
+		respondWithJSON(w, http.StatusOK, res)
+	}
+}
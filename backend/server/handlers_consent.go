@@ -0,0 +1,248 @@
+// server/handlers_consent.go
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Consent scopes accepted by patient_consent.scope (see chk_pc_scope)
+const (
+	ConsentDataProcessing = "data_processing" // Processing of the patient's records (e.g. predictions)
+	ConsentModelTraining  = "model_training"  // Use of the patient's records for model training
+	ConsentContactSMS     = "contact_sms"     // Contacting the patient by SMS
+	ConsentContactEmail   = "contact_email"   // Contacting the patient by email
+)
+
+var consentScopes = map[string]bool{
+	ConsentDataProcessing: true,
+	ConsentModelTraining:  true,
+	ConsentContactSMS:     true,
+	ConsentContactEmail:   true,
+}
+
+// swagger:model GrantConsentRequest
+type GrantConsentRequest struct {
+	Scope string `json:"scope" validate:"required,oneof=data_processing model_training contact_sms contact_email" example:"data_processing"` // data_processing, model_training, contact_sms or contact_email
+}
+
+// swagger:model ConsentResponse
+type ConsentResponse struct {
+	ConsentID  int32            `json:"consent_id"`
+	PatientID  int32            `json:"patient_id"`
+	Scope      string           `json:"scope"`
+	Active     bool             `json:"active"`
+	GrantedAt  pgtype.Timestamp `json:"granted_at"`
+	RevokedAt  pgtype.Timestamp `json:"revoked_at"` // null while the consent is active
+	CapturedBy *int32           `json:"captured_by"` // User who recorded the consent; null for consents recorded before users were tracked
+	RevokedBy  *int32           `json:"revoked_by"`  // User who recorded the revocation
+}
+
+func consentResponseFromDB(c db.PatientConsent) ConsentResponse {
+	return ConsentResponse{
+		ConsentID:  c.ConsentID,
+		PatientID:  c.PatientID,
+		Scope:      c.Scope,
+		Active:     !c.RevokedAt.Valid,
+		GrantedAt:  c.GrantedAt,
+		RevokedAt:  c.RevokedAt,
+		CapturedBy: int32PtrFromPgtypeInt4(c.CapturedByUserID),
+		RevokedBy:  int32PtrFromPgtypeInt4(c.RevokedByUserID),
+	}
+}
+
+// swagger:model TrainingCaseResponse
+type TrainingCaseResponse struct {
+	PatientDiseaseID int32    `json:"patient_disease_id"`
+	DiseaseName      string   `json:"disease_name"`
+	ClinicalStatus   string   `json:"clinical_status"`
+	Symptoms         []string `json:"symptoms"` // Catalog names of the symptoms linked to the diagnosis
+}
+
+// requireConsent enforces a purpose-bound operation: it responds with 403 and
+// returns false when the patient holds no active consent for the scope.
+func (s *Server) requireConsent(w http.ResponseWriter, r *http.Request, patientID int32, scope string) bool {
+	ok, err := s.queries.PatientHasConsent(r.Context(), db.PatientHasConsentParams{
+		PatientID: patientID,
+		Scope:     scope,
+	})
+	if err != nil {
+		s.respondWithDBError(w, r, err, "Failed to check patient consent", "scope", scope, "patient_id", patientID)
+		return false
+	}
+	if !ok {
+		respondWithError(w, r, http.StatusForbidden, fmt.Sprintf("Patient %d has no active %s consent", patientID, scope))
+		return false
+	}
+	return true
+}
+
+// handleListConsentsForPatient godoc
+// @Summary      List consents for a patient
+// @Description  Get all active and revoked consents recorded for a patient, newest first.
+// @Tags         Consents
+// @Accept       json
+// @Produce      json
+// @Param        patientID path      int true "Patient ID" Format(int32)
+// @Success      200       {array}   ConsentResponse "Successfully retrieved consents"
+// @Failure      400       {object}  Problem "Invalid Patient ID format"
+// @Failure      404       {object}  Problem "Patient not found"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /patients/{patientID}/consents [get]
+func (s *Server) handleListConsentsForPatient() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		patientID, err := parseInt32Param(r, "patientID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
+			return
+		}
+
+		consents, err := s.queries.ListConsentsForPatient(r.Context(), patientID)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to list consents for patient", "patient_id", patientID)
+			return
+		}
+
+		responseConsents := make([]ConsentResponse, len(consents))
+		for i, c := range consents {
+			responseConsents[i] = consentResponseFromDB(c)
+		}
+		respondWithJSON(w, http.StatusOK, responseConsents)
+	}
+}
+
+// handleGrantConsent godoc
+// @Summary      Grant a consent
+// @Description  Record that the patient consented to a scope (data_processing, model_training, contact_sms, contact_email), The caller is recorded as the user who captured it.
+// @Tags         Consents
+// @Accept       json
+// @Produce      json
+// @Param        patientID path      int                 true "Patient ID" Format(int32)
+// @Param        consent   body      GrantConsentRequest true "Consent scope"
+// @Param        Idempotency-Key header string false "Unique key for this request; a retry with the same key replays the first response"
+// @Success      201       {object}  ConsentResponse "Consent recorded successfully"
+// @Failure      400       {object}  Problem "Invalid Patient ID or request payload"
+// @Failure      422       {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      404       {object}  Problem "Patient not found (FK constraint)"
+// @Failure      409       {object}  Problem "An active consent for this scope already exists"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /patients/{patientID}/consents [post]
+func (s *Server) handleGrantConsent() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		patientID, err := parseInt32Param(r, "patientID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
+			return
+		}
+
+		var req GrantConsentRequest
+		if !s.decodeAndValidate(w, r, &req) {
+			return
+		}
+
+		caller, _ := principalFromContext(r.Context())
+		consent, err := s.queries.GrantPatientConsent(r.Context(), db.GrantPatientConsentParams{
+			PatientID:        patientID,
+			Scope:            req.Scope,
+			CapturedByUserID: pgtype.Int4{Int32: caller.UserID, Valid: true},
+		})
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to record consent", "scope", req.Scope, "patient_id", patientID)
+			return
+		}
+
+		respondWithJSON(w, http.StatusCreated, consentResponseFromDB(consent))
+	}
+}
+
+// handleRevokeConsent godoc
+// @Summary      Revoke a consent
+// @Description  Mark an active consent as revoked. The record is kept so the consent history stays auditable, and the caller is recorded as the user who revoked it.
+// @Tags         Consents
+// @Accept       json
+// @Produce      json
+// @Param        patientID path      int                  true "Patient ID" Format(int32)
+// @Param        consentID path      int                  true "Consent ID" Format(int32)
+// @Param        Idempotency-Key header string false "Unique key for this request; a retry with the same key replays the first response"
+// @Success      200       {object}  ConsentResponse "Consent revoked successfully"
+// @Failure      400       {object}  Problem "Invalid ID format"
+// @Failure      404       {object}  Problem "No active consent with this ID for the patient"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /patients/{patientID}/consents/{consentID}/revoke [post]
+func (s *Server) handleRevokeConsent() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		patientID, err := parseInt32Param(r, "patientID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
+			return
+		}
+		consentID, err := parseInt32Param(r, "consentID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid consent ID: "+err.Error())
+			return
+		}
+
+		caller, _ := principalFromContext(r.Context())
+		consent, err := s.queries.RevokePatientConsent(r.Context(), db.RevokePatientConsentParams{
+			ConsentID:       consentID,
+			PatientID:       patientID,
+			RevokedByUserID: pgtype.Int4{Int32: caller.UserID, Valid: true},
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, r, http.StatusNotFound, "Active consent not found")
+			} else {
+				s.respondWithDBError(w, r, err, "Failed to revoke consent", "consent_id", consentID, "patient_id", patientID)
+			}
+			return
+		}
+
+		respondWithJSON(w, http.StatusOK, consentResponseFromDB(consent))
+	}
+}
+
+// handleListTrainingCases godoc
+// @Summary      Model training export
+// @Description  Administrator export of settled diagnoses (confirmed, resolved or chronic) with their linked symptoms, for training the prediction model. Only patients with an active model_training consent are included, and no patient identifiers are returned.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Success      200   {array}   TrainingCaseResponse "Training cases, oldest diagnosis first"
+// @Failure      403   {object}  Problem "Caller is not an administrator"
+// @Failure      429   {object}  Problem "Rate limit exceeded (see Retry-After)"
+// @Failure      500   {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /admin/training-cases [get]
+func (s *Server) handleListTrainingCases() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cases, err := s.queries.ListTrainingCases(r.Context())
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to list training cases")
+			return
+		}
+
+		responseCases := make([]TrainingCaseResponse, len(cases))
+		for i, c := range cases {
+			responseCases[i] = TrainingCaseResponse{
+				PatientDiseaseID: c.PatientDiseaseID,
+				DiseaseName:      c.DiseaseName,
+				ClinicalStatus:   c.ClinicalStatus,
+				Symptoms:         c.Symptoms,
+			}
+		}
+		respondWithJSON(w, http.StatusOK, responseCases)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
-	"log"
 	"net/http"
 
 	"github.com/dukunuu/munkhjin-diplom/backend/db"
@@ -14,17 +13,17 @@ import (
 
 // swagger:model CreateDiseaseRequest
 type CreateDiseaseRequest struct {
-	DiseaseName        string          `json:"disease_name"`
-	DiseaseCode        string          `json:"disease_code"`
-	DiseaseDescription *string         `json:"disease_description,omitempty"`
+	DiseaseName        string          `json:"disease_name" validate:"required,notblank,max=255"`
+	DiseaseCode        string          `json:"disease_code" validate:"required,notblank,max=255"`
+	DiseaseDescription *string         `json:"disease_description,omitempty" validate:"omitempty,max=10000"`
 	DiseaseTreatment   ArbitraryJSON 	 `json:"disease_treatment,omitempty"` // Accept raw JSON
 }
 
 // swagger:model UpdateDiseaseRequest
 type UpdateDiseaseRequest struct {
-	DiseaseName        string          `json:"disease_name"`
-	DiseaseCode        string          `json:"disease_code"`
-	DiseaseDescription *string         `json:"disease_description,omitempty"`
+	DiseaseName        string          `json:"disease_name" validate:"required,notblank,max=255"`
+	DiseaseCode        string          `json:"disease_code" validate:"required,notblank,max=255"`
+	DiseaseDescription *string         `json:"disease_description,omitempty" validate:"omitempty,max=10000"`
 	DiseaseTreatment   ArbitraryJSON   `json:"disease_treatment,omitempty"` // Accept raw JSON
 }
 
@@ -37,37 +36,110 @@ type DiseaseResponse struct {
 	DiseaseTreatment   ArbitraryJSON   `json:"disease_treatment"` // Send raw JSON back
 	CreatedAt          pgtype.Timestamp `json:"created_at"` // Or format as string
 	UpdatedAt          pgtype.Timestamp `json:"updated_at"` // Or format as string
+	Version            int32           `json:"version" example:"3"` // Also sent as the ETag; echo it in If-Match
+	DeprecatedAt       pgtype.Timestamp `json:"deprecated_at"`       // null unless retired from pickers
+	ReplacedBy         *int32           `json:"replaced_by"`         // Disease to use instead, if any
+}
+
+// newDiseaseResponse maps a disease to the API shape.
+func newDiseaseResponse(d db.Disease) DiseaseResponse {
+	return DiseaseResponse{
+		DiseaseID:          d.DiseaseID,
+		DiseaseName:        d.DiseaseName,
+		DiseaseCode:        d.DiseaseCode,
+		DiseaseDescription: stringPtrFromPgtypeText(d.DiseaseDescription),
+		DiseaseTreatment:   d.DiseaseTreatment,
+		CreatedAt:          d.CreatedAt,
+		UpdatedAt:          d.UpdatedAt,
+		Version:            d.Version,
+		DeprecatedAt:       d.DeprecatedAt,
+		ReplacedBy:         int32PtrFromPgtypeInt4(d.ReplacedBy),
+	}
+}
+
+// swagger:model DiseaseListItem
+type DiseaseListItem struct {
+	DiseaseResponse
+	UsageCount int32 `json:"usage_count" example:"17"` // Instances recorded of it, across all clinics
+}
+
+// swagger:model DiseasePage
+type DiseasePage struct {
+	Data []DiseaseListItem `json:"data"`
+	Page PageInfo          `json:"page"`
 }
 
 // handleListDiseases godoc
 // @Summary      List diseases
-// @Description  Get a list of all diseases
+// @Description  Get a page of the disease catalog, each with how many instances of it were recorded. Deprecated diseases are left out unless include_deprecated is set. q finds diseases whose name or description contains the text, whose code starts with it, or whose name holds a similar word (tolerating misspellings). Pages are cursor-based: pass page.next_cursor or page.prev_cursor back as cursor.
 // @Tags         Diseases
 // @Accept       json
 // @Produce      json
-// @Success      200 {array}   DiseaseResponse "Successfully retrieved list of diseases"
-// @Failure      500 {object}  HTTPError "Internal server error"
+// @Param        q       query     string  false  "Search text (at most 100 characters)"
+// @Param        sort    query     string  false  "Sort field, prefixed with - for descending; -usage lists the most common first" Enums(name, -name, code, -code, usage, -usage, id, -id) default(name)
+// @Param        limit   query     int     false  "Page size (at most 100)" default(10)
+// @Param        cursor  query     string  false  "Cursor from a previous page"
+// @Param        count   query     bool    false  "Include the total number of matching diseases"
+// @Param        include_deprecated query bool false "Also list deprecated diseases"
+// @Success      200 {object}  DiseasePage "A page of diseases"
+// @Header       200 {string}  Link "first, prev and next page URLs (RFC 8288)"
+// @Failure      400 {object}  Problem "Invalid query, sort, limit or cursor"
+// @Failure      429 {object}  Problem "Rate limit exceeded (see Retry-After)"
+// @Failure      500 {object}  Problem "Internal server error"
 // @Router       /diseases [get]
 func (s *Server) handleListDiseases() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		diseases, err := s.queries.ListDiseases(r.Context())
+		limit, ok := parsePageLimit(w, r)
+		if !ok {
+			return
+		}
+		field, desc, cursor, ok := parseSortAndCursor(w, r, db.DiseaseSortFields(), "name")
+		if !ok {
+			return
+		}
+		filter, ok := parseCatalogFilter(w, r)
+		if !ok {
+			return
+		}
+
+		diseases, err := s.queries.ListDiseasesPage(r.Context(), db.ListCatalogPageParams{
+			Filter:     filter,
+			PageParams: newPageParams(field, desc, limit, cursor),
+		})
 		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve diseases")
+			if errors.Is(err, db.ErrInvalidPageKey) {
+				respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidCursor, Field: "cursor", Detail: "The cursor is not valid; start again from the first page"})
+				return
+			}
+			s.respondWithDBError(w, r, err, "Failed to retrieve diseases")
 			return
 		}
-		responseDiseases := make([]DiseaseResponse, len(diseases))
+
+		page := PageInfo{Limit: limit, Sort: sortParam(field, desc)}
+		keys := make([][]string, len(diseases))
 		for i, d := range diseases {
-			responseDiseases[i] = DiseaseResponse{
-				DiseaseID:          d.DiseaseID,
-				DiseaseName:        d.DiseaseName,
-				DiseaseCode:        d.DiseaseCode,
-				DiseaseDescription: stringPtrFromPgtypeText(d.DiseaseDescription),
-				DiseaseTreatment:   d.DiseaseTreatment, // Pass raw bytes
-				CreatedAt:          d.CreatedAt,
-				UpdatedAt:          d.UpdatedAt,
+			keys[i] = db.DiseaseSortKeyValues(d, field)
+		}
+		from, to := pageCursors(&page, cursor, field, desc, keys)
+
+		if r.URL.Query().Get("count") == "true" {
+			total, err := s.queries.CountDiseases(r.Context(), filter)
+			if err != nil {
+				s.respondWithDBError(w, r, err, "Failed to count diseases")
+				return
 			}
+			page.Total = &total
 		}
-		respondWithJSON(w, http.StatusOK, responseDiseases)
+
+		response := DiseasePage{Data: make([]DiseaseListItem, 0, to-from), Page: page}
+		for _, d := range diseases[from:to] {
+			response.Data = append(response.Data, DiseaseListItem{
+				DiseaseResponse: newDiseaseResponse(d.Disease),
+				UsageCount: d.UsageCount,
+			})
+		}
+		setPageLinks(w, r, page)
+		respondWithJSON(w, http.StatusOK, response)
 	}
 }
 
@@ -79,20 +151,15 @@ func (s *Server) handleListDiseases() http.HandlerFunc {
 // @Produce      json
 // @Param        disease body      CreateDiseaseRequest true "Disease data to create"
 // @Success      201     {object}  DiseaseResponse "Disease created successfully"
-// @Failure      400     {object}  HTTPError "Invalid request payload or validation error"
-// @Failure      500     {object}  HTTPError "Internal server error"
+// @Header       201     {string}  ETag "Version to send in If-Match when changing it"
+// @Failure      400     {object}  Problem "Malformed JSON, wrong type or unknown field"
+// @Failure      422     {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      500     {object}  Problem "Internal server error"
 // @Router       /diseases [post]
 func (s *Server) handleCreateDisease() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req CreateDiseaseRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
-			return
-		}
-		defer r.Body.Close()
-
-		if req.DiseaseName == "" || req.DiseaseCode == "" {
-			respondWithError(w, http.StatusBadRequest, "Missing required fields: disease_name, disease_code")
+		if !s.decodeAndValidate(w, r, &req) {
 			return
 		}
 
@@ -100,7 +167,7 @@ func (s *Server) handleCreateDisease() http.HandlerFunc {
 		var treatmentBytes []byte
 		if len(req.DiseaseTreatment) > 0 {
 			if !json.Valid(req.DiseaseTreatment) {
-				respondWithError(w, http.StatusBadRequest, "Invalid JSON format for disease_treatment")
+				respondWithError(w, r, http.StatusBadRequest, "Invalid JSON format for disease_treatment")
 				return
 			}
 			treatmentBytes = req.DiseaseTreatment
@@ -115,21 +182,12 @@ func (s *Server) handleCreateDisease() http.HandlerFunc {
 
 		newDisease, err := s.queries.CreateDisease(r.Context(), params)
 		if err != nil {
-			// TODO: Check unique constraints
-			log.Printf("Error creating disease: %v", err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to create disease")
+			s.respondWithDBError(w, r, err, "Failed to create disease")
 			return
 		}
 
-		responseDisease := DiseaseResponse{
-			DiseaseID:          newDisease.DiseaseID,
-			DiseaseName:        newDisease.DiseaseName,
-			DiseaseCode:        newDisease.DiseaseCode,
-			DiseaseDescription: stringPtrFromPgtypeText(newDisease.DiseaseDescription),
-			DiseaseTreatment:   newDisease.DiseaseTreatment,
-			CreatedAt:          newDisease.CreatedAt,
-			UpdatedAt:          newDisease.UpdatedAt,
-		}
+		responseDisease := newDiseaseResponse(newDisease)
+		setETag(w, newDisease.Version)
 		respondWithJSON(w, http.StatusCreated, responseDisease)
 	}
 }
@@ -142,37 +200,33 @@ func (s *Server) handleCreateDisease() http.HandlerFunc {
 // @Produce      json
 // @Param        diseaseID path      int true "Disease ID" Format(int32)
 // @Success      200       {object}  DiseaseResponse "Successfully retrieved disease"
-// @Failure      400       {object}  HTTPError "Invalid Disease ID format"
-// @Failure      404       {object}  HTTPError "Disease not found"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Header       200       {string}  ETag "Version to send in If-Match when changing it"
+// @Success      304       {string}  string "Not Modified (If-None-Match lists the current version)"
+// @Failure      400       {object}  Problem "Invalid Disease ID format"
+// @Failure      404       {object}  Problem "Disease not found"
+// @Failure      500       {object}  Problem "Internal server error"
 // @Router       /diseases/{diseaseID} [get]
 func (s *Server) handleGetDiseaseByID() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		diseaseID, err := parseInt32Param(r, "diseaseID")
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, err.Error())
+			respondWithError(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		disease, err := s.queries.GetDiseaseByID(r.Context(), diseaseID)
 		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
-				respondWithError(w, http.StatusNotFound, "Disease not found")
+				respondWithError(w, r, http.StatusNotFound, "Disease not found")
 			} else {
-				log.Printf("Error retrieving disease %d: %v", diseaseID, err)
-				respondWithError(w, http.StatusInternalServerError, "Failed to retrieve disease")
+				s.respondWithDBError(w, r, err, "Failed to retrieve disease", "disease_id", diseaseID)
 			}
 			return
 		}
 
-		responseDisease := DiseaseResponse{
-			DiseaseID:          disease.DiseaseID,
-			DiseaseName:        disease.DiseaseName,
-			DiseaseCode:        disease.DiseaseCode,
-			DiseaseDescription: stringPtrFromPgtypeText(disease.DiseaseDescription),
-			DiseaseTreatment:   disease.DiseaseTreatment,
-			CreatedAt:          disease.CreatedAt,
-			UpdatedAt:          disease.UpdatedAt,
+		responseDisease := newDiseaseResponse(disease)
+		if notModified(w, r, disease.Version) {
+			return
 		}
 		respondWithJSON(w, http.StatusOK, responseDisease)
 	}
@@ -180,105 +234,198 @@ func (s *Server) handleGetDiseaseByID() http.HandlerFunc {
 
 // handleUpdateDisease godoc
 // @Summary      Update disease details
-// @Description  Update details for an existing disease
+// @Description  Replace the details of an existing disease. If-Match must carry the ETag from a previous read.
 // @Tags         Diseases
 // @Accept       json
 // @Produce      json
 // @Param        diseaseID path      int                true "Disease ID" Format(int32)
+// @Param        If-Match  header    string             true "ETag of the version being replaced"
 // @Param        disease   body      UpdateDiseaseRequest true "Disease data to update"
 // @Success      200       {object}  DiseaseResponse "Disease updated successfully"
-// @Failure      400       {object}  HTTPError "Invalid request payload or validation error"
-// @Failure      404       {object}  HTTPError "Disease not found"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Header       200       {string}  ETag "Version of the updated disease"
+// @Failure      400       {object}  Problem "Malformed JSON, wrong type or unknown field"
+// @Failure      422       {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      404       {object}  Problem "Disease not found"
+// @Failure      412       {object}  Problem "The disease changed since the ETag in If-Match was read"
+// @Failure      428       {object}  Problem "If-Match is missing"
+// @Failure      500       {object}  Problem "Internal server error"
 // @Router       /diseases/{diseaseID} [put]
 func (s *Server) handleUpdateDisease() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		diseaseID, err := parseInt32Param(r, "diseaseID")
-		if err != nil {
-			respondWithError(w, http.StatusBadRequest, err.Error())
+		current, ok := s.diseaseForWrite(w, r)
+		if !ok {
 			return
 		}
 
 		var req UpdateDiseaseRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		if !s.decodeAndValidate(w, r, &req) {
 			return
 		}
-		defer r.Body.Close()
+		s.updateDisease(w, r, current, req)
+	}
+}
 
-		if req.DiseaseName == "" || req.DiseaseCode == "" {
-			respondWithError(w, http.StatusBadRequest, "Missing required fields: disease_name, disease_code")
+// handlePatchDisease godoc
+// @Summary      Partially update a disease
+// @Description  Apply a JSON Merge Patch (RFC 7396) to a disease. disease_treatment is merged member by member; null clears a member, or the description or whole treatment. If-Match must carry the ETag from a previous read.
+// @Tags         Diseases
+// @Accept       application/merge-patch+json
+// @Produce      json
+// @Param        diseaseID path      int                true "Disease ID" Format(int32)
+// @Param        If-Match  header    string             true "ETag of the version being changed"
+// @Param        patch     body      UpdateDiseaseRequest true "Members to change"
+// @Success      200       {object}  DiseaseResponse "Disease updated successfully"
+// @Header       200       {string}  ETag "Version of the updated disease"
+// @Failure      400       {object}  Problem "Malformed JSON, wrong type or unknown field"
+// @Failure      422       {object}  Problem "Patched disease failed validation; every violation is listed in errors"
+// @Failure      404       {object}  Problem "Disease not found"
+// @Failure      412       {object}  Problem "The disease changed since the ETag in If-Match was read"
+// @Failure      415       {object}  Problem "Body is not application/merge-patch+json"
+// @Failure      428       {object}  Problem "If-Match is missing"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Router       /diseases/{diseaseID} [patch]
+func (s *Server) handlePatchDisease() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		current, ok := s.diseaseForWrite(w, r)
+		if !ok {
 			return
 		}
 
-		var treatmentBytes []byte
-		if len(req.DiseaseTreatment) > 0 {
-			if !json.Valid(req.DiseaseTreatment) {
-				respondWithError(w, http.StatusBadRequest, "Invalid JSON format for disease_treatment")
-				return
-			}
-			treatmentBytes = req.DiseaseTreatment
+		var req UpdateDiseaseRequest
+		if !s.decodeMergePatch(w, r, UpdateDiseaseRequest{
+			DiseaseName:        current.DiseaseName,
+			DiseaseCode:        current.DiseaseCode,
+			DiseaseDescription: stringPtrFromPgtypeText(current.DiseaseDescription),
+			DiseaseTreatment:   current.DiseaseTreatment,
+		}, &req) {
+			return
 		}
+		s.updateDisease(w, r, current, req)
+	}
+}
 
-		params := db.UpdateDiseaseParams{
-			DiseaseID:          diseaseID,
-			DiseaseName:        req.DiseaseName,
-			DiseaseCode:        req.DiseaseCode,
-			DiseaseDescription: pgtypeText(req.DiseaseDescription),
-			DiseaseTreatment:   treatmentBytes,
+// diseaseForWrite loads the disease named in the path and checks If-Match
+// against its version. It writes the error response and returns false on failure.
+func (s *Server) diseaseForWrite(w http.ResponseWriter, r *http.Request) (db.Disease, bool) {
+	diseaseID, err := parseInt32Param(r, "diseaseID")
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error())
+		return db.Disease{}, false
+	}
+	current, err := s.queries.GetDiseaseByID(r.Context(), diseaseID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, r, http.StatusNotFound, "Disease not found")
+		} else {
+			s.respondWithDBError(w, r, err, "Failed to retrieve disease", "disease_id", diseaseID)
 		}
+		return db.Disease{}, false
+	}
+	if !checkIfMatch(w, r, current.Version) {
+		return db.Disease{}, false
+	}
+	return current, true
+}
 
-		updatedDisease, err := s.queries.UpdateDisease(r.Context(), params)
-		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
-				respondWithError(w, http.StatusNotFound, "Disease not found")
-			} else {
-				// TODO: Check unique constraints
-				log.Printf("Error updating disease %d: %v", diseaseID, err)
-				respondWithError(w, http.StatusInternalServerError, "Failed to update disease")
-			}
+// updateDisease stores a validated update request over the version in current.
+func (s *Server) updateDisease(w http.ResponseWriter, r *http.Request, current db.Disease, req UpdateDiseaseRequest) {
+	var treatmentBytes []byte
+	if len(req.DiseaseTreatment) > 0 {
+		if !json.Valid(req.DiseaseTreatment) {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid JSON format for disease_treatment")
 			return
 		}
+		treatmentBytes = req.DiseaseTreatment
+	}
 
-		responseDisease := DiseaseResponse{
-			DiseaseID:          updatedDisease.DiseaseID,
-			DiseaseName:        updatedDisease.DiseaseName,
-			DiseaseCode:        updatedDisease.DiseaseCode,
-			DiseaseDescription: stringPtrFromPgtypeText(updatedDisease.DiseaseDescription),
-			DiseaseTreatment:   updatedDisease.DiseaseTreatment,
-			CreatedAt:          updatedDisease.CreatedAt,
-			UpdatedAt:          updatedDisease.UpdatedAt,
+	params := db.UpdateDiseaseParams{
+		DiseaseID:          current.DiseaseID,
+		DiseaseName:        req.DiseaseName,
+		DiseaseCode:        req.DiseaseCode,
+		DiseaseDescription: pgtypeText(req.DiseaseDescription),
+		DiseaseTreatment:   treatmentBytes,
+		Version:            current.Version,
+	}
+
+	updatedDisease, err := s.queries.UpdateDisease(r.Context(), params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+			// The row existed a moment ago, so another write got in first
+			respondWithStaleVersion(w, r, 0)
+		} else {
+			s.respondWithDBError(w, r, err, "Failed to update disease", "disease_id", current.DiseaseID)
 		}
-		respondWithJSON(w, http.StatusOK, responseDisease)
+		return
 	}
+
+	responseDisease := newDiseaseResponse(updatedDisease)
+	setETag(w, updatedDisease.Version)
+	respondWithJSON(w, http.StatusOK, responseDisease)
 }
 
 // handleDeleteDisease godoc
 // @Summary      Delete disease
-// @Description  Delete a disease record by ID
+// @Description  Delete a disease that no patient record uses, such as one created by mistake. While any disease instance (in any clinic) still records it, the delete is refused with their number in references; deprecate it instead.
 // @Tags         Diseases
 // @Accept       json
 // @Produce      json
-// @Param        diseaseID path      int true "Disease ID" Format(int32)
+// @Param        diseaseID path      int    true "Disease ID" Format(int32)
+// @Param        If-Match  header    string true "ETag of the version being deleted"
 // @Success      204       {string}  string "No Content (Successful deletion)"
-// @Failure      400       {object}  HTTPError "Invalid Disease ID format"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Failure      400       {object}  Problem "Invalid Disease ID format"
+// @Failure      404       {object}  Problem "Disease not found"
+// @Failure      409       {object}  Problem "Patient records still use the disease; counted in references"
+// @Failure      412       {object}  Problem "The disease changed since the ETag in If-Match was read"
+// @Failure      428       {object}  Problem "If-Match is missing"
+// @Failure      500       {object}  Problem "Internal server error"
 // @Router       /diseases/{diseaseID} [delete]
 func (s *Server) handleDeleteDisease() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		diseaseID, err := parseInt32Param(r, "diseaseID")
+		current, ok := s.diseaseForWrite(w, r)
+		if !ok {
+			return
+		}
+		diseaseID := current.DiseaseID
+
+		// Records of every clinic count (count_disease_references)
+		tx, err := s.pool.Begin(r.Context())
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to delete disease", "disease_id", diseaseID)
+			return
+		}
+		defer tx.Rollback(r.Context())
+		qtx := s.queries.WithTx(tx)
+
+		instances, err := qtx.CountDiseaseReferences(r.Context(), diseaseID)
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, err.Error())
+			s.respondWithDBError(w, r, err, "Failed to delete disease", "disease_id", diseaseID)
+			return
+		}
+		if instances > 0 {
+			respondWithProblem(w, r, &APIError{
+				Status:     http.StatusConflict,
+				Code:       CodeStillReferenced,
+				Detail:     "Patient records still use this disease; deprecate it instead",
+				References: map[string]int64{"disease_instances": instances},
+			})
 			return
 		}
 
-		err = s.queries.DeleteDisease(r.Context(), diseaseID)
+		// An instance recorded since the count still fails the delete, via fk_pd_disease
+		deleted, err := qtx.DeleteDisease(r.Context(), db.DeleteDiseaseParams{DiseaseID: diseaseID, Version: current.Version})
 		if err != nil {
-			log.Printf("Error deleting disease %d: %v", diseaseID, err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to delete disease")
+			s.respondWithDBError(w, r, err, "Failed to delete disease", "disease_id", diseaseID)
+			return
+		}
+		if deleted == 0 {
+			// The row existed a moment ago, so another write got in first
+			respondWithStaleVersion(w, r, 0)
+			return
+		}
+		if err := tx.Commit(r.Context()); err != nil {
+			s.respondWithDBError(w, r, err, "Failed to delete disease", "disease_id", diseaseID)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
-
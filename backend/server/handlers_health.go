@@ -0,0 +1,201 @@
+// server/handlers_health.go
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/db"
+)
+
+// Check and overall statuses reported by /livez and /readyz
+const (
+	healthOK   = "ok"
+	healthFail = "fail"
+)
+
+// swagger:model LivenessResponse
+type LivenessResponse struct {
+	Status string `json:"status" example:"ok"`
+}
+
+// swagger:model HealthCheck
+type HealthCheck struct {
+	Status     string  `json:"status" example:"ok"` // ok or fail
+	DurationMs float64 `json:"duration_ms" example:"1.7"`
+	Error      string  `json:"error,omitempty"`
+	Detail     any     `json:"detail,omitempty"` // Check-specific facts, e.g. the migration version
+}
+
+// swagger:model ReadinessResponse
+type ReadinessResponse struct {
+	Status    string                 `json:"status" example:"ok"` // ok only when every check is ok
+	CheckedAt time.Time              `json:"checked_at"`
+	Checks    map[string]HealthCheck `json:"checks"`
+}
+
+// readiness caches the last readiness result so frequent probes do not each
+// hit Postgres and the model service.
+type readiness struct {
+	mu     sync.Mutex
+	result *ReadinessResponse
+}
+
+// modelStatusURL is the model service's status endpoint ("/"), next to /predict.
+func modelStatusURL(predictURL string) (string, error) {
+	u, err := url.Parse(predictURL)
+	if err != nil {
+		return "", err
+	}
+	return u.ResolveReference(&url.URL{Path: "/"}).String(), nil
+}
+
+// runCheck times fn under the per-check deadline.
+func (s *Server) runCheck(ctx context.Context, fn func(ctx context.Context) (any, error)) HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Readiness_Check_Timeout)
+	defer cancel()
+
+	start := time.Now()
+	detail, err := fn(ctx)
+	check := HealthCheck{Status: healthOK, DurationMs: millis(time.Since(start)), Detail: detail}
+	if err != nil {
+		check.Status = healthFail
+		check.Error = err.Error()
+	}
+	return check
+}
+
+func (s *Server) checkDatabase(ctx context.Context) (any, error) {
+	return nil, s.pool.Ping(ctx)
+}
+
+func (s *Server) checkMigrations(ctx context.Context) (any, error) {
+	expected := db.ExpectedMigrationVersion()
+	version, dirty, err := db.MigrationVersion(ctx, s.pool)
+	if err != nil {
+		return nil, err
+	}
+	detail := map[string]any{"version": version, "expected": expected, "dirty": dirty}
+	if dirty {
+		return detail, fmt.Errorf("migration %d failed and left the schema dirty", version)
+	}
+	if version != expected {
+		return detail, fmt.Errorf("schema is at version %d, this build expects %d", version, expected)
+	}
+	return detail, nil
+}
+
+// checkModelService calls the model service's status endpoint, which reports
+// whether the model, features and label encoder were loaded.
+func (s *Server) checkModelService(ctx context.Context) (any, error) {
+	statusURL, err := modelStatusURL(s.modelUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MODEL_URL: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, statusURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("model service returned %d", resp.StatusCode)
+	}
+
+	var status struct {
+		ModelStatus string `json:"model_status"`
+		ModelLoaded *bool  `json:"model_loaded"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("unreadable model service status: %w", err)
+	}
+	detail := map[string]any{"model_status": status.ModelStatus}
+	loaded := strings.Contains(status.ModelStatus, "loaded successfully") // Older model services only send the text
+	if status.ModelLoaded != nil {
+		loaded = *status.ModelLoaded
+	}
+	if !loaded {
+		return detail, fmt.Errorf("model artifacts are not loaded")
+	}
+	return detail, nil
+}
+
+// checkReadiness runs every check concurrently, or returns the cached result
+// while it is younger than the cache TTL.
+func (s *Server) checkReadiness(ctx context.Context) ReadinessResponse {
+	s.readiness.mu.Lock()
+	defer s.readiness.mu.Unlock()
+	if cached := s.readiness.result; cached != nil && time.Since(cached.CheckedAt) < s.config.Readiness_Cache_TTL {
+		return *cached
+	}
+
+	checks := map[string]func(context.Context) (any, error){
+		"database":      s.checkDatabase,
+		"migrations":    s.checkMigrations,
+		"model_service": s.checkModelService,
+	}
+	result := ReadinessResponse{Status: healthOK, CheckedAt: time.Now(), Checks: make(map[string]HealthCheck, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, fn := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			check := s.runCheck(ctx, fn)
+			mu.Lock()
+			result.Checks[name] = check
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for _, check := range result.Checks {
+		if check.Status != healthOK {
+			result.Status = healthFail
+		}
+	}
+	s.readiness.result = &result
+	return result
+}
+
+// handleLivez godoc
+// @Summary      Liveness probe
+// @Description  Reports that the process is up and serving. It checks no dependencies, so a failing database does not get the backend restarted.
+// @Tags         Health
+// @Produce      json
+// @Success      200 {object} LivenessResponse "Process is alive"
+// @Router       /livez [get]
+func (s *Server) handleLivez() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		respondWithJSON(w, http.StatusOK, LivenessResponse{Status: healthOK})
+	}
+}
+
+// handleReadyz godoc
+// @Summary      Readiness probe
+// @Description  Checks the database connection, that the schema is at the migration version this build expects, and that the model service is reachable and has loaded its model. Each check reports its duration; results are cached for a few seconds.
+// @Tags         Health
+// @Produce      json
+// @Success      200 {object} ReadinessResponse "All checks passed"
+// @Failure      503 {object} ReadinessResponse "At least one check failed"
+// @Router       /readyz [get]
+func (s *Server) handleReadyz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Checks outlive a probe that gives up, so the cached result stays useful
+		result := s.checkReadiness(context.WithoutCancel(r.Context()))
+		code := http.StatusOK
+		if result.Status != healthOK {
+			code = http.StatusServiceUnavailable
+		}
+		respondWithJSON(w, code, result)
+	}
+}
@@ -2,32 +2,93 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
-	"log"
+	"maps"
 	"net/http"
+	"slices"
+	"strconv"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type PredictRequest struct {
-	KnownSymptoms any `json:"known_symptoms" binding:"required" example:"{\"feature1\": 10.5, \"feature2\": 2.3}"`
+	KnownSymptoms any   `json:"known_symptoms" validate:"required" example:"{\"feature1\": 10.5, \"feature2\": 2.3}"`
+	PatientID     int32 `json:"patient_id" validate:"required,gt=0" example:"123"` // The patient whose data is processed; requires access and data_processing consent
+}
+
+// modelPredictRequest is the body sent to the model service. It carries only
+// the symptoms: the model has no use for the patient ID, so it never sees it.
+type modelPredictRequest struct {
+	KnownSymptoms any `json:"known_symptoms"`
 }
 
 type PredictResponse struct {
 	Predictions []string `json:"predictions" example:"[\"ClassA\", \"ClassB\"]"`
 }
 
+// canonicalSymptomNames renames the known_symptoms keys that are symptom
+// aliases to the symptom's name, matching case-insensitively. A value given
+// under the canonical name itself wins over one given under an alias. Keys
+// naming no symptom are passed on as they are.
+func (s *Server) canonicalSymptomNames(ctx context.Context, known map[string]any) (map[string]any, bool, error) {
+	names := make([]string, 0, len(known))
+	for name := range known {
+		names = append(names, name)
+	}
+	resolved, err := s.queries.ResolveSymptomNames(ctx, names)
+	if err != nil {
+		return nil, false, err
+	}
+	rename := make(map[string]string, len(resolved))
+	for _, row := range resolved {
+		if row.Name != row.SymptomName {
+			rename[row.Name] = row.SymptomName
+		}
+	}
+	if len(rename) == 0 {
+		return known, false, nil
+	}
+
+	canonical := make(map[string]any, len(known))
+	for name, value := range known {
+		if _, isAlias := rename[name]; !isAlias {
+			canonical[name] = value
+		}
+	}
+	for _, alias := range slices.Sorted(maps.Keys(rename)) { // Sorted so the same request always resolves alike
+		if _, taken := canonical[rename[alias]]; !taken {
+			canonical[rename[alias]] = known[alias]
+		}
+	}
+	return canonical, true, nil
+}
+
 // predictHandler creates the HTTP handler function for proxying predictions.
 // @Summary      Proxy Prediction Request
-// @Description  Accepts feature data in JSON format, forwards it to the configured Flask ML service, and returns the prediction result. The 'features' field in the request body can be a single JSON object or an array of JSON objects.
+// @Description  Accepts feature data in JSON format, forwards it to the configured Flask ML service, and returns the prediction result. The 'features' field in the request body can be a single JSON object or an array of JSON objects. When known_symptoms is an object keyed by symptom name, keys that are symptom aliases are renamed to the symptom's catalog name first. patient_id names the patient the prediction is for: the caller must be on their care team (or hold a break-glass grant) and the patient must have an active data_processing consent.
 // @Tags         predictions
 // @Accept       json
 // @Produce      json
 // @Param        request body PredictRequest true "Prediction Request Features (single object or array of objects)"
+// @Param        Idempotency-Key header string false "Unique key for this request; a retry with the same key replays the first response"
 // @Success      200  {object}  PredictResponse  "Successful prediction response (forwarded from Flask)"
-// @Failure      400  {object}  HTTPError    "Bad Request - Invalid JSON format or missing 'features' key"
-// @Failure      500  {object}  HTTPError    "Internal Server Error - Error during proxy processing or creating request"
-// @Failure      502  {object}  HTTPError    "Bad Gateway - Failed to contact or get a valid response from the backend Flask service"
+// @Failure      400  {object}  Problem    "Bad Request - Invalid JSON format or unknown field"
+// @Failure      413  {object}  Problem    "Payload Too Large - body exceeds MAX_BODY_BYTES"
+// @Failure      422  {object}  Problem    "Unprocessable Entity - known_symptoms or patient_id missing or invalid"
+// @Failure      403  {object}  Problem    "Forbidden - not on the patient's care team, or the patient has no active data_processing consent"
+// @Failure      404  {object}  Problem    "Not Found - patient does not exist"
+// @Failure      429  {object}  Problem    "Too Many Requests - predict budget exhausted (see Retry-After)"
+// @Failure      500  {object}  Problem    "Internal Server Error - Error during proxy processing or creating request"
+// @Failure      502  {object}  Problem    "Bad Gateway - Failed to contact or get a valid response from the backend Flask service"
+// @Security     UserHeader
+// @Security     BearerToken
 // @Router       /predict [post]
 func (s *Server) predictHandler(flaskPredictURL string) (http.HandlerFunc) {
 	client := &http.Client{
@@ -36,51 +97,90 @@ func (s *Server) predictHandler(flaskPredictURL string) (http.HandlerFunc) {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		var requestPayload PredictRequest
+		r.Body = http.MaxBytesReader(w, r.Body, s.config.Max_Body_Bytes)
 		bodyBytes, err := io.ReadAll(r.Body)
+		defer r.Body.Close() // Close the original request body
 		if err != nil {
-			log.Printf("Error reading request body: %v", err)
-			http.Error(w, "Error reading request body", http.StatusInternalServerError)
+			s.log(r).Warn("Error reading prediction request body", "err", err)
+			respondWithProblem(w, r, decodeError(err))
 			return
 		}
-		defer r.Body.Close() // Close the original request body
 
-		if err := json.Unmarshal(bodyBytes, &requestPayload); err != nil {
-			log.Printf("Error decoding request JSON: %v. Body: %s", err, string(bodyBytes))
-			respondWithError(w, http.StatusBadRequest, "Could not read body")
+		if err := decodeStrict(bytes.NewReader(bodyBytes), &requestPayload); err != nil {
+			s.log(r).Warn("Error decoding prediction request", "err", err, "body_bytes", len(bodyBytes)) // Never the body itself
+			respondWithProblem(w, r, decodeError(err))
+			return
+		}
+		if apiErr := validationError(&requestPayload); apiErr != nil {
+			respondWithProblem(w, r, apiErr)
 			return
 		}
 
-		if requestPayload.KnownSymptoms == nil {
-			log.Printf("Missing 'known_symptoms' key in request. Body: %s", string(bodyBytes))
-			respondWithError(w, http.StatusBadRequest, "Could not read known_symptoms in the request body.")
+		// Predictions process the patient's data: the caller needs access to the
+		// patient before the consent is looked at, so its status does not leak
+		if _, ok := s.checkPatientAccess(w, r, requestPayload.PatientID); !ok {
 			return
 		}
+		if !s.requireConsent(w, r, requestPayload.PatientID, ConsentDataProcessing) {
+			return
+		}
+
+		// The model knows symptoms by their catalog names; map aliases onto them
+		if known, ok := requestPayload.KnownSymptoms.(map[string]any); ok {
+			canonical, changed, err := s.canonicalSymptomNames(r.Context(), known)
+			if err != nil {
+				s.respondWithDBError(w, r, err, "Failed to resolve symptom names")
+				return
+			}
+			if changed {
+				requestPayload.KnownSymptoms = canonical
+			}
+		}
+		modelBody, _ := json.Marshal(modelPredictRequest{KnownSymptoms: requestPayload.KnownSymptoms}) // Decoded from JSON: cannot fail
 
-		flaskReq, err := http.NewRequest(http.MethodPost, flaskPredictURL, bytes.NewBuffer(bodyBytes))
+		// Span for the model call; its traceparent lets the model service join the trace
+		ctx, span := tracer.Start(r.Context(), "model.predict",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.URLFull(flaskPredictURL)),
+		)
+		defer span.End()
+
+		flaskReq, err := http.NewRequestWithContext(ctx, http.MethodPost, flaskPredictURL, bytes.NewBuffer(modelBody))
 		if err != nil {
-			log.Printf("Error creating request to Flask: %v", err)
-			respondWithError(w, http.StatusInternalServerError, "Could not create request.")
+			s.log(r).Error("Error creating request to Flask", "err", err)
+			respondWithError(w, r, http.StatusInternalServerError, "Could not create request.")
 			return
 		}
 		flaskReq.Header.Set("Content-Type", "application/json")
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(flaskReq.Header))
 
-		log.Printf("Forwarding request to %s", flaskPredictURL)
+		s.log(r).Debug("Forwarding prediction request", "model_url", flaskPredictURL)
+		modelStart := time.Now()
 		flaskResp, err := client.Do(flaskReq)
 		if err != nil {
-			log.Printf("Error sending request to Flask: %v", err)
-			respondWithError(w, http.StatusBadGateway, "Error sending request to Model service.")
+			s.metrics.modelDuration.WithLabelValues("error").Observe(time.Since(modelStart).Seconds())
+			s.metrics.modelErrors.WithLabelValues("unreachable").Inc()
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "model service unreachable")
+			s.log(r).Error("Error sending request to Flask", "err", err)
+			respondWithError(w, r, http.StatusBadGateway, "Error sending request to Model service.")
 			return
 		}
 		defer flaskResp.Body.Close()
 
 		flaskRespBodyBytes, err := io.ReadAll(flaskResp.Body)
+		modelLatency := time.Since(modelStart)
+		span.SetAttributes(semconv.HTTPResponseStatusCode(flaskResp.StatusCode))
+		s.metrics.modelDuration.WithLabelValues(strconv.Itoa(flaskResp.StatusCode)).Observe(modelLatency.Seconds())
 		if err != nil {
-			log.Printf("Error reading Flask response body: %v", err)
-			http.Error(w, "Error reading prediction service response", http.StatusInternalServerError)
+			s.metrics.modelErrors.WithLabelValues("read").Inc()
+			s.log(r).Error("Error reading Flask response body", "err", err)
+			respondWithError(w, r, http.StatusBadGateway, "Error reading prediction service response")
 			return
 		}
 
-		log.Printf("Received response from Flask - Status: %d, Body: %s", flaskResp.StatusCode, string(flaskRespBodyBytes))
+		// Predictions are patient data: log the outcome, not the payload
+		s.log(r).Info("Received response from model service", "model_status", flaskResp.StatusCode, "model_latency_ms", millis(modelLatency), "body_bytes", len(flaskRespBodyBytes))
 
 		contentType := flaskResp.Header.Get("Content-Type")
 		if contentType == "" {
@@ -88,11 +188,18 @@ func (s *Server) predictHandler(flaskPredictURL string) (http.HandlerFunc) {
 		}
 		w.Header().Set("Content-Type", contentType)
 
+		if flaskResp.StatusCode == http.StatusOK {
+			s.metrics.predictionsServed.Inc()
+		} else {
+			s.metrics.modelErrors.WithLabelValues("status").Inc()
+			span.SetStatus(codes.Error, "model service returned "+strconv.Itoa(flaskResp.StatusCode))
+		}
+
 		w.WriteHeader(flaskResp.StatusCode)
 
 		_, err = w.Write(flaskRespBodyBytes)
 		if err != nil {
-			log.Printf("Error writing response to client: %v", err)
+			s.log(r).Error("Error writing response to client", "err", err)
 		}
 	}
 }
@@ -2,17 +2,19 @@
 package server
 
 import (
+	"context"
 	"database/sql" // Required for sql.ErrNoRows check alongside pgx.ErrNoRows
-	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/dukunuu/munkhjin-diplom/backend/db" // Adjust import path if needed
 	"github.com/jackc/pgx/v5"                     // For pgx.ErrNoRows
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -20,28 +22,28 @@ import (
 
 // swagger:model CreatePatientRequest
 type CreatePatientRequest struct {
-	Firstname   string  `json:"firstname"`
-	Lastname    string  `json:"lastname"`
-	Register    string  `json:"register"`
-	Age         int32   `json:"age"`
-	Gender      string  `json:"gender"`
-	Birthdate   string  `json:"birthdate"` // Expect YYYY-MM-DD string
-	Address     *string `json:"address,omitempty"` // Use pointer for optional field
-	Phonenumber string  `json:"phonenumber"`
-	Email       string  `json:"email"`
+	Firstname   string  `json:"firstname" validate:"required,notblank,max=255"`
+	Lastname    string  `json:"lastname" validate:"required,notblank,max=255"`
+	Register    string  `json:"register" validate:"required,register" example:"УБ99032215"` // Mongolian civil register number
+	Age         *int32  `json:"age,omitempty" swaggerignore:"true"` // Deprecated: ignored, age is computed from birthdate
+	Gender      string  `json:"gender,omitempty" validate:"omitempty,oneof=Male Female Other"` // Derived from the register when omitted
+	Birthdate   string  `json:"birthdate,omitempty" validate:"omitempty,datetime=2006-01-02,pastdate"` // YYYY-MM-DD; derived from the register when omitted
+	Address     *string `json:"address,omitempty" validate:"omitempty,max=255"` // Use pointer for optional field
+	Phonenumber string  `json:"phonenumber" validate:"required,phone,max=255"`
+	Email       string  `json:"email" validate:"required,email,max=255"`
 }
 
 // swagger:model UpdatePatientRequest
 type UpdatePatientRequest struct {
-	Firstname   string  `json:"firstname"`
-	Lastname    string  `json:"lastname"`
-	Register    string  `json:"register"`
-	Age         int32   `json:"age"`
-	Gender      string  `json:"gender"`
-	Birthdate   string  `json:"birthdate"` // Expect YYYY-MM-DD string
-	Address     *string `json:"address,omitempty"`
-	Phonenumber string  `json:"phonenumber"`
-	Email       string  `json:"email"`
+	Firstname   string  `json:"firstname" validate:"required,notblank,max=255"`
+	Lastname    string  `json:"lastname" validate:"required,notblank,max=255"`
+	Register    string  `json:"register" validate:"required,register" example:"УБ99032215"` // Mongolian civil register number
+	Age         *int32  `json:"age,omitempty" swaggerignore:"true"` // Deprecated: ignored, age is computed from birthdate
+	Gender      string  `json:"gender,omitempty" validate:"omitempty,oneof=Male Female Other"` // Derived from the register when omitted
+	Birthdate   string  `json:"birthdate,omitempty" validate:"omitempty,datetime=2006-01-02,pastdate"` // YYYY-MM-DD; derived from the register when omitted
+	Address     *string `json:"address,omitempty" validate:"omitempty,max=255"`
+	Phonenumber string  `json:"phonenumber" validate:"required,phone,max=255"`
+	Email       string  `json:"email" validate:"required,email,max=255"`
 }
 
 // swagger:model PatientResponse
@@ -50,12 +52,20 @@ type PatientResponse struct {
 	Firstname   string  `json:"firstname"`
 	Lastname    string  `json:"lastname"`
 	Register    string  `json:"register"`
-	Age         int32   `json:"age"`
+	Age         int32   `json:"age"` // Computed from birthdate
 	Gender      string  `json:"gender"`
 	Birthdate   *string `json:"birthdate"` // YYYY-MM-DD or null
 	Address     *string `json:"address"`   // string or null
 	Phonenumber string  `json:"phonenumber"`
 	Email       string  `json:"email"`
+	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+	Version     int32   `json:"version" example:"3"` // Also sent as the ETag; echo it in If-Match
+	// Disagreements between the register and the record: invalid_register, birthdate_mismatch, gender_mismatch
+	RegisterIssues []string `json:"register_issues,omitempty"`
+	// Set only on soft-deleted patients, which admins see with include_deleted
+	DeletedAt      *pgtype.Timestamp `json:"deleted_at,omitempty"`
+	DeletedBy      *int32            `json:"deleted_by,omitempty"`
+	DeletionReason *string           `json:"deletion_reason,omitempty"`
 }
 
 // swagger:model PatientDetailsResponse Used for the /details endpoint with aggregated lists
@@ -83,6 +93,44 @@ func pgDateFromString(dateStr string) (pgtype.Date, error) {
 	return pgtype.Date{Time: t, Valid: true}, nil
 }
 
+// Helper to convert db.Patient to PatientResponse, computing age and register issues
+func newPatientResponse(p db.Patient) PatientResponse {
+	response := PatientResponse{
+		PatientID:      p.PatientID,
+		Firstname:      p.Firstname,
+		Lastname:       p.Lastname,
+		Register:       p.Register,
+		Age:            ageOn(p.Birthdate, time.Now()),
+		Gender:         p.Gender,
+		Birthdate:      stringPtrFromPgtypeDate(p.Birthdate),
+		Address:        stringPtrFromPgtypeText(p.Address),
+		Phonenumber:    p.Phonenumber,
+		Email:          p.Email,
+		UpdatedAt:      p.UpdatedAt,
+		Version:        p.Version,
+		RegisterIssues: registerIssues(p.Register, p.Birthdate, p.Gender),
+	}
+	if p.DeletedAt.Valid {
+		response.DeletedAt = &p.DeletedAt
+		response.DeletedBy = int32PtrFromPgtypeInt4(p.DeletedBy)
+		response.DeletionReason = stringPtrFromPgtypeText(p.DeletionReason)
+	}
+	return response
+}
+
+// parseIncludeDeleted reads ?include_deleted=. Only admins may see
+// soft-deleted patients; anyone else asking gets a 403 and false.
+func parseIncludeDeleted(w http.ResponseWriter, r *http.Request) (include bool, ok bool) {
+	if r.URL.Query().Get("include_deleted") != "true" {
+		return false, true
+	}
+	if caller, _ := principalFromContext(r.Context()); caller.Role != RoleAdmin {
+		respondWithProblem(w, r, &APIError{Status: http.StatusForbidden, Code: CodeForbidden, Field: "include_deleted", Detail: "Only admins can see deleted patients"})
+		return false, false
+	}
+	return true, true
+}
+
 
 // Helper to parse comma-separated string from STRING_AGG (byte slice)
 func parseAggregatedList(byteData []byte) []string {
@@ -109,94 +157,228 @@ func parseAggregatedList(byteData []byte) []string {
 
 // --- Patient Handlers ---
 
+// maxDeletionReasonLength bounds ?reason= when deleting a patient.
+const maxDeletionReasonLength = 1000
+
+// swagger:model PatientPage
+type PatientPage struct {
+	Data []PatientResponse `json:"data"`
+	Page PageInfo          `json:"page"`
+}
+
+// parsePatientFilter reads the patient listing's filters from the query. On
+// a bad value it writes a 400 and returns false.
+func parsePatientFilter(w http.ResponseWriter, r *http.Request) (db.PatientFilter, bool) {
+	query := r.URL.Query()
+	var f db.PatientFilter
+	invalid := func(field, detail string) (db.PatientFilter, bool) {
+		respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidValue, Field: field, Detail: detail})
+		return db.PatientFilter{}, false
+	}
+
+	// Optional consent filters
+	for name, target := range map[string]*pgtype.Text{"consent": &f.Consent, "without_consent": &f.WithoutConsent} {
+		if scope := query.Get(name); scope != "" {
+			if !consentScopes[scope] {
+				return invalid(name, "Invalid consent scope: "+scope)
+			}
+			*target = pgtype.Text{String: scope, Valid: true}
+		}
+	}
+	if gender := query.Get("gender"); gender != "" {
+		if gender != genderMale && gender != genderFemale && gender != genderOther {
+			return invalid("gender", "gender must be Male, Female or Other")
+		}
+		f.Gender = pgtype.Text{String: gender, Valid: true}
+	}
+
+	dates := map[string]*pgtype.Date{
+		"birthdate_from": &f.BirthdateFrom, "birthdate_to": &f.BirthdateTo,
+		"diagnosed_from": &f.DiagnosedFrom, "diagnosed_to": &f.DiagnosedTo,
+	}
+	for name, target := range dates {
+		if value := query.Get(name); value != "" {
+			t, err := time.Parse(time.DateOnly, value)
+			if err != nil {
+				return invalid(name, "Invalid "+name+" (use YYYY-MM-DD)")
+			}
+			*target = pgtype.Date{Time: t, Valid: true}
+		}
+	}
+	for name, target := range map[string]*pgtype.Int4{"disease_id": &f.DiseaseID, "symptom_id": &f.SymptomID} {
+		if value := query.Get(name); value != "" {
+			id, err := strconv.ParseInt(value, 10, 32)
+			if err != nil || id <= 0 {
+				return invalid(name, name+" must be a positive integer")
+			}
+			*target = pgtype.Int4{Int32: int32(id), Valid: true}
+		}
+	}
+
+	// Ages become birthdate bounds, so they use the birthdate index and agree
+	// with the age computed for each patient (ageOn)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for _, name := range []string{"min_age", "max_age"} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		age, err := strconv.Atoi(value)
+		if err != nil || age < 0 || age > 150 {
+			return invalid(name, name+" must be a whole number of years between 0 and 150")
+		}
+		if name == "min_age" {
+			// At least age years old: born on or before today, age years ago
+			f.BirthdateTo = earlierDate(f.BirthdateTo, today.AddDate(-age, 0, 0))
+		} else {
+			// Not yet age+1: born after today, age+1 years ago
+			f.BirthdateFrom = laterDate(f.BirthdateFrom, today.AddDate(-age-1, 0, 1))
+		}
+	}
+	return f, true
+}
+
+// earlierDate is the tighter of an optional upper bound and t.
+func earlierDate(bound pgtype.Date, t time.Time) pgtype.Date {
+	if bound.Valid && bound.Time.Before(t) {
+		return bound
+	}
+	return pgtype.Date{Time: t, Valid: true}
+}
+
+// laterDate is the tighter of an optional lower bound and t.
+func laterDate(bound pgtype.Date, t time.Time) pgtype.Date {
+	if bound.Valid && bound.Time.After(t) {
+		return bound
+	}
+	return pgtype.Date{Time: t, Valid: true}
+}
+
 // handleListPatients godoc
 // @Summary      List patients
-// @Description  Get a paginated list of patients
+// @Description  Get a page of patients. Pages are keyset-paginated: follow page.next_cursor / page.prev_cursor (or the Link header) instead of computing offsets, so pages stay stable while patients are added. Only patients on the caller's care team or under an open break-glass grant are listed. Filters combine with AND.
 // @Tags         Patients
 // @Accept       json
 // @Produce      json
-// @Param        limit   query     int  false  "Pagination limit" default(10)
-// @Param        offset  query     int  false  "Pagination offset" default(0)
-// @Success      200     {array}   PatientResponse "Successfully retrieved list of patients"
-// @Failure      500     {object}  HTTPError "Internal server error"
+// @Param        limit           query  int     false  "Page size (at most 100)" default(10)
+// @Param        cursor          query  string  false  "Cursor from a previous page's next_cursor or prev_cursor"
+// @Param        sort            query  string  false  "Sort field, prefixed with - for descending" Enums(name, -name, birthdate, -birthdate, created_at, -created_at, updated_at, -updated_at, id, -id) default(name)
+// @Param        count           query  bool    false  "Also return the total number of matching patients in page.total"
+// @Param        gender          query  string  false  "Only this gender" Enums(Male, Female, Other)
+// @Param        min_age         query  int     false  "Only patients at least this old (years)"
+// @Param        max_age         query  int     false  "Only patients at most this old (years)"
+// @Param        birthdate_from  query  string  false  "Only patients born on or after (YYYY-MM-DD)"
+// @Param        birthdate_to    query  string  false  "Only patients born on or before (YYYY-MM-DD)"
+// @Param        disease_id      query  int     false  "Only patients with a recorded instance of this disease"
+// @Param        symptom_id      query  int     false  "Only patients who reported this general symptom"
+// @Param        diagnosed_from  query  string  false  "Only patients with a diagnosis (of disease_id, when given) dated on or after (YYYY-MM-DD)"
+// @Param        diagnosed_to    query  string  false  "Only patients with a diagnosis (of disease_id, when given) dated on or before (YYYY-MM-DD)"
+// @Param        consent          query  string  false  "Only patients with an active consent for this scope" Enums(data_processing, model_training, contact_sms, contact_email)
+// @Param        without_consent  query  string  false  "Only patients without an active consent for this scope" Enums(data_processing, model_training, contact_sms, contact_email)
+// @Param        include_deleted  query  bool    false  "Also list soft-deleted patients (admins only)"
+// @Success      200     {object}  PatientPage "A page of patients"
+// @Header       200     {string}  Link "first, prev and next page URLs (RFC 8288)"
+// @Failure      400     {object}  Problem "Invalid filter, sort, limit or cursor (offset is no longer supported)"
+// @Failure      403     {object}  Problem "include_deleted asked for by a non-admin"
+// @Failure      429     {object}  Problem "Rate limit exceeded (see Retry-After)"
+// @Failure      500     {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
 // @Router       /patients [get]
 func (s *Server) handleListPatients() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		limitStr := r.URL.Query().Get("limit")
-		offsetStr := r.URL.Query().Get("offset")
-
-		limit, err := strconv.ParseInt(limitStr, 10, 32)
-		if err != nil || limit <= 0 {
-			limit = 10 // Default limit
+		if r.URL.Query().Has("offset") {
+			respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidValue, Field: "offset", Detail: "offset pagination was replaced by cursors; follow page.next_cursor instead"})
+			return
 		}
-
-		offset, err := strconv.ParseInt(offsetStr, 10, 32)
-		if err != nil || offset < 0 {
-			offset = 0 // Default offset
+		limit, ok := parsePageLimit(w, r)
+		if !ok {
+			return
 		}
-
-		params := db.ListPatientsParams{
-			Limit:  int32(limit),
-			Offset: int32(offset),
+		field, desc, cursor, ok := parseSortAndCursor(w, r, db.PatientSortFields(), "name")
+		if !ok {
+			return
+		}
+		filter, ok := parsePatientFilter(w, r)
+		if !ok {
+			return
+		}
+		caller, _ := principalFromContext(r.Context())
+		filter.ReaderID = caller.UserID
+		if filter.IncludeDeleted, ok = parseIncludeDeleted(w, r); !ok {
+			return
 		}
 
-		patients, err := s.queries.ListPatients(r.Context(), params)
+		patients, err := s.queries.ListPatientsPage(r.Context(), db.ListPatientsPageParams{
+			Filter:     filter,
+			PageParams: newPageParams(field, desc, limit, cursor),
+		})
 		if err != nil {
-			log.Printf("Error listing patients: %v", err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve patients")
+			if errors.Is(err, db.ErrInvalidPageKey) {
+				respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidCursor, Field: "cursor", Detail: "The cursor is not valid; start again from the first page"})
+				return
+			}
+			s.respondWithDBError(w, r, err, "Failed to retrieve patients")
 			return
 		}
 
-		// Convert db.Patient to PatientResponse
-		responsePatients := make([]PatientResponse, len(patients))
+		page := PageInfo{Limit: limit, Sort: sortParam(field, desc)}
+		keys := make([][]string, len(patients))
 		for i, p := range patients {
-			responsePatients[i] = PatientResponse{
-				PatientID:   p.PatientID,
-				Firstname:   p.Firstname,
-				Lastname:    p.Lastname,
-				Register:    p.Register,
-				Age:         p.Age,
-				Gender:      p.Gender,
-				Birthdate:   stringPtrFromPgtypeDate(p.Birthdate),
-				Address:     stringPtrFromPgtypeText(p.Address),
-				Phonenumber: p.Phonenumber,
-				Email:       p.Email,
+			keys[i] = db.PatientSortKeyValues(p, field)
+		}
+		from, to := pageCursors(&page, cursor, field, desc, keys)
+
+		if r.URL.Query().Get("count") == "true" {
+			total, err := s.queries.CountPatients(r.Context(), filter)
+			if err != nil {
+				s.respondWithDBError(w, r, err, "Failed to count patients")
+				return
 			}
+			page.Total = &total
 		}
 
-		respondWithJSON(w, http.StatusOK, responsePatients)
+		response := PatientPage{Data: make([]PatientResponse, 0, to-from), Page: page}
+		for _, p := range patients[from:to] {
+			response.Data = append(response.Data, newPatientResponse(p))
+		}
+		setPageLinks(w, r, page)
+		respondWithJSON(w, http.StatusOK, response)
 	}
 }
 
 // handleCreatePatient godoc
 // @Summary      Create a new patient
-// @Description  Add a new patient record to the database
+// @Description  Add a new patient record to the database. The caller joins the patient's care team. A patient of the clinic with the same register or phone number, or a similar name and the same birthdate, may be the same person: the request is then refused with code possible_duplicate and the candidates, each with a confidence score. Merge into an existing record instead, or repeat with allow_duplicate=true.
 // @Tags         Patients
 // @Accept       json
 // @Produce      json
 // @Param        patient body      CreatePatientRequest true "Patient data to create"
+// @Param        allow_duplicate query bool false "Create the patient even if it may already be registered"
+// @Param        Idempotency-Key header string false "Unique key for this request; a retry with the same key replays the first response"
 // @Success      201     {object}  PatientResponse "Patient created successfully"
-// @Failure      400     {object}  HTTPError "Invalid request payload or validation error"
-// @Failure      500     {object}  HTTPError "Internal server error (e.g., DB error)"
+// @Header       201     {string}  ETag "Version to send in If-Match when changing it"
+// @Failure      400     {object}  Problem "Malformed JSON, wrong type or unknown field"
+// @Failure      422     {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      409     {object}  Problem "A patient with this email already exists (code patient_email_taken), or the patient may already be registered (code possible_duplicate, candidates in duplicates)"
+// @Failure      500     {object}  Problem "Internal server error (e.g., DB error)"
+// @Security     UserHeader
+// @Security     BearerToken
 // @Router       /patients [post]
 func (s *Server) handleCreatePatient() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req CreatePatientRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
-			return
-		}
-		defer r.Body.Close()
-
-		// Basic Validation
-		if req.Firstname == "" || req.Lastname == "" || req.Email == "" || req.Register == "" || req.Phonenumber == "" || req.Gender == "" || req.Birthdate == "" {
-			respondWithError(w, http.StatusBadRequest, "Missing required fields (firstname, lastname, email, register, phonenumber, gender, birthdate)")
+		if !s.decodeAndValidate(w, r, &req) {
 			return
 		}
 
+		applyRegister(&req.Register, &req.Birthdate, &req.Gender)
 		birthdatePg, err := pgDateFromString(req.Birthdate)
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid birthdate format (use YYYY-MM-DD)")
+			respondWithError(w, r, http.StatusBadRequest, "Invalid birthdate format (use YYYY-MM-DD)")
+			return
+		}
+		if !s.checkDuplicates(w, r, 0, req.Firstname, req.Lastname, req.Register, req.Phonenumber, birthdatePg) {
 			return
 		}
 
@@ -204,7 +386,6 @@ func (s *Server) handleCreatePatient() http.HandlerFunc {
 			Firstname:   req.Firstname,
 			Lastname:    req.Lastname,
 			Register:    req.Register,
-			Age:         req.Age, // Assuming age is provided correctly
 			Gender:      req.Gender,
 			Birthdate:   birthdatePg,
 			Address:     pgtypeText(req.Address), // Use helper for nullable text
@@ -212,195 +393,347 @@ func (s *Server) handleCreatePatient() http.HandlerFunc {
 			Email:       req.Email,
 		}
 
-		newPatient, err := s.queries.CreatePatient(r.Context(), params)
+		tx, err := s.pool.Begin(r.Context())
 		if err != nil {
-			// TODO: Check for specific DB errors like unique constraint violation on email
-			log.Printf("Error creating patient: %v", err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to create patient")
+			s.respondWithDBError(w, r, err, "Failed to create patient")
 			return
 		}
+		defer tx.Rollback(r.Context()) // No-op once committed
+		qtx := s.queries.WithTx(tx)
 
-		// Convert db.Patient to PatientResponse
-		responsePatient := PatientResponse{
-			PatientID:   newPatient.PatientID,
-			Firstname:   newPatient.Firstname,
-			Lastname:    newPatient.Lastname,
-			Register:    newPatient.Register,
-			Age:         newPatient.Age,
-			Gender:      newPatient.Gender,
-			Birthdate:   stringPtrFromPgtypeDate(newPatient.Birthdate),
-			Address:     stringPtrFromPgtypeText(newPatient.Address),
-			Phonenumber: newPatient.Phonenumber,
-			Email:       newPatient.Email,
+		newPatient, err := qtx.CreatePatient(r.Context(), params)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to create patient")
+			return
 		}
 
+		// The creator joins the new patient's care team, otherwise nobody could read the record
+		caller, _ := principalFromContext(r.Context())
+		_, err = qtx.AddCareTeamMember(r.Context(), db.AddCareTeamMemberParams{
+			PatientID:  newPatient.PatientID,
+			UserID:     caller.UserID,
+			AssignedBy: caller.Username,
+		})
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to create patient", "patient_id", newPatient.PatientID)
+			return
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			s.respondWithDBError(w, r, err, "Failed to create patient")
+			return
+		}
+		s.metrics.patientsCreated.Inc()
+
+		// Convert db.Patient to PatientResponse
+		responsePatient := newPatientResponse(newPatient)
+		setETag(w, newPatient.Version)
+
 		respondWithJSON(w, http.StatusCreated, responsePatient)
 	}
 }
 
 // handleGetPatientByID godoc
 // @Summary      Get patient by ID
-// @Description  Retrieve details of a specific patient by their ID
+// @Description  Retrieve details of a specific patient by their ID. A soft-deleted patient is not found unless an admin sets include_deleted.
 // @Tags         Patients
 // @Accept       json
 // @Produce      json
 // @Param        patientID path      int true "Patient ID" Format(int32)
+// @Param        include_deleted query bool false "Also find a soft-deleted patient (admins only)"
 // @Success      200       {object}  PatientResponse "Successfully retrieved patient"
-// @Failure      400       {object}  HTTPError "Invalid Patient ID format"
-// @Failure      404       {object}  HTTPError "Patient not found"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Header       200       {string}  ETag "Version to send in If-Match when changing it"
+// @Success      304       {string}  string "Not Modified (If-None-Match lists the current version)"
+// @Failure      400       {object}  Problem "Invalid Patient ID format"
+// @Failure      403       {object}  Problem "include_deleted asked for by a non-admin"
+// @Failure      404       {object}  Problem "Patient not found"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
 // @Router       /patients/{patientID} [get]
 func (s *Server) handleGetPatientByID() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		patientID, err := parseInt32Param(r, "patientID")
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
+			respondWithError(w, r, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
 			return
 		}
 
-		patient, err := s.queries.GetPatientByID(r.Context(), patientID)
+		// Only admins get past requirePatientAccess to a deleted patient
+		getPatient := s.queries.GetPatientByID
+		if r.URL.Query().Get("include_deleted") == "true" {
+			getPatient = s.queries.GetPatientByIDWithDeleted
+		}
+		patient, err := getPatient(r.Context(), patientID)
 		if err != nil {
 			// Check for pgx specific no rows error first
 			if errors.Is(err, pgx.ErrNoRows) {
-				respondWithError(w, http.StatusNotFound, "Patient not found")
+				respondWithError(w, r, http.StatusNotFound, "Patient not found")
 			} else if errors.Is(err, sql.ErrNoRows) { // Fallback check (less likely with pgx)
-				respondWithError(w, http.StatusNotFound, "Patient not found")
+				respondWithError(w, r, http.StatusNotFound, "Patient not found")
 			} else {
-				log.Printf("Error retrieving patient %d: %v", patientID, err)
-				respondWithError(w, http.StatusInternalServerError, "Failed to retrieve patient")
+				s.respondWithDBError(w, r, err, "Failed to retrieve patient", "patient_id", patientID)
 			}
 			return
 		}
 
-		// Convert db.Patient to PatientResponse
-		responsePatient := PatientResponse{
-			PatientID:   patient.PatientID,
-			Firstname:   patient.Firstname,
-			Lastname:    patient.Lastname,
-			Register:    patient.Register,
-			Age:         patient.Age,
-			Gender:      patient.Gender,
-			Birthdate:   stringPtrFromPgtypeDate(patient.Birthdate),
-			Address:     stringPtrFromPgtypeText(patient.Address),
-			Phonenumber: patient.Phonenumber,
-			Email:       patient.Email,
+		if notModified(w, r, patient.Version) {
+			return
 		}
-
-		respondWithJSON(w, http.StatusOK, responsePatient)
+		respondWithJSON(w, http.StatusOK, newPatientResponse(patient))
 	}
 }
 
 // handleUpdatePatientDetails godoc
 // @Summary      Update patient details
-// @Description  Update details for an existing patient
+// @Description  Replace the details of an existing patient. If-Match must carry the ETag from a previous read; a patient changed since then is not overwritten. A change to the name, register, phone number or birthdate is checked for duplicates as on create (override with allow_duplicate=true).
 // @Tags         Patients
 // @Accept       json
 // @Produce      json
 // @Param        patientID path      int                true "Patient ID" Format(int32)
+// @Param        If-Match  header    string             true "ETag of the version being replaced"
 // @Param        patient   body      UpdatePatientRequest true "Patient data to update"
+// @Param        allow_duplicate query bool false "Save the change even if the patient may then duplicate another"
 // @Success      200       {object}  PatientResponse "Patient updated successfully"
-// @Failure      400       {object}  HTTPError "Invalid request payload or validation error"
-// @Failure      404       {object}  HTTPError "Patient not found"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Header       200       {string}  ETag "Version of the updated patient"
+// @Failure      400       {object}  Problem "Malformed JSON, wrong type or unknown field"
+// @Failure      422       {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      404       {object}  Problem "Patient not found"
+// @Failure      409       {object}  Problem "A patient with this email already exists (code patient_email_taken), or the changed name, register, phone or birthdate match another patient (code possible_duplicate)"
+// @Failure      412       {object}  Problem "The patient changed since the ETag in If-Match was read"
+// @Failure      428       {object}  Problem "If-Match is missing"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
 // @Router       /patients/{patientID} [put]
 func (s *Server) handleUpdatePatientDetails() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		patientID, err := parseInt32Param(r, "patientID")
-		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
+		current, ok := s.patientForWrite(w, r)
+		if !ok {
 			return
 		}
 
 		var req UpdatePatientRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		if !s.decodeAndValidate(w, r, &req) {
 			return
 		}
-		defer r.Body.Close()
+		s.updatePatient(w, r, current, req)
+	}
+}
 
-		// Basic Validation
-		if req.Firstname == "" || req.Lastname == "" || req.Email == "" || req.Register == "" || req.Phonenumber == "" || req.Gender == "" || req.Birthdate == "" {
-			respondWithError(w, http.StatusBadRequest, "Missing required fields (firstname, lastname, email, register, phonenumber, gender, birthdate)")
+// handlePatchPatient godoc
+// @Summary      Partially update a patient
+// @Description  Apply a JSON Merge Patch (RFC 7396) to a patient: members present in the body replace the stored values and null clears optional ones (a cleared birthdate or gender is derived from the register again). The merged patient is validated and checked for duplicates like a PUT. If-Match must carry the ETag from a previous read.
+// @Tags         Patients
+// @Accept       application/merge-patch+json
+// @Produce      json
+// @Param        patientID path      int                true "Patient ID" Format(int32)
+// @Param        If-Match  header    string             true "ETag of the version being changed"
+// @Param        patch     body      UpdatePatientRequest true "Members to change"
+// @Param        allow_duplicate query bool false "Save the change even if the patient may then duplicate another"
+// @Success      200       {object}  PatientResponse "Patient updated successfully"
+// @Header       200       {string}  ETag "Version of the updated patient"
+// @Failure      400       {object}  Problem "Malformed JSON, wrong type or unknown field"
+// @Failure      422       {object}  Problem "Patched patient failed validation; every violation is listed in errors"
+// @Failure      404       {object}  Problem "Patient not found"
+// @Failure      409       {object}  Problem "A patient with this email already exists (code patient_email_taken), or the changed name, register, phone or birthdate match another patient (code possible_duplicate)"
+// @Failure      412       {object}  Problem "The patient changed since the ETag in If-Match was read"
+// @Failure      415       {object}  Problem "Body is not application/merge-patch+json"
+// @Failure      428       {object}  Problem "If-Match is missing"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /patients/{patientID} [patch]
+func (s *Server) handlePatchPatient() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		current, ok := s.patientForWrite(w, r)
+		if !ok {
 			return
 		}
 
-		birthdatePg, err := pgDateFromString(req.Birthdate)
-		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid birthdate format (use YYYY-MM-DD)")
+		var req UpdatePatientRequest
+		if !s.decodeMergePatch(w, r, patientPatchBase(current), &req) {
 			return
 		}
+		s.updatePatient(w, r, current, req)
+	}
+}
 
-		params := db.UpdatePatientDetailsParams{
-			PatientID:   patientID, // From URL param
-			Firstname:   req.Firstname,
-			Lastname:    req.Lastname,
-			Register:    req.Register,
-			Age:         req.Age,
-			Gender:      req.Gender,
-			Birthdate:   birthdatePg,
-			Address:     pgtypeText(req.Address),
-			Phonenumber: req.Phonenumber,
-			Email:       req.Email,
-		}
+// patientPatchBase is the patient's writable fields, as a PUT would send
+// them; a merge patch applies to these.
+func patientPatchBase(current db.Patient) UpdatePatientRequest {
+	return UpdatePatientRequest{
+		Firstname:   current.Firstname,
+		Lastname:    current.Lastname,
+		Register:    current.Register,
+		Gender:      current.Gender,
+		Birthdate:   stringFromPgtypeDate(current.Birthdate),
+		Address:     stringPtrFromPgtypeText(current.Address),
+		Phonenumber: current.Phonenumber,
+		Email:       current.Email,
+	}
+}
 
-		updatedPatient, err := s.queries.UpdatePatientDetails(r.Context(), params)
-		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
-				respondWithError(w, http.StatusNotFound, "Patient not found")
-			} else {
-				// TODO: Check for specific DB errors like unique constraints on email update
-				log.Printf("Error updating patient %d: %v", patientID, err)
-				respondWithError(w, http.StatusInternalServerError, "Failed to update patient")
-			}
-			return
+// patientForWrite loads the patient named in the path and checks If-Match
+// against its version. It writes the error response and returns false on failure.
+func (s *Server) patientForWrite(w http.ResponseWriter, r *http.Request) (db.Patient, bool) {
+	patientID, err := parseInt32Param(r, "patientID")
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
+		return db.Patient{}, false
+	}
+	current, err := s.queries.GetPatientByID(r.Context(), patientID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, r, http.StatusNotFound, "Patient not found")
+		} else {
+			s.respondWithDBError(w, r, err, "Failed to retrieve patient", "patient_id", patientID)
 		}
+		return db.Patient{}, false
+	}
+	if !checkIfMatch(w, r, current.Version) {
+		return db.Patient{}, false
+	}
+	return current, true
+}
 
-		// Convert db.Patient to PatientResponse
-		responsePatient := PatientResponse{
-			PatientID:   updatedPatient.PatientID,
-			Firstname:   updatedPatient.Firstname,
-			Lastname:    updatedPatient.Lastname,
-			Register:    updatedPatient.Register,
-			Age:         updatedPatient.Age,
-			Gender:      updatedPatient.Gender,
-			Birthdate:   stringPtrFromPgtypeDate(updatedPatient.Birthdate),
-			Address:     stringPtrFromPgtypeText(updatedPatient.Address),
-			Phonenumber: updatedPatient.Phonenumber,
-			Email:       updatedPatient.Email,
-		}
+// updatePatient stores a validated update request over the version in current.
+func (s *Server) updatePatient(w http.ResponseWriter, r *http.Request, current db.Patient, req UpdatePatientRequest) {
+	applyRegister(&req.Register, &req.Birthdate, &req.Gender)
+	birthdatePg, err := pgDateFromString(req.Birthdate)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid birthdate format (use YYYY-MM-DD)")
+		return
+	}
+	// Only a change to who the record identifies can make it a duplicate
+	identityChanged := req.Firstname != current.Firstname || req.Lastname != current.Lastname ||
+		req.Register != current.Register || req.Phonenumber != current.Phonenumber ||
+		req.Birthdate != stringFromPgtypeDate(current.Birthdate)
+	if identityChanged && !s.checkDuplicates(w, r, current.PatientID, req.Firstname, req.Lastname, req.Register, req.Phonenumber, birthdatePg) {
+		return
+	}
+
+	params := db.UpdatePatientDetailsParams{
+		PatientID:   current.PatientID,
+		Firstname:   req.Firstname,
+		Lastname:    req.Lastname,
+		Register:    req.Register,
+		Gender:      req.Gender,
+		Birthdate:   birthdatePg,
+		Address:     pgtypeText(req.Address),
+		Phonenumber: req.Phonenumber,
+		Email:       req.Email,
+		Version:     current.Version,
+	}
 
-		respondWithJSON(w, http.StatusOK, responsePatient)
+	updatedPatient, err := s.queries.UpdatePatientDetails(r.Context(), params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+			// The row existed a moment ago, so another write got in first
+			respondWithStaleVersion(w, r, 0)
+		} else {
+			s.respondWithDBError(w, r, err, "Failed to update patient", "patient_id", current.PatientID)
+		}
+		return
 	}
+
+	setETag(w, updatedPatient.Version)
+	respondWithJSON(w, http.StatusOK, newPatientResponse(updatedPatient))
 }
 
 // handleDeletePatient godoc
 // @Summary      Delete patient
-// @Description  Delete a patient record by ID. Associated records in junction tables (patient_symptoms, patient_disease) should be removed via ON DELETE CASCADE.
+// @Description  Soft-delete a patient: the record and its history are hidden from every listing and lookup, but kept until the purge job removes them after the retention period (PATIENT_RETENTION). Until then an admin can restore it. The caller is recorded as deleted_by.
 // @Tags         Patients
 // @Accept       json
 // @Produce      json
-// @Param        patientID path      int true "Patient ID" Format(int32)
+// @Param        patientID path      int    true  "Patient ID" Format(int32)
+// @Param        reason    query     string false "Why the patient is deleted (at most 1000 characters)"
+// @Param        If-Match  header    string true  "ETag of the version being deleted"
 // @Success      204       {string}  string "No Content (Successful deletion)"
-// @Failure      400       {object}  HTTPError "Invalid Patient ID format"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Failure      400       {object}  Problem "Invalid Patient ID format or reason too long"
+// @Failure      404       {object}  Problem "Patient not found"
+// @Failure      412       {object}  Problem "The patient changed since the ETag in If-Match was read"
+// @Failure      428       {object}  Problem "If-Match is missing"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
 // @Router       /patients/{patientID} [delete]
 func (s *Server) handleDeletePatient() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reason := strings.TrimSpace(r.URL.Query().Get("reason"))
+		if utf8.RuneCountInString(reason) > maxDeletionReasonLength {
+			respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidValue, Field: "reason", Detail: "reason must be at most 1000 characters"})
+			return
+		}
+		current, ok := s.patientForWrite(w, r)
+		if !ok {
+			return
+		}
+		patientID := current.PatientID
+
+		caller, _ := principalFromContext(r.Context())
+		patient, err := s.queries.SoftDeletePatient(r.Context(), db.SoftDeletePatientParams{
+			PatientID:      patientID,
+			DeletedBy:      caller.UserID,
+			DeletionReason: pgtype.Text{String: reason, Valid: reason != ""},
+			Version:        pgtype.Int4{Int32: current.Version, Valid: true},
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+				// The row existed a moment ago, so another write got in first
+				respondWithStaleVersion(w, r, 0)
+			} else {
+				s.respondWithDBError(w, r, err, "Failed to delete patient", "patient_id", patientID)
+			}
+			return
+		}
+		// The reason stays in the patient row; it may describe the patient
+		s.log(r).Info("Patient soft-deleted", "patient_id", patientID, "deleted_by", caller.UserID, "purge_after", patient.DeletedAt.Time.Add(s.config.Patient_Retention))
+
+		w.WriteHeader(http.StatusNoContent) // Standard response for successful DELETE
+	}
+}
+
+// handleRestorePatient godoc
+// @Summary      Restore a deleted patient
+// @Description  Undo a soft delete before the purge job removes the patient. The record and its history become visible again. Admins only.
+// @Tags         Patients
+// @Produce      json
+// @Param        patientID path      int true "Patient ID" Format(int32)
+// @Param        Idempotency-Key header string false "Unique key for this request; a retry with the same key replays the first response"
+// @Success      200       {object}  PatientResponse "Patient restored"
+// @Header       200       {string}  ETag "Version of the restored patient"
+// @Failure      400       {object}  Problem "Invalid Patient ID format"
+// @Failure      401       {object}  Problem "Missing or invalid credentials"
+// @Failure      403       {object}  Problem "Caller is not an admin"
+// @Failure      404       {object}  Problem "No deleted patient with this ID (never deleted, or already purged)"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /patients/{patientID}/restore [post]
+func (s *Server) handleRestorePatient() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		patientID, err := parseInt32Param(r, "patientID")
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
+			respondWithError(w, r, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
 			return
 		}
 
-		err = s.queries.DeletePatient(r.Context(), patientID)
+		patient, err := s.queries.RestorePatient(r.Context(), patientID)
 		if err != nil {
-			// Note: DELETE often doesn't error if the ID doesn't exist, but FK errors could occur if CASCADE isn't set up.
-			log.Printf("Error deleting patient %d: %v", patientID, err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to delete patient")
+			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, r, http.StatusNotFound, "No deleted patient with this ID")
+			} else {
+				s.respondWithDBError(w, r, err, "Failed to restore patient", "patient_id", patientID)
+			}
 			return
 		}
+		caller, _ := principalFromContext(r.Context())
+		s.log(r).Info("Patient restored", "patient_id", patientID, "restored_by", caller.UserID)
 
-		w.WriteHeader(http.StatusNoContent) // Standard response for successful DELETE
+		setETag(w, patient.Version)
+		respondWithJSON(w, http.StatusOK, newPatientResponse(patient))
 	}
 }
 
@@ -412,15 +745,17 @@ func (s *Server) handleDeletePatient() http.HandlerFunc {
 // @Produce      json
 // @Param        patientID path      int true "Patient ID" Format(int32)
 // @Success      200       {object}  PatientDetailsResponse "Successfully retrieved patient summary details"
-// @Failure      400       {object}  HTTPError "Invalid Patient ID format"
-// @Failure      404       {object}  HTTPError "Patient not found"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Failure      400       {object}  Problem "Invalid Patient ID format"
+// @Failure      404       {object}  Problem "Patient not found"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
 // @Router       /patients/{patientID}/details [get]
 func (s *Server) handleGetPatientDetails() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		patientID, err := parseInt32Param(r, "patientID")
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
+			respondWithError(w, r, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
 			return
 		}
 
@@ -428,10 +763,9 @@ func (s *Server) handleGetPatientDetails() http.HandlerFunc {
 		summary, err := s.queries.GetPatientSummary(r.Context(), patientID)
 		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
-				respondWithError(w, http.StatusNotFound, "Patient not found")
+				respondWithError(w, r, http.StatusNotFound, "Patient not found")
 			} else {
-				log.Printf("Error getting patient summary %d: %v", patientID, err)
-				respondWithError(w, http.StatusInternalServerError, "Failed to retrieve patient summary")
+				s.respondWithDBError(w, r, err, "Failed to retrieve patient summary", "patient_id", patientID)
 			}
 			return
 		}
@@ -450,3 +784,519 @@ func (s *Server) handleGetPatientDetails() http.HandlerFunc {
 	}
 }
 
+
+// --- Care Team & Break-Glass Access ---
+
+// headerBreakGlass flags responses served under a break-glass grant
+const headerBreakGlass = "X-Break-Glass-Grant"
+
+type breakGlassKey struct{}
+
+// breakGlassGrantFromContext returns the grant a request reached the patient
+// through, when the caller is not on the care team.
+func breakGlassGrantFromContext(ctx context.Context) (int32, bool) {
+	grantID, ok := ctx.Value(breakGlassKey{}).(int32)
+	return grantID, ok
+}
+
+// refuseBreakGlass rejects requests that reach the patient only through a
+// break-glass grant. It runs after requirePatientAccess.
+func refuseBreakGlass(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if grantID, ok := breakGlassGrantFromContext(r.Context()); ok {
+			respondWithError(w, r, http.StatusForbidden, fmt.Sprintf("Not allowed under break-glass grant %d", grantID))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// swagger:model BreakGlassRequest
+type BreakGlassRequest struct {
+	Reason string `json:"reason" validate:"required,notblank,max=1000" example:"Unconscious patient admitted to ER, on-call physician"` // Mandatory justification
+}
+
+// swagger:model BreakGlassGrantResponse
+type BreakGlassGrantResponse struct {
+	GrantID   int32            `json:"grant_id"`
+	PatientID int32            `json:"patient_id"`
+	UserID    int32            `json:"user_id"`
+	Reason    string           `json:"reason"`
+	GrantedAt pgtype.Timestamp `json:"granted_at"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
+}
+
+// swagger:model AddCareTeamMemberRequest
+type AddCareTeamMemberRequest struct {
+	UserID int32 `json:"user_id" validate:"required,gt=0"`
+}
+
+// swagger:model CareTeamMemberResponse
+type CareTeamMemberResponse struct {
+	PatientID   int32            `json:"patient_id"`
+	UserID      int32            `json:"user_id"`
+	Username    string           `json:"username"`
+	DisplayName *string          `json:"display_name"`
+	AssignedBy  string           `json:"assigned_by"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
+
+// swagger:model BreakGlassAccessResponse
+type BreakGlassAccessResponse struct {
+	Method     string           `json:"method"`
+	Path       string           `json:"path"`
+	AccessedAt pgtype.Timestamp `json:"accessed_at"`
+}
+
+// swagger:model BreakGlassEventResponse One break-glass grant in the review report
+type BreakGlassEventResponse struct {
+	GrantID          int32                      `json:"grant_id"`
+	PatientID        int32                      `json:"patient_id"`
+	PatientFirstname string                     `json:"patient_firstname"`
+	PatientLastname  string                     `json:"patient_lastname"`
+	UserID           int32                      `json:"user_id"`
+	Username         string                     `json:"username"`
+	Reason           string                     `json:"reason"`
+	GrantedAt        pgtype.Timestamp           `json:"granted_at"`
+	ExpiresAt        pgtype.Timestamp           `json:"expires_at"`
+	AccessCount      int64                      `json:"access_count"`
+	LastAccessedAt   pgtype.Timestamp           `json:"last_accessed_at"`
+	Accesses         []BreakGlassAccessResponse `json:"accesses"`
+}
+
+// requirePatientAccess guards the /patients/{patientID} routes. Only the patient's
+// care team has access by default; anyone else needs an open break-glass grant,
+// and every request made under one is recorded and flagged.
+func (s *Server) requirePatientAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		patientID, err := parseInt32Param(r, "patientID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
+			return
+		}
+		if r, ok := s.checkPatientAccess(w, r, patientID); ok {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// requireInstanceAccess guards the /disease-instances/{instanceID} routes with the
+// same rule as requirePatientAccess, applied to the instance's patient.
+func (s *Server) requireInstanceAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		instanceID, err := parseInt32Param(r, "instanceID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid disease instance ID: "+err.Error())
+			return
+		}
+		instance, err := s.queries.GetPatientDiseaseInstanceByID(r.Context(), instanceID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, r, http.StatusNotFound, "Disease instance not found")
+			} else {
+				s.respondWithDBError(w, r, err, "Failed to check patient access", "instance_id", instanceID)
+			}
+			return
+		}
+		if r, ok := s.checkPatientAccess(w, r, instance.PatientID); ok {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// requirePatientSymptomAccess guards the /patient-symptoms/{id} routes with the
+// same rule as requirePatientAccess, applied to the record's patient.
+func (s *Server) requirePatientSymptomAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		patientSymptomID, err := parseInt32Param(r, "id")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid patient symptom record ID: "+err.Error())
+			return
+		}
+		record, err := s.queries.GetPatientSymptomByID(r.Context(), patientSymptomID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, r, http.StatusNotFound, "Patient symptom record not found")
+			} else {
+				s.respondWithDBError(w, r, err, "Failed to check patient access", "patient_symptom_id", patientSymptomID)
+			}
+			return
+		}
+		if r, ok := s.checkPatientAccess(w, r, record.PatientID); ok {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// checkPatientAccess applies the care-team/break-glass rule for one patient.
+// It writes the error response and returns false when access is denied, or
+// with 404 when the caller's clinic has no such patient. Access through a
+// grant is recorded and the returned request carries the grant.
+func (s *Server) checkPatientAccess(w http.ResponseWriter, r *http.Request, patientID int32) (*http.Request, bool) {
+	caller, ok := principalFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, "Unauthenticated")
+		return r, false
+	}
+
+	// A patient outside the caller's clinic does not exist for them. A
+	// soft-deleted patient's records are gone for everyone, except admins
+	// reading them with include_deleted
+	deletedAt, err := s.queries.GetPatientDeletedAt(r.Context(), patientID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, r, http.StatusNotFound, "Patient not found")
+		} else {
+			s.respondWithDBError(w, r, err, "Failed to check patient access", "patient_id", patientID)
+		}
+		return r, false
+	}
+	if deletedAt.Valid {
+		include, ok := parseIncludeDeleted(w, r)
+		if !ok {
+			return r, false
+		}
+		if !include || r.Method != http.MethodGet {
+			respondWithError(w, r, http.StatusNotFound, "Patient not found")
+			return r, false
+		}
+	}
+
+	onTeam, err := s.queries.IsOnCareTeam(r.Context(), db.IsOnCareTeamParams{
+		PatientID: patientID,
+		UserID:    caller.UserID,
+	})
+	if err != nil {
+		s.respondWithDBError(w, r, err, "Failed to check patient access", "patient_id", patientID, "user_id", caller.UserID)
+		return r, false
+	}
+	if onTeam {
+		return r, true
+	}
+
+	grant, err := s.queries.GetActiveBreakGlassGrant(r.Context(), db.GetActiveBreakGlassGrantParams{
+		UserID:    caller.UserID,
+		PatientID: patientID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, r, http.StatusForbidden, "Not on the patient's care team; use POST /patients/{patientID}/break-glass in an emergency")
+		} else {
+			s.respondWithDBError(w, r, err, "Failed to check patient access", "patient_id", patientID, "user_id", caller.UserID)
+		}
+		return r, false
+	}
+
+	err = s.queries.RecordBreakGlassAccess(r.Context(), db.RecordBreakGlassAccessParams{
+		GrantID: grant.GrantID,
+		Method:  r.Method,
+		Path:    r.URL.Path,
+	})
+	if err != nil {
+		// An unrecorded break-glass access must not happen
+		s.respondWithDBError(w, r, err, "Failed to record break-glass access", "grant_id", grant.GrantID)
+		return r, false
+	}
+	s.log(r).Warn("BREAK-GLASS access", "patient_id", patientID, "grant_id", grant.GrantID, "path", r.URL.Path)
+	w.Header().Set(headerBreakGlass, strconv.Itoa(int(grant.GrantID)))
+	return r.WithContext(context.WithValue(r.Context(), breakGlassKey{}, grant.GrantID)), true
+}
+
+// handleBreakGlass godoc
+// @Summary      Break the glass on a patient record
+// @Description  Grant the caller time-limited emergency access to a patient outside their care team. A reason is mandatory. Every request made during the window is recorded and flagged with the X-Break-Glass-Grant response header.
+// @Tags         Patients
+// @Accept       json
+// @Produce      json
+// @Param        patientID path      int               true "Patient ID" Format(int32)
+// @Param        request   body      BreakGlassRequest true "Justification"
+// @Param        Idempotency-Key header string false "Unique key for this request; a retry with the same key replays the first response"
+// @Success      201       {object}  BreakGlassGrantResponse "Emergency access granted"
+// @Failure      400       {object}  Problem "Invalid Patient ID or request payload"
+// @Failure      422       {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      404       {object}  Problem "Patient not found"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /patients/{patientID}/break-glass [post]
+func (s *Server) handleBreakGlass() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		patientID, err := parseInt32Param(r, "patientID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
+			return
+		}
+
+		var req BreakGlassRequest
+		if !s.decodeAndValidate(w, r, &req) {
+			return
+		}
+
+		req.Reason = strings.TrimSpace(req.Reason)
+		// Patients of other clinics are invisible here (row-level security)
+		if _, err := s.queries.GetPatientByID(r.Context(), patientID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, r, http.StatusNotFound, "Patient not found")
+			} else {
+				s.respondWithDBError(w, r, err, "Failed to retrieve patient", "patient_id", patientID)
+			}
+			return
+		}
+
+		caller, _ := principalFromContext(r.Context())
+		grant, err := s.queries.CreateBreakGlassGrant(r.Context(), db.CreateBreakGlassGrantParams{
+			PatientID:       patientID,
+			UserID:          caller.UserID,
+			Reason:          req.Reason,
+			DurationMinutes: int32(s.config.Break_Glass_Minutes),
+		})
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to grant emergency access", "patient_id", patientID, "user_id", caller.UserID)
+			return
+		}
+		// The free-text reason stays in break_glass_grant; it may describe the patient
+		s.log(r).Warn("BREAK-GLASS granted", "patient_id", patientID, "grant_id", grant.GrantID, "expires_at", grant.ExpiresAt.Time)
+
+		respondWithJSON(w, http.StatusCreated, BreakGlassGrantResponse{
+			GrantID:   grant.GrantID,
+			PatientID: grant.PatientID,
+			UserID:    grant.UserID,
+			Reason:    grant.Reason,
+			GrantedAt: grant.GrantedAt,
+			ExpiresAt: grant.ExpiresAt,
+		})
+	}
+}
+
+// handleListCareTeam godoc
+// @Summary      List a patient's care team
+// @Description  Get the users assigned to the patient's care team.
+// @Tags         Patients
+// @Accept       json
+// @Produce      json
+// @Param        patientID path      int true "Patient ID" Format(int32)
+// @Success      200       {array}   CareTeamMemberResponse "Successfully retrieved care team"
+// @Failure      400       {object}  Problem "Invalid Patient ID format"
+// @Failure      403       {object}  Problem "Not on the patient's care team"
+// @Failure      404       {object}  Problem "Patient not found"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /patients/{patientID}/care-team [get]
+func (s *Server) handleListCareTeam() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		patientID, err := parseInt32Param(r, "patientID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
+			return
+		}
+
+		members, err := s.queries.ListCareTeamForPatient(r.Context(), patientID)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to list care team", "patient_id", patientID)
+			return
+		}
+
+		responseMembers := make([]CareTeamMemberResponse, len(members))
+		for i, m := range members {
+			responseMembers[i] = CareTeamMemberResponse{
+				PatientID:   m.PatientID,
+				UserID:      m.UserID,
+				Username:    m.Username,
+				DisplayName: stringPtrFromPgtypeText(m.DisplayName),
+				AssignedBy:  m.AssignedBy,
+				CreatedAt:   m.CreatedAt,
+			}
+		}
+		respondWithJSON(w, http.StatusOK, responseMembers)
+	}
+}
+
+// handleAddCareTeamMember godoc
+// @Summary      Assign a user to a patient's care team
+// @Description  Add a user of the same clinic to the patient's care team. Requires the admin role and access through the care team; a break-glass grant does not allow it.
+// @Tags         Patients
+// @Accept       json
+// @Produce      json
+// @Param        patientID path      int                      true "Patient ID" Format(int32)
+// @Param        member    body      AddCareTeamMemberRequest true "User to assign"
+// @Param        Idempotency-Key header string false "Unique key for this request; a retry with the same key replays the first response"
+// @Success      201       {object}  db.CareTeam "User assigned"
+// @Failure      400       {object}  Problem "Invalid Patient ID or request payload"
+// @Failure      422       {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      403       {object}  Problem "Not an administrator on the patient's care team"
+// @Failure      404       {object}  Problem "User not found in this clinic"
+// @Failure      409       {object}  Problem "User already on the care team"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /patients/{patientID}/care-team [post]
+func (s *Server) handleAddCareTeamMember() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		patientID, err := parseInt32Param(r, "patientID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
+			return
+		}
+
+		var req AddCareTeamMemberRequest
+		if !s.decodeAndValidate(w, r, &req) {
+			return
+		}
+
+		// Only users of the caller's clinic can join the care team
+		caller, _ := principalFromContext(r.Context())
+		clinics, err := s.queries.ListClinicsForUser(r.Context(), req.UserID)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to assign care team member", "user_id", req.UserID)
+			return
+		}
+		inClinic := false
+		for _, c := range clinics {
+			if c.ClinicID == caller.ClinicID {
+				inClinic = true
+			}
+		}
+		if !inClinic {
+			respondWithError(w, r, http.StatusNotFound, "User not found in this clinic")
+			return
+		}
+
+		member, err := s.queries.AddCareTeamMember(r.Context(), db.AddCareTeamMemberParams{
+			PatientID:  patientID,
+			UserID:     req.UserID,
+			AssignedBy: caller.Username,
+		})
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+				respondWithError(w, r, http.StatusConflict, "User already on the care team")
+				return
+			}
+			s.respondWithDBError(w, r, err, "Failed to assign care team member", "user_id", req.UserID, "patient_id", patientID)
+			return
+		}
+
+		respondWithJSON(w, http.StatusCreated, member)
+	}
+}
+
+// handleRemoveCareTeamMember godoc
+// @Summary      Remove a user from a patient's care team
+// @Description  Requires the admin role and access through the care team; a break-glass grant does not allow it.
+// @Tags         Patients
+// @Accept       json
+// @Produce      json
+// @Param        patientID path      int true "Patient ID" Format(int32)
+// @Param        userID    path      int true "User ID" Format(int32)
+// @Success      204       {string}  string "No Content (Successful removal)"
+// @Failure      400       {object}  Problem "Invalid ID format"
+// @Failure      403       {object}  Problem "Not an administrator on the patient's care team"
+// @Failure      404       {object}  Problem "Patient not found"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /patients/{patientID}/care-team/{userID} [delete]
+func (s *Server) handleRemoveCareTeamMember() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		patientID, err := parseInt32Param(r, "patientID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
+			return
+		}
+		userID, err := parseInt32Param(r, "userID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid user ID: "+err.Error())
+			return
+		}
+
+		err = s.queries.RemoveCareTeamMember(r.Context(), db.RemoveCareTeamMemberParams{
+			PatientID: patientID,
+			UserID:    userID,
+		})
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to remove care team member", "user_id", userID, "patient_id", patientID)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleListBreakGlassEvents godoc
+// @Summary      Break-glass review report
+// @Description  Administrator report of every break-glass grant in the clinic, with reason, window and each access made under it. Filter by grant time with since/until (RFC 3339).
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        since query     string false "Only grants at or after this time (RFC 3339)"
+// @Param        until query     string false "Only grants before this time (RFC 3339)"
+// @Success      200   {array}   BreakGlassEventResponse "Break-glass events, newest first"
+// @Failure      400   {object}  Problem "Invalid time filter"
+// @Failure      403   {object}  Problem "Caller is not an administrator"
+// @Failure      429   {object}  Problem "Rate limit exceeded (see Retry-After)"
+// @Failure      500   {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /admin/break-glass-events [get]
+func (s *Server) handleListBreakGlassEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var params db.ListBreakGlassEventsParams
+		for name, target := range map[string]*pgtype.Timestamp{"since": &params.Since, "until": &params.Until} {
+			if value := r.URL.Query().Get(name); value != "" {
+				t, err := time.Parse(time.RFC3339, value)
+				if err != nil {
+					respondWithError(w, r, http.StatusBadRequest, "Invalid "+name+" (use RFC 3339)")
+					return
+				}
+				*target = pgtype.Timestamp{Time: t, Valid: true}
+			}
+		}
+
+		events, err := s.queries.ListBreakGlassEvents(r.Context(), params)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to list break-glass events")
+			return
+		}
+
+		grantIDs := make([]int32, len(events))
+		for i, e := range events {
+			grantIDs[i] = e.GrantID
+		}
+		accesses, err := s.queries.ListBreakGlassAccessesForGrants(r.Context(), grantIDs)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to list break-glass events")
+			return
+		}
+		accessesByGrant := make(map[int32][]BreakGlassAccessResponse)
+		for _, a := range accesses {
+			accessesByGrant[a.GrantID] = append(accessesByGrant[a.GrantID], BreakGlassAccessResponse{
+				Method:     a.Method,
+				Path:       a.Path,
+				AccessedAt: a.AccessedAt,
+			})
+		}
+
+		responseEvents := make([]BreakGlassEventResponse, len(events))
+		for i, e := range events {
+			responseEvents[i] = BreakGlassEventResponse{
+				GrantID:          e.GrantID,
+				PatientID:        e.PatientID,
+				PatientFirstname: e.PatientFirstname,
+				PatientLastname:  e.PatientLastname,
+				UserID:           e.UserID,
+				Username:         e.Username,
+				Reason:           e.Reason,
+				GrantedAt:        e.GrantedAt,
+				ExpiresAt:        e.ExpiresAt,
+				AccessCount:      e.AccessCount,
+				LastAccessedAt:   e.LastAccessedAt,
+				Accesses:         accessesByGrant[e.GrantID],
+			}
+			if responseEvents[i].Accesses == nil {
+				responseEvents[i].Accesses = []BreakGlassAccessResponse{}
+			}
+		}
+		respondWithJSON(w, http.StatusOK, responseEvents)
+	}
+}
@@ -0,0 +1,307 @@
+// server/handlers_patient_merge.go
+package server
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CodePossibleDuplicate rejects a patient write that may register someone
+// twice; the candidates are listed in the problem's duplicates.
+const CodePossibleDuplicate = "possible_duplicate"
+
+// How much each signal says two records are the same person. Signals combine
+// as independent evidence: 1 - (1-a)(1-b)...
+const (
+	registerMatchConfidence = 0.95 // Same civil register number
+	phoneMatchConfidence    = 0.6  // Same phone number; families share one
+	nameMatchConfidence     = 0.85 // Same birthdate, scaled by how alike the names are
+
+	minDuplicateNameSimilarity = 0.6 // pg_trgm similarity of the full names
+	maxDuplicateCandidates     = 5
+)
+
+// swagger:model DuplicateCandidate
+type DuplicateCandidate struct {
+	PatientID   int32   `json:"patient_id" example:"87"`
+	Firstname   string  `json:"firstname"`
+	Lastname    string  `json:"lastname"`
+	Register    string  `json:"register" example:"УБ99032215"`
+	Birthdate   *string `json:"birthdate"` // YYYY-MM-DD or null
+	Phonenumber string  `json:"phonenumber"`
+	Confidence  float64 `json:"confidence" example:"0.98"` // 0 to 1 that this is the same person
+	// Signals that matched: register, phonenumber, name_birthdate
+	Reasons []string `json:"reasons" example:"register,phonenumber"`
+}
+
+// swagger:model MergePatientRequest
+type MergePatientRequest struct {
+	DuplicateID int32   `json:"duplicate_id" validate:"required,gt=0" example:"88"` // Record absorbed into the patient in the path
+	Reason      *string `json:"reason,omitempty" validate:"omitempty,max=1000"`     // Kept in the merge's audit record
+	DryRun      bool    `json:"dry_run,omitempty"`                                  // Report what the merge would change, changing nothing
+}
+
+// swagger:model PatientMergeResponse
+type PatientMergeResponse struct {
+	MergeID                int32             `json:"merge_id,omitempty" example:"5"` // Audit record of the merge; absent on a dry run
+	SurvivorID             int32             `json:"survivor_id" example:"87"`
+	DuplicateID            int32             `json:"duplicate_id" example:"88"`
+	DryRun                 bool              `json:"dry_run"`
+	SymptomsMoved          int64             `json:"symptoms_moved"`           // Reported symptoms moved to the survivor
+	SymptomsCollapsed      int64             `json:"symptoms_collapsed"`       // Dropped: the survivor reported the same symptom that day
+	DiagnosesMoved         int64             `json:"diagnoses_moved"`          // Disease instances moved to the survivor
+	DiagnosesCollapsed     int64             `json:"diagnoses_collapsed"`      // Folded into the survivor's diagnosis of the same disease and date
+	DiagnosisSymptomsMoved int64             `json:"diagnosis_symptoms_moved"` // Symptom links the folded diagnoses handed over
+	StatusHistoryMoved     int64             `json:"status_history_moved"`     // Status history rows the folded diagnoses handed over
+	NotesMerged            int64             `json:"notes_merged"`             // Survivor diagnoses the folded notes were appended to
+	CareTeamAdded          int64             `json:"care_team_added"`          // Members of the duplicate's care team who joined the survivor's
+	MergedAt               *pgtype.Timestamp `json:"merged_at,omitempty"`
+}
+
+// findDuplicates lists the patients of the caller's clinic who may be the
+// person described, best match first. exclude is the patient being updated,
+// or 0.
+func (s *Server) findDuplicates(ctx context.Context, exclude int32, firstname, lastname, register, phonenumber string, birthdate pgtype.Date) ([]DuplicateCandidate, error) {
+	// Compare every spelling of the name, as the patient search does
+	name := strings.ToLower(strings.TrimSpace(firstname) + " " + strings.TrimSpace(lastname))
+	rows, err := s.queries.FindDuplicatePatients(ctx, db.FindDuplicatePatientsParams{
+		Register:          register,
+		Phonenumber:       phonenumber,
+		Birthdate:         birthdate,
+		Name:              name,
+		NameCyrillic:      toCyrillic(name),
+		NameLatin:         toLatin(name),
+		ExcludeID:         exclude,
+		MinNameSimilarity: minDuplicateNameSimilarity,
+		Limit:             maxDuplicateCandidates,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]DuplicateCandidate, 0, len(rows))
+	for _, row := range rows {
+		candidates = append(candidates, newDuplicateCandidate(row))
+	}
+	slices.SortStableFunc(candidates, func(a, b DuplicateCandidate) int {
+		return cmp.Compare(b.Confidence, a.Confidence)
+	})
+	return candidates, nil
+}
+
+// newDuplicateCandidate scores one match from the signals that matched.
+func newDuplicateCandidate(row db.FindDuplicatePatientsRow) DuplicateCandidate {
+	c := DuplicateCandidate{
+		PatientID:   row.PatientID,
+		Firstname:   row.Firstname,
+		Lastname:    row.Lastname,
+		Register:    row.Register,
+		Birthdate:   stringPtrFromPgtypeDate(row.Birthdate),
+		Phonenumber: row.Phonenumber,
+		Reasons:     []string{},
+	}
+	unlikely := 1.0
+	if row.RegisterMatch {
+		unlikely *= 1 - registerMatchConfidence
+		c.Reasons = append(c.Reasons, "register")
+	}
+	if row.PhoneMatch {
+		unlikely *= 1 - phoneMatchConfidence
+		c.Reasons = append(c.Reasons, "phonenumber")
+	}
+	if row.NameSimilarity >= minDuplicateNameSimilarity {
+		unlikely *= 1 - nameMatchConfidence*row.NameSimilarity
+		c.Reasons = append(c.Reasons, "name_birthdate")
+	}
+	c.Confidence = math.Round((1-unlikely)*100) / 100
+	return c
+}
+
+// checkDuplicates stops a patient write that may register someone twice with
+// a 409 listing the candidates, unless the caller overrides it with
+// ?allow_duplicate=true. It writes the error response and returns false when
+// the write must not go ahead.
+func (s *Server) checkDuplicates(w http.ResponseWriter, r *http.Request, exclude int32, firstname, lastname, register, phonenumber string, birthdate pgtype.Date) bool {
+	candidates, err := s.findDuplicates(r.Context(), exclude, firstname, lastname, register, phonenumber, birthdate)
+	if err != nil {
+		s.respondWithDBError(w, r, err, "Failed to check for duplicate patients", "patient_id", exclude)
+		return false
+	}
+	if len(candidates) == 0 {
+		return true
+	}
+	if r.URL.Query().Get("allow_duplicate") == "true" {
+		ids := make([]int32, len(candidates))
+		for i, c := range candidates {
+			ids[i] = c.PatientID
+		}
+		s.log(r).Info("Possible duplicate patient saved on override", "patient_id", exclude, "candidates", ids)
+		return true
+	}
+	respondWithProblem(w, r, &APIError{
+		Status:     http.StatusConflict,
+		Code:       CodePossibleDuplicate,
+		Detail:     "This patient may already be registered; merge the records, or repeat the request with allow_duplicate=true if they are different people",
+		Duplicates: candidates,
+	})
+	return false
+}
+
+// mergePatients moves the duplicate's history to the survivor, soft-deletes
+// the duplicate and records the merge. qtx must be in a transaction holding
+// both patients locked.
+func mergePatients(ctx context.Context, qtx *db.Queries, survivor, duplicate, mergedBy int32, reason *string) (PatientMergeResponse, error) {
+	res := PatientMergeResponse{SurvivorID: survivor, DuplicateID: duplicate}
+	var err error
+	// Collisions go first, so the moves that follow cannot break a unique key
+	if res.SymptomsCollapsed, err = qtx.DeleteCollidingReportedSymptoms(ctx, db.DeleteCollidingReportedSymptomsParams{SurvivorID: survivor, DuplicateID: duplicate}); err != nil {
+		return res, err
+	}
+	if res.SymptomsMoved, err = qtx.MoveReportedSymptoms(ctx, db.MoveReportedSymptomsParams{SurvivorID: survivor, DuplicateID: duplicate}); err != nil {
+		return res, err
+	}
+	// A diagnosis the survivor also has hands over its links, history and
+	// notes before it is dropped
+	if res.DiagnosisSymptomsMoved, err = qtx.MoveCollidingDiagnosisSymptoms(ctx, db.MoveCollidingDiagnosisSymptomsParams{SurvivorID: survivor, DuplicateID: duplicate}); err != nil {
+		return res, err
+	}
+	if res.StatusHistoryMoved, err = qtx.MoveCollidingDiagnosisHistory(ctx, db.MoveCollidingDiagnosisHistoryParams{SurvivorID: survivor, DuplicateID: duplicate}); err != nil {
+		return res, err
+	}
+	if res.NotesMerged, err = qtx.MergeCollidingDiagnosisNotes(ctx, db.MergeCollidingDiagnosisNotesParams{SurvivorID: survivor, DuplicateID: duplicate}); err != nil {
+		return res, err
+	}
+	if res.DiagnosesCollapsed, err = qtx.DeleteCollidingDiagnoses(ctx, db.DeleteCollidingDiagnosesParams{SurvivorID: survivor, DuplicateID: duplicate}); err != nil {
+		return res, err
+	}
+	if res.DiagnosesMoved, err = qtx.MoveDiagnoses(ctx, db.MoveDiagnosesParams{SurvivorID: survivor, DuplicateID: duplicate}); err != nil {
+		return res, err
+	}
+	if res.CareTeamAdded, err = qtx.AddMergedCareTeam(ctx, db.AddMergedCareTeamParams{SurvivorID: survivor, DuplicateID: duplicate}); err != nil {
+		return res, err
+	}
+
+	// The emptied duplicate goes the way of any deleted patient, so it can
+	// still be restored until the purge
+	_, err = qtx.SoftDeletePatient(ctx, db.SoftDeletePatientParams{
+		PatientID:      duplicate,
+		DeletedBy:      mergedBy,
+		DeletionReason: pgtype.Text{String: "Merged into patient " + strconv.Itoa(int(survivor)), Valid: true},
+	})
+	if err != nil {
+		return res, err
+	}
+	merge, err := qtx.CreatePatientMerge(ctx, db.CreatePatientMergeParams{
+		SurvivorID:         survivor,
+		DuplicateID:        duplicate,
+		MergedBy:           mergedBy,
+		Reason:             pgtypeText(reason),
+		SymptomsMoved:      int32(res.SymptomsMoved),
+		SymptomsCollapsed:  int32(res.SymptomsCollapsed),
+		DiagnosesMoved:     int32(res.DiagnosesMoved),
+		DiagnosesCollapsed: int32(res.DiagnosesCollapsed),
+		CareTeamAdded:      int32(res.CareTeamAdded),
+	})
+	if err != nil {
+		return res, err
+	}
+	res.MergeID = merge.PatientMergeID
+	res.MergedAt = &merge.MergedAt
+	return res, nil
+}
+
+// handleMergePatient godoc
+// @Summary      Merge a duplicate patient record
+// @Description  Absorb duplicate_id, a second record of the same person, into the patient in the path, in one transaction. Every reported symptom and disease instance of the duplicate moves to the survivor. A symptom the survivor reported on the same date is dropped instead. A diagnosis the survivor has of the same disease and date hands its symptom links, status history and notes to that one and is dropped. The duplicate's care team joins the survivor's, and the emptied duplicate is soft-deleted. Consents are not moved. An audit record of the merge, with its counts, is kept. The caller needs access to both patients. With dry_run the merge is carried out and rolled back, so the counts are exactly what a real merge would change.
+// @Tags         Patients
+// @Accept       json
+// @Produce      json
+// @Param        patientID path      int                 true "Surviving patient ID" Format(int32)
+// @Param        merge     body      MergePatientRequest true "Duplicate to absorb"
+// @Param        Idempotency-Key header string false "Unique key for this request; a retry with the same key replays the first response"
+// @Success      200       {object}  PatientMergeResponse "What the merge changed (or would change, with dry_run)"
+// @Failure      400       {object}  Problem "Invalid ID, malformed JSON, or a patient merged into itself"
+// @Failure      401       {object}  Problem "Missing or invalid credentials"
+// @Failure      403       {object}  Problem "Caller has no access to one of the patients"
+// @Failure      404       {object}  Problem "Either patient not found"
+// @Failure      422       {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /patients/{patientID}/merge [post]
+func (s *Server) handleMergePatient() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		survivor, err := parseInt32Param(r, "patientID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
+			return
+		}
+		var req MergePatientRequest
+		if !s.decodeAndValidate(w, r, &req) {
+			return
+		}
+		if req.DuplicateID == survivor {
+			respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidValue, Field: "duplicate_id", Detail: "A patient cannot be merged into itself"})
+			return
+		}
+		// The survivor was checked on the way in; the duplicate's history is
+		// read and moved too
+		if _, ok := s.checkPatientAccess(w, r, req.DuplicateID); !ok {
+			return
+		}
+
+		tx, err := s.pool.Begin(r.Context())
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to start merge")
+			return
+		}
+		defer tx.Rollback(r.Context()) // Undoes a dry run, or everything on any failure below
+		qtx := s.queries.WithTx(tx)
+
+		// Locked in ID order, so two merges of the same pair cannot deadlock,
+		// and neither is changed or merged elsewhere meanwhile
+		for _, id := range []int32{min(survivor, req.DuplicateID), max(survivor, req.DuplicateID)} {
+			if _, err := qtx.GetPatientForUpdate(r.Context(), id); err != nil {
+				if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+					respondWithError(w, r, http.StatusNotFound, "Patient "+strconv.Itoa(int(id))+" not found")
+				} else {
+					s.respondWithDBError(w, r, err, "Failed to merge patients", "patient_id", id)
+				}
+				return
+			}
+		}
+
+		caller, _ := principalFromContext(r.Context())
+		res, err := mergePatients(r.Context(), qtx, survivor, req.DuplicateID, caller.UserID, req.Reason)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to merge patients", "patient_id", survivor, "duplicate_id", req.DuplicateID)
+			return
+		}
+		res.DryRun = req.DryRun
+		if req.DryRun {
+			res.MergeID = 0
+			res.MergedAt = nil
+		} else {
+			if err := tx.Commit(r.Context()); err != nil {
+				s.respondWithDBError(w, r, err, "Failed to merge patients", "patient_id", survivor, "duplicate_id", req.DuplicateID)
+				return
+			}
+			// The reason stays in the audit record; it may describe the patient
+			s.log(r).Info("Merged patients", "patient_id", survivor, "duplicate_id", req.DuplicateID, "merge_id", res.MergeID, "merged_by", caller.UserID,
+				"symptoms_moved", res.SymptomsMoved, "symptoms_collapsed", res.SymptomsCollapsed,
+				"diagnoses_moved", res.DiagnosesMoved, "diagnoses_collapsed", res.DiagnosesCollapsed)
+		}
+		respondWithJSON(w, http.StatusOK, res)
+	}
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestNewDuplicateCandidate(t *testing.T) {
+	row := db.FindDuplicatePatientsRow{
+		PatientID:      87,
+		Register:       "УБ99032215",
+		Birthdate:      pgtype.Date{Time: time.Date(1999, 3, 22, 0, 0, 0, 0, time.UTC), Valid: true},
+		RegisterMatch:  true,
+		PhoneMatch:     true,
+		NameSimilarity: 0,
+	}
+	c := newDuplicateCandidate(row)
+
+	// The birthdate is what a clinician confirms a duplicate by
+	if c.Birthdate == nil || *c.Birthdate != "1999-03-22" {
+		t.Errorf("birthdate = %v, want 1999-03-22", c.Birthdate)
+	}
+	// 1 - (1-0.95)(1-0.6)
+	if c.Confidence != 0.98 {
+		t.Errorf("confidence = %v, want 0.98", c.Confidence)
+	}
+	if want := []string{"register", "phonenumber"}; !slices.Equal(c.Reasons, want) {
+		t.Errorf("reasons = %v, want %v", c.Reasons, want)
+	}
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/config"
+	"github.com/dukunuu/munkhjin-diplom/backend/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// A PATCH that leaves birthdate out must keep the stored one, not derive it
+// from the register again.
+func TestPatchPatientKeepsBirthdate(t *testing.T) {
+	s := &Server{config: &config.Config{Max_Body_Bytes: 1 << 20}}
+	current := db.Patient{
+		Firstname:   "Бат",
+		Lastname:    "Дорж",
+		Register:    "УБ99032215",
+		Gender:      "Male",
+		Birthdate:   pgtype.Date{Time: time.Date(1999, 3, 22, 0, 0, 0, 0, time.UTC), Valid: true},
+		Phonenumber: "99123456",
+		Email:       "bat.dorj@example.com",
+	}
+
+	r := httptest.NewRequest(http.MethodPatch, "/patients/1", strings.NewReader(`{"address": "Ulaanbaatar"}`))
+	r.Header.Set("Content-Type", mergePatchContentType)
+	w := httptest.NewRecorder()
+	var req UpdatePatientRequest
+	if !s.decodeMergePatch(w, r, patientPatchBase(current), &req) {
+		t.Fatalf("decodeMergePatch failed: %d %s", w.Code, w.Body.String())
+	}
+
+	if req.Birthdate != "1999-03-22" {
+		t.Errorf("birthdate = %q, want 1999-03-22", req.Birthdate)
+	}
+	if req.Address == nil || *req.Address != "Ulaanbaatar" {
+		t.Errorf("address = %v, want Ulaanbaatar", req.Address)
+	}
+}
+
+func TestNewPatientResponseBirthdate(t *testing.T) {
+	p := db.Patient{
+		Register:  "УБ99032215",
+		Gender:    "Male",
+		Birthdate: pgtype.Date{Time: time.Date(1999, 3, 22, 0, 0, 0, 0, time.UTC), Valid: true},
+	}
+	got := newPatientResponse(p)
+	if got.Birthdate == nil || *got.Birthdate != "1999-03-22" {
+		t.Errorf("birthdate = %v, want 1999-03-22", got.Birthdate)
+	}
+	if len(got.RegisterIssues) != 0 {
+		t.Errorf("register_issues = %v, want none", got.RegisterIssues)
+	}
+}
+
+// Care-team writes are refused under a break-glass grant and allowed through
+// the care team.
+func TestRefuseBreakGlass(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	tests := []struct {
+		name string
+		ctx  func(r *http.Request) *http.Request
+		want int
+	}{
+		{"care team", func(r *http.Request) *http.Request { return r }, http.StatusNoContent},
+		{"break-glass grant", func(r *http.Request) *http.Request {
+			return r.WithContext(context.WithValue(r.Context(), breakGlassKey{}, int32(7)))
+		}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := tt.ctx(httptest.NewRequest(http.MethodPost, "/patients/1/care-team", nil))
+			refuseBreakGlass(next).ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
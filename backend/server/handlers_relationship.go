@@ -2,31 +2,67 @@
 package server
 
 import (
-	"encoding/json"
-	"log"
+	"context"
+	"errors"
 	"net/http"
 	"time" // Needed for diagnosis_date
 
 	"github.com/dukunuu/munkhjin-diplom/backend/db"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype" // Needed for pgtype.Date, pgtype.Text etc.
 )
 
 // swagger:model RecordPatientSymptomRequest Used for adding general symptoms
 type RecordPatientSymptomRequest struct {
-	SymptomID    int32      `json:"symptom_id"`
+	SymptomID    int32      `json:"symptom_id" validate:"required,gt=0"`
 	ReportedDate *time.Time `json:"reported_date,omitempty"` // Use pointer for optional date
 }
 
 // swagger:model RecordPatientDiseaseInstanceRequest
 type RecordPatientDiseaseInstanceRequest struct {
-	DiseaseID     int32      `json:"disease_id"`
+	DiseaseID     int32      `json:"disease_id" validate:"required,gt=0"`
 	DiagnosisDate *time.Time `json:"diagnosis_date,omitempty"` // Use pointer for optional date
-	Notes         *string    `json:"notes,omitempty"`          // Use pointer for optional notes
+	Notes         *string    `json:"notes,omitempty" validate:"omitempty,max=10000"` // Use pointer for optional notes
+	// Defaults to confirmed; a diagnosis cannot be recorded as already refuted or resolved
+	ClinicalStatus string `json:"clinical_status,omitempty" validate:"omitempty,oneof=suspected confirmed chronic" example:"suspected"`
+}
+
+// swagger:model RecordDiagnosisRequest
+type RecordDiagnosisRequest struct {
+	DiseaseID      int32      `json:"disease_id" validate:"required,gt=0"`
+	DiagnosisDate  *time.Time `json:"diagnosis_date,omitempty"`
+	Notes          *string    `json:"notes,omitempty" validate:"omitempty,max=10000"`
+	ClinicalStatus string     `json:"clinical_status,omitempty" validate:"omitempty,oneof=suspected confirmed chronic" example:"suspected"` // Defaults to confirmed
+	SymptomIDs     []int32    `json:"symptom_ids" validate:"required,min=1,max=100,unique,dive,gt=0" example:"1,4,7"` // Symptoms to link to the instance
+	// Also record the symptoms as reported by the patient (patient_symptoms) on the diagnosis date, or today
+	RecordGeneralSymptoms bool `json:"record_general_symptoms,omitempty"`
+}
+
+// swagger:model DiagnosisResponse
+type DiagnosisResponse struct {
+	PatientDiseaseInstanceResponse
+	Symptoms []LinkedSymptomResponse `json:"symptoms"`
+}
+
+// swagger:model LinkedSymptomResponse
+type LinkedSymptomResponse struct {
+	SymptomID          int32   `json:"symptom_id"`
+	SymptomName        string  `json:"symptom_name"`
+	SymptomDescription *string `json:"symptom_description"`
+}
+
+// swagger:model UpdateDiseaseInstanceRequest
+type UpdateDiseaseInstanceRequest struct {
+	DiseaseID     int32   `json:"disease_id" validate:"required,gt=0"`
+	DiagnosisDate *string `json:"diagnosis_date,omitempty" validate:"omitempty,datetime=2006-01-02,pastdate"` // YYYY-MM-DD
+	Notes         *string `json:"notes,omitempty" validate:"omitempty,max=10000"`
+	// Changes must follow the lifecycle: suspected -> confirmed|refuted, confirmed -> resolved|chronic|refuted, chronic -> resolved, resolved -> confirmed
+	ClinicalStatus string `json:"clinical_status" validate:"required,oneof=suspected confirmed refuted resolved chronic" example:"confirmed"`
 }
 
 // swagger:model LinkSymptomToDiseaseInstanceRequest
 type LinkSymptomToDiseaseInstanceRequest struct {
-	SymptomID int32 `json:"symptom_id"`
+	SymptomID int32 `json:"symptom_id" validate:"required,gt=0"`
 }
 
 // swagger:model PatientDiseaseInstanceResponse
@@ -38,8 +74,11 @@ type PatientDiseaseInstanceResponse struct {
 	DiseaseCode      string           `json:"disease_code"` // Added from join
 	DiagnosisDate    pgtype.Date      `json:"diagnosis_date"`
 	Notes            *string          `json:"notes"` // Keep as pointer
+	ClinicalStatus   string           `json:"clinical_status" example:"confirmed"` // suspected, confirmed, refuted, resolved or chronic
+	StatusChangedAt  pgtype.Timestamp `json:"status_changed_at"`
 	CreatedAt        pgtype.Timestamp `json:"created_at"`
 	UpdatedAt        pgtype.Timestamp `json:"updated_at"`
+	Version          int32            `json:"version" example:"1"` // Also sent as the ETag; echo it in If-Match
 }
 
 // --- Helper Functions ---
@@ -52,6 +91,40 @@ func pgDateFromTimePtr(t *time.Time) pgtype.Date {
 	return pgtype.Date{Time: *t, Valid: true}
 }
 
+// Helper to convert a joined disease instance row to PatientDiseaseInstanceResponse
+func newDiseaseInstanceResponse(inst db.GetDiseaseInstanceRow) PatientDiseaseInstanceResponse {
+	return PatientDiseaseInstanceResponse{
+		PatientDiseaseID: inst.PatientDiseaseID,
+		PatientID:        inst.PatientID,
+		DiseaseID:        inst.DiseaseID,
+		DiseaseName:      inst.DiseaseName,
+		DiseaseCode:      inst.DiseaseCode,
+		DiagnosisDate:    inst.DiagnosisDate,
+		Notes:            stringPtrFromPgtypeText(inst.Notes),
+		ClinicalStatus:   inst.ClinicalStatus,
+		StatusChangedAt:  inst.StatusChangedAt,
+		CreatedAt:        inst.CreatedAt,
+		UpdatedAt:        inst.UpdatedAt,
+		Version:          inst.Version,
+	}
+}
+
+// recordDiseaseInstance inserts a disease instance and starts its status
+// history with the status it was recorded with. Run it inside a transaction.
+func recordDiseaseInstance(ctx context.Context, qtx *db.Queries, params db.RecordPatientDiseaseInstanceParams) (db.PatientDisease, error) {
+	instance, err := qtx.RecordPatientDiseaseInstance(ctx, params)
+	if err != nil {
+		return db.PatientDisease{}, err
+	}
+	caller, _ := principalFromContext(ctx)
+	err = qtx.RecordDiseaseInstanceStatus(ctx, db.RecordDiseaseInstanceStatusParams{
+		PatientDiseaseID: instance.PatientDiseaseID,
+		ToStatus:         instance.ClinicalStatus,
+		ChangedBy:        caller.Username,
+	})
+	return instance, err
+}
+
 // Helper to convert *string to pgtype.Text
 func pgTextFromStringPtr(s *string) pgtype.Text {
 	if s == nil {
@@ -70,22 +143,24 @@ func pgTextFromStringPtr(s *string) pgtype.Text {
 // @Produce      json
 // @Param        patientID path      int true "Patient ID" Format(int32)
 // @Success      200       {array}   db.ListGeneralSymptomsForPatientRow "Successfully retrieved general symptoms for patient"
-// @Failure      400       {object}  HTTPError "Invalid Patient ID format"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Failure      400       {object}  Problem "Invalid Patient ID format"
+// @Failure      404       {object}  Problem "Patient not found"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
 // @Router       /patients/{patientID}/general-symptoms [get]
 func (s *Server) handleListGeneralSymptomsForPatient() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		patientID, err := parseInt32Param(r, "patientID")
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, err.Error())
+			respondWithError(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		// Use the correct sqlc generated query name
 		symptoms, err := s.queries.ListGeneralSymptomsForPatient(r.Context(), patientID)
 		if err != nil {
-			log.Printf("Error listing general symptoms for patient %d: %v", patientID, err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to list general symptoms for patient")
+			s.respondWithDBError(w, r, err, "Failed to list general symptoms for patient", "patient_id", patientID)
 			return
 		}
 
@@ -105,29 +180,27 @@ func (s *Server) handleListGeneralSymptomsForPatient() http.HandlerFunc {
 // @Produce      json
 // @Param        patientID path      int                          true "Patient ID" Format(int32)
 // @Param        symptom   body      RecordPatientSymptomRequest true "Symptom ID and optional reported date"
+// @Param        Idempotency-Key header string false "Unique key for this request; a retry with the same key replays the first response"
 // @Success      201       {object}  db.PatientSymptom "Symptom recorded successfully"
-// @Failure      400       {object}  HTTPError "Invalid Patient ID or request payload"
-// @Failure      404       {object}  HTTPError "Patient or Symptom not found (FK constraint)"
-// @Failure      409       {object}  HTTPError "Relationship already exists for this date (unique constraint)"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Header       201       {string}  ETag "Version to send in If-Match when removing it"
+// @Failure      400       {object}  Problem "Invalid Patient ID or request payload"
+// @Failure      422       {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      404       {object}  Problem "Patient or Symptom not found (FK constraint)"
+// @Failure      409       {object}  Problem "Relationship already exists for this date (unique constraint)"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
 // @Router       /patients/{patientID}/general-symptoms [post]
 func (s *Server) handleRecordPatientSymptom() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		patientID, err := parseInt32Param(r, "patientID")
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
+			respondWithError(w, r, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
 			return
 		}
 
 		var req RecordPatientSymptomRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
-			return
-		}
-		defer r.Body.Close()
-
-		if req.SymptomID <= 0 {
-			respondWithError(w, http.StatusBadRequest, "Missing or invalid symptom_id")
+		if !s.decodeAndValidate(w, r, &req) {
 			return
 		}
 
@@ -140,46 +213,58 @@ func (s *Server) handleRecordPatientSymptom() http.HandlerFunc {
 		// Use the correct sqlc generated query name
 		recordedSymptom, err := s.queries.RecordPatientSymptom(r.Context(), params)
 		if err != nil {
-			// Add more specific error checking if needed (e.g., for 409 Conflict)
-			// var pgErr *pgconn.PgError
-			// if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
-			// 	respondWithError(w, http.StatusConflict, "Symptom already recorded for this patient on this date")
-			// 	return
-			// }
-			log.Printf("Error recording symptom %d for patient %d: %v", req.SymptomID, patientID, err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to record symptom for patient")
+			s.respondWithDBError(w, r, err, "Failed to record symptom for patient", "symptom_id", req.SymptomID, "patient_id", patientID)
 			return
 		}
 
+		setETag(w, recordedSymptom.Version)
 		respondWithJSON(w, http.StatusCreated, recordedSymptom)
 	}
 }
 
 // handleDeletePatientSymptom godoc
 // @Summary      Delete a specific general symptom record
-// @Description  Remove a specific patient_symptoms entry by its unique ID.
+// @Description  Remove a specific patient_symptoms entry by its unique ID. Limited to the patient's care team or an open break-glass grant, like the patient's other records. Records of a soft-deleted patient cannot be removed (404) until the patient is restored.
 // @Tags         Patient Relationships
 // @Accept       json
 // @Produce      json
-// @Param        id path      int true "Patient Symptom Record ID" Format(int32)
+// @Param        id       path      int    true "Patient Symptom Record ID" Format(int32)
+// @Param        If-Match header    string true "ETag of the version being removed (record_version in the patient's general symptoms)"
 // @Success      204       {string}  string "No Content (Successful removal)"
-// @Failure      400       {object}  HTTPError "Invalid ID format"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Failure      400       {object}  Problem "Invalid ID format"
+// @Failure      403       {object}  Problem "Not on the patient's care team and no open break-glass grant"
+// @Failure      404       {object}  Problem "Patient symptom record not found, or its patient is deleted"
+// @Failure      412       {object}  Problem "The record changed since the ETag in If-Match was read"
+// @Failure      428       {object}  Problem "If-Match is missing"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
 // @Router       /patient-symptoms/{id} [delete]
 func (s *Server) handleDeletePatientSymptom() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		patientSymptomID, err := parseInt32Param(r, "id") // Get ID from path
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid patient symptom record ID: "+err.Error())
+			respondWithError(w, r, http.StatusBadRequest, "Invalid patient symptom record ID: "+err.Error())
 			return
 		}
 
-		// Use the correct sqlc generated query name
-		err = s.queries.RemovePatientSymptomByID(r.Context(), patientSymptomID)
+		current, err := s.queries.GetPatientSymptomByID(r.Context(), patientSymptomID)
 		if err != nil {
-			// DELETE might not error if the record doesn't exist. Check if needed.
-			log.Printf("Error removing patient symptom record %d: %v", patientSymptomID, err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to remove patient symptom record")
+			s.respondWithDBError(w, r, err, "Failed to retrieve patient symptom record", "patient_symptom_id", patientSymptomID)
+			return
+		}
+		if !checkIfMatch(w, r, current.Version) {
+			return
+		}
+
+		removed, err := s.queries.RemovePatientSymptomByID(r.Context(), db.RemovePatientSymptomByIDParams{ID: patientSymptomID, Version: current.Version})
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to remove patient symptom record", "patient_symptom_id", patientSymptomID)
+			return
+		}
+		if removed == 0 {
+			// Changed or gone meanwhile, or its patient was deleted after the access check
+			respondWithStaleVersion(w, r, 0)
 			return
 		}
 
@@ -191,28 +276,39 @@ func (s *Server) handleDeletePatientSymptom() http.HandlerFunc {
 
 // handleListDiseaseInstancesForPatient godoc
 // @Summary      List disease instances for a specific patient
-// @Description  Get all recorded disease instances (diagnoses) for a given patient ID.
+// @Description  Get the recorded disease instances (diagnoses) for a given patient ID, optionally only those in the given clinical statuses.
 // @Tags         Patient Relationships
 // @Accept       json
 // @Produce      json
-// @Param        patientID path      int true "Patient ID" Format(int32)
+// @Param        patientID path      int    true  "Patient ID" Format(int32)
+// @Param        status    query     string false "Comma-separated clinical statuses to include, e.g. confirmed,chronic"
 // @Success      200       {array}   PatientDiseaseInstanceResponse "Successfully retrieved disease instances"
-// @Failure      400       {object}  HTTPError "Invalid Patient ID format"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Failure      400       {object}  Problem "Invalid Patient ID format or unknown status"
+// @Failure      404       {object}  Problem "Patient not found"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
 // @Router       /patients/{patientID}/disease-instances [get]
 func (s *Server) handleListDiseaseInstancesForPatient() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		patientID, err := parseInt32Param(r, "patientID")
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, err.Error())
+			respondWithError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		statuses, ok := parseStatusFilter(w, r)
+		if !ok {
 			return
 		}
 
 		// Use the correct sqlc generated query name
-		instances, err := s.queries.ListDiseaseInstancesForPatient(r.Context(), patientID)
+		instances, err := s.queries.ListDiseaseInstancesForPatient(r.Context(), db.ListDiseaseInstancesForPatientParams{
+			PatientID: patientID,
+			Statuses:  statuses,
+		})
 		if err != nil {
-			log.Printf("Error listing disease instances for patient %d: %v", patientID, err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to list disease instances for patient")
+			s.respondWithDBError(w, r, err, "Failed to list disease instances for patient", "patient_id", patientID)
 			return
 		}
 
@@ -227,8 +323,11 @@ func (s *Server) handleListDiseaseInstancesForPatient() http.HandlerFunc {
 				DiseaseCode:      inst.DiseaseCode,
 				DiagnosisDate:    inst.DiagnosisDate,
 				Notes:            stringPtrFromPgtypeText(inst.Notes), // Convert pgtype.Text to *string
+				ClinicalStatus:   inst.ClinicalStatus,
+				StatusChangedAt:  inst.StatusChangedAt,
 				CreatedAt:        inst.CreatedAt,
 				UpdatedAt:        inst.UpdatedAt,
+				Version:          inst.Version,
 			}
 		}
 
@@ -244,77 +343,377 @@ func (s *Server) handleListDiseaseInstancesForPatient() http.HandlerFunc {
 // @Produce      json
 // @Param        patientID path      int                                   true "Patient ID" Format(int32)
 // @Param        instance  body      RecordPatientDiseaseInstanceRequest true "Disease ID, optional diagnosis date and notes"
+// @Param        Idempotency-Key header string false "Unique key for this request; a retry with the same key replays the first response"
 // @Success      201       {object}  db.PatientDisease "Disease instance recorded successfully"
-// @Failure      400       {object}  HTTPError "Invalid Patient ID or request payload"
-// @Failure      404       {object}  HTTPError "Patient or Disease not found (FK constraint)"
-// @Failure      409       {object}  HTTPError "Duplicate instance for this patient/disease/date (unique constraint)"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Failure      400       {object}  Problem "Invalid Patient ID or request payload"
+// @Failure      422       {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      404       {object}  Problem "Patient or Disease not found (FK constraint)"
+// @Failure      409       {object}  Problem "Duplicate instance for this patient/disease/date (unique constraint)"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
 // @Router       /patients/{patientID}/disease-instances [post]
 func (s *Server) handleRecordPatientDiseaseInstance() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		patientID, err := parseInt32Param(r, "patientID")
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
+			respondWithError(w, r, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
 			return
 		}
 
 		var req RecordPatientDiseaseInstanceRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		if !s.decodeAndValidate(w, r, &req) {
 			return
 		}
-		defer r.Body.Close()
 
-		if req.DiseaseID <= 0 {
-			respondWithError(w, http.StatusBadRequest, "Missing or invalid disease_id")
-			return
+		if req.ClinicalStatus == "" {
+			req.ClinicalStatus = StatusConfirmed
 		}
 
 		params := db.RecordPatientDiseaseInstanceParams{
-			PatientID:     patientID,
-			DiseaseID:     req.DiseaseID,
-			DiagnosisDate: pgDateFromTimePtr(req.DiagnosisDate), // Convert *time.Time to pgtype.Date
-			Notes:         pgTextFromStringPtr(req.Notes),       // Convert *string to pgtype.Text
+			PatientID:      patientID,
+			DiseaseID:      req.DiseaseID,
+			DiagnosisDate:  pgDateFromTimePtr(req.DiagnosisDate), // Convert *time.Time to pgtype.Date
+			Notes:          pgTextFromStringPtr(req.Notes),       // Convert *string to pgtype.Text
+			ClinicalStatus: req.ClinicalStatus,
 		}
 
-		// Use the correct sqlc generated query name
-		instance, err := s.queries.RecordPatientDiseaseInstance(r.Context(), params)
+		tx, err := s.pool.Begin(r.Context())
 		if err != nil {
-			// Add specific error checking (e.g., 409 Conflict) if needed
-			log.Printf("Error recording disease instance for patient %d, disease %d: %v", patientID, req.DiseaseID, err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to record disease instance")
+			s.respondWithDBError(w, r, err, "Failed to record disease instance")
+			return
+		}
+		defer tx.Rollback(r.Context()) // No-op once committed
+		qtx := s.queries.WithTx(tx)
+
+		instance, err := recordDiseaseInstance(r.Context(), qtx, params)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to record disease instance", "patient_id", patientID, "disease_id", req.DiseaseID)
+			return
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			s.respondWithDBError(w, r, err, "Failed to record disease instance")
 			return
 		}
+		s.metrics.diseaseInstancesRecorded.Inc()
 
+		setETag(w, instance.Version)
 		respondWithJSON(w, http.StatusCreated, instance)
 	}
 }
 
+// handleRecordDiagnosis godoc
+// @Summary      Record a diagnosis with its symptoms
+// @Description  Create a disease instance and link all of its symptoms in one transaction, so a failure leaves nothing half-recorded. With record_general_symptoms the symptoms are also recorded as reported by the patient on the diagnosis date (or today); ones already recorded for that date are kept as they are. Returns the composed instance with its linked symptoms.
+// @Tags         Patient Relationships
+// @Accept       json
+// @Produce      json
+// @Param        patientID path      int                    true "Patient ID" Format(int32)
+// @Param        diagnosis body      RecordDiagnosisRequest true "Disease, symptoms and optional date, notes and status"
+// @Param        Idempotency-Key header string false "Unique key for this request; a retry with the same key replays the first response"
+// @Success      201       {object}  DiagnosisResponse "Diagnosis recorded"
+// @Header       201       {string}  ETag "Version of the disease instance"
+// @Failure      400       {object}  Problem "Invalid Patient ID or request payload"
+// @Failure      422       {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      404       {object}  Problem "Patient, disease or a symptom not found"
+// @Failure      409       {object}  Problem "Duplicate instance for this patient/disease/date (code disease_instance_exists)"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /patients/{patientID}/diagnoses [post]
+func (s *Server) handleRecordDiagnosis() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		patientID, err := parseInt32Param(r, "patientID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid patient ID: "+err.Error())
+			return
+		}
+
+		var req RecordDiagnosisRequest
+		if !s.decodeAndValidate(w, r, &req) {
+			return
+		}
+		if req.ClinicalStatus == "" {
+			req.ClinicalStatus = StatusConfirmed
+		}
+
+		tx, err := s.pool.Begin(r.Context())
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to record diagnosis")
+			return
+		}
+		defer tx.Rollback(r.Context()) // No-op once committed; undoes everything on any failure below
+		qtx := s.queries.WithTx(tx)
+
+		instance, err := recordDiseaseInstance(r.Context(), qtx, db.RecordPatientDiseaseInstanceParams{
+			PatientID:      patientID,
+			DiseaseID:      req.DiseaseID,
+			DiagnosisDate:  pgDateFromTimePtr(req.DiagnosisDate),
+			Notes:          pgTextFromStringPtr(req.Notes),
+			ClinicalStatus: req.ClinicalStatus,
+		})
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to record diagnosis", "patient_id", patientID, "disease_id", req.DiseaseID)
+			return
+		}
+
+		for _, symptomID := range req.SymptomIDs {
+			_, err := qtx.LinkSymptomToPatientDisease(r.Context(), db.LinkSymptomToPatientDiseaseParams{
+				PatientDiseaseID: instance.PatientDiseaseID,
+				SymptomID:        symptomID,
+			})
+			if err != nil {
+				s.respondWithDBError(w, r, err, "Failed to record diagnosis", "instance_id", instance.PatientDiseaseID, "symptom_id", symptomID)
+				return
+			}
+			if req.RecordGeneralSymptoms {
+				_, err := qtx.RecordPatientSymptomIfAbsent(r.Context(), db.RecordPatientSymptomIfAbsentParams{
+					PatientID:    patientID,
+					SymptomID:    symptomID,
+					ReportedDate: instance.DiagnosisDate,
+				})
+				if err != nil {
+					s.respondWithDBError(w, r, err, "Failed to record diagnosis", "patient_id", patientID, "symptom_id", symptomID)
+					return
+				}
+			}
+		}
+
+		// Read the composed instance back inside the transaction
+		composed, err := qtx.GetDiseaseInstance(r.Context(), instance.PatientDiseaseID)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to record diagnosis", "instance_id", instance.PatientDiseaseID)
+			return
+		}
+		linked, err := qtx.GetSymptomsForPatientDiseaseInstance(r.Context(), instance.PatientDiseaseID)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to record diagnosis", "instance_id", instance.PatientDiseaseID)
+			return
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			s.respondWithDBError(w, r, err, "Failed to record diagnosis")
+			return
+		}
+		s.metrics.diseaseInstancesRecorded.Inc()
+
+		response := DiagnosisResponse{
+			PatientDiseaseInstanceResponse: newDiseaseInstanceResponse(composed),
+			Symptoms:                       make([]LinkedSymptomResponse, len(linked)),
+		}
+		for i, sym := range linked {
+			response.Symptoms[i] = LinkedSymptomResponse{
+				SymptomID:          sym.SymptomID,
+				SymptomName:        sym.SymptomName,
+				SymptomDescription: stringPtrFromPgtypeText(sym.SymptomDescription),
+			}
+		}
+		setETag(w, composed.Version)
+		respondWithJSON(w, http.StatusCreated, response)
+	}
+}
+
+// handleGetDiseaseInstance godoc
+// @Summary      Get a disease instance
+// @Description  Retrieve one recorded diagnosis with its disease's name and code.
+// @Tags         Patient Relationships
+// @Produce      json
+// @Param        instanceID path      int true "Patient Disease Instance ID" Format(int32)
+// @Success      200       {object}  PatientDiseaseInstanceResponse "Successfully retrieved disease instance"
+// @Header       200       {string}  ETag "Version of the disease instance"
+// @Success      304       {string}  string "Not Modified (If-None-Match lists the current version)"
+// @Failure      400       {object}  Problem "Invalid ID format"
+// @Failure      404       {object}  Problem "Disease instance not found"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /disease-instances/{instanceID} [get]
+func (s *Server) handleGetDiseaseInstance() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		instanceID, err := parseInt32Param(r, "instanceID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid disease instance ID: "+err.Error())
+			return
+		}
+
+		instance, err := s.queries.GetDiseaseInstance(r.Context(), instanceID)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to retrieve disease instance", "instance_id", instanceID)
+			return
+		}
+		if notModified(w, r, instance.Version) {
+			return
+		}
+		respondWithJSON(w, http.StatusOK, newDiseaseInstanceResponse(instance))
+	}
+}
+
+// diseaseInstancePatchBase is the instance's writable fields, as a PUT would
+// send them; a merge patch applies to these.
+func diseaseInstancePatchBase(current db.GetDiseaseInstanceRow) UpdateDiseaseInstanceRequest {
+	return UpdateDiseaseInstanceRequest{
+		DiseaseID:      current.DiseaseID,
+		DiagnosisDate:  stringPtrFromPgtypeDate(current.DiagnosisDate),
+		Notes:          stringPtrFromPgtypeText(current.Notes),
+		ClinicalStatus: current.ClinicalStatus,
+	}
+}
+
+// handlePatchDiseaseInstance godoc
+// @Summary      Partially update a disease instance
+// @Description  Apply a JSON Merge Patch (RFC 7396) to a recorded diagnosis to correct its disease, diagnosis date or notes, or move its clinical_status; null clears the date or notes. The patient cannot be changed. Status changes must follow the lifecycle (suspected -> confirmed|refuted, confirmed -> resolved|chronic|refuted, chronic -> resolved, resolved -> confirmed), are timestamped in status_changed_at and recorded in the status history. If-Match must carry the ETag from a previous read.
+// @Tags         Patient Relationships
+// @Accept       application/merge-patch+json
+// @Produce      json
+// @Param        instanceID path      int                          true "Patient Disease Instance ID" Format(int32)
+// @Param        If-Match   header    string                       true "ETag of the version being changed"
+// @Param        patch      body      UpdateDiseaseInstanceRequest true "Members to change"
+// @Success      200       {object}  PatientDiseaseInstanceResponse "Disease instance updated successfully"
+// @Header       200       {string}  ETag "Version of the updated disease instance"
+// @Failure      400       {object}  Problem "Malformed JSON, wrong type or unknown field"
+// @Failure      422       {object}  Problem "Patched instance failed validation, or the status change is not allowed (code invalid_status_transition)"
+// @Failure      404       {object}  Problem "Disease instance or disease not found"
+// @Failure      409       {object}  Problem "Duplicate instance for this patient/disease/date (code disease_instance_exists)"
+// @Failure      412       {object}  Problem "The instance changed since the ETag in If-Match was read"
+// @Failure      415       {object}  Problem "Body is not application/merge-patch+json"
+// @Failure      428       {object}  Problem "If-Match is missing"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /disease-instances/{instanceID} [patch]
+func (s *Server) handlePatchDiseaseInstance() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		instanceID, err := parseInt32Param(r, "instanceID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid disease instance ID: "+err.Error())
+			return
+		}
+
+		current, err := s.queries.GetDiseaseInstance(r.Context(), instanceID)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to retrieve disease instance", "instance_id", instanceID)
+			return
+		}
+		if !checkIfMatch(w, r, current.Version) {
+			return
+		}
+
+		var req UpdateDiseaseInstanceRequest
+		if !s.decodeMergePatch(w, r, diseaseInstancePatchBase(current), &req) {
+			return
+		}
+		if apiErr := statusTransitionError(current.ClinicalStatus, req.ClinicalStatus); apiErr != nil {
+			respondWithProblem(w, r, apiErr)
+			return
+		}
+
+		diagnosisDate := pgtype.Date{}
+		if req.DiagnosisDate != nil {
+			if diagnosisDate, err = pgDateFromString(*req.DiagnosisDate); err != nil {
+				respondWithError(w, r, http.StatusBadRequest, "Invalid diagnosis_date format (use YYYY-MM-DD)")
+				return
+			}
+		}
+
+		tx, err := s.pool.Begin(r.Context())
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to update disease instance")
+			return
+		}
+		defer tx.Rollback(r.Context()) // No-op once committed
+		qtx := s.queries.WithTx(tx)
+
+		_, err = qtx.UpdatePatientDiseaseInstance(r.Context(), db.UpdatePatientDiseaseInstanceParams{
+			PatientDiseaseID: instanceID,
+			DiseaseID:        req.DiseaseID,
+			DiagnosisDate:    diagnosisDate,
+			Notes:            pgTextFromStringPtr(req.Notes),
+			ClinicalStatus:   req.ClinicalStatus,
+			Version:          current.Version,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				// The row existed a moment ago, so another write got in first
+				respondWithStaleVersion(w, r, 0)
+			} else {
+				s.respondWithDBError(w, r, err, "Failed to update disease instance", "instance_id", instanceID)
+			}
+			return
+		}
+
+		if req.ClinicalStatus != current.ClinicalStatus {
+			caller, _ := principalFromContext(r.Context())
+			err = qtx.RecordDiseaseInstanceStatus(r.Context(), db.RecordDiseaseInstanceStatusParams{
+				PatientDiseaseID: instanceID,
+				FromStatus:       pgtype.Text{String: current.ClinicalStatus, Valid: true},
+				ToStatus:         req.ClinicalStatus,
+				ChangedBy:        caller.Username,
+			})
+			if err != nil {
+				s.respondWithDBError(w, r, err, "Failed to update disease instance", "instance_id", instanceID)
+				return
+			}
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			s.respondWithDBError(w, r, err, "Failed to update disease instance")
+			return
+		}
+
+		// Read back with the (possibly new) disease's name and code
+		updated, err := s.queries.GetDiseaseInstance(r.Context(), instanceID)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to retrieve disease instance", "instance_id", instanceID)
+			return
+		}
+		setETag(w, updated.Version)
+		respondWithJSON(w, http.StatusOK, newDiseaseInstanceResponse(updated))
+	}
+}
+
 // handleDeletePatientDiseaseInstance godoc
 // @Summary      Delete a specific disease instance record
 // @Description  Remove a specific patient_disease entry by its unique ID (patient_disease_id). This also removes associated symptom links via ON DELETE CASCADE.
 // @Tags         Patient Relationships
 // @Accept       json
 // @Produce      json
-// @Param        instanceID path      int true "Patient Disease Instance ID" Format(int32)
+// @Param        instanceID path      int    true "Patient Disease Instance ID" Format(int32)
+// @Param        If-Match   header    string true "ETag of the version being removed"
 // @Success      204       {string}  string "No Content (Successful removal)"
-// @Failure      400       {object}  HTTPError "Invalid ID format"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Failure      400       {object}  Problem "Invalid ID format"
+// @Failure      404       {object}  Problem "Disease instance not found"
+// @Failure      412       {object}  Problem "The instance changed since the ETag in If-Match was read"
+// @Failure      428       {object}  Problem "If-Match is missing"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
 // @Router       /disease-instances/{instanceID} [delete]
 func (s *Server) handleDeletePatientDiseaseInstance() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		instanceID, err := parseInt32Param(r, "instanceID") // Get ID from path
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid disease instance ID: "+err.Error())
+			respondWithError(w, r, http.StatusBadRequest, "Invalid disease instance ID: "+err.Error())
 			return
 		}
 
-		// Use the correct sqlc generated query name
-		err = s.queries.DeletePatientDiseaseInstance(r.Context(), instanceID)
+		current, err := s.queries.GetDiseaseInstance(r.Context(), instanceID)
 		if err != nil {
-			// DELETE might not error if the record doesn't exist.
-			log.Printf("Error removing disease instance %d: %v", instanceID, err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to remove disease instance")
+			s.respondWithDBError(w, r, err, "Failed to retrieve disease instance", "instance_id", instanceID)
+			return
+		}
+		if !checkIfMatch(w, r, current.Version) {
+			return
+		}
+
+		removed, err := s.queries.DeletePatientDiseaseInstance(r.Context(), db.DeletePatientDiseaseInstanceParams{PatientDiseaseID: instanceID, Version: current.Version})
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to remove disease instance", "instance_id", instanceID)
+			return
+		}
+		if removed == 0 {
+			// The row existed a moment ago, so another write got in first
+			respondWithStaleVersion(w, r, 0)
 			return
 		}
 
@@ -332,15 +731,17 @@ func (s *Server) handleDeletePatientDiseaseInstance() http.HandlerFunc {
 // @Produce      json
 // @Param        instanceID path      int true "Patient Disease Instance ID" Format(int32)
 // @Success      200       {array}   db.GetSymptomsForPatientDiseaseInstanceRow "Successfully retrieved symptoms for the instance"
-// @Failure      400       {object}  HTTPError "Invalid Instance ID format"
-// @Failure      404       {object}  HTTPError "Disease instance not found"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Failure      400       {object}  Problem "Invalid Instance ID format"
+// @Failure      404       {object}  Problem "Disease instance not found"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
 // @Router       /disease-instances/{instanceID}/symptoms [get]
 func (s *Server) handleGetSymptomsForDiseaseInstance() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		instanceID, err := parseInt32Param(r, "instanceID")
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid disease instance ID: "+err.Error())
+			respondWithError(w, r, http.StatusBadRequest, "Invalid disease instance ID: "+err.Error())
 			return
 		}
 
@@ -348,8 +749,7 @@ func (s *Server) handleGetSymptomsForDiseaseInstance() http.HandlerFunc {
 		symptoms, err := s.queries.GetSymptomsForPatientDiseaseInstance(r.Context(), instanceID)
 		if err != nil {
 			// Check if the instance itself was not found (though the query might just return empty)
-			log.Printf("Error listing symptoms for disease instance %d: %v", instanceID, err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to list symptoms for disease instance")
+			s.respondWithDBError(w, r, err, "Failed to list symptoms for disease instance", "instance_id", instanceID)
 			return
 		}
 
@@ -367,29 +767,26 @@ func (s *Server) handleGetSymptomsForDiseaseInstance() http.HandlerFunc {
 // @Produce      json
 // @Param        instanceID path      int                                   true "Patient Disease Instance ID" Format(int32)
 // @Param        link       body      LinkSymptomToDiseaseInstanceRequest true "Symptom ID to link"
+// @Param        Idempotency-Key header string false "Unique key for this request; a retry with the same key replays the first response"
 // @Success      201       {object}  db.PatientDiseaseSymptom "Symptom linked successfully"
-// @Failure      400       {object}  HTTPError "Invalid Instance ID or request payload"
-// @Failure      404       {object}  HTTPError "Disease instance or Symptom not found (FK constraint)"
-// @Failure      409       {object}  HTTPError "Symptom already linked to this instance (unique constraint)"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Failure      400       {object}  Problem "Invalid Instance ID or request payload"
+// @Failure      422       {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      404       {object}  Problem "Disease instance or Symptom not found (FK constraint)"
+// @Failure      409       {object}  Problem "Symptom already linked to this instance (unique constraint)"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
 // @Router       /disease-instances/{instanceID}/symptoms [post]
 func (s *Server) handleLinkSymptomToDiseaseInstance() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		instanceID, err := parseInt32Param(r, "instanceID")
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid disease instance ID: "+err.Error())
+			respondWithError(w, r, http.StatusBadRequest, "Invalid disease instance ID: "+err.Error())
 			return
 		}
 
 		var req LinkSymptomToDiseaseInstanceRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
-			return
-		}
-		defer r.Body.Close()
-
-		if req.SymptomID <= 0 {
-			respondWithError(w, http.StatusBadRequest, "Missing or invalid symptom_id")
+		if !s.decodeAndValidate(w, r, &req) {
 			return
 		}
 
@@ -401,9 +798,7 @@ func (s *Server) handleLinkSymptomToDiseaseInstance() http.HandlerFunc {
 		// Use the correct sqlc generated query name
 		link, err := s.queries.LinkSymptomToPatientDisease(r.Context(), params)
 		if err != nil {
-			// Add specific error checking (e.g., 409 Conflict) if needed
-			log.Printf("Error linking symptom %d to disease instance %d: %v", req.SymptomID, instanceID, err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to link symptom to disease instance")
+			s.respondWithDBError(w, r, err, "Failed to link symptom to disease instance", "symptom_id", req.SymptomID, "instance_id", instanceID)
 			return
 		}
 
@@ -420,19 +815,21 @@ func (s *Server) handleLinkSymptomToDiseaseInstance() http.HandlerFunc {
 // @Param        instanceID path      int true "Patient Disease Instance ID" Format(int32)
 // @Param        symptomID  path      int true "Symptom ID to unlink" Format(int32)
 // @Success      204       {string}  string "No Content (Successful removal)"
-// @Failure      400       {object}  HTTPError "Invalid Instance ID or Symptom ID format"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Failure      400       {object}  Problem "Invalid Instance ID or Symptom ID format"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
 // @Router       /disease-instances/{instanceID}/symptoms/{symptomID} [delete]
 func (s *Server) handleUnlinkSymptomFromDiseaseInstance() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		instanceID, err := parseInt32Param(r, "instanceID")
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid disease instance ID: "+err.Error())
+			respondWithError(w, r, http.StatusBadRequest, "Invalid disease instance ID: "+err.Error())
 			return
 		}
 		symptomID, err := parseInt32Param(r, "symptomID")
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid symptom ID: "+err.Error())
+			respondWithError(w, r, http.StatusBadRequest, "Invalid symptom ID: "+err.Error())
 			return
 		}
 
@@ -445,8 +842,7 @@ func (s *Server) handleUnlinkSymptomFromDiseaseInstance() http.HandlerFunc {
 		err = s.queries.UnlinkSymptomFromPatientDisease(r.Context(), params)
 		if err != nil {
 			// DELETE might not error if the link doesn't exist.
-			log.Printf("Error unlinking symptom %d from disease instance %d: %v", symptomID, instanceID, err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to unlink symptom from disease instance")
+			s.respondWithDBError(w, r, err, "Failed to unlink symptom from disease instance", "symptom_id", symptomID, "instance_id", instanceID)
 			return
 		}
 
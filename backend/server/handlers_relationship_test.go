@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/config"
+	"github.com/dukunuu/munkhjin-diplom/backend/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestStringFromPgtypeDate(t *testing.T) {
+	d := pgtype.Date{Time: time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), Valid: true}
+	if got := stringFromPgtypeDate(d); got != "2024-03-09" {
+		t.Errorf("stringFromPgtypeDate(%v) = %q, want 2024-03-09", d, got)
+	}
+	if got := stringPtrFromPgtypeDate(pgtype.Date{}); got != nil {
+		t.Errorf("stringPtrFromPgtypeDate(NULL) = %q, want nil", *got)
+	}
+}
+
+// A PATCH that leaves diagnosis_date out, such as a status change, must keep
+// the stored date.
+func TestPatchDiseaseInstanceKeepsDiagnosisDate(t *testing.T) {
+	s := &Server{config: &config.Config{Max_Body_Bytes: 1 << 20}}
+	current := db.GetDiseaseInstanceRow{
+		DiseaseID:      3,
+		DiagnosisDate:  pgtype.Date{Time: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), Valid: true},
+		ClinicalStatus: "suspected",
+	}
+
+	r := httptest.NewRequest(http.MethodPatch, "/disease-instances/1", strings.NewReader(`{"clinical_status": "confirmed"}`))
+	r.Header.Set("Content-Type", mergePatchContentType)
+	w := httptest.NewRecorder()
+	var req UpdateDiseaseInstanceRequest
+	if !s.decodeMergePatch(w, r, diseaseInstancePatchBase(current), &req) {
+		t.Fatalf("decodeMergePatch failed: %d %s", w.Code, w.Body.String())
+	}
+
+	if req.DiagnosisDate == nil || *req.DiagnosisDate != "2024-01-15" {
+		t.Errorf("diagnosis_date = %v, want 2024-01-15", req.DiagnosisDate)
+	}
+	if req.ClinicalStatus != "confirmed" {
+		t.Errorf("clinical_status = %q, want confirmed", req.ClinicalStatus)
+	}
+}
@@ -0,0 +1,241 @@
+// server/handlers_search.go
+package server
+
+import (
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/db"
+)
+
+// Bounds on GET /patients/search.
+const (
+	minSearchLength    = 2
+	maxSearchLength    = 100
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+// fuzzyHighlightSimilarity is how similar (pg_trgm style) a word must be to a
+// search token to be highlighted when it does not contain it literally.
+const fuzzyHighlightSimilarity = 0.4
+
+// swagger:model PatientSearchResult
+type PatientSearchResult struct {
+	PatientResponse
+	Score float64 `json:"score" example:"1.35"` // Higher is a better match
+	// Matching fields (firstname, lastname, register, phonenumber, email) with
+	// the matching parts wrapped in <mark>; the rest of the value is HTML-escaped
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// searchTokens splits a lower-case query into words of letters and digits.
+func searchTokens(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// prefixTSQuery matches documents holding a word starting with every token,
+// in any one of the spellings: (бат:* & болд:*) | (bat:* & bold:*).
+// Tokens are letters and digits only, so they need no quoting.
+func prefixTSQuery(spellings ...string) string {
+	seen := map[string]bool{}
+	var alternatives []string
+	for _, spelling := range spellings {
+		tokens := searchTokens(spelling)
+		if len(tokens) == 0 || seen[spelling] {
+			continue
+		}
+		seen[spelling] = true
+		for i, token := range tokens {
+			tokens[i] = token + ":*"
+		}
+		alternatives = append(alternatives, "("+strings.Join(tokens, " & ")+")")
+	}
+	return strings.Join(alternatives, " | ")
+}
+
+// escapeLike makes a term match literally inside an ILIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// trigrams returns the pg_trgm trigrams of a lower-case word.
+func trigrams(word string) map[string]bool {
+	runes := []rune("  " + word + " ")
+	set := make(map[string]bool, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		set[string(runes[i:i+3])] = true
+	}
+	return set
+}
+
+// trigramSimilarity is pg_trgm's similarity of two lower-case words: shared
+// trigrams over all distinct trigrams.
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// highlight wraps the parts of value that contain a token in <mark>, or, when
+// none does, the words similar to a token (a misspelling). It returns false
+// when nothing in value matches.
+func highlight(value string, tokens []string) (string, bool) {
+	runes := []rune(value)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	marked := make([]bool, len(runes))
+	found := false
+
+	for _, token := range tokens {
+		t := []rune(token)
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if string(lower[i:i+len(t)]) == token {
+				for j := i; j < i+len(t); j++ {
+					marked[j] = true
+				}
+				found = true
+			}
+		}
+	}
+	if !found {
+		for start := 0; start < len(runes); {
+			if !unicode.IsLetter(runes[start]) && !unicode.IsDigit(runes[start]) {
+				start++
+				continue
+			}
+			end := start
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end])) {
+				end++
+			}
+			word := string(lower[start:end])
+			for _, token := range tokens {
+				if trigramSimilarity(word, token) >= fuzzyHighlightSimilarity {
+					for j := start; j < end; j++ {
+						marked[j] = true
+					}
+					found = true
+					break
+				}
+			}
+			start = end
+		}
+	}
+	if !found {
+		return "", false
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && marked[j] == marked[i] {
+			j++
+		}
+		part := html.EscapeString(string(runes[i:j]))
+		if marked[i] {
+			part = "<mark>" + part + "</mark>"
+		}
+		b.WriteString(part)
+		i = j
+	}
+	return b.String(), true
+}
+
+// handleSearchPatients godoc
+// @Summary      Search patients
+// @Description  Find patients by part of their name, register number, phone or email, tolerating misspellings. Latin input also finds Cyrillic names and the other way round ("Bat" finds "Бат"). Results are ranked by similarity, best first, and say which fields matched. Only patients on the caller's care team or under an open break-glass grant are searched.
+// @Tags         Patients
+// @Produce      json
+// @Param        q      query     string  true   "Search text (2-100 characters)"
+// @Param        limit  query     int     false  "Maximum results (at most 50)" default(20)
+// @Success      200    {array}   PatientSearchResult "Matching patients, best match first"
+// @Failure      400    {object}  Problem "Missing, too short or too long query, or invalid limit"
+// @Failure      429    {object}  Problem "Rate limit exceeded (see Retry-After)"
+// @Failure      500    {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /patients/search [get]
+func (s *Server) handleSearchPatients() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+		if n := utf8.RuneCountInString(q); n < minSearchLength || n > maxSearchLength {
+			respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidValue, Field: "q", Detail: "q must be 2 to 100 characters"})
+			return
+		}
+		limit := int64(defaultSearchLimit)
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			var err error
+			limit, err = strconv.ParseInt(raw, 10, 32)
+			if err != nil || limit <= 0 {
+				respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidValue, Field: "limit", Detail: "limit must be a positive integer"})
+				return
+			}
+			limit = min(limit, maxSearchLimit)
+		}
+
+		// Search every spelling: as typed, in Cyrillic and in Latin letters
+		cyrillic, latin := toCyrillic(q), toLatin(q)
+		tsquery := prefixTSQuery(q, cyrillic, latin)
+		if tsquery == "" {
+			respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidValue, Field: "q", Detail: "q must contain letters or digits"})
+			return
+		}
+		caller, _ := principalFromContext(r.Context())
+		rows, err := s.queries.SearchPatients(r.Context(), db.SearchPatientsParams{
+			UserID:       caller.UserID,
+			Term:         escapeLike(q),
+			TermCyrillic: escapeLike(cyrillic),
+			TermLatin:    escapeLike(latin),
+			Tsquery:      tsquery,
+			Limit:        int32(limit),
+		})
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to search patients")
+			return
+		}
+
+		var tokens []string
+		for _, spelling := range []string{q, cyrillic, latin} {
+			tokens = append(tokens, searchTokens(spelling)...)
+		}
+		results := make([]PatientSearchResult, len(rows))
+		for i, row := range rows {
+			results[i] = PatientSearchResult{
+				PatientResponse: newPatientResponse(db.Patient{
+					PatientID: row.PatientID, Firstname: row.Firstname, Lastname: row.Lastname,
+					Register: row.Register, Gender: row.Gender, Birthdate: row.Birthdate,
+					Address: row.Address, Phonenumber: row.Phonenumber, Email: row.Email,
+					ClinicID: row.ClinicID, CreatedAt: row.CreatedAt, UpdatedAt: row.UpdatedAt,
+					Version: row.Version,
+				}),
+				Score: row.Score,
+			}
+			fields := map[string]string{
+				"firstname": row.Firstname, "lastname": row.Lastname, "register": row.Register,
+				"phonenumber": row.Phonenumber, "email": row.Email,
+			}
+			for name, value := range fields {
+				if marked, ok := highlight(value, tokens); ok {
+					if results[i].Highlights == nil {
+						results[i].Highlights = map[string]string{}
+					}
+					results[i].Highlights[name] = marked
+				}
+			}
+		}
+		respondWithJSON(w, http.StatusOK, results)
+	}
+}
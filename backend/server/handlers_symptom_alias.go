@@ -0,0 +1,261 @@
+// server/handlers_symptom_alias.go
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Defaults for aliases created without a language or source.
+const (
+	defaultAliasLanguage = "mn"
+	defaultAliasSource   = "manual"
+)
+
+// swagger:model SymptomAliasRequest
+type SymptomAliasRequest struct {
+	Alias    string `json:"alias" validate:"required,notblank,max=255" example:"Халуурах"`
+	Language string `json:"language,omitempty" validate:"omitempty,max=35,bcp47_language_tag" example:"mn"` // BCP 47 tag; defaults to mn
+	Source   string `json:"source,omitempty" validate:"omitempty,max=50" example:"clinician"`              // Where the alias came from, e.g. seed or clinician; defaults to manual
+}
+
+// swagger:model SymptomAliasResponse
+type SymptomAliasResponse struct {
+	SymptomAliasID int32            `json:"symptom_alias_id"`
+	SymptomID      int32            `json:"symptom_id"`
+	Alias          string           `json:"alias"`
+	Language       string           `json:"language"`
+	Source         string           `json:"source"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+	UpdatedAt      pgtype.Timestamp `json:"updated_at"`
+}
+
+func newSymptomAliasResponse(a db.SymptomAlias) SymptomAliasResponse {
+	return SymptomAliasResponse{
+		SymptomAliasID: a.SymptomAliasID,
+		SymptomID:      a.SymptomID,
+		Alias:          a.Alias,
+		Language:       a.Language,
+		Source:         a.Source,
+		CreatedAt:      a.CreatedAt,
+		UpdatedAt:      a.UpdatedAt,
+	}
+}
+
+// normalize trims the alias and fills in the defaults.
+func (req *SymptomAliasRequest) normalize() {
+	req.Alias = strings.TrimSpace(req.Alias)
+	if req.Language == "" {
+		req.Language = defaultAliasLanguage
+	}
+	if req.Source == "" {
+		req.Source = defaultAliasSource
+	}
+}
+
+// aliasNamesOtherSymptom rejects an alias that is already another symptom's
+// name: lookups prefer names, so such an alias would never resolve. It writes
+// the error response and returns true when the alias is unusable.
+func (s *Server) aliasNamesOtherSymptom(w http.ResponseWriter, r *http.Request, symptomID int32, alias string) bool {
+	resolved, err := s.queries.ResolveSymptomNames(r.Context(), []string{alias})
+	if err != nil {
+		s.respondWithDBError(w, r, err, "Failed to check symptom alias", "symptom_id", symptomID)
+		return true
+	}
+	for _, row := range resolved {
+		if row.SymptomID != symptomID && strings.EqualFold(row.SymptomName, alias) {
+			respondWithProblem(w, r, &APIError{Status: http.StatusConflict, Code: "symptom_alias_taken", Field: "alias", Detail: fmt.Sprintf("%q is the name of symptom %d", alias, row.SymptomID)})
+			return true
+		}
+	}
+	return false
+}
+
+// handleListSymptomAliases godoc
+// @Summary      List aliases of a symptom
+// @Description  Get the other names that resolve to a symptom, alphabetically.
+// @Tags         Symptoms
+// @Produce      json
+// @Param        symptomID path      int true "Symptom ID" Format(int32)
+// @Success      200       {array}   SymptomAliasResponse "Successfully retrieved aliases"
+// @Failure      400       {object}  Problem "Invalid Symptom ID format"
+// @Failure      429       {object}  Problem "Rate limit exceeded (see Retry-After)"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Router       /symptoms/{symptomID}/aliases [get]
+func (s *Server) handleListSymptomAliases() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		symptomID, err := parseInt32Param(r, "symptomID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid symptom ID: "+err.Error())
+			return
+		}
+
+		aliases, err := s.queries.ListSymptomAliases(r.Context(), symptomID)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to list symptom aliases", "symptom_id", symptomID)
+			return
+		}
+
+		response := make([]SymptomAliasResponse, len(aliases))
+		for i, a := range aliases {
+			response[i] = newSymptomAliasResponse(a)
+		}
+		respondWithJSON(w, http.StatusOK, response)
+	}
+}
+
+// handleCreateSymptomAlias godoc
+// @Summary      Add an alias to a symptom
+// @Description  Record another name for a symptom, such as a spelling variant, synonym or translation. Only admins can change aliases. Catalog search and lookups by name then resolve it to this symptom. An alias can name only one symptom, and cannot be another symptom's name.
+// @Tags         Symptoms
+// @Accept       json
+// @Produce      json
+// @Param        symptomID path      int                 true "Symptom ID" Format(int32)
+// @Param        alias     body      SymptomAliasRequest true "Alias to add"
+// @Param        Idempotency-Key header string false "Unique key for this request; a retry with the same key replays the first response"
+// @Success      201       {object}  SymptomAliasResponse "Alias created successfully"
+// @Failure      400       {object}  Problem "Invalid Symptom ID or malformed JSON"
+// @Failure      401       {object}  Problem "Missing or invalid credentials"
+// @Failure      403       {object}  Problem "Caller is not an admin"
+// @Failure      422       {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      404       {object}  Problem "Symptom not found"
+// @Failure      409       {object}  Problem "The alias already names a symptom"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /symptoms/{symptomID}/aliases [post]
+func (s *Server) handleCreateSymptomAlias() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		symptomID, err := parseInt32Param(r, "symptomID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid symptom ID: "+err.Error())
+			return
+		}
+
+		var req SymptomAliasRequest
+		if !s.decodeAndValidate(w, r, &req) {
+			return
+		}
+		req.normalize()
+		if s.aliasNamesOtherSymptom(w, r, symptomID, req.Alias) {
+			return
+		}
+
+		alias, err := s.queries.CreateSymptomAlias(r.Context(), db.CreateSymptomAliasParams{
+			SymptomID: symptomID,
+			Alias:     req.Alias,
+			Language:  req.Language,
+			Source:    req.Source,
+		})
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to create symptom alias", "symptom_id", symptomID)
+			return
+		}
+		respondWithJSON(w, http.StatusCreated, newSymptomAliasResponse(alias))
+	}
+}
+
+// handleUpdateSymptomAlias godoc
+// @Summary      Update a symptom alias
+// @Description  Replace an alias's text, language and source.
+// @Tags         Symptoms
+// @Accept       json
+// @Produce      json
+// @Param        symptomID path      int                 true "Symptom ID" Format(int32)
+// @Param        aliasID   path      int                 true "Alias ID" Format(int32)
+// @Param        alias     body      SymptomAliasRequest true "New alias data"
+// @Success      200       {object}  SymptomAliasResponse "Alias updated successfully"
+// @Failure      400       {object}  Problem "Invalid ID or malformed JSON"
+// @Failure      401       {object}  Problem "Missing or invalid credentials"
+// @Failure      403       {object}  Problem "Caller is not an admin"
+// @Failure      422       {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      404       {object}  Problem "No alias with this ID for the symptom"
+// @Failure      409       {object}  Problem "The alias already names a symptom"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /symptoms/{symptomID}/aliases/{aliasID} [put]
+func (s *Server) handleUpdateSymptomAlias() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		symptomID, err := parseInt32Param(r, "symptomID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid symptom ID: "+err.Error())
+			return
+		}
+		aliasID, err := parseInt32Param(r, "aliasID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid alias ID: "+err.Error())
+			return
+		}
+
+		var req SymptomAliasRequest
+		if !s.decodeAndValidate(w, r, &req) {
+			return
+		}
+		req.normalize()
+		if s.aliasNamesOtherSymptom(w, r, symptomID, req.Alias) {
+			return
+		}
+
+		alias, err := s.queries.UpdateSymptomAlias(r.Context(), db.UpdateSymptomAliasParams{
+			SymptomAliasID: aliasID,
+			SymptomID:      symptomID,
+			Alias:          req.Alias,
+			Language:       req.Language,
+			Source:         req.Source,
+		})
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to update symptom alias", "symptom_id", symptomID, "symptom_alias_id", aliasID)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, newSymptomAliasResponse(alias))
+	}
+}
+
+// handleDeleteSymptomAlias godoc
+// @Summary      Delete a symptom alias
+// @Description  Remove an alias; the name no longer resolves to the symptom.
+// @Tags         Symptoms
+// @Param        symptomID path      int true "Symptom ID" Format(int32)
+// @Param        aliasID   path      int true "Alias ID" Format(int32)
+// @Success      204       {string}  string "No Content (Successful deletion)"
+// @Failure      400       {object}  Problem "Invalid ID format"
+// @Failure      401       {object}  Problem "Missing or invalid credentials"
+// @Failure      403       {object}  Problem "Caller is not an admin"
+// @Failure      404       {object}  Problem "No alias with this ID for the symptom"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Security     UserHeader
+// @Security     BearerToken
+// @Router       /symptoms/{symptomID}/aliases/{aliasID} [delete]
+func (s *Server) handleDeleteSymptomAlias() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		symptomID, err := parseInt32Param(r, "symptomID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid symptom ID: "+err.Error())
+			return
+		}
+		aliasID, err := parseInt32Param(r, "aliasID")
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid alias ID: "+err.Error())
+			return
+		}
+
+		deleted, err := s.queries.DeleteSymptomAlias(r.Context(), db.DeleteSymptomAliasParams{
+			SymptomAliasID: aliasID,
+			SymptomID:      symptomID,
+		})
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to delete symptom alias", "symptom_id", symptomID, "symptom_alias_id", aliasID)
+			return
+		}
+		if deleted == 0 {
+			respondWithError(w, r, http.StatusNotFound, "Symptom alias not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
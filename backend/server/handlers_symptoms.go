@@ -3,10 +3,10 @@ package server
 
 import (
 	"database/sql"
-	"encoding/json"
 	"errors"
-	"log"
 	"net/http"
+	"strings"
+	"unicode/utf8"
 	// "strconv" // Needed if parseInt32Param is defined here
 
 	"github.com/dukunuu/munkhjin-diplom/backend/db" // Adjust import path
@@ -19,58 +19,167 @@ import (
 
 // swagger:model CreateSymptomRequest
 type CreateSymptomRequest struct {
-	SymptomName        string  `json:"symptom_name"` // Changed to string (required)
-	SymptomDescription *string `json:"symptom_description,omitempty"`
+	SymptomName        string  `json:"symptom_name" validate:"required,notblank,max=255"`
+	SymptomDescription *string `json:"symptom_description,omitempty" validate:"omitempty,max=10000"`
 }
 
 // swagger:model UpdateSymptomRequest
 type UpdateSymptomRequest struct {
-	SymptomName        string  `json:"symptom_name"` // Changed to string (required)
-	SymptomDescription *string `json:"symptom_description,omitempty"`
+	SymptomName        string  `json:"symptom_name" validate:"required,notblank,max=255"`
+	SymptomDescription *string `json:"symptom_description,omitempty" validate:"omitempty,max=10000"`
 }
 
 // swagger:model SymptomResponse
 type SymptomResponse struct {
-	SymptomID          int32            `json:"symptom_id"`
-	SymptomName        string           `json:"symptom_name"` // Changed to string (cannot be null)
-	SymptomDescription *string          `json:"symptom_description"`
-	CreatedAt          pgtype.Timestamp `json:"created_at"`
-	UpdatedAt          pgtype.Timestamp `json:"updated_at"`
+	SymptomID          int32                  `json:"symptom_id"`
+	SymptomName        string                 `json:"symptom_name"` // Changed to string (cannot be null)
+	SymptomDescription *string                `json:"symptom_description"`
+	CreatedAt          pgtype.Timestamp       `json:"created_at"`
+	UpdatedAt          pgtype.Timestamp       `json:"updated_at"`
+	Version            int32                  `json:"version" example:"3"` // Also sent as the ETag; echo it in If-Match
+	Aliases            []SymptomAliasResponse `json:"aliases"`             // Other names that resolve to this symptom
+	DeprecatedAt       pgtype.Timestamp       `json:"deprecated_at"`       // null unless retired from pickers
+	ReplacedBy         *int32                 `json:"replaced_by"`         // Symptom to use instead, if any
+}
+
+// newSymptomResponse maps a symptom and its aliases to the API shape.
+func newSymptomResponse(sym db.Symptom, aliases []db.SymptomAlias) SymptomResponse {
+	response := SymptomResponse{
+		SymptomID:          sym.SymptomID,
+		SymptomName:        sym.SymptomName,
+		SymptomDescription: stringPtrFromPgtypeText(sym.SymptomDescription),
+		CreatedAt:          sym.CreatedAt,
+		UpdatedAt:          sym.UpdatedAt,
+		Version:            sym.Version,
+		Aliases:            make([]SymptomAliasResponse, len(aliases)),
+		DeprecatedAt:       sym.DeprecatedAt,
+		ReplacedBy:         int32PtrFromPgtypeInt4(sym.ReplacedBy),
+	}
+	for i, a := range aliases {
+		response.Aliases[i] = newSymptomAliasResponse(a)
+	}
+	return response
 }
 
 // --- Assume Helper functions exist (pgtypeText, stringPtrFromPgtypeText, parseInt32Param, etc.) ---
 // --- If not, define them here or in a utils package ---
 
+// swagger:model SymptomListItem
+type SymptomListItem struct {
+	SymptomResponse
+	UsageCount int32 `json:"usage_count" example:"42"` // Times patients reported it, across all clinics
+}
+
+// swagger:model SymptomPage
+type SymptomPage struct {
+	Data []SymptomListItem `json:"data"`
+	Page PageInfo          `json:"page"`
+}
+
+// maxCatalogSearchLength bounds ?q= on the catalog listings.
+const maxCatalogSearchLength = 100
+
+// parseCatalogFilter reads ?q= (blank lists everything) and
+// ?include_deprecated=.
+func parseCatalogFilter(w http.ResponseWriter, r *http.Request) (db.CatalogFilter, bool) {
+	f := db.CatalogFilter{IncludeDeprecated: r.URL.Query().Get("include_deprecated") == "true"}
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		return f, true
+	}
+	if utf8.RuneCountInString(q) > maxCatalogSearchLength {
+		respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidValue, Field: "q", Detail: "q must be at most 100 characters"})
+		return f, false
+	}
+	f.Search = pgtype.Text{String: q, Valid: true}
+	return f, true
+}
+
 // handleListSymptoms godoc
 // @Summary      List symptoms
-// @Description  Get a list of all symptoms
+// @Description  Get a page of the symptom catalog, each with how often patients reported it. Deprecated symptoms are left out unless include_deprecated is set. q finds symptoms whose name or description contains the text, or whose name holds a similar word (tolerating misspellings). Pages are cursor-based: pass page.next_cursor or page.prev_cursor back as cursor.
 // @Tags         Symptoms
 // @Accept       json
 // @Produce      json
-// @Success      200 {array}   SymptomResponse "Successfully retrieved list of symptoms"
-// @Failure      500 {object}  HTTPError "Internal server error"
+// @Param        q       query     string  false  "Search text (at most 100 characters)"
+// @Param        sort    query     string  false  "Sort field, prefixed with - for descending; -usage lists the most common first" Enums(name, -name, usage, -usage, id, -id) default(name)
+// @Param        limit   query     int     false  "Page size (at most 100)" default(10)
+// @Param        cursor  query     string  false  "Cursor from a previous page"
+// @Param        count   query     bool    false  "Include the total number of matching symptoms"
+// @Param        include_deprecated query bool false "Also list deprecated symptoms"
+// @Success      200 {object}  SymptomPage "A page of symptoms"
+// @Header       200 {string}  Link "first, prev and next page URLs (RFC 8288)"
+// @Failure      400 {object}  Problem "Invalid query, sort, limit or cursor"
+// @Failure      429 {object}  Problem "Rate limit exceeded (see Retry-After)"
+// @Failure      500 {object}  Problem "Internal server error"
 // @Router       /symptoms [get]
 func (s *Server) handleListSymptoms() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		symptoms, err := s.queries.ListSymptoms(r.Context())
+		limit, ok := parsePageLimit(w, r)
+		if !ok {
+			return
+		}
+		field, desc, cursor, ok := parseSortAndCursor(w, r, db.SymptomSortFields(), "name")
+		if !ok {
+			return
+		}
+		filter, ok := parseCatalogFilter(w, r)
+		if !ok {
+			return
+		}
+
+		symptoms, err := s.queries.ListSymptomsPage(r.Context(), db.ListCatalogPageParams{
+			Filter:     filter,
+			PageParams: newPageParams(field, desc, limit, cursor),
+		})
 		if err != nil {
-			log.Printf("Error listing symptoms: %v", err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve symptoms")
+			if errors.Is(err, db.ErrInvalidPageKey) {
+				respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidCursor, Field: "cursor", Detail: "The cursor is not valid; start again from the first page"})
+				return
+			}
+			s.respondWithDBError(w, r, err, "Failed to retrieve symptoms")
 			return
 		}
 
-		responseSymptoms := make([]SymptomResponse, len(symptoms))
+		page := PageInfo{Limit: limit, Sort: sortParam(field, desc)}
+		keys := make([][]string, len(symptoms))
 		for i, sym := range symptoms {
-			// SymptomName from DB cannot be null based on schema and sqlc generation
-			responseSymptoms[i] = SymptomResponse{
-				SymptomID:          sym.SymptomID,
-				SymptomName:        sym.SymptomName, // Directly use string
-				SymptomDescription: stringPtrFromPgtypeText(sym.SymptomDescription),
-				CreatedAt:          sym.CreatedAt,
-				UpdatedAt:          sym.UpdatedAt,
+			keys[i] = db.SymptomSortKeyValues(sym, field)
+		}
+		from, to := pageCursors(&page, cursor, field, desc, keys)
+
+		if r.URL.Query().Get("count") == "true" {
+			total, err := s.queries.CountSymptoms(r.Context(), filter)
+			if err != nil {
+				s.respondWithDBError(w, r, err, "Failed to count symptoms")
+				return
 			}
+			page.Total = &total
+		}
+
+		ids := make([]int32, 0, to-from)
+		for _, sym := range symptoms[from:to] {
+			ids = append(ids, sym.SymptomID)
 		}
-		respondWithJSON(w, http.StatusOK, responseSymptoms)
+		aliases, err := s.queries.ListAliasesForSymptoms(r.Context(), ids)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to retrieve symptom aliases")
+			return
+		}
+		aliasesOf := make(map[int32][]db.SymptomAlias, len(ids))
+		for _, a := range aliases {
+			aliasesOf[a.SymptomID] = append(aliasesOf[a.SymptomID], a)
+		}
+
+		response := SymptomPage{Data: make([]SymptomListItem, 0, to-from), Page: page}
+		for _, sym := range symptoms[from:to] {
+			response.Data = append(response.Data, SymptomListItem{
+				SymptomResponse: newSymptomResponse(sym.Symptom, aliasesOf[sym.SymptomID]),
+				UsageCount:      sym.UsageCount,
+			})
+		}
+		setPageLinks(w, r, page)
+		respondWithJSON(w, http.StatusOK, response)
 	}
 }
 
@@ -82,22 +191,16 @@ func (s *Server) handleListSymptoms() http.HandlerFunc {
 // @Produce      json
 // @Param        symptom body      CreateSymptomRequest true "Symptom data to create"
 // @Success      201     {object}  SymptomResponse "Symptom created successfully"
-// @Failure      400     {object}  HTTPError "Invalid request payload or missing symptom name"
-// @Failure      409     {object}  HTTPError "Symptom name already exists (unique constraint)"
-// @Failure      500     {object}  HTTPError "Internal server error"
+// @Header       201     {string}  ETag "Version to send in If-Match when changing it"
+// @Failure      400     {object}  Problem "Malformed JSON, wrong type or unknown field"
+// @Failure      422     {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      409     {object}  Problem "Symptom name already exists (unique constraint)"
+// @Failure      500     {object}  Problem "Internal server error"
 // @Router       /symptoms [post]
 func (s *Server) handleCreateSymptom() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req CreateSymptomRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
-			return
-		}
-		defer r.Body.Close()
-
-		// Validation: SymptomName is required
-		if req.SymptomName == "" {
-			respondWithError(w, http.StatusBadRequest, "Missing required field: symptom_name")
+		if !s.decodeAndValidate(w, r, &req) {
 			return
 		}
 
@@ -108,24 +211,12 @@ func (s *Server) handleCreateSymptom() http.HandlerFunc {
 
 		newSymptom, err := s.queries.CreateSymptom(r.Context(), params)
 		if err != nil {
-			// Optional: Check for unique constraint violation
-			// var pgErr *pgconn.PgError
-			// if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
-			// 	respondWithError(w, http.StatusConflict, "Symptom name already exists")
-			// 	return
-			// }
-			log.Printf("Error creating symptom: %v", err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to create symptom")
+			s.respondWithDBError(w, r, err, "Failed to create symptom")
 			return
 		}
 
-		responseSymptom := SymptomResponse{
-			SymptomID:          newSymptom.SymptomID,
-			SymptomName:        newSymptom.SymptomName, // Use string directly
-			SymptomDescription: stringPtrFromPgtypeText(newSymptom.SymptomDescription),
-			CreatedAt:          newSymptom.CreatedAt,
-			UpdatedAt:          newSymptom.UpdatedAt,
-		}
+		responseSymptom := newSymptomResponse(newSymptom, nil) // A new symptom has no aliases yet
+		setETag(w, newSymptom.Version)
 		respondWithJSON(w, http.StatusCreated, responseSymptom)
 	}
 }
@@ -138,137 +229,234 @@ func (s *Server) handleCreateSymptom() http.HandlerFunc {
 // @Produce      json
 // @Param        symptomID path      int true "Symptom ID" Format(int32)
 // @Success      200       {object}  SymptomResponse "Successfully retrieved symptom"
-// @Failure      400       {object}  HTTPError "Invalid Symptom ID format"
-// @Failure      404       {object}  HTTPError "Symptom not found"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Header       200       {string}  ETag "Version to send in If-Match when changing it"
+// @Success      304       {string}  string "Not Modified (If-None-Match lists the current version)"
+// @Failure      400       {object}  Problem "Invalid Symptom ID format"
+// @Failure      404       {object}  Problem "Symptom not found"
+// @Failure      500       {object}  Problem "Internal server error"
 // @Router       /symptoms/{symptomID} [get]
 func (s *Server) handleGetSymptomByID() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		symptomID, err := parseInt32Param(r, "symptomID")
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid symptom ID: "+err.Error())
+			respondWithError(w, r, http.StatusBadRequest, "Invalid symptom ID: "+err.Error())
 			return
 		}
 
 		symptom, err := s.queries.GetSymptomByID(r.Context(), symptomID)
 		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
-				respondWithError(w, http.StatusNotFound, "Symptom not found")
+				respondWithError(w, r, http.StatusNotFound, "Symptom not found")
 			} else {
-				log.Printf("Error retrieving symptom %d: %v", symptomID, err)
-				respondWithError(w, http.StatusInternalServerError, "Failed to retrieve symptom")
+				s.respondWithDBError(w, r, err, "Failed to retrieve symptom", "symptom_id", symptomID)
 			}
 			return
 		}
 
-		responseSymptom := SymptomResponse{
-			SymptomID:          symptom.SymptomID,
-			SymptomName:        symptom.SymptomName, // Use string directly
-			SymptomDescription: stringPtrFromPgtypeText(symptom.SymptomDescription),
-			CreatedAt:          symptom.CreatedAt,
-			UpdatedAt:          symptom.UpdatedAt,
+		if notModified(w, r, symptom.Version) {
+			return
+		}
+		aliases, err := s.queries.ListSymptomAliases(r.Context(), symptomID)
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to retrieve symptom aliases", "symptom_id", symptomID)
+			return
 		}
+		responseSymptom := newSymptomResponse(symptom, aliases)
 		respondWithJSON(w, http.StatusOK, responseSymptom)
 	}
 }
 
 // handleUpdateSymptom godoc
 // @Summary      Update symptom details
-// @Description  Update details for an existing symptom. Symptom name is required.
+// @Description  Replace the details of an existing symptom. Symptom name is required. If-Match must carry the ETag from a previous read.
 // @Tags         Symptoms
 // @Accept       json
 // @Produce      json
 // @Param        symptomID path      int                true "Symptom ID" Format(int32)
+// @Param        If-Match  header    string             true "ETag of the version being replaced"
 // @Param        symptom   body      UpdateSymptomRequest true "Symptom data to update"
 // @Success      200       {object}  SymptomResponse "Symptom updated successfully"
-// @Failure      400       {object}  HTTPError "Invalid request payload or missing symptom name"
-// @Failure      404       {object}  HTTPError "Symptom not found"
-// @Failure      409       {object}  HTTPError "Symptom name already exists (unique constraint)"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Header       200       {string}  ETag "Version of the updated symptom"
+// @Failure      400       {object}  Problem "Malformed JSON, wrong type or unknown field"
+// @Failure      422       {object}  Problem "Body failed validation; every violation is listed in errors"
+// @Failure      404       {object}  Problem "Symptom not found"
+// @Failure      409       {object}  Problem "Symptom name already exists (unique constraint)"
+// @Failure      412       {object}  Problem "The symptom changed since the ETag in If-Match was read"
+// @Failure      428       {object}  Problem "If-Match is missing"
+// @Failure      500       {object}  Problem "Internal server error"
 // @Router       /symptoms/{symptomID} [put]
 func (s *Server) handleUpdateSymptom() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		symptomID, err := parseInt32Param(r, "symptomID")
-		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid symptom ID: "+err.Error())
+		current, ok := s.symptomForWrite(w, r)
+		if !ok {
 			return
 		}
 
 		var req UpdateSymptomRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		if !s.decodeAndValidate(w, r, &req) {
 			return
 		}
-		defer r.Body.Close()
+		s.updateSymptom(w, r, current, req)
+	}
+}
 
-		// Validation: SymptomName is required
-		if req.SymptomName == "" {
-			respondWithError(w, http.StatusBadRequest, "Missing required field: symptom_name")
+// handlePatchSymptom godoc
+// @Summary      Partially update a symptom
+// @Description  Apply a JSON Merge Patch (RFC 7396) to a symptom; null clears the description. If-Match must carry the ETag from a previous read.
+// @Tags         Symptoms
+// @Accept       application/merge-patch+json
+// @Produce      json
+// @Param        symptomID path      int                true "Symptom ID" Format(int32)
+// @Param        If-Match  header    string             true "ETag of the version being changed"
+// @Param        patch     body      UpdateSymptomRequest true "Members to change"
+// @Success      200       {object}  SymptomResponse "Symptom updated successfully"
+// @Header       200       {string}  ETag "Version of the updated symptom"
+// @Failure      400       {object}  Problem "Malformed JSON, wrong type or unknown field"
+// @Failure      422       {object}  Problem "Patched symptom failed validation; every violation is listed in errors"
+// @Failure      404       {object}  Problem "Symptom not found"
+// @Failure      409       {object}  Problem "Symptom name already exists (unique constraint)"
+// @Failure      412       {object}  Problem "The symptom changed since the ETag in If-Match was read"
+// @Failure      415       {object}  Problem "Body is not application/merge-patch+json"
+// @Failure      428       {object}  Problem "If-Match is missing"
+// @Failure      500       {object}  Problem "Internal server error"
+// @Router       /symptoms/{symptomID} [patch]
+func (s *Server) handlePatchSymptom() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		current, ok := s.symptomForWrite(w, r)
+		if !ok {
 			return
 		}
 
-		params := db.UpdateSymptomParams{
-			SymptomID:          symptomID,
-			SymptomName:        req.SymptomName, // Use string directly
-			SymptomDescription: pgtypeText(req.SymptomDescription),
+		var req UpdateSymptomRequest
+		if !s.decodeMergePatch(w, r, UpdateSymptomRequest{
+			SymptomName:        current.SymptomName,
+			SymptomDescription: stringPtrFromPgtypeText(current.SymptomDescription),
+		}, &req) {
+			return
 		}
+		s.updateSymptom(w, r, current, req)
+	}
+}
 
-		updatedSymptom, err := s.queries.UpdateSymptom(r.Context(), params)
-		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
-				respondWithError(w, http.StatusNotFound, "Symptom not found")
-			} else {
-				// Optional: Check for unique constraint violation
-				// var pgErr *pgconn.PgError
-				// if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
-				// 	respondWithError(w, http.StatusConflict, "Symptom name already exists")
-				// 	return
-				// }
-				log.Printf("Error updating symptom %d: %v", symptomID, err)
-				respondWithError(w, http.StatusInternalServerError, "Failed to update symptom")
-			}
-			return
+// symptomForWrite loads the symptom named in the path and checks If-Match
+// against its version. It writes the error response and returns false on failure.
+func (s *Server) symptomForWrite(w http.ResponseWriter, r *http.Request) (db.Symptom, bool) {
+	symptomID, err := parseInt32Param(r, "symptomID")
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid symptom ID: "+err.Error())
+		return db.Symptom{}, false
+	}
+	current, err := s.queries.GetSymptomByID(r.Context(), symptomID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, r, http.StatusNotFound, "Symptom not found")
+		} else {
+			s.respondWithDBError(w, r, err, "Failed to retrieve symptom", "symptom_id", symptomID)
 		}
+		return db.Symptom{}, false
+	}
+	if !checkIfMatch(w, r, current.Version) {
+		return db.Symptom{}, false
+	}
+	return current, true
+}
 
-		responseSymptom := SymptomResponse{
-			SymptomID:          updatedSymptom.SymptomID,
-			SymptomName:        updatedSymptom.SymptomName, // Use string directly
-			SymptomDescription: stringPtrFromPgtypeText(updatedSymptom.SymptomDescription),
-			CreatedAt:          updatedSymptom.CreatedAt,
-			UpdatedAt:          updatedSymptom.UpdatedAt,
+// updateSymptom stores a validated update request over the version in current.
+func (s *Server) updateSymptom(w http.ResponseWriter, r *http.Request, current db.Symptom, req UpdateSymptomRequest) {
+	params := db.UpdateSymptomParams{
+		SymptomID:          current.SymptomID,
+		SymptomName:        req.SymptomName, // Use string directly
+		SymptomDescription: pgtypeText(req.SymptomDescription),
+		Version:            current.Version,
+	}
+
+	updatedSymptom, err := s.queries.UpdateSymptom(r.Context(), params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+			// The row existed a moment ago, so another write got in first
+			respondWithStaleVersion(w, r, 0)
+		} else {
+			s.respondWithDBError(w, r, err, "Failed to update symptom", "symptom_id", current.SymptomID)
 		}
-		respondWithJSON(w, http.StatusOK, responseSymptom)
+		return
 	}
+
+	aliases, err := s.queries.ListSymptomAliases(r.Context(), updatedSymptom.SymptomID)
+	if err != nil {
+		s.respondWithDBError(w, r, err, "Failed to retrieve symptom aliases", "symptom_id", updatedSymptom.SymptomID)
+		return
+	}
+	responseSymptom := newSymptomResponse(updatedSymptom, aliases)
+	setETag(w, updatedSymptom.Version)
+	respondWithJSON(w, http.StatusOK, responseSymptom)
 }
 
 // handleDeleteSymptom godoc
 // @Summary      Delete symptom
-// @Description  Delete a symptom record by ID. Associated records in junction tables (patient_symptoms, patient_disease_symptom) should be removed via ON DELETE CASCADE.
+// @Description  Delete a symptom that no patient record uses, such as one created by mistake. While any reported symptom or diagnosis link (in any clinic) still uses it, the delete is refused with the number of each in references; deprecate it instead.
 // @Tags         Symptoms
 // @Accept       json
 // @Produce      json
-// @Param        symptomID path      int true "Symptom ID" Format(int32)
+// @Param        symptomID path      int    true "Symptom ID" Format(int32)
+// @Param        If-Match  header    string true "ETag of the version being deleted"
 // @Success      204       {string}  string "No Content (Successful deletion)"
-// @Failure      400       {object}  HTTPError "Invalid Symptom ID format"
-// @Failure      500       {object}  HTTPError "Internal server error"
+// @Failure      400       {object}  Problem "Invalid Symptom ID format"
+// @Failure      404       {object}  Problem "Symptom not found"
+// @Failure      409       {object}  Problem "Patient records still use the symptom; counted in references"
+// @Failure      412       {object}  Problem "The symptom changed since the ETag in If-Match was read"
+// @Failure      428       {object}  Problem "If-Match is missing"
+// @Failure      500       {object}  Problem "Internal server error"
 // @Router       /symptoms/{symptomID} [delete]
 func (s *Server) handleDeleteSymptom() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		symptomID, err := parseInt32Param(r, "symptomID")
+		current, ok := s.symptomForWrite(w, r)
+		if !ok {
+			return
+		}
+		symptomID := current.SymptomID
+
+		// Records of every clinic count (count_symptom_references)
+		tx, err := s.pool.Begin(r.Context())
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid symptom ID: "+err.Error())
+			s.respondWithDBError(w, r, err, "Failed to delete symptom", "symptom_id", symptomID)
 			return
 		}
+		defer tx.Rollback(r.Context())
+		qtx := s.queries.WithTx(tx)
 
-		err = s.queries.DeleteSymptom(r.Context(), symptomID)
+		refs, err := qtx.CountSymptomReferences(r.Context(), symptomID)
 		if err != nil {
-			// Note: DELETE often doesn't error if the ID doesn't exist.
-			// FK errors could occur if CASCADE isn't set up correctly.
-			log.Printf("Error deleting symptom %d: %v", symptomID, err)
-			respondWithError(w, http.StatusInternalServerError, "Failed to delete symptom")
+			s.respondWithDBError(w, r, err, "Failed to delete symptom", "symptom_id", symptomID)
+			return
+		}
+		if refs.PatientSymptoms > 0 || refs.DiseaseInstanceSymptoms > 0 {
+			respondWithProblem(w, r, &APIError{
+				Status: http.StatusConflict,
+				Code:   CodeStillReferenced,
+				Detail: "Patient records still use this symptom; deprecate it instead",
+				References: map[string]int64{
+					"patient_symptoms":          refs.PatientSymptoms,
+					"disease_instance_symptoms": refs.DiseaseInstanceSymptoms,
+				},
+			})
+			return
+		}
+
+		// A record added since the count still fails the delete, via fk_ps_symptom or fk_pds_symptom
+		deleted, err := qtx.DeleteSymptom(r.Context(), db.DeleteSymptomParams{SymptomID: symptomID, Version: current.Version})
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to delete symptom", "symptom_id", symptomID)
+			return
+		}
+		if deleted == 0 {
+			// The row existed a moment ago, so another write got in first
+			respondWithStaleVersion(w, r, 0)
+			return
+		}
+		if err := tx.Commit(r.Context()); err != nil {
+			s.respondWithDBError(w, r, err, "Failed to delete symptom", "symptom_id", symptomID)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
-
@@ -3,7 +3,7 @@ package server
 import (
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
@@ -15,17 +15,32 @@ import (
 // swagger:type object
 type ArbitraryJSON json.RawMessage
 
-// swagger:model HTTPError
-type HTTPError struct {
-	Error string `json:"error" example:"Resource not found"` // Error message
+// MarshalJSON writes the raw JSON as is (a plain []byte would be base64), or
+// null when empty.
+func (j ArbitraryJSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return json.RawMessage(j).MarshalJSON()
+}
+
+// UnmarshalJSON keeps a copy of the raw JSON value; null leaves it empty, so
+// it is stored as NULL.
+func (j *ArbitraryJSON) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*j = nil
+		return nil
+	}
+	return (*json.RawMessage)(j).UnmarshalJSON(data)
 }
 
 func respondWithJSON(w http.ResponseWriter, code int, payload any) {
 	response, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Error marshalling JSON: %v", err)
+		slog.Error("Error marshalling JSON", "err", err)
+		w.Header().Set("Content-Type", "application/problem+json")
 		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(`{"error": "Internal server error marshalling response"}`))
+		w.Write([]byte(`{"type": "about:blank", "title": "Internal Server Error", "status": 500, "code": "internal", "detail": "Internal server error marshalling response"}`))
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -33,9 +48,10 @@ func respondWithJSON(w http.ResponseWriter, code int, payload any) {
 	w.Write(response) // Use Write directly
 }
 
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	log.Printf("HTTP Error %d: %s", code, message) // Log the error server-side
-	respondWithJSON(w, code, map[string]string{"error": message})
+// respondWithError answers with a problem+json body carrying the status's
+// generic error code. Use respondWithProblem for a specific code or field.
+func respondWithError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	respondWithProblem(w, r, &APIError{Status: code, Detail: message})
 }
 
 func parseInt32Param(r *http.Request, paramName string) (int32, error) {
@@ -65,17 +81,19 @@ func stringPtrFromPgtypeText(pt pgtype.Text) *string {
 	return &s
 }
 
+func int32PtrFromPgtypeInt4(pi pgtype.Int4) *int32 {
+	if !pi.Valid {
+		return nil
+	}
+	i := pi.Int32
+	return &i
+}
+
 func stringFromPgtypeDate(pd pgtype.Date) string {
 	if !pd.Valid {
 		return ""
 	}
-	var t time.Time
-	err := pd.Scan(&t) // Scan into time.Time
-	if err != nil {
-		log.Printf("Warning: Failed to scan pgtype.Date back to time.Time: %v", err)
-		return ""
-	}
-	return t.Format("2006-01-02") // Format as YYYY-MM-DD
+	return pd.Time.Format(time.DateOnly) // YYYY-MM-DD
 }
 
 func stringPtrFromPgtypeDate(pd pgtype.Date) *string {
@@ -0,0 +1,240 @@
+// server/idempotency.go
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	headerIdempotencyKey     = "Idempotency-Key"
+	headerIdempotentReplayed = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	idempotencySweepInterval = time.Hour
+)
+
+// Problem codes for Idempotency-Key misuse.
+const (
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodeIdempotencyKeyInUse  = "idempotency_key_in_use"
+)
+
+// replayedHeaders are the response headers stored with a response and sent
+// again when it is replayed. Everything else is set by the middleware chain.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// idempotencyRecorder keeps a copy of the response so it can be stored.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// validIdempotencyKey accepts 1-255 printable ASCII characters, which covers
+// UUIDs and any other key a client is likely to generate.
+func validIdempotencyKey(key string) bool {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// idempotencyScope is the key space of the caller's keys: the user in the
+// clinic the request acts in, so a key is never replayed into another clinic.
+// Anonymous callers have none, as the client IP is shared by everyone behind
+// the same NAT.
+func idempotencyScope(r *http.Request) (string, bool) {
+	caller, ok := principalFromContext(r.Context())
+	if !ok {
+		return "", false
+	}
+	return "user:" + strconv.Itoa(int(caller.UserID)) + "|clinic:" + strconv.Itoa(int(caller.ClinicID)), true
+}
+
+// requestFingerprint identifies what a request asks for, so a key reused for
+// a different request can be told apart from a retry.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+"\n"+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotent makes POSTs sent with an Idempotency-Key safe to retry. The first
+// request with a key is handled and its response stored for the configured
+// TTL; a retry with the same key and body gets that response replayed
+// (marked Idempotent-Replayed: true) instead of being handled again. The same
+// key with a different request is 422, and a retry that arrives while the
+// first request is still being handled is 409. Keys are scoped to the caller
+// and clinic (see idempotencyScope), so mount this after authentication; the
+// key of an anonymous request is ignored. Server errors are not stored, so the
+// request can be retried with the same key.
+func (s *Server) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(headerIdempotencyKey)
+		scope, identified := idempotencyScope(r)
+		if r.Method != http.MethodPost || key == "" || !identified {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !validIdempotencyKey(key) {
+			respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidValue, Field: headerIdempotencyKey, Detail: "Idempotency-Key must be 1 to 255 printable ASCII characters"})
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, s.config.Max_Body_Bytes)
+		body, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			respondWithProblem(w, r, decodeError(err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := requestFingerprint(r, body)
+		claimed, err := s.queries.ClaimIdempotencyKey(r.Context(), db.ClaimIdempotencyKeyParams{
+			Scope:          scope,
+			IdempotencyKey: key,
+			Fingerprint:    fingerprint,
+			TtlSeconds:     int32(s.config.Idempotency_TTL / time.Second),
+		})
+		if err != nil {
+			s.respondWithDBError(w, r, err, "Failed to check idempotency key")
+			return
+		}
+		if claimed == 0 {
+			s.replayIdempotent(w, r, scope, key, fingerprint)
+			return
+		}
+
+		// Store the outcome even if the client has gone away by then: its retry will ask for it
+		storeCtx := context.WithoutCancel(r.Context())
+		rec := &idempotencyRecorder{ResponseWriter: w}
+		stored := false
+		defer func() {
+			if !stored { // A server error or a panic: let a retry run the request again
+				if err := s.queries.ReleaseIdempotencyKey(storeCtx, db.ReleaseIdempotencyKeyParams{Scope: scope, IdempotencyKey: key}); err != nil {
+					s.log(r).Error("Error releasing idempotency key", "err", err)
+				}
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+			return
+		}
+
+		headers := make(map[string]string, len(replayedHeaders))
+		for _, name := range replayedHeaders {
+			if value := w.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		headersJSON, err := json.Marshal(headers)
+		if err != nil {
+			s.log(r).Error("Error encoding idempotent response headers", "err", err)
+			return
+		}
+		err = s.queries.CompleteIdempotencyKey(storeCtx, db.CompleteIdempotencyKeyParams{
+			Scope:           scope,
+			IdempotencyKey:  key,
+			StatusCode:      pgtype.Int4{Int32: int32(rec.status), Valid: true},
+			ResponseHeaders: headersJSON,
+			ResponseBody:    rec.body.Bytes(),
+		})
+		if err != nil {
+			s.log(r).Error("Error storing idempotent response", "err", err)
+			return
+		}
+		stored = true
+	})
+}
+
+// replayIdempotent answers a request whose key is already taken: with the
+// stored response, or with why it cannot be replayed.
+func (s *Server) replayIdempotent(w http.ResponseWriter, r *http.Request, scope, key, fingerprint string) {
+	entry, err := s.queries.GetIdempotencyKey(r.Context(), db.GetIdempotencyKeyParams{Scope: scope, IdempotencyKey: key})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Released or expired since the claim was attempted
+		respondWithProblem(w, r, &APIError{Status: http.StatusConflict, Code: CodeIdempotencyKeyInUse, Field: headerIdempotencyKey, Detail: "The request with this Idempotency-Key did not complete; retry it"})
+		return
+	}
+	if err != nil {
+		s.respondWithDBError(w, r, err, "Failed to check idempotency key")
+		return
+	}
+
+	if entry.Fingerprint != fingerprint {
+		respondWithProblem(w, r, &APIError{Status: http.StatusUnprocessableEntity, Code: CodeIdempotencyKeyReused, Field: headerIdempotencyKey, Detail: "This Idempotency-Key was already used for a different request; use a new key"})
+		return
+	}
+	if !entry.StatusCode.Valid {
+		w.Header().Set("Retry-After", "1")
+		respondWithProblem(w, r, &APIError{Status: http.StatusConflict, Code: CodeIdempotencyKeyInUse, Field: headerIdempotencyKey, Detail: "The request with this Idempotency-Key is still being handled; retry shortly"})
+		return
+	}
+
+	var headers map[string]string
+	if err := json.Unmarshal(entry.ResponseHeaders, &headers); err != nil {
+		s.log(r).Error("Error decoding stored response headers", "err", err)
+	}
+	for name, value := range headers {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set(headerIdempotentReplayed, "true")
+	w.WriteHeader(int(entry.StatusCode.Int32))
+	w.Write(entry.ResponseBody)
+	s.metrics.idempotentReplays.Inc()
+}
+
+// sweepIdempotencyKeys periodically deletes expired keys until ctx is cancelled.
+func (s *Server) sweepIdempotencyKeys(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.queries.DeleteExpiredIdempotencyKeys(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Error("Error sweeping idempotency keys", "err", err)
+			}
+		}
+	}
+}
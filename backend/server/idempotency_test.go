@@ -0,0 +1,212 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/config"
+	"github.com/dukunuu/munkhjin-diplom/backend/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestIdempotencyScope(t *testing.T) {
+	withCaller := func(p Principal) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/patients", nil)
+		return r.WithContext(context.WithValue(r.Context(), principalKey{}, p))
+	}
+
+	clinicA, ok := idempotencyScope(withCaller(Principal{UserID: 4, ClinicID: 1}))
+	if !ok {
+		t.Fatal("authenticated caller has no scope")
+	}
+	clinicB, _ := idempotencyScope(withCaller(Principal{UserID: 4, ClinicID: 2}))
+	if clinicA == clinicB {
+		t.Errorf("scope %q is shared by both clinics", clinicA)
+	}
+	if _, ok := idempotencyScope(httptest.NewRequest(http.MethodPost, "/symptoms", nil)); ok {
+		t.Error("anonymous caller has a scope")
+	}
+}
+
+// idempotencyStore stands in for the idempotency_key table behind db.Queries.
+// It answers the idempotency queries only, and never expires an entry.
+type idempotencyStore struct {
+	entries map[string]*db.IdempotencyKey
+}
+
+func (st *idempotencyStore) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	id := args[0].(string) + "\x00" + args[1].(string)
+	switch {
+	case strings.Contains(sql, "name: ClaimIdempotencyKey "):
+		if _, taken := st.entries[id]; taken {
+			return pgconn.NewCommandTag("INSERT 0 0"), nil
+		}
+		st.entries[id] = &db.IdempotencyKey{Scope: args[0].(string), IdempotencyKey: args[1].(string), Fingerprint: args[2].(string)}
+		return pgconn.NewCommandTag("INSERT 0 1"), nil
+	case strings.Contains(sql, "name: CompleteIdempotencyKey "):
+		if e, ok := st.entries[id]; ok {
+			e.StatusCode, e.ResponseHeaders, e.ResponseBody = args[2].(pgtype.Int4), args[3].([]byte), args[4].([]byte)
+		}
+		return pgconn.NewCommandTag("UPDATE 1"), nil
+	case strings.Contains(sql, "name: ReleaseIdempotencyKey "):
+		if e, ok := st.entries[id]; ok && !e.StatusCode.Valid {
+			delete(st.entries, id)
+		}
+		return pgconn.NewCommandTag("DELETE 1"), nil
+	}
+	return pgconn.CommandTag{}, errors.New("unexpected query: " + sql)
+}
+
+func (st *idempotencyStore) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	return nil, errors.New("unexpected query")
+}
+
+func (st *idempotencyStore) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	if !strings.Contains(sql, "name: GetIdempotencyKey ") {
+		return idempotencyRow{err: errors.New("unexpected query: " + sql)}
+	}
+	e, ok := st.entries[args[0].(string)+"\x00"+args[1].(string)]
+	if !ok {
+		return idempotencyRow{err: pgx.ErrNoRows}
+	}
+	return idempotencyRow{entry: *e}
+}
+
+type idempotencyRow struct {
+	entry db.IdempotencyKey
+	err   error
+}
+
+func (row idempotencyRow) Scan(dest ...any) error {
+	if row.err != nil {
+		return row.err
+	}
+	*dest[0].(*string) = row.entry.Scope
+	*dest[1].(*string) = row.entry.IdempotencyKey
+	*dest[2].(*string) = row.entry.Fingerprint
+	*dest[3].(*pgtype.Int4) = row.entry.StatusCode
+	*dest[4].(*[]byte) = row.entry.ResponseHeaders
+	*dest[5].(*[]byte) = row.entry.ResponseBody
+	return nil
+}
+
+func TestIdempotent(t *testing.T) {
+	const body = `{"symptom_id": 3}`
+	caller := Principal{UserID: 4, ClinicID: 1, Username: "nurse", Role: RoleClinician}
+	newRequest := func(method, key, body string, p *Principal) *http.Request {
+		r := httptest.NewRequest(method, "/patients/1/general-symptoms", strings.NewReader(body))
+		if key != "" {
+			r.Header.Set(headerIdempotencyKey, key)
+		}
+		if p != nil {
+			r = r.WithContext(context.WithValue(r.Context(), principalKey{}, *p))
+		}
+		return r
+	}
+	// entry is what the store holds for key-1 once a request with body has
+	// claimed it, and with a status once that request completed
+	entry := func(body string, status int32) *db.IdempotencyKey {
+		scope, _ := idempotencyScope(newRequest(http.MethodPost, "", "", &caller))
+		e := &db.IdempotencyKey{
+			Scope:          scope,
+			IdempotencyKey: "key-1",
+			Fingerprint:    requestFingerprint(newRequest(http.MethodPost, "", "", nil), []byte(body)),
+		}
+		if status != 0 {
+			e.StatusCode = pgtype.Int4{Int32: status, Valid: true}
+			e.ResponseHeaders = []byte(`{"Content-Type":"application/json","ETag":"\"1\""}`)
+			e.ResponseBody = []byte(`{"id":7}`)
+		}
+		return e
+	}
+
+	tests := []struct {
+		name        string
+		stored      *db.IdempotencyKey // Entry for key-1 before the request
+		method      string
+		key         string
+		body        string
+		caller      *Principal
+		handlerCode int // Status the wrapped handler answers with
+		wantCode    int
+		wantHandled bool
+		wantReplay  bool
+		wantStored  int32 // Status stored for key-1 afterwards; -1 for no entry, 0 for in flight
+	}{
+		{"first request claims the key", nil, http.MethodPost, "key-1", body, &caller, http.StatusCreated, http.StatusCreated, true, false, http.StatusCreated},
+		{"client error is stored", nil, http.MethodPost, "key-1", body, &caller, http.StatusNotFound, http.StatusNotFound, true, false, http.StatusNotFound},
+		{"retry replays the response", entry(body, http.StatusCreated), http.MethodPost, "key-1", body, &caller, http.StatusCreated, http.StatusCreated, false, true, http.StatusCreated},
+		{"same key, different body", entry(body, http.StatusCreated), http.MethodPost, "key-1", `{"symptom_id": 4}`, &caller, http.StatusCreated, http.StatusUnprocessableEntity, false, false, http.StatusCreated},
+		{"retry while in flight", entry(body, 0), http.MethodPost, "key-1", body, &caller, http.StatusCreated, http.StatusConflict, false, false, 0},
+		{"server error releases the key", nil, http.MethodPost, "key-1", body, &caller, http.StatusInternalServerError, http.StatusInternalServerError, true, false, -1},
+		{"no key", nil, http.MethodPost, "", body, &caller, http.StatusCreated, http.StatusCreated, true, false, -1},
+		{"anonymous key is ignored", nil, http.MethodPost, "key-1", body, nil, http.StatusCreated, http.StatusCreated, true, false, -1},
+		{"not a POST", nil, http.MethodPut, "key-1", body, &caller, http.StatusOK, http.StatusOK, true, false, -1},
+		{"key with a control character", nil, http.MethodPost, "key\x01", body, &caller, http.StatusCreated, http.StatusBadRequest, false, false, -1},
+		{"key too long", nil, http.MethodPost, strings.Repeat("k", maxIdempotencyKeyLength+1), body, &caller, http.StatusCreated, http.StatusBadRequest, false, false, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &idempotencyStore{entries: map[string]*db.IdempotencyKey{}}
+			if tt.stored != nil {
+				store.entries[tt.stored.Scope+"\x00"+tt.stored.IdempotencyKey] = tt.stored
+			}
+			s := &Server{
+				config:  &config.Config{Max_Body_Bytes: 1 << 20, Idempotency_TTL: time.Hour},
+				queries: db.New(store),
+				logger:  slog.New(slog.DiscardHandler),
+				metrics: newMetrics(nil),
+			}
+			handled := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handled = true
+				if got, _ := io.ReadAll(r.Body); string(got) != tt.body {
+					t.Errorf("handler read body %q, want %q", got, tt.body)
+				}
+				w.Header().Set("ETag", `"1"`)
+				respondWithJSON(w, tt.handlerCode, map[string]int{"id": 7})
+			})
+
+			w := httptest.NewRecorder()
+			s.idempotent(next).ServeHTTP(w, newRequest(tt.method, tt.key, tt.body, tt.caller))
+
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if handled != tt.wantHandled {
+				t.Errorf("handled = %v, want %v", handled, tt.wantHandled)
+			}
+			if replayed := w.Header().Get(headerIdempotentReplayed) == "true"; replayed != tt.wantReplay {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplay)
+			}
+			if tt.wantReplay {
+				if got := w.Body.String(); got != `{"id":7}` {
+					t.Errorf("replayed body = %s, want the stored one", got)
+				}
+				if got := w.Header().Get("ETag"); got != `"1"` {
+					t.Errorf("replayed ETag = %s, want the stored one", got)
+				}
+			}
+
+			scope, _ := idempotencyScope(newRequest(http.MethodPost, "", "", &caller))
+			e, ok := store.entries[scope+"\x00key-1"]
+			switch {
+			case tt.wantStored == -1 && ok:
+				t.Errorf("key-1 stored with status %v, want no entry", e.StatusCode)
+			case tt.wantStored == 0 && (!ok || e.StatusCode.Valid):
+				t.Errorf("key-1 entry = %+v, want one in flight", e)
+			case tt.wantStored > 0 && (!ok || e.StatusCode.Int32 != tt.wantStored):
+				t.Errorf("key-1 entry = %+v, want status %d", e, tt.wantStored)
+			}
+		})
+	}
+}
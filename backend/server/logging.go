@@ -0,0 +1,129 @@
+// server/logging.go
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestLogKey struct{}
+
+// requestLogState is shared by the access log middleware and everything
+// below it, so the final access line can see the principal resolved later.
+type requestLogState struct {
+	start     time.Time
+	principal string
+}
+
+// statusRecorder captures what was written so the access line can report it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status     int
+	bytes      int
+	errMessage string // Set by respondWithError
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// findStatusRecorder returns the access log's recorder behind w, looking
+// through writers that middleware such as idempotent wrapped around it.
+func findStatusRecorder(w http.ResponseWriter) *statusRecorder {
+	for {
+		switch inner := w.(type) {
+		case *statusRecorder:
+			return inner
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = inner.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+// notePrincipal records the authenticated caller for the request's log lines.
+func notePrincipal(ctx context.Context, username string) {
+	if state, ok := ctx.Value(requestLogKey{}).(*requestLogState); ok {
+		state.principal = username
+	}
+}
+
+// log returns the server logger annotated with the request ID, trace ID,
+// route pattern, principal and the latency so far. Never pass request or response
+// bodies or patient fields to it; log IDs instead (see package logging).
+func (s *Server) log(r *http.Request) *slog.Logger {
+	attrs := []any{
+		"request_id", middleware.GetReqID(r.Context()),
+		"method", r.Method,
+	}
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		attrs = append(attrs, "route", rctx.RoutePattern())
+	}
+	if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+		attrs = append(attrs, "trace_id", sc.TraceID().String())
+	}
+	if state, ok := r.Context().Value(requestLogKey{}).(*requestLogState); ok {
+		if state.principal != "" {
+			attrs = append(attrs, "principal", state.principal)
+		}
+		attrs = append(attrs, "latency_ms", millis(time.Since(state.start)))
+	}
+	return s.logger.With(attrs...)
+}
+
+// requestLog writes one access line per request, after it completes. The
+// path is left out in favour of the route pattern, since paths can carry
+// identifiers that are better looked up by request ID.
+func (s *Server) requestLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := &requestLogState{start: time.Now()}
+		r = r.WithContext(context.WithValue(r.Context(), requestLogKey{}, state))
+		rec := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		attrs := []any{"status", rec.status, "bytes", rec.bytes}
+		if rec.errMessage != "" {
+			attrs = append(attrs, "error", rec.errMessage)
+		}
+		level := slog.LevelInfo
+		switch {
+		case rec.status >= 500:
+			level = slog.LevelError
+		case rec.status >= 400:
+			level = slog.LevelWarn
+		}
+		s.log(r).Log(r.Context(), level, "request completed", attrs...)
+	})
+}
+
+// millis renders a duration as fractional milliseconds for log fields.
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,179 @@
+// server/pagination.go
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/db"
+)
+
+// Page size bounds for cursor-paginated listings.
+const (
+	defaultPageLimit = 10
+	maxPageLimit     = 100
+)
+
+// CodeInvalidCursor is the problem code for a cursor that cannot be used.
+const CodeInvalidCursor = "invalid_cursor"
+
+// swagger:model PageInfo
+type PageInfo struct {
+	Limit      int32   `json:"limit" example:"10"`
+	Sort       string  `json:"sort" example:"name"`           // The sort in effect, "-" prefixed when descending
+	NextCursor *string `json:"next_cursor"`                   // null on the last page
+	PrevCursor *string `json:"prev_cursor"`                   // null on the first page
+	Total      *int64  `json:"total,omitempty" example:"137"` // Only with count=true
+}
+
+// pageCursor is the position a cursor points at. Clients see it only as an
+// opaque string; the sort is kept in it so a cursor cannot be replayed
+// against a different order.
+type pageCursor struct {
+	Sort     string   `json:"s"`
+	Desc     bool     `json:"d,omitempty"`
+	Key      []string `json:"k"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+func encodeCursor(c pageCursor) *string {
+	raw, _ := json.Marshal(c) // Only strings and bools: cannot fail
+	s := base64.RawURLEncoding.EncodeToString(raw)
+	return &s
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	var c pageCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(raw, &c)
+	}
+	if err != nil || c.Sort == "" || len(c.Key) == 0 {
+		return pageCursor{}, errors.New("malformed cursor")
+	}
+	return c, nil
+}
+
+// sortParam formats a sort the way ?sort= takes it.
+func sortParam(field string, desc bool) string {
+	if desc {
+		return "-" + field
+	}
+	return field
+}
+
+// parsePageLimit reads ?limit=, defaulting to defaultPageLimit. Values above
+// maxPageLimit are capped rather than rejected.
+func parsePageLimit(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	raw := r.URL.Query().Get("limit")
+	if raw == "" {
+		return defaultPageLimit, true
+	}
+	limit, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil || limit <= 0 {
+		respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidValue, Field: "limit", Detail: "limit must be a positive integer"})
+		return 0, false
+	}
+	return int32(min(limit, maxPageLimit)), true
+}
+
+// parseSortAndCursor reads ?sort= (a field, "-" prefixed for descending) and
+// ?cursor=. Without a sort the cursor's own is used, then defaultSort; a sort
+// that contradicts the cursor is rejected, since the cursor's position only
+// means something in its own order.
+func parseSortAndCursor(w http.ResponseWriter, r *http.Request, fields []string, defaultSort string) (string, bool, *pageCursor, bool) {
+	var cursor *pageCursor
+	if raw := r.URL.Query().Get("cursor"); raw != "" {
+		c, err := decodeCursor(raw)
+		if err != nil {
+			respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidCursor, Field: "cursor", Detail: "The cursor is not valid; start again from the first page"})
+			return "", false, nil, false
+		}
+		cursor = &c
+	}
+
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		if cursor != nil {
+			return cursor.Sort, cursor.Desc, cursor, true
+		}
+		sort = defaultSort
+	}
+	field, desc := strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")
+	if !slices.Contains(fields, field) {
+		respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidValue, Field: "sort", Detail: "sort must be one of " + strings.Join(fields, ", ") + ", optionally prefixed with - for descending"})
+		return "", false, nil, false
+	}
+	if cursor != nil && (cursor.Sort != field || cursor.Desc != desc) {
+		respondWithProblem(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidCursor, Field: "cursor", Detail: "The cursor belongs to sort " + sortParam(cursor.Sort, cursor.Desc) + "; drop the cursor to change the sort"})
+		return "", false, nil, false
+	}
+	return field, desc, cursor, true
+}
+
+// newPageParams asks for a page of limit rows at the cursor, plus one more
+// to tell whether another page follows.
+func newPageParams(field string, desc bool, limit int32, cursor *pageCursor) db.PageParams {
+	params := db.PageParams{Sort: field, Desc: desc, Limit: limit + 1}
+	if cursor != nil {
+		params.After = cursor.Key
+		params.Backward = cursor.Backward
+	}
+	return params
+}
+
+// pageCursors works out the cursors around a page fetched with one row more
+// than the limit (to learn whether more follow in the direction walked).
+// keys holds the sort key of every fetched row, in sort order; it returns the
+// range of rows to keep, keys[from:to].
+func pageCursors(info *PageInfo, cursor *pageCursor, field string, desc bool, keys [][]string) (int, int) {
+	limit := int(info.Limit)
+	from, to := 0, len(keys)
+	backward := cursor != nil && cursor.Backward
+	more := len(keys) > limit
+	if more {
+		if backward {
+			from = len(keys) - limit // The extra row is the one furthest back
+		} else {
+			to = limit
+		}
+	}
+	if to == from {
+		return from, to
+	}
+
+	if (backward && more) || (!backward && cursor != nil) {
+		info.PrevCursor = encodeCursor(pageCursor{Sort: field, Desc: desc, Key: keys[from], Backward: true})
+	}
+	if (!backward && more) || backward {
+		info.NextCursor = encodeCursor(pageCursor{Sort: field, Desc: desc, Key: keys[to-1]})
+	}
+	return from, to
+}
+
+// setPageLinks adds RFC 8288 Link headers for the neighbouring pages: the
+// request's own URL with the cursor swapped.
+func setPageLinks(w http.ResponseWriter, r *http.Request, info PageInfo) {
+	link := func(cursor *string, rel string) string {
+		query := r.URL.Query()
+		query.Del("cursor")
+		if cursor != nil {
+			query.Set("cursor", *cursor)
+		}
+		query.Set("sort", info.Sort)
+		return "<" + r.URL.Path + "?" + query.Encode() + `>; rel="` + rel + `"`
+	}
+	links := []string{link(nil, "first")}
+	if info.PrevCursor != nil {
+		links = append(links, link(info.PrevCursor, "prev"))
+	}
+	if info.NextCursor != nil {
+		links = append(links, link(info.NextCursor, "next"))
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
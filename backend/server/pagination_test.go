@@ -0,0 +1,194 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, c := range []pageCursor{
+		{Sort: "name", Key: []string{"Дорж", "Бат", "7"}},
+		{Sort: "created_at", Desc: true, Key: []string{"2025-01-02T03:04:05.123456", "7"}, Backward: true},
+	} {
+		got, err := decodeCursor(*encodeCursor(c))
+		if err != nil {
+			t.Fatalf("decodeCursor(encodeCursor(%+v)): %v", c, err)
+		}
+		if !reflect.DeepEqual(got, c) {
+			t.Errorf("round trip = %+v, want %+v", got, c)
+		}
+	}
+}
+
+func TestDecodeCursorMalformed(t *testing.T) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"id","k":["7"]}`))},
+		{"not JSON", b64([]byte("id:7"))},
+		{"no sort", b64([]byte(`{"k":["7"]}`))},
+		{"no key", b64([]byte(`{"s":"id"}`))},
+		{"empty key", b64([]byte(`{"s":"id","k":[]}`))},
+		{"key of numbers", b64([]byte(`{"s":"id","k":[7]}`))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c, err := decodeCursor(tt.cursor); err == nil {
+				t.Errorf("decodeCursor(%q) = %+v, want error", tt.cursor, c)
+			}
+		})
+	}
+}
+
+func TestParsePageLimit(t *testing.T) {
+	tests := []struct {
+		query string
+		want  int32
+		ok    bool
+	}{
+		{"", defaultPageLimit, true},
+		{"limit=5", 5, true},
+		{"limit=100", maxPageLimit, true},
+		{"limit=1000", maxPageLimit, true},
+		{"limit=0", 0, false},
+		{"limit=-1", 0, false},
+		{"limit=ten", 0, false},
+		{"limit=99999999999", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			got, ok := parsePageLimit(w, httptest.NewRequest(http.MethodGet, "/patients?"+tt.query, nil))
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("parsePageLimit = %d, %v, want %d, %v", got, ok, tt.want, tt.ok)
+			}
+			if !ok && w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400", w.Code)
+			}
+		})
+	}
+}
+
+func TestParseSortAndCursor(t *testing.T) {
+	fields := []string{"birthdate", "id", "name"}
+	cursor := func(sort string, desc bool) string {
+		return *encodeCursor(pageCursor{Sort: sort, Desc: desc, Key: []string{"7"}})
+	}
+	tests := []struct {
+		name       string
+		sort       string
+		cursor     string
+		wantField  string
+		wantDesc   bool
+		wantCursor bool
+		wantCode   string // Problem code when refused
+	}{
+		{name: "default sort", wantField: "name"},
+		{name: "ascending", sort: "birthdate", wantField: "birthdate"},
+		{name: "descending", sort: "-birthdate", wantField: "birthdate", wantDesc: true},
+		{name: "unknown field", sort: "email", wantCode: CodeInvalidValue},
+		{name: "cursor brings its sort", cursor: cursor("id", true), wantField: "id", wantDesc: true, wantCursor: true},
+		{name: "cursor with its own sort", sort: "-id", cursor: cursor("id", true), wantField: "id", wantDesc: true, wantCursor: true},
+		{name: "cursor of another field", sort: "name", cursor: cursor("id", false), wantCode: CodeInvalidCursor},
+		{name: "cursor of another direction", sort: "id", cursor: cursor("id", true), wantCode: CodeInvalidCursor},
+		{name: "malformed cursor", cursor: "!!!", wantCode: CodeInvalidCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := url.Values{}
+			if tt.sort != "" {
+				query.Set("sort", tt.sort)
+			}
+			if tt.cursor != "" {
+				query.Set("cursor", tt.cursor)
+			}
+			w := httptest.NewRecorder()
+			field, desc, c, ok := parseSortAndCursor(w, httptest.NewRequest(http.MethodGet, "/patients?"+query.Encode(), nil), fields, "name")
+
+			if tt.wantCode != "" {
+				if ok {
+					t.Fatalf("accepted as %s (desc %v), want %s", field, desc, tt.wantCode)
+				}
+				var problem Problem
+				json.NewDecoder(w.Body).Decode(&problem)
+				if w.Code != http.StatusBadRequest || problem.Code != tt.wantCode {
+					t.Errorf("got %d %s, want 400 %s", w.Code, problem.Code, tt.wantCode)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("refused: %d %s", w.Code, w.Body.String())
+			}
+			if field != tt.wantField || desc != tt.wantDesc || (c != nil) != tt.wantCursor {
+				t.Errorf("got %s, desc %v, cursor %v; want %s, desc %v, cursor %v", field, desc, c != nil, tt.wantField, tt.wantDesc, tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestPageCursors(t *testing.T) {
+	// keysFor returns the keys of n rows of a listing keyed a, b, c, ...,
+	// starting after its first rows
+	keysFor := func(first, n int) [][]string {
+		keys := make([][]string, n)
+		for i := range keys {
+			keys[i] = []string{string(rune('a' + first + i))}
+		}
+		return keys
+	}
+	tests := []struct {
+		name     string
+		cursor   *pageCursor
+		keys     [][]string // limit+1 fetched means more rows follow
+		wantFrom int
+		wantTo   int
+		wantPrev []string // Key of the prev cursor, nil for none
+		wantNext []string
+	}{
+		{"first page, more follow", nil, keysFor(0, 4), 0, 3, nil, []string{"c"}},
+		{"only page", nil, keysFor(0, 2), 0, 2, nil, nil},
+		{"empty listing", nil, nil, 0, 0, nil, nil},
+		{"middle page forward", &pageCursor{Key: []string{"c"}}, keysFor(3, 4), 0, 3, []string{"d"}, []string{"f"}},
+		{"last page forward", &pageCursor{Key: []string{"c"}}, keysFor(3, 2), 0, 2, []string{"d"}, nil},
+		{"middle page backward", &pageCursor{Key: []string{"g"}, Backward: true}, keysFor(2, 4), 1, 4, []string{"d"}, []string{"f"}},
+		{"back at the start", &pageCursor{Key: []string{"d"}, Backward: true}, keysFor(0, 3), 0, 3, nil, []string{"c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := PageInfo{Limit: 3, Sort: "name"}
+			from, to := pageCursors(&info, tt.cursor, "name", false, tt.keys)
+			if from != tt.wantFrom || to != tt.wantTo {
+				t.Errorf("rows [%d:%d], want [%d:%d]", from, to, tt.wantFrom, tt.wantTo)
+			}
+			checkCursor(t, "prev", info.PrevCursor, tt.wantPrev, true)
+			checkCursor(t, "next", info.NextCursor, tt.wantNext, false)
+		})
+	}
+}
+
+// checkCursor compares a page's cursor with the key it should point at.
+func checkCursor(t *testing.T, name string, got *string, wantKey []string, backward bool) {
+	t.Helper()
+	if got == nil || wantKey == nil {
+		if (got == nil) != (wantKey == nil) {
+			t.Errorf("%s cursor = %v, want key %v", name, got, wantKey)
+		}
+		return
+	}
+	c, err := decodeCursor(*got)
+	if err != nil {
+		t.Fatalf("%s cursor: %v", name, err)
+	}
+	want := pageCursor{Sort: "name", Key: wantKey, Backward: backward}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("%s cursor = %+v, want %+v", name, c, want)
+	}
+}
@@ -0,0 +1,66 @@
+// server/patient_purge.go
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/db"
+)
+
+// purgeBatchSize bounds how many patients one purge transaction removes, so a
+// backlog is worked off without holding locks on it all at once.
+const purgeBatchSize = 100
+
+// purgeDeletedPatients periodically hard-deletes patients soft-deleted longer
+// ago than Patient_Retention, until ctx is cancelled.
+func (s *Server) purgeDeletedPatients(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.purgeExpiredPatients(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Error("Error purging deleted patients", "err", err)
+			}
+		}
+	}
+}
+
+// purgeExpiredPatients removes every patient past retention, a batch per
+// transaction, and logs each one removed.
+func (s *Server) purgeExpiredPatients(ctx context.Context) error {
+	retention := int32(s.config.Patient_Retention / time.Second)
+	for {
+		purged, err := s.purgePatientBatch(ctx, retention)
+		if err != nil {
+			return err
+		}
+		for _, p := range purged {
+			s.logger.Info("Purged deleted patient", "patient_id", p.PatientID, "clinic_id", p.ClinicID,
+				"deleted_at", p.DeletedAt.Time, "deleted_by", p.DeletedBy)
+		}
+		if len(purged) < purgeBatchSize {
+			return nil
+		}
+	}
+}
+
+func (s *Server) purgePatientBatch(ctx context.Context, retention int32) ([]db.PurgeDeletedPatientsRow, error) {
+	// The worker is bound to no clinic; purge_deleted_patients reaches every clinic's patients
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	purged, err := s.queries.WithTx(tx).PurgeDeletedPatients(ctx, db.PurgeDeletedPatientsParams{
+		RetentionSeconds: retention,
+		BatchSize:        purgeBatchSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return purged, tx.Commit(ctx)
+}
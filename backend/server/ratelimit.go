@@ -0,0 +1,179 @@
+// server/ratelimit.go
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/config"
+	"github.com/dukunuu/munkhjin-diplom/backend/db"
+)
+
+// Route groups with separate rate limit budgets
+const (
+	rateGroupDefault = "default"
+	rateGroupList    = "list"
+	rateGroupPredict = "predict"
+)
+
+// rateLimitIdle is how long an unused bucket is kept; by then it has refilled
+// for any sensible budget, so dropping it loses nothing.
+const rateLimitIdle = time.Hour
+
+// rateLimitStore keeps the token buckets.
+type rateLimitStore interface {
+	// take refills the bucket for key and takes one token from it. When no
+	// token is left it returns false and how long until one is available.
+	take(ctx context.Context, key string, limit config.RateLimit) (bool, time.Duration, error)
+	// sweep drops buckets that have been idle for rateLimitIdle.
+	sweep(ctx context.Context) error
+}
+
+func newRateLimitStore(cfg *config.Config, queries *db.Queries) rateLimitStore {
+	if cfg.Rate_Limit_Store == "postgres" {
+		return &pgRateLimitStore{queries: queries}
+	}
+	return &memoryRateLimitStore{buckets: make(map[string]*tokenBucket)}
+}
+
+func perSecond(limit config.RateLimit) float64 {
+	return float64(limit.Per_Minute) / 60
+}
+
+// retryAfter is the wait until a bucket holding tokens has a whole token again.
+func retryAfter(tokens float64, limit config.RateLimit) time.Duration {
+	return time.Duration((1 - tokens) / perSecond(limit) * float64(time.Second))
+}
+
+// memoryRateLimitStore keeps buckets in this process, so each replica
+// enforces its own budget.
+type memoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func (m *memoryRateLimitStore) take(_ context.Context, key string, limit config.RateLimit) (bool, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(limit.Burst), updated: now}
+		m.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*perSecond(limit))
+	b.updated = now
+	if b.tokens < 1 {
+		return false, retryAfter(b.tokens, limit), nil
+	}
+	b.tokens--
+	return true, 0, nil
+}
+
+func (m *memoryRateLimitStore) sweep(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cutoff := time.Now().Add(-rateLimitIdle)
+	for key, b := range m.buckets {
+		if b.updated.Before(cutoff) {
+			delete(m.buckets, key)
+		}
+	}
+	return nil
+}
+
+// pgRateLimitStore keeps buckets in the rate_limit_bucket table so limits hold
+// across all backend replicas. Each take is a single atomic upsert.
+type pgRateLimitStore struct {
+	queries *db.Queries
+}
+
+func (p *pgRateLimitStore) take(ctx context.Context, key string, limit config.RateLimit) (bool, time.Duration, error) {
+	row, err := p.queries.TakeRateLimitToken(ctx, db.TakeRateLimitTokenParams{
+		BucketKey: key,
+		Burst:     float64(limit.Burst),
+		PerSecond: perSecond(limit),
+	})
+	if err != nil {
+		return false, 0, err
+	}
+	if !row.Allowed {
+		return false, retryAfter(row.Tokens, limit), nil
+	}
+	return true, 0, nil
+}
+
+func (p *pgRateLimitStore) sweep(ctx context.Context) error {
+	_, err := p.queries.DeleteIdleRateLimitBuckets(ctx, int32(rateLimitIdle/time.Second))
+	return err
+}
+
+// sweepRateLimits periodically drops idle buckets until ctx is cancelled.
+func (s *Server) sweepRateLimits(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.rateLimits.sweep(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Error("Error sweeping rate limit buckets", "err", err)
+			}
+		}
+	}
+}
+
+// callerKey identifies the caller: by principal when the route is
+// authenticated, otherwise by client IP (set from X-Forwarded-For / X-Real-IP
+// by middleware.RealIP).
+func callerKey(r *http.Request) string {
+	if caller, ok := principalFromContext(r.Context()); ok {
+		return "user:" + strconv.Itoa(int(caller.UserID))
+	}
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host // RealIP leaves RemoteAddr as host:port when no proxy header is set
+	}
+	return "ip:" + ip
+}
+
+// rateLimit limits each caller (see callerKey) to the group's budget.
+func (s *Server) rateLimit(group string, limit config.RateLimit) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit.Per_Minute == 0 {
+			return next // Disabled
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := group + "|" + callerKey(r)
+
+			allowed, wait, err := s.rateLimits.take(r.Context(), key, limit)
+			if err != nil {
+				// Fail open: an unavailable store must not take the API down with it
+				s.log(r).Error("Error checking rate limit", "bucket", key, "err", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !allowed {
+				seconds := int(math.Ceil(wait.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				respondWithError(w, r, http.StatusTooManyRequests, fmt.Sprintf("Rate limit exceeded; retry in %d seconds", seconds))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
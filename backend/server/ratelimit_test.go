@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dukunuu/munkhjin-diplom/backend/config"
+)
+
+func TestCallerKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		caller     *Principal
+		want       string
+	}{
+		{"authenticated", "203.0.113.5:4711", &Principal{UserID: 4, ClinicID: 1}, "user:4"},
+		{"same user from another address", "198.51.100.9:80", &Principal{UserID: 4, ClinicID: 2}, "user:4"},
+		{"IPv4 with port", "203.0.113.5:4711", nil, "ip:203.0.113.5"},
+		{"IPv4 set by RealIP", "203.0.113.5", nil, "ip:203.0.113.5"},
+		{"IPv6 with port", "[2001:db8::1]:4711", nil, "ip:2001:db8::1"},
+		{"IPv6 set by RealIP", "2001:db8::1", nil, "ip:2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/symptoms", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.caller != nil {
+				r = r.WithContext(context.WithValue(r.Context(), principalKey{}, *tt.caller))
+			}
+			if got := callerKey(r); got != tt.want {
+				t.Errorf("callerKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	limit := config.RateLimit{Per_Minute: 60, Burst: 3} // A token a second
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration // Since the previous take, before taking
+		want    []bool        // Outcome of each take
+	}{
+		{"a new bucket is full", 0, []bool{true, true, true, false}},
+		{"refills at the rate", 2 * time.Second, []bool{true, true, false}},
+		{"a partial token is not enough", 500 * time.Millisecond, []bool{false}},
+		{"refills to the burst only", time.Hour, []bool{true, true, true, false}},
+	}
+	store := &memoryRateLimitStore{buckets: make(map[string]*tokenBucket)}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if b, ok := store.buckets["a"]; ok {
+				b.updated = b.updated.Add(-tt.elapsed)
+			}
+			for i, want := range tt.want {
+				allowed, wait, err := store.take(ctx, "a", limit)
+				if err != nil {
+					t.Fatalf("take: %v", err)
+				}
+				if allowed != want {
+					t.Fatalf("take %d allowed = %v, want %v", i+1, allowed, want)
+				}
+				if !allowed && (wait <= 0 || wait > time.Second) {
+					t.Errorf("take %d wait = %s, want at most the second a token takes", i+1, wait)
+				}
+			}
+		})
+	}
+
+	if allowed, _, _ := store.take(ctx, "b", limit); !allowed {
+		t.Error("another key shares the exhausted bucket")
+	}
+}
+
+func TestMemoryRateLimitStoreSweep(t *testing.T) {
+	store := &memoryRateLimitStore{buckets: map[string]*tokenBucket{
+		"idle":   {tokens: 1, updated: time.Now().Add(-rateLimitIdle - time.Minute)},
+		"recent": {tokens: 1, updated: time.Now()},
+	}}
+	if err := store.sweep(context.Background()); err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	if _, ok := store.buckets["idle"]; ok {
+		t.Error("idle bucket kept")
+	}
+	if _, ok := store.buckets["recent"]; !ok {
+		t.Error("recent bucket dropped")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	tests := []struct {
+		name      string
+		limit     config.RateLimit
+		want      []int
+		wantRetry string // Retry-After of the last response
+	}{
+		{"within the burst", config.RateLimit{Per_Minute: 60, Burst: 2}, []int{204, 204}, ""},
+		{"past the burst", config.RateLimit{Per_Minute: 60, Burst: 2}, []int{204, 204, 429}, "1"},
+		{"slow refill", config.RateLimit{Per_Minute: 6, Burst: 1}, []int{204, 429}, "10"},
+		{"disabled", config.RateLimit{Per_Minute: 0}, []int{204, 204, 204, 204}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{rateLimits: &memoryRateLimitStore{buckets: make(map[string]*tokenBucket)}}
+			h := s.rateLimit(rateGroupDefault, tt.limit)(next)
+			var w *httptest.ResponseRecorder
+			for i, want := range tt.want {
+				w = httptest.NewRecorder()
+				h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/symptoms", nil))
+				if w.Code != want {
+					t.Fatalf("request %d: status %d, want %d", i+1, w.Code, want)
+				}
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.wantRetry {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetry)
+			}
+		})
+	}
+}
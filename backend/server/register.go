@@ -0,0 +1,152 @@
+// server/register.go
+package server
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Genders accepted for patients. A register only encodes Male or Female; Other
+// is never reported as a mismatch.
+const (
+	genderMale   = "Male"
+	genderFemale = "Female"
+	genderOther  = "Other"
+)
+
+// Issues reported in PatientResponse.register_issues for stored patients whose
+// register does not agree with the rest of the record.
+const (
+	registerInvalid           = "invalid_register"
+	registerBirthdateMismatch = "birthdate_mismatch"
+	registerGenderMismatch    = "gender_mismatch"
+)
+
+// registerPattern is a Mongolian civil register number: two Cyrillic letters
+// (the area of first registration), YYMMDD of birth, a serial digit whose
+// parity encodes sex, and a final digit. The final digit is not checked: its
+// algorithm is not published, so any digit is accepted.
+var registerPattern = regexp.MustCompile(`^[А-ЯЁӨҮ]{2}[0-9]{8}$`)
+
+// civilRegister is what a register number says about its holder.
+type civilRegister struct {
+	Birthdate time.Time
+	Gender    string // Male or Female
+}
+
+// normalizeRegister trims and upper-cases a register; registers are stored
+// this way so lookups and duplicate checks compare like with like.
+func normalizeRegister(register string) string {
+	return strings.ToUpper(strings.TrimSpace(register))
+}
+
+// parseRegister decodes a register number. Months 01-12 are births in the
+// 1900s; for births from 2000 on, 20 is added to the month (21-32).
+func parseRegister(register string) (civilRegister, error) {
+	register = normalizeRegister(register)
+	if !registerPattern.MatchString(register) {
+		return civilRegister{}, errors.New("register must be two Cyrillic letters followed by eight digits")
+	}
+	digits := []rune(register)[2:]
+	num := func(i int) int { return int(digits[i]-'0')*10 + int(digits[i+1]-'0') }
+
+	year, month, day := 1900+num(0), num(2), num(4)
+	if month > 20 {
+		year += 100
+		month -= 20
+	}
+	if month < 1 || month > 12 {
+		return civilRegister{}, errors.New("register encodes an invalid birth month")
+	}
+	birthdate := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	// time.Date normalizes 31 February into March; a real date survives the round trip
+	if birthdate.Day() != day {
+		return civilRegister{}, errors.New("register encodes an invalid birth date")
+	}
+	if birthdate.After(time.Now()) {
+		return civilRegister{}, errors.New("register encodes a birth date in the future")
+	}
+
+	gender := genderFemale
+	if (digits[6]-'0')%2 == 1 {
+		gender = genderMale
+	}
+	return civilRegister{Birthdate: birthdate, Gender: gender}, nil
+}
+
+// registerIssues compares a stored patient with what its register says.
+func registerIssues(register string, birthdate pgtype.Date, gender string) []string {
+	reg, err := parseRegister(register)
+	if err != nil {
+		return []string{registerInvalid}
+	}
+	var issues []string
+	if birthdate.Valid && !birthdate.Time.Equal(reg.Birthdate) {
+		issues = append(issues, registerBirthdateMismatch)
+	}
+	if gender != genderOther && gender != reg.Gender {
+		issues = append(issues, registerGenderMismatch)
+	}
+	return issues
+}
+
+// ageOn is the age in whole years on the given day, or 0 without a birthdate.
+func ageOn(birthdate pgtype.Date, now time.Time) int32 {
+	if !birthdate.Valid {
+		return 0
+	}
+	b := birthdate.Time
+	age := now.Year() - b.Year()
+	if now.Month() < b.Month() || (now.Month() == b.Month() && now.Day() < b.Day()) {
+		age--
+	}
+	return int32(max(age, 0))
+}
+
+// applyRegister fills in the birthdate and gender a patient request left out
+// from its register. Call it after validation, which guarantees the register
+// parses and agrees with the values that were given.
+func applyRegister(register, birthdate, gender *string) {
+	*register = normalizeRegister(*register)
+	reg, err := parseRegister(*register)
+	if err != nil {
+		return
+	}
+	if *birthdate == "" {
+		*birthdate = reg.Birthdate.Format("2006-01-02")
+	}
+	if *gender == "" {
+		*gender = reg.Gender
+	}
+}
+
+// validateRegister is the "register" rule: the value parses as a register.
+func validateRegister(fl validator.FieldLevel) bool {
+	_, err := parseRegister(fl.Field().String())
+	return err == nil
+}
+
+// validatePatientRegister reports a birthdate or gender that contradicts the
+// register. It is registered for the patient create and update requests, which
+// share the Register, Birthdate and Gender fields.
+func validatePatientRegister(sl validator.StructLevel) {
+	v := sl.Current()
+	reg, err := parseRegister(v.FieldByName("Register").String())
+	if err != nil {
+		return // Reported by the field's own "register" rule
+	}
+	if birthdate := v.FieldByName("Birthdate").String(); birthdate != "" && birthdate != reg.Birthdate.Format("2006-01-02") {
+		sl.ReportError(birthdate, "birthdate", "Birthdate", "register_birthdate", reg.Birthdate.Format("2006-01-02"))
+	}
+	if gender := v.FieldByName("Gender").String(); gender != "" && gender != genderOther && gender != reg.Gender {
+		sl.ReportError(gender, "gender", "Gender", "register_gender", reg.Gender)
+	}
+}
+
+// patientRegisterTypes are the requests validatePatientRegister applies to.
+var patientRegisterTypes = []any{CreatePatientRequest{}, UpdatePatientRequest{}}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRegister(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name      string
+		register  string
+		birthdate time.Time
+		gender    string
+		wantErr   bool
+	}{
+		{"1900s month as is", "УБ99032215", date(1999, time.March, 22), genderMale, false},
+		{"2000s month plus 20", "УБ05231224", date(2005, time.March, 12), genderFemale, false},
+		{"2000s December", "УБ10321235", date(2010, time.December, 12), genderMale, false},
+		{"odd serial digit is male", "УБ88101073", date(1988, time.October, 10), genderMale, false},
+		{"even serial digit is female", "УБ88101083", date(1988, time.October, 10), genderFemale, false},
+		{"lowercase", "уб99032215", date(1999, time.March, 22), genderMale, false},
+		{"surrounding spaces", "  УБ99032215 ", date(1999, time.March, 22), genderMale, false},
+		{"letters Ө and Ү", "ӨҮ99032215", date(1999, time.March, 22), genderMale, false},
+		{"lowercase ө and ү", "өү99032215", date(1999, time.March, 22), genderMale, false},
+		{"any final digit", "УБ99032210", date(1999, time.March, 22), genderMale, false},
+		{"31 February", "УБ99023115", time.Time{}, "", true},
+		{"29 February outside a leap year", "УБ99022915", time.Time{}, "", true},
+		{"29 February in a leap year", "УБ04222915", date(2004, time.February, 29), genderMale, false},
+		{"day zero", "УБ99030015", time.Time{}, "", true},
+		{"month zero", "УБ99002215", time.Time{}, "", true},
+		{"month 13", "УБ99132215", time.Time{}, "", true},
+		{"month between the centuries", "УБ99202215", time.Time{}, "", true},
+		{"month 33", "УБ99332215", time.Time{}, "", true},
+		{"future birth date", "УБ99320115", time.Time{}, "", true},
+		{"Latin letters", "UB99032215", time.Time{}, "", true},
+		{"one letter", "У99032215", time.Time{}, "", true},
+		{"seven digits", "УБ9903221", time.Time{}, "", true},
+		{"nine digits", "УБ990322155", time.Time{}, "", true},
+		{"empty", "", time.Time{}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRegister(tt.register)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseRegister(%q) = %+v, want error", tt.register, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRegister(%q): %v", tt.register, err)
+			}
+			if !got.Birthdate.Equal(tt.birthdate) {
+				t.Errorf("birthdate = %s, want %s", got.Birthdate.Format("2006-01-02"), tt.birthdate.Format("2006-01-02"))
+			}
+			if got.Gender != tt.gender {
+				t.Errorf("gender = %q, want %q", got.Gender, tt.gender)
+			}
+		})
+	}
+}
+
+func TestNormalizeRegister(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"УБ99032215", "УБ99032215"},
+		{"уб99032215", "УБ99032215"},
+		{" өү99032215\n", "ӨҮ99032215"},
+	}
+	for _, tt := range tests {
+		if got := normalizeRegister(tt.in); got != tt.want {
+			t.Errorf("normalizeRegister(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
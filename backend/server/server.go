@@ -1,9 +1,14 @@
 package server
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"sync"
+	"time"
 
+	"github.com/dukunuu/munkhjin-diplom/backend/config"
 	"github.com/dukunuu/munkhjin-diplom/backend/db" // Your sqlc package
 	_ "github.com/dukunuu/munkhjin-diplom/backend/docs" // Adjust path to your generated docs
 	"github.com/go-chi/chi/v5"
@@ -16,12 +21,21 @@ type Server struct {
 	pool    *pgxpool.Pool
 	queries *db.Queries
 	router  *chi.Mux
+	config  *config.Config
+	logger  *slog.Logger
+	metrics *metrics
+	readiness readiness
+	rateLimits rateLimitStore
+	// Background workers run until Start shuts the server down
+	workerCtx   context.Context
+	stopWorkers context.CancelFunc
+	workers     sync.WaitGroup
 	// Add other fields like modelUrl if needed
 	modelUrl string
 }
 
 // Assume Init function initializes pool, queries, router, modelUrl
-func Init(pool *pgxpool.Pool, modelUrl string) *Server {
+func Init(pool *pgxpool.Pool, cfg *config.Config, logger *slog.Logger) *Server {
 	queries := db.New(pool)
 	router := chi.NewRouter()
 
@@ -29,13 +43,38 @@ func Init(pool *pgxpool.Pool, modelUrl string) *Server {
 		pool:     pool,
 		router:   router,
 		queries:  queries,
-		modelUrl: modelUrl, // Store modelUrl if predictHandler needs it
+		config:   cfg,
+		logger:   logger,
+		metrics:  newMetrics(pool),
+		modelUrl: cfg.Model_Url, // Store modelUrl if predictHandler needs it
 	}
+	server.workerCtx, server.stopWorkers = context.WithCancel(context.Background())
+	server.rateLimits = newRateLimitStore(cfg, queries)
+	server.runWorker("rate-limit-sweep", func(ctx context.Context) {
+		server.sweepRateLimits(ctx, 10*time.Minute)
+	})
+	server.runWorker("idempotency-sweep", func(ctx context.Context) {
+		server.sweepIdempotencyKeys(ctx, idempotencySweepInterval)
+	})
+	server.runWorker("patient-purge", func(ctx context.Context) {
+		server.purgeDeletedPatients(ctx, cfg.Patient_Purge_Interval)
+	})
 
 	router.Use(middleware.RequestID)
 	router.Use(middleware.RealIP)
-	router.Use(middleware.Logger) // Log requests
+	router.Use(server.trace) // OpenTelemetry span per request
+	router.Use(server.instrument) // Prometheus metrics for every route
+	router.Use(server.requestLog) // One structured access line per request
 	router.Use(middleware.Recoverer) // Recover from panics
+	router.Use(securityHeaders)
+	router.Use(cors(cfg.CORS)) // Answers preflight requests for every route
+
+	router.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		respondWithError(w, r, http.StatusNotFound, "No route for "+r.URL.Path)
+	})
+	router.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		respondWithError(w, r, http.StatusMethodNotAllowed, r.Method+" is not allowed on "+r.URL.Path)
+	})
 
 	server.setupRoutes() // Call setupRoutes internally
 	return server
@@ -49,72 +88,222 @@ func (s *Server) setupRoutes() { // Removed modelUrl param, use s.modelUrl if ne
 		w.Write([]byte("Welcome to the Patient API! View docs at /swagger/index.html"))
 	})
 
-	// --- Patient Base Routes ---
-	s.router.Route("/patients", func(r chi.Router) {
-		r.Get("/", s.handleListPatients())          // GET /patients?limit=10&offset=0
-		r.Post("/", s.handleCreatePatient())         // POST /patients
-		r.Get("/{patientID}", s.handleGetPatientByID()) // GET /patients/123
-		r.Put("/{patientID}", s.handleUpdatePatientDetails()) // PUT /patients/123
-		r.Delete("/{patientID}", s.handleDeletePatient())   // DELETE /patients/123
-
-		r.Get("/{patientID}/details", s.handleGetPatientDetails())
-
-		// --- General Symptoms Reported by Patient (patient_symptoms table) ---
-		r.Route("/{patientID}/general-symptoms", func(gsr chi.Router) {
-			gsr.Get("/", s.handleListGeneralSymptomsForPatient()) // GET /patients/123/general-symptoms
-			gsr.Post("/", s.handleRecordPatientSymptom())         // POST /patients/123/general-symptoms
-			// Deletion is handled by /patient-symptoms/{id}
+	// Patient data is clinic-scoped: these routes require a caller bound to a clinic
+	listLimit := s.rateLimit(rateGroupList, s.config.Rate_Limit_List)
+
+	s.router.Group(func(tenant chi.Router) {
+		tenant.Use(s.authenticate)
+		tenant.Use(s.rateLimit(rateGroupDefault, s.config.Rate_Limit_Default)) // Keyed by principal
+		tenant.Use(s.idempotent) // Replays retried POSTs sent with an Idempotency-Key
+
+		// --- Patient Base Routes ---
+		tenant.Route("/patients", func(r chi.Router) {
+			r.With(listLimit).Get("/", s.handleListPatients()) // GET /patients?limit=10&sort=-created_at&gender=Female&cursor=...
+			r.Post("/", s.handleCreatePatient())         // POST /patients
+			r.With(listLimit).Get("/search", s.handleSearchPatients()) // GET /patients/search?q=bat
+
+			r.Route("/{patientID}", func(pr chi.Router) {
+				// Emergency access is requested before the care-team check
+				pr.Post("/break-glass", s.handleBreakGlass()) // POST /patients/123/break-glass
+				// Admins restore deleted patients whether or not they are on the care team
+				pr.With(requireRole(RoleAdmin)).Post("/restore", s.handleRestorePatient()) // POST /patients/123/restore
+
+				// Everything else is limited to the care team or an open break-glass grant
+				pr.Group(func(r chi.Router) {
+					r.Use(s.requirePatientAccess)
+
+					r.Get("/", s.handleGetPatientByID()) // GET /patients/123
+					r.Put("/", s.handleUpdatePatientDetails()) // PUT /patients/123
+					r.Patch("/", s.handlePatchPatient())       // PATCH /patients/123
+					r.Delete("/", s.handleDeletePatient())   // DELETE /patients/123
+
+					r.Get("/details", s.handleGetPatientDetails())
+					r.Post("/merge", s.handleMergePatient()) // POST /patients/123/merge
+
+					// --- Care team assigned to the patient (care_team table) ---
+					r.Route("/care-team", func(ctr chi.Router) {
+						ctr.Get("/", s.handleListCareTeam())                    // GET /patients/123/care-team
+						// Membership is managed by admins on the care team, never under a break-glass grant
+						ctr.With(requireRole(RoleAdmin), refuseBreakGlass).Post("/", s.handleAddCareTeamMember())              // POST /patients/123/care-team
+						ctr.With(requireRole(RoleAdmin), refuseBreakGlass).Delete("/{userID}", s.handleRemoveCareTeamMember()) // DELETE /patients/123/care-team/4
+					})
+
+					// --- Consents captured for the patient (patient_consent table) ---
+					r.Route("/consents", func(cr chi.Router) {
+						cr.Get("/", s.handleListConsentsForPatient())           // GET /patients/123/consents
+						cr.Post("/", s.handleGrantConsent())                    // POST /patients/123/consents
+						cr.Post("/{consentID}/revoke", s.handleRevokeConsent()) // POST /patients/123/consents/7/revoke
+					})
+
+					// --- General Symptoms Reported by Patient (patient_symptoms table) ---
+					r.Route("/general-symptoms", func(gsr chi.Router) {
+						gsr.Get("/", s.handleListGeneralSymptomsForPatient()) // GET /patients/123/general-symptoms
+						gsr.Post("/", s.handleRecordPatientSymptom())         // POST /patients/123/general-symptoms
+						// Deletion is handled by /patient-symptoms/{id}
+					})
+
+					// --- Disease Instances Recorded for Patient (patient_disease table) ---
+					r.Route("/disease-instances", func(dir chi.Router) {
+						dir.Get("/", s.handleListDiseaseInstancesForPatient()) // GET /patients/123/disease-instances
+						dir.Post("/", s.handleRecordPatientDiseaseInstance())  // POST /patients/123/disease-instances
+					})
+
+					// --- Diagnosis with its symptom links, recorded atomically ---
+					r.Post("/diagnoses", s.handleRecordDiagnosis()) // POST /patients/123/diagnoses
+				})
+			})
 		})
 
-		// --- Disease Instances Recorded for Patient (patient_disease table) ---
-		r.Route("/{patientID}/disease-instances", func(dir chi.Router) {
-			dir.Get("/", s.handleListDiseaseInstancesForPatient()) // GET /patients/123/disease-instances
-			dir.Post("/", s.handleRecordPatientDiseaseInstance())  // POST /patients/123/disease-instances
+		// --- Direct Management of General Symptom Records ---
+		tenant.Route("/patient-symptoms/{id}", func(r chi.Router) {
+			r.Use(s.requirePatientSymptomAccess) // Same care-team/break-glass rule as the patient
+
+			r.Delete("/", s.handleDeletePatientSymptom()) // DELETE /patient-symptoms/5
 		})
-	})
 
-	// --- Direct Management of General Symptom Records ---
-	s.router.Route("/patient-symptoms", func(r chi.Router) {
-		r.Delete("/{id}", s.handleDeletePatientSymptom()) // DELETE /patient-symptoms/5
-	})
+		// --- Direct Management of Disease Instances & Their Linked Symptoms ---
+		tenant.Route("/disease-instances/{instanceID}", func(r chi.Router) {
+			r.Use(s.requireInstanceAccess) // Same care-team/break-glass rule as the patient
 
-	// --- Direct Management of Disease Instances & Their Linked Symptoms ---
-	s.router.Route("/disease-instances", func(r chi.Router) {
-		r.Delete("/{instanceID}", s.handleDeletePatientDiseaseInstance()) // DELETE /disease-instances/10
+			r.Get("/", s.handleGetDiseaseInstance())              // GET /disease-instances/10
+			r.Patch("/", s.handlePatchDiseaseInstance())          // PATCH /disease-instances/10
+			r.Delete("/", s.handleDeletePatientDiseaseInstance()) // DELETE /disease-instances/10
+			r.Get("/status-history", s.handleListDiseaseInstanceStatusHistory()) // GET /disease-instances/10/status-history
 
-		r.Route("/{instanceID}/symptoms", func(disr chi.Router) {
-			disr.Get("/", s.handleGetSymptomsForDiseaseInstance()) // GET /disease-instances/10/symptoms
-			disr.Post("/", s.handleLinkSymptomToDiseaseInstance()) // POST /disease-instances/10/symptoms
-			disr.Delete("/{symptomID}", s.handleUnlinkSymptomFromDiseaseInstance()) // DELETE /disease-instances/10/symptoms/456
+			r.Route("/symptoms", func(disr chi.Router) {
+				disr.Get("/", s.handleGetSymptomsForDiseaseInstance()) // GET /disease-instances/10/symptoms
+				disr.Post("/", s.handleLinkSymptomToDiseaseInstance()) // POST /disease-instances/10/symptoms
+				disr.Delete("/{symptomID}", s.handleUnlinkSymptomFromDiseaseInstance()) // DELETE /disease-instances/10/symptoms/456
+			})
+		})
+
+		tenant.With(s.rateLimit(rateGroupPredict, s.config.Rate_Limit_Predict)).Post("/predict", s.predictHandler(s.modelUrl))
+
+		// --- Administration ---
+		tenant.Route("/admin", func(r chi.Router) {
+			r.Use(requireRole(RoleAdmin))
+			r.With(listLimit).Get("/break-glass-events", s.handleListBreakGlassEvents()) // GET /admin/break-glass-events?since=2025-01-01T00:00:00Z
+			r.Get("/training-cases", s.handleListTrainingCases()) // GET /admin/training-cases
 		})
 	})
 
-	s.router.Route("/symptoms", func(r chi.Router) {
-		r.Get("/", s.handleListSymptoms())        // GET /symptoms
+	// --- Global symptom/disease catalog (shared by all clinics) ---
+	catalog := s.router.With(s.rateLimit(rateGroupDefault, s.config.Rate_Limit_Default)) // Keyed by client IP
+	catalog.Route("/symptoms", func(r chi.Router) {
+		r.With(listLimit).Get("/", s.handleListSymptoms())        // GET /symptoms
 		r.Post("/", s.handleCreateSymptom())       // POST /symptoms
 		r.Get("/{symptomID}", s.handleGetSymptomByID()) // GET /symptoms/456
 		r.Put("/{symptomID}", s.handleUpdateSymptom())   // PUT /symptoms/456
+		r.Patch("/{symptomID}", s.handlePatchSymptom())  // PATCH /symptoms/456
 		r.Delete("/{symptomID}", s.handleDeleteSymptom()) // DELETE /symptoms/456
+		r.With(s.authenticate, requireRole(RoleAdmin), s.idempotent).Post("/{symptomID}/merge", s.handleMergeSymptoms()) // POST /symptoms/456/merge
+		r.With(s.authenticate, requireRole(RoleAdmin), s.idempotent).Post("/{symptomID}/deprecate", s.handleDeprecateSymptom()) // POST /symptoms/456/deprecate
+		r.With(s.authenticate, requireRole(RoleAdmin), s.idempotent).Post("/{symptomID}/reinstate", s.handleReinstateSymptom()) // POST /symptoms/456/reinstate
+		r.Route("/{symptomID}/aliases", func(r chi.Router) {
+			r.Get("/", s.handleListSymptomAliases()) // GET /symptoms/456/aliases
+			// Aliases change what names resolve to a symptom, for every clinic
+			r.Group(func(r chi.Router) {
+				r.Use(s.authenticate, requireRole(RoleAdmin))
+				r.With(s.idempotent).Post("/", s.handleCreateSymptomAlias()) // POST /symptoms/456/aliases
+				r.Put("/{aliasID}", s.handleUpdateSymptomAlias())            // PUT /symptoms/456/aliases/12
+				r.Delete("/{aliasID}", s.handleDeleteSymptomAlias())         // DELETE /symptoms/456/aliases/12
+			})
+		})
 	})
 
-	s.router.Route("/diseases", func(r chi.Router) {
-		r.Get("/", s.handleListDiseases())        // GET /diseases
+	catalog.Route("/diseases", func(r chi.Router) {
+		r.With(listLimit).Get("/", s.handleListDiseases())        // GET /diseases
 		r.Post("/", s.handleCreateDisease())       // POST /diseases
 		r.Get("/{diseaseID}", s.handleGetDiseaseByID()) // GET /diseases/789
 		r.Put("/{diseaseID}", s.handleUpdateDisease())   // PUT /diseases/789
+		r.Patch("/{diseaseID}", s.handlePatchDisease())  // PATCH /diseases/789
 		r.Delete("/{diseaseID}", s.handleDeleteDisease()) // DELETE /diseases/789
+		r.With(s.authenticate, requireRole(RoleAdmin), s.idempotent).Post("/{diseaseID}/merge", s.handleMergeDiseases()) // POST /diseases/789/merge
+		r.With(s.authenticate, requireRole(RoleAdmin), s.idempotent).Post("/{diseaseID}/deprecate", s.handleDeprecateDisease()) // POST /diseases/789/deprecate
+		r.With(s.authenticate, requireRole(RoleAdmin), s.idempotent).Post("/{diseaseID}/reinstate", s.handleReinstateDisease()) // POST /diseases/789/reinstate
 	})
 
-	s.router.Post("/predict", s.predictHandler(s.modelUrl))
+	s.router.Handle("/metrics", s.metrics.handler()) // Prometheus scrape endpoint
 
+	// --- Probes ---
+	s.router.Get("/livez", s.handleLivez())   // GET /livez
+	s.router.Get("/readyz", s.handleReadyz()) // GET /readyz
+
+	// Kept for existing callers; prefer /livez and /readyz
 	s.router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
 }
 
-func (s *Server) Start(addr string) error {
-	log.Printf("Server listening on %s", addr)
-	log.Printf("API docs available at http://%s/swagger/index.html", addr) // Log swagger URL
-	return http.ListenAndServe(addr, s.router)
+// runWorker starts a background job. fn must return once ctx is cancelled.
+func (s *Server) runWorker(name string, fn func(ctx context.Context)) {
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		fn(s.workerCtx)
+		s.logger.Info("Background worker stopped", "worker", name)
+	}()
+}
+
+// Start serves HTTP (or HTTPS when TLS files are configured) until ctx is
+// cancelled, then stops accepting connections, drains in-flight requests and
+// stops the background workers, all within the shutdown timeout.
+// The caller closes the pool afterwards.
+func (s *Server) Start(ctx context.Context) error {
+	httpServer := &http.Server{
+		Addr:              s.config.Port,
+		Handler:           s.router,
+		ReadTimeout:       s.config.HTTP.Read_Timeout,
+		ReadHeaderTimeout: s.config.HTTP.Read_Header_Timeout,
+		WriteTimeout:      s.config.HTTP.Write_Timeout,
+		IdleTimeout:       s.config.HTTP.Idle_Timeout,
+		MaxHeaderBytes:    s.config.HTTP.Max_Header_Bytes,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if s.config.TLS_Cert_File != "" {
+			s.logger.Info("Server listening", "addr", s.config.Port, "tls", true, "docs", "https://"+s.config.Port+"/swagger/index.html")
+			serveErr <- httpServer.ListenAndServeTLS(s.config.TLS_Cert_File, s.config.TLS_Key_File)
+		} else {
+			s.logger.Info("Server listening", "addr", s.config.Port, "tls", false, "docs", "http://"+s.config.Port+"/swagger/index.html")
+			serveErr <- httpServer.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-serveErr:
+		// Failed to listen (e.g. port in use or bad certificate)
+		s.stopWorkers()
+		s.workers.Wait()
+		return err
+	case <-ctx.Done():
+	}
+
+	s.logger.Info("Shutting down: draining in-flight requests", "timeout", s.config.HTTP.Shutdown_Timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.HTTP.Shutdown_Timeout)
+	defer cancel()
+
+	err := httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		s.logger.Error("Error draining requests", "err", err)
+	}
+	if serveErr := <-serveErr; !errors.Is(serveErr, http.ErrServerClosed) {
+		err = errors.Join(err, serveErr)
+	}
+
+	s.stopWorkers()
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.logger.Info("Background workers stopped")
+	case <-shutdownCtx.Done():
+		err = errors.Join(err, errors.New("background workers did not stop before the shutdown deadline"))
+	}
+	return err
 }
@@ -0,0 +1,63 @@
+// server/transliterate.go
+package server
+
+import "strings"
+
+// cyrillicToLatin romanizes Mongolian Cyrillic the way names are commonly
+// typed on a Latin keyboard. Ө and Ү have no Latin letter of their own and
+// fall together with О and У; similarity matching absorbs the difference.
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "ye", 'ё': "yo",
+	'ж': "j", 'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'ө': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ү': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh",
+	'щ': "sh", 'ъ': "", 'ы': "y", 'ь': "i", 'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+// latinDigraphs are read before single letters when going back to Cyrillic.
+var latinDigraphs = map[string]rune{
+	"kh": 'х', "ts": 'ц', "ch": 'ч', "sh": 'ш', "ya": 'я', "yo": 'ё', "yu": 'ю', "ye": 'е',
+}
+
+var latinToCyrillic = map[rune]rune{
+	'a': 'а', 'b': 'б', 'c': 'ц', 'd': 'д', 'e': 'э', 'f': 'ф', 'g': 'г',
+	'h': 'х', 'i': 'и', 'j': 'ж', 'k': 'к', 'l': 'л', 'm': 'м', 'n': 'н',
+	'o': 'о', 'p': 'п', 'q': 'к', 'r': 'р', 's': 'с', 't': 'т', 'u': 'у',
+	'v': 'в', 'w': 'в', 'x': 'х', 'y': 'ы', 'z': 'з',
+}
+
+// toLatin romanizes the Cyrillic letters of a lower-case string and leaves
+// everything else as it is.
+func toLatin(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if latin, ok := cyrillicToLatin[r]; ok {
+			b.WriteString(latin)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// toCyrillic spells the Latin letters of a lower-case string in Cyrillic and
+// leaves everything else as it is.
+func toCyrillic(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if i+1 < len(runes) {
+			if cyr, ok := latinDigraphs[string(runes[i:i+2])]; ok {
+				b.WriteRune(cyr)
+				i++
+				continue
+			}
+		}
+		if cyr, ok := latinToCyrillic[runes[i]]; ok {
+			b.WriteRune(cyr)
+		} else {
+			b.WriteRune(runes[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package server
+
+import "testing"
+
+func TestToLatin(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"бат", "bat"},
+		{"дорж", "dorj"},
+		{"цэцэг", "tsetseg"},
+		{"хүрэлбаатар", "khurelbaatar"},
+		{"өлзий", "olzii"},
+		{"чулуун", "chuluun"},
+		{"шагдар", "shagdar"},
+		{"янжмаа", "yanjmaa"},
+		{"ёндон", "yondon"},
+		{"юмжир", "yumjir"},
+		{"пүрэвсүрэн", "purevsuren"},
+		{"бат-эрдэнэ 99", "bat-erdene 99"},
+		{"bat", "bat"}, // Latin stays
+		{"Бат", "Бat"}, // Callers lower-case first
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := toLatin(tt.in); got != tt.want {
+			t.Errorf("toLatin(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestToCyrillic(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"bat", "бат"},
+		{"dorj", "дорж"},
+		{"tsetseg", "цэцэг"},
+		{"khurelbaatar", "хурэлбаатар"},
+		{"chuluun", "чулуун"},
+		{"shagdar", "шагдар"},
+		{"yanjmaa", "янжмаа"},
+		{"yondon", "ёндон"},
+		{"yumjir", "юмжир"},
+		{"enkh", "энх"},
+		{"hulan", "хулан"}, // h and x both stand for х
+		{"xulan", "хулан"},
+		{"cecg", "цэцг"},
+		{"y", "ы"}, // A lone y is not the start of a digraph
+		{"bat-erdene 99", "бат-эрдэнэ 99"},
+		{"бат", "бат"}, // Cyrillic stays
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := toCyrillic(tt.in); got != tt.want {
+			t.Errorf("toCyrillic(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// Names without Ө, Ү or Е come back as they were, so a name typed in either
+// script finds the other.
+func TestTransliterateRoundTrip(t *testing.T) {
+	for _, name := range []string{"бат", "цэцэг", "чулуун", "шагдар", "янжмаа", "хулан", "энхбаяр"} {
+		if got := toCyrillic(toLatin(name)); got != name {
+			t.Errorf("toCyrillic(toLatin(%q)) = %q", name, got)
+		}
+	}
+}
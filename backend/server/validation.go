@@ -0,0 +1,194 @@
+// server/validation.go
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// CodeValidationFailed is the problem code for request bodies that break
+// their struct's `validate` rules; every violation is listed in "errors".
+const CodeValidationFailed = "validation_failed"
+
+// swagger:model FieldViolation
+type FieldViolation struct {
+	Field   string `json:"field" example:"email"`                           // JSON path of the offending value
+	Rule    string `json:"rule" example:"email"`                            // Rule that failed: required, max, email, oneof, ...
+	Message string `json:"message" example:"must be a valid email address"` // Human-readable explanation
+}
+
+// phonePattern accepts an optional leading +, then digits with optional
+// spaces, dashes or parentheses (8 digits for Mongolian numbers, more with a
+// country code).
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ()-]{5,20}[0-9]$`)
+
+// validate checks request structs against their `validate` tags. Violations
+// are reported under the fields' JSON names.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	// notblank: not empty after trimming whitespace
+	v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+	// phone: see phonePattern
+	v.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		return phonePattern.MatchString(fl.Field().String())
+	})
+	// pastdate: a YYYY-MM-DD date that is not in the future
+	v.RegisterValidation("pastdate", func(fl validator.FieldLevel) bool {
+		t, err := time.Parse("2006-01-02", fl.Field().String())
+		return err == nil && !t.After(time.Now())
+	})
+	// register: a Mongolian civil register number (see register.go)
+	v.RegisterValidation("register", validateRegister)
+	v.RegisterStructValidation(validatePatientRegister, patientRegisterTypes...)
+	return v
+}
+
+// violationMessage explains a failed rule in words.
+func violationMessage(fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+	switch fe.Tag() {
+	case "required", "notblank":
+		return "is required"
+	case "max":
+		if isString {
+			return "must be at most " + fe.Param() + " characters"
+		}
+		return "must be at most " + fe.Param()
+	case "min":
+		if isString {
+			return "must be at least " + fe.Param() + " characters"
+		}
+		return "must be at least " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be " + fe.Param() + " or more"
+	case "lte":
+		return "must be " + fe.Param() + " or less"
+	case "email":
+		return "must be a valid email address"
+	case "phone":
+		return "must be a valid phone number"
+	case "datetime":
+		return "must be a date in YYYY-MM-DD format"
+	case "pastdate":
+		return "must be a YYYY-MM-DD date that is not in the future"
+	case "register":
+		return "must be a civil register number: two Cyrillic letters and eight digits encoding a valid birth date"
+	case "register_birthdate":
+		return "does not match the register, which gives " + fe.Param()
+	case "register_gender":
+		return "does not match the register, which gives " + fe.Param()
+	case "bcp47_language_tag":
+		return "must be a language tag such as mn, en or mn-Latn"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	}
+	return "failed the " + fe.Tag() + " rule"
+}
+
+// validationError runs the struct's rules and returns every violation at
+// once, or nil when the struct is valid.
+func validationError(v any) *APIError {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return &APIError{Status: http.StatusInternalServerError, Code: CodeInternal, Detail: "Failed to validate request", Err: err}
+	}
+
+	violations := make([]FieldViolation, len(fieldErrs))
+	for i, fe := range fieldErrs {
+		// Namespace is "CreatePatientRequest.email"; the JSON path drops the struct name
+		_, path, _ := strings.Cut(fe.Namespace(), ".")
+		violations[i] = FieldViolation{Field: path, Rule: fe.Tag(), Message: violationMessage(fe)}
+	}
+	return &APIError{
+		Status:     http.StatusUnprocessableEntity,
+		Code:       CodeValidationFailed,
+		Field:      violations[0].Field,
+		Detail:     fmt.Sprintf("The request has %d invalid field(s)", len(violations)),
+		Violations: violations,
+	}
+}
+
+// decodeStrict decodes a single JSON value into dst, rejecting unknown fields
+// and trailing data. An empty body decodes to the zero value, so required
+// fields are then reported by validation.
+func decodeStrict(body io.Reader, dst any) error {
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("body must contain a single JSON value")
+	}
+	return nil
+}
+
+// decodeError reports why a body could not be decoded.
+func decodeError(err error) *APIError {
+	var maxErr *http.MaxBytesError
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	var timeErr *time.ParseError
+	switch {
+	case errors.As(err, &maxErr):
+		return &APIError{Status: http.StatusRequestEntityTooLarge, Code: "payload_too_large", Detail: fmt.Sprintf("Request body exceeds %d bytes", maxErr.Limit)}
+	case errors.As(err, &typeErr):
+		return &APIError{Status: http.StatusBadRequest, Code: "invalid_type", Field: typeErr.Field, Detail: "Expected a JSON " + typeErr.Type.Kind().String() + ", got " + typeErr.Value}
+	case errors.As(err, &syntaxErr):
+		return &APIError{Status: http.StatusBadRequest, Code: "malformed_json", Detail: fmt.Sprintf("Malformed JSON at byte %d", syntaxErr.Offset)}
+	case errors.As(err, &timeErr):
+		return &APIError{Status: http.StatusBadRequest, Code: CodeInvalidValue, Detail: "Invalid timestamp (use RFC 3339, e.g. 2025-01-31T00:00:00Z)"}
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		field = strings.Trim(field, `"`)
+		return &APIError{Status: http.StatusBadRequest, Code: "unknown_field", Field: field, Detail: "Unknown field " + field}
+	}
+	return &APIError{Status: http.StatusBadRequest, Code: "malformed_json", Detail: "Invalid request payload: " + err.Error()}
+}
+
+// decodeAndValidate reads the request body into dst (capped at the configured
+// size, strictly) and validates it. On failure it writes the problem response
+// and returns false.
+func (s *Server) decodeAndValidate(w http.ResponseWriter, r *http.Request, dst any) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.Max_Body_Bytes)
+	defer r.Body.Close()
+
+	if err := decodeStrict(r.Body, dst); err != nil {
+		respondWithProblem(w, r, decodeError(err))
+		return false
+	}
+	if apiErr := validationError(dst); apiErr != nil {
+		respondWithProblem(w, r, apiErr)
+		return false
+	}
+	return true
+}
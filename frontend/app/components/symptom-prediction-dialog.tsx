@@ -20,16 +20,36 @@ import { Badge } from "~/components/ui/badge";
 import { X as RemoveIcon, Loader2, AlertCircle, Check } from "lucide-react"; // Added Check icon
 import { ScrollArea } from "~/components/ui/scroll-area";
 import { cn } from "~/lib/utils"; // Assuming you have a utility for class names
+import { apiFetch } from "~/lib/api";
 
 // --- Interfaces (mostly the same, added PatientDisease response) ---
 interface ISymptomOption {
   symptom_id: number;
   symptom_name: string;
   symptom_description?: string;
+  usage_count?: number;
+}
+
+// A page of GET /symptoms or GET /diseases
+interface ICatalogPage<T> {
+  data: T[];
+}
+
+// How long typing must pause before the catalog is searched
+const CATALOG_SEARCH_DEBOUNCE_MS = 250;
+// How many catalog entries to offer at once
+const CATALOG_PAGE_SIZE = 50;
+
+// catalogUrl searches a catalog, most used entries first
+function catalogUrl(catalog: "symptoms" | "diseases", term: string): string {
+  const params = new URLSearchParams({ sort: "-usage", limit: String(CATALOG_PAGE_SIZE) });
+  if (term.trim()) params.set("q", term.trim());
+  return `http://localhost:8080/${catalog}?${params}`;
 }
 
 interface IPredictionRequest {
   known_symptoms: Record<string, number>;
+  patient_id: number;
 }
 
 interface IPredictionResponse {
@@ -41,30 +61,28 @@ interface IDiseaseOption {
   disease_name: string;
   disease_code?: string;
   disease_description?: string;
+  usage_count?: number;
 }
 
-// Interface for the request to create a disease instance
-interface IRecordPatientDiseaseInstanceRequest {
+// Interface for the request to record a diagnosis with its symptoms in one go
+interface IRecordDiagnosisRequest {
   disease_id: number;
+  symptom_ids: number[];
   diagnosis_date?: string | null; // Optional: YYYY-MM-DD or null
   notes?: string | null;          // Optional
+  clinical_status?: "suspected" | "confirmed" | "chronic";
+  record_general_symptoms?: boolean; // Also record them as the patient's reported symptoms
 }
 
-// Interface for the response when creating a disease instance
-interface IPatientDiseaseInstanceResponse {
+// Interface for the response when recording a diagnosis
+interface IDiagnosisResponse {
     patient_disease_id: number;
     patient_id: number;
     disease_id: number;
     diagnosis_date: string | null; // Assuming string date YYYY-MM-DD
     notes: string | null;
-    created_at: string | null; // Assuming string timestamp
-    updated_at: string | null; // Assuming string timestamp
-}
-
-
-// Interface for the request to link a symptom
-interface ILinkSymptomRequest {
-  symptom_id: number;
+    clinical_status: string;
+    symptoms: ISymptomOption[];
 }
 
 interface SymptomPredictionDialogProps {
@@ -98,54 +116,72 @@ export function SymptomPredictionDialog({
   const [saveLoading, setSaveLoading] = useState(false);
   const [saveError, setSaveError] = useState<string | null>(null);
 
-  // --- useEffect for fetching data (remains the same) ---
+  // --- Reset the dialog whenever it opens or closes ---
   useEffect(() => {
     if (open) {
-      const fetchData = async () => {
-        setSymptomsLoading(true);
-        setDiseasesLoading(true);
-        setSymptomsError(null);
-        setDiseasesError(null);
-        setAllSymptoms([]);
-        setAllDiseases([]);
-        setSelectedSymptoms([]);
-        // --- Reset single selected disease ---
-        setSelectedDisease(null);
-        setPredictions(null);
-        setPredictionError(null);
-        setSaveError(null);
-
-        try {
-          const [symptomsResponse, diseasesResponse] = await Promise.all([
-            fetch("http://localhost:8080/symptoms"),
-            fetch("http://localhost:8080/diseases"),
-          ]);
-
-          if (!symptomsResponse.ok) throw new Error(`Шинж тэмдгүүдийг татахад алдаа гарлаа (${symptomsResponse.status})`);
-          const symptomsData: ISymptomOption[] = await symptomsResponse.json();
-          setAllSymptoms(symptomsData);
-
-          if (!diseasesResponse.ok) throw new Error(`Өвчнүүдийг татахад алдаа гарлаа (${diseasesResponse.status})`);
-          const diseasesData: IDiseaseOption[] = await diseasesResponse.json();
-          setAllDiseases(diseasesData);
-
-        } catch (error) {
-          console.error("Error fetching initial data:", error);
-          const errorMsg = error instanceof Error ? error.message : "Тодорхойгүй алдаа";
-          setSymptomsError(errorMsg);
-          setDiseasesError(errorMsg);
-        } finally {
-          setSymptomsLoading(false);
-          setDiseasesLoading(false);
-        }
-      };
-      fetchData();
+      setSelectedSymptoms([]);
+      setSelectedDisease(null);
+      setPredictions(null);
+      setPredictionError(null);
+      setSaveError(null);
     } else {
       setSearchTerm("");
       setDiseaseSearchTerm("");
     }
   }, [open]);
 
+  // --- Search the symptom catalog as the user types (most used first) ---
+  useEffect(() => {
+    if (!open) return;
+    const controller = new AbortController();
+    const timer = setTimeout(async () => {
+      setSymptomsLoading(true);
+      setSymptomsError(null);
+      try {
+        const response = await apiFetch(catalogUrl("symptoms", searchTerm), { signal: controller.signal });
+        if (!response.ok) throw new Error(`Шинж тэмдгүүдийг татахад алдаа гарлаа (${response.status})`);
+        const page: ICatalogPage<ISymptomOption> = await response.json();
+        setAllSymptoms(page.data);
+      } catch (error) {
+        if (controller.signal.aborted) return;
+        console.error("Error fetching symptoms:", error);
+        setSymptomsError(error instanceof Error ? error.message : "Тодорхойгүй алдаа");
+      } finally {
+        if (!controller.signal.aborted) setSymptomsLoading(false);
+      }
+    }, CATALOG_SEARCH_DEBOUNCE_MS);
+    return () => {
+      clearTimeout(timer);
+      controller.abort();
+    };
+  }, [open, searchTerm]);
+
+  // --- Search the disease catalog as the user types (most used first) ---
+  useEffect(() => {
+    if (!open) return;
+    const controller = new AbortController();
+    const timer = setTimeout(async () => {
+      setDiseasesLoading(true);
+      setDiseasesError(null);
+      try {
+        const response = await apiFetch(catalogUrl("diseases", diseaseSearchTerm), { signal: controller.signal });
+        if (!response.ok) throw new Error(`Өвчнүүдийг татахад алдаа гарлаа (${response.status})`);
+        const page: ICatalogPage<IDiseaseOption> = await response.json();
+        setAllDiseases(page.data);
+      } catch (error) {
+        if (controller.signal.aborted) return;
+        console.error("Error fetching diseases:", error);
+        setDiseasesError(error instanceof Error ? error.message : "Тодорхойгүй алдаа");
+      } finally {
+        if (!controller.signal.aborted) setDiseasesLoading(false);
+      }
+    }, CATALOG_SEARCH_DEBOUNCE_MS);
+    return () => {
+      clearTimeout(timer);
+      controller.abort();
+    };
+  }, [open, diseaseSearchTerm]);
+
   // --- Symptom Selection Handlers (remain the same) ---
   const handleSelectSymptom = (symptom: ISymptomOption) => {
     if (!selectedSymptoms.some((s) => s.symptom_id === symptom.symptom_id)) {
@@ -168,6 +204,10 @@ export function SymptomPredictionDialog({
 
   // --- Prediction Handler (remains the same) ---
   const handlePredict = async () => {
+    if (!patientId) {
+      setPredictionError("Өвчтөний дугаар тодорхойгүй байна.");
+      return;
+    }
      if (selectedSymptoms.length === 0) {
       setPredictionError("Таамаглал хийхийн тулд дор хаяж нэг шинж тэмдэг сонгоно уу.");
       return;
@@ -188,10 +228,11 @@ export function SymptomPredictionDialog({
     );
     const requestBody: IPredictionRequest = {
       known_symptoms: known_symptoms_obj,
+      patient_id: patientId,
     };
 
     try {
-      const response = await fetch("http://localhost:8080/predict", {
+      const response = await apiFetch("http://localhost:8080/predict", {
         method: "POST",
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify(requestBody),
@@ -231,68 +272,30 @@ export function SymptomPredictionDialog({
     setSaveLoading(true);
     setSaveError(null);
 
-    let createdInstanceId: number | null = null;
-
     try {
-      // --- Step 1: Create the Disease Instance ---
-      const instanceUrl = `http://localhost:8080/patients/${patientId}/disease-instances`;
-      const instanceBody: IRecordPatientDiseaseInstanceRequest = {
+      // The instance and all its symptom links are saved in one transaction:
+      // either everything is recorded or nothing is.
+      const diagnosisUrl = `http://localhost:8080/patients/${patientId}/diagnoses`;
+      const diagnosisBody: IRecordDiagnosisRequest = {
         disease_id: selectedDisease.disease_id,
-        // Add diagnosis_date or notes here if needed, e.g.:
-        // diagnosis_date: new Date().toISOString().split('T')[0], // Today's date
+        symptom_ids: selectedSymptoms.map((symptom) => symptom.symptom_id),
+        record_general_symptoms: true,
       };
 
-      const instanceResponse = await fetch(instanceUrl, {
+      const diagnosisResponse = await apiFetch(diagnosisUrl, {
         method: "POST",
         headers: { "Content-Type": "application/json" },
-        body: JSON.stringify(instanceBody),
+        body: JSON.stringify(diagnosisBody),
       });
 
-      if (!instanceResponse.ok) {
-        let errorDetail = `Status: ${instanceResponse.status}`;
-        try { errorDetail = await instanceResponse.text(); } catch (_) {}
+      if (!diagnosisResponse.ok) {
+        let errorDetail = `Status: ${diagnosisResponse.status}`;
+        try { errorDetail = (await diagnosisResponse.json()).detail ?? errorDetail; } catch (_) {}
         throw new Error(`Өвчний онош '${selectedDisease.disease_name}' бүртгэж чадсангүй: ${errorDetail}`);
       }
 
-      const createdInstance: IPatientDiseaseInstanceResponse = await instanceResponse.json();
-      createdInstanceId = createdInstance.patient_disease_id;
-      console.log(`Disease instance created with ID: ${createdInstanceId}`);
-
-      // --- Step 2: Link Selected Symptoms to the Created Instance ---
-      if (createdInstanceId) {
-        const symptomLinkPromises = selectedSymptoms.map(symptom => {
-          const linkUrl = `http://localhost:8080/disease-instances/${createdInstanceId}/symptoms`;
-          const linkBody: ILinkSymptomRequest = { symptom_id: symptom.symptom_id };
-          return fetch(linkUrl, {
-            method: "POST",
-            headers: { "Content-Type": "application/json" },
-            body: JSON.stringify(linkBody),
-          }).then(async (res) => {
-            if (!res.ok) {
-              let errorDetail = `Status: ${res.status}`;
-              try { errorDetail = await res.text(); } catch (_) {}
-              // Throw specific error for linking failure
-              throw new Error(`Шинж тэмдэг '${symptom.symptom_name}' холбож чадсангүй: ${errorDetail}`);
-            }
-            return { success: true, symptomName: symptom.symptom_name };
-          });
-        });
-
-        // Wait for all symptom linking requests to settle
-        const linkResults = await Promise.allSettled(symptomLinkPromises);
-        const linkFailures = linkResults.filter(r => r.status === 'rejected');
-
-        if (linkFailures.length > 0) {
-          // Report which symptoms failed to link
-          const errorMessages = linkFailures.map(f => (f as PromiseRejectedResult).reason?.message || 'Тодорхойгүй холболтын алдаа');
-          throw new Error(`Онош бүртгэгдсэн (${selectedDisease.disease_name}), гэвч дараах шинж тэмдгүүдийг холбоход алдаа гарлаа: ${errorMessages.join("; ")}`);
-        }
-
-        console.log("All selected symptoms linked successfully to instance", createdInstanceId);
-      }
-
-      // All steps succeeded
-      console.log("Disease Instance and Symptom Links saved successfully!");
+      const diagnosis: IDiagnosisResponse = await diagnosisResponse.json();
+      console.log(`Diagnosis recorded with ID ${diagnosis.patient_disease_id} and ${diagnosis.symptoms.length} symptoms`);
       onSaveSuccess?.(); // Call the success callback
       onOpenChange(false); // Close dialog
 
@@ -300,8 +303,6 @@ export function SymptomPredictionDialog({
       console.error("Save error:", error);
       // Display the combined or specific error message
       setSaveError(error instanceof Error ? error.message : "Хадгалахад тодорхойгүй алдаа гарлаа.");
-      // Note: If instance creation succeeded but linking failed, the instance still exists.
-      // Consider adding logic to delete the instance if linking fails completely, if desired.
     } finally {
       setSaveLoading(false);
     }
@@ -334,7 +335,7 @@ export function SymptomPredictionDialog({
           <div className="space-y-4 py-2">
             <h4 className="font-medium text-sm mb-2">1. Шинж тэмдэг сонгох</h4>
             <Command shouldFilter={false} className="overflow-visible">
-              <CommandInput placeholder="Шинж тэмдэг хайх..." value={searchTerm} onValueChange={setSearchTerm} disabled={symptomsLoading || diseasesLoading}/>
+              <CommandInput placeholder="Шинж тэмдэг хайх..." value={searchTerm} onValueChange={setSearchTerm}/>
               <div className="mt-2 flex flex-wrap gap-1 min-h-[24px]">
                 {selectedSymptoms.map((symptom) => (
                   <Badge key={symptom.symptom_id} variant="secondary">
@@ -353,9 +354,7 @@ export function SymptomPredictionDialog({
                     <CommandEmpty>{allSymptoms.length > 0 ? "Шинж тэмдэг олдсонгүй." : "Шинж тэмдгийн жагсаалт хоосон байна."}</CommandEmpty>
                     <ScrollArea className="max-h-[150px]">
                       <CommandGroup heading="Боломжит шинж тэмдгүүд">
-                        {availableSymptoms
-                          .filter(s => s.symptom_name.toLowerCase().includes(searchTerm.toLowerCase()))
-                          .map((symptom) => (
+                        {availableSymptoms.map((symptom) => (
                             <CommandItem key={symptom.symptom_id} value={symptom.symptom_name} onSelect={() => handleSelectSymptom(symptom)} className="cursor-pointer">
                               {symptom.symptom_name}
                             </CommandItem>
@@ -402,7 +401,7 @@ export function SymptomPredictionDialog({
             <div className="space-y-4 py-2 border-t">
               <h4 className="font-medium text-sm mb-2">4. Онош сонгох (Нэгийг сонгоно уу)</h4>
               <Command shouldFilter={false} className="overflow-visible">
-                <CommandInput placeholder="Өвчин хайх..." value={diseaseSearchTerm} onValueChange={setDiseaseSearchTerm} disabled={diseasesLoading || symptomsLoading}/>
+                <CommandInput placeholder="Өвчин хайх..." value={diseaseSearchTerm} onValueChange={setDiseaseSearchTerm}/>
                 {/* Display single selected disease */}
                 <div className="mt-2 flex flex-wrap gap-1 min-h-[24px]">
                   {selectedDisease && (
@@ -422,9 +421,7 @@ export function SymptomPredictionDialog({
                       <CommandEmpty>{allDiseases.length > 0 ? "Өвчин олдсонгүй." : "Өвчний жагсаалт хоосон байна."}</CommandEmpty>
                       <ScrollArea className="max-h-[150px]">
                         <CommandGroup heading="Боломжит өвчнүүд">
-                          {allDiseases // Show all diseases for selection
-                            .filter(d => d.disease_name.toLowerCase().includes(diseaseSearchTerm.toLowerCase()))
-                            .map((disease) => (
+                          {allDiseases.map((disease) => ( // Already filtered by the server
                               <CommandItem
                                 key={disease.disease_id}
                                 value={disease.disease_name}
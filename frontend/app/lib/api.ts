@@ -0,0 +1,26 @@
+// Every call to the backend goes through apiFetch, which identifies the
+// caller: the API rejects patient and prediction requests without it.
+//
+// VITE_API_TOKEN is sent as a bearer token. Without one, VITE_API_USER is
+// sent as X-User (for development; in production a trusted gateway sets it).
+// VITE_API_CLINIC_ID picks the clinic when the user belongs to several.
+export function apiHeaders(headers?: HeadersInit): Headers {
+  const result = new Headers(headers);
+  const token = import.meta.env.VITE_API_TOKEN;
+  const user = import.meta.env.VITE_API_USER;
+  const clinicId = import.meta.env.VITE_API_CLINIC_ID;
+
+  if (token) {
+    result.set("Authorization", `Bearer ${token}`);
+  } else if (user) {
+    result.set("X-User", user);
+  }
+  if (clinicId) {
+    result.set("X-Clinic-ID", clinicId);
+  }
+  return result;
+}
+
+export function apiFetch(input: string | URL, init: RequestInit = {}): Promise<Response> {
+  return fetch(input, { ...init, headers: apiHeaders(init.headers) });
+}
@@ -8,6 +8,7 @@ import { SymptomPredictionDialog } from "~/components/symptom-prediction-dialog"
 import type { Route } from "./+types/patient";
 import { Badge } from "~/components/ui/badge";
 import { decodeBase64Utf8 } from "~/lib/utils";
+import { apiFetch } from "~/lib/api";
 
 export interface IPatientData {
   patient_id: number;
@@ -76,8 +77,8 @@ export async function loader({ params }: Route.LoaderArgs) {
 
   try {
     const [patientRes, instancesRes] = await Promise.all([
-      fetch(patientUrl),
-      fetch(instancesUrl),
+      apiFetch(patientUrl),
+      apiFetch(instancesUrl),
     ]);
 
     if (!patientRes.ok) {
@@ -100,7 +101,7 @@ export async function loader({ params }: Route.LoaderArgs) {
 
     const symptomFetchPromises = instances.map(instance => {
       const symptomsUrl = `http://localhost:8080/disease-instances/${instance.patient_disease_id}/symptoms`;
-      return fetch(symptomsUrl).then(async (res) => { // Make async
+      return apiFetch(symptomsUrl).then(async (res) => { // Make async
         if (!res.ok) {
           console.error(`Failed to fetch symptoms for instance ${instance.patient_disease_id} (${res.status})`);
           return []; // Return empty array on error for this instance
@@ -121,7 +122,7 @@ export async function loader({ params }: Route.LoaderArgs) {
     const uniqueDiseaseIds = [...new Set(instances.map(inst => inst.disease_id))];
     const diseaseDetailPromises = uniqueDiseaseIds.map(async id => {
         const diseaseUrl = `http://localhost:8080/diseases/${id}`;
-        return fetch(diseaseUrl).then(async (res) => { // Make async
+        return apiFetch(diseaseUrl).then(async (res) => { // Make async
             if (!res.ok) {
                 console.error(`Failed to fetch disease details for ID ${id} (${res.status})`);
                 return null; // Handle error gracefully
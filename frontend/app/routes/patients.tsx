@@ -31,6 +31,7 @@ import {
 import type { Route } from "./+types/patients";
 import { Form, useActionData, useNavigate, useSearchParams } from "react-router";
 import { Label } from "~/components/ui/label";
+import { apiFetch } from "~/lib/api";
 
 // --- Interface (unchanged) ---
 export interface IPatientData {
@@ -46,26 +47,54 @@ export interface IPatientData {
   register: string;
 }
 
-export async function loader({ request }: Route.ClientLoaderArgs) {
+// Pagination metadata returned with every page of patients
+interface IPageInfo {
+  limit: number;
+  sort: string;
+  next_cursor: string | null;
+  prev_cursor: string | null;
+  total?: number;
+}
+
+// Existing patient the API thinks may be the same person (409 possible_duplicate)
+interface IDuplicateCandidate {
+  patient_id: number;
+  firstname: string;
+  lastname: string;
+  register: string;
+  birthdate: string | null;
+  phonenumber: string;
+  confidence: number;
+  reasons: string[];
+}
+
+interface IPatientPage {
+  data: IPatientData[];
+  page: IPageInfo;
+}
+
+export async function loader({ request }: Route.ClientLoaderArgs): Promise<IPatientPage | null> {
   const url = new URL(request.url);
   const limit = url.searchParams.get("limit") || "10";
-  const offset = url.searchParams.get("offset") || "0";
+  const cursor = url.searchParams.get("cursor");
 
   const apiUrl = new URL("http://localhost:8080/patients");
   apiUrl.searchParams.set("limit", limit);
-  apiUrl.searchParams.set("offset", offset);
+  apiUrl.searchParams.set("count", "true");
+  if (cursor) {
+    apiUrl.searchParams.set("cursor", cursor);
+  }
 
   try {
-    const res = await fetch(apiUrl.toString());
+    const res = await apiFetch(apiUrl.toString());
     if (!res.ok) {
       console.error("Өвчтөнүүдийг татахад алдаа гарлаа:", res.statusText);
-      return [];
+      return null;
     }
-    const data = await res.json();
-    return data as IPatientData[];
+    return (await res.json()) as IPatientPage;
   } catch (error) {
     console.error("Өвчтөнүүдийг татах үеийн алдаа:", error);
-    return [];
+    return null;
   }
 }
 
@@ -78,10 +107,14 @@ export async function action({ request }: Route.ClientActionArgs) {
   }
 
   try {
-    const postData = data as Partial<IPatientData>;
-    postData.age = calculateAge(data?.birthdate);
+    // Age is computed by the backend from the birthdate
+    const { allow_duplicate, ...postData } = data as FormData;
 
-    const response = await fetch("http://localhost:8080/patients", {
+    const apiUrl = new URL("http://localhost:8080/patients");
+    if (allow_duplicate) {
+      apiUrl.searchParams.set("allow_duplicate", "true");
+    }
+    const response = await apiFetch(apiUrl.toString(), {
       method: "POST",
       headers: {
         "Content-type": "application/json",
@@ -89,6 +122,18 @@ export async function action({ request }: Route.ClientActionArgs) {
       body: JSON.stringify(postData),
     });
 
+    if (response.status === 409) {
+      const problem = await response.json();
+      if (problem.code === "possible_duplicate") {
+        return {
+          apiError: "Энэ өвчтөн бүртгэгдсэн байж магадгүй. Доорх өвчтөнүүдийг шалгана уу.",
+          duplicates: problem.duplicates as IDuplicateCandidate[],
+          receivedValues,
+        };
+      }
+      return { apiError: problem.detail ?? response.statusText, receivedValues };
+    }
+
     if (!response.ok) {
       const errorData = await response.text();
       console.error("API Алдаа:", errorData);
@@ -106,25 +151,6 @@ export async function action({ request }: Route.ClientActionArgs) {
   }
 }
 
-function calculateAge(birthdate: string): number {
-  if (!birthdate) return 0;
-  try {
-    const today = new Date();
-    const birthDate = new Date(birthdate);
-    if (isNaN(birthDate.getTime())) return 0;
-
-    let age = today.getFullYear() - birthDate.getFullYear();
-    const m = today.getMonth() - birthDate.getMonth();
-    if (m < 0 || (m === 0 && today.getDate() < birthDate.getDate())) {
-      age--;
-    }
-    return age > 0 ? age : 0;
-  } catch (e) {
-    console.error("Нас тооцоолоход алдаа гарлаа:", e);
-    return 0;
-  }
-}
-
 const patientFormSchema = z.object({
   firstname: z
     .string()
@@ -143,13 +169,15 @@ const patientFormSchema = z.object({
   }),
   register: z
     .string()
-    .regex(/^[A-ZА-Я]{2}\d{8}$/u, {
-      message: "Регистр нь 2 крилл/латин үсэг, 8 тооноос бүрдсэн байх ёстой.",
+    .regex(/^[А-ЯЁӨҮа-яёөү]{2}\d{8}$/u, {
+      message: "Регистр нь 2 кирилл үсэг, 8 тооноос бүрдсэн байх ёстой.",
     }),
   gender: z.string().min(1, { message: "Хүйсээ сонгоно уу." }),
   address: z
     .string()
     .min(5, { message: "Хаяг дор хаяж 5 тэмдэгттэй байх ёстой." }),
+  // Save even though the API reported possible duplicates
+  allow_duplicate: z.boolean().optional(),
 });
 
 type FormData = z.infer<typeof patientFormSchema>;
@@ -179,20 +207,23 @@ export default function PatientsPage({ loaderData }: Route.ComponentProps) {
     }
   }, [actionData, reset, navigate]);
 
-  const patientsOnPage = loaderData || []; // loaderData is IPatientData[] for the current page
+  const patientsOnPage = loaderData?.data ?? []; // Patients on the current page
+  const pageInfo = loaderData?.page;
   const limit = parseInt(searchParams.get("limit") || "10", 10);
-  const offset = parseInt(searchParams.get("offset") || "0", 10);
-
-  const currentPage = Math.floor(offset / limit) + 1;
+  const isFirstPage = !searchParams.get("cursor");
 
-  const hasNextPage = patientsOnPage.length === limit;
-  const hasPreviousPage = offset > 0;
+  const hasNextPage = !!pageInfo?.next_cursor;
+  const hasPreviousPage = !!pageInfo?.prev_cursor;
 
-  const handlePageChange = (newPage: number) => {
-    const newOffset = Math.max(0, (newPage - 1) * limit);
+  // Pages are cursor-based: follow the cursors the API returned
+  const handlePageChange = (cursor: string | null | undefined) => {
     const newSearchParams = new URLSearchParams(searchParams);
     newSearchParams.set("limit", String(limit));
-    newSearchParams.set("offset", String(newOffset));
+    if (cursor) {
+      newSearchParams.set("cursor", cursor);
+    } else {
+      newSearchParams.delete("cursor");
+    }
     setSearchParams(newSearchParams);
   };
 
@@ -200,7 +231,7 @@ export default function PatientsPage({ loaderData }: Route.ComponentProps) {
     const newLimit = parseInt(value, 10);
     const newSearchParams = new URLSearchParams(searchParams);
     newSearchParams.set("limit", String(newLimit));
-    newSearchParams.set("offset", "0");
+    newSearchParams.delete("cursor");
     setSearchParams(newSearchParams);
   };
 
@@ -215,9 +246,6 @@ export default function PatientsPage({ loaderData }: Route.ComponentProps) {
     ) : null;
   };
 
-  const displayStartIndex = offset;
-  const displayEndIndex = offset + patientsOnPage.length;
-
   return (
     <div className="container mx-auto py-8 px-4 md:px-6">
       <div className="flex flex-col sm:flex-row justify-between items-start sm:items-center mb-6 gap-4">
@@ -241,6 +269,23 @@ export default function PatientsPage({ loaderData }: Route.ComponentProps) {
                 {actionData.apiError}
               </p>
             )}
+            {actionData?.duplicates && actionData.duplicates.length > 0 && (
+              <ul className="text-sm mb-4 space-y-1">
+                {actionData.duplicates.map((d) => (
+                  <li key={d.patient_id}>
+                    <button
+                      type="button"
+                      className="underline"
+                      onClick={() => navigate(`/patients/${d.patient_id}`)}
+                    >
+                      {d.lastname} {d.firstname}
+                    </button>{" "}
+                    ({d.register}, {d.phonenumber}) —{" "}
+                    {Math.round(d.confidence * 100)}%
+                  </li>
+                ))}
+              </ul>
+            )}
             <Form
               method="POST"
               onSubmit={handleSubmit}
@@ -341,6 +386,19 @@ export default function PatientsPage({ loaderData }: Route.ComponentProps) {
                 </div>
               </div>
 
+              {actionData?.duplicates && actionData.duplicates.length > 0 && (
+                <div className="flex items-center gap-2">
+                  <input
+                    id="allow_duplicate"
+                    type="checkbox"
+                    {...register("allow_duplicate")}
+                  />
+                  <Label htmlFor="allow_duplicate">
+                    Өөр хүн тул шинээр бүртгэх
+                  </Label>
+                </div>
+              )}
+
               <div className="flex justify-end pt-4">
                 <Button type="submit" disabled={formState.isSubmitting}>
                   {formState.isSubmitting ? "Нэмж байна..." : "Өвчтөн нэмэх"}
@@ -386,7 +444,7 @@ export default function PatientsPage({ loaderData }: Route.ComponentProps) {
             ) : (
               <TableRow>
                 <TableCell colSpan={7} className="h-24 text-center">
-                  {offset > 0 ? "Энэ хуудсанд өвчтөн олдсонгүй." : "Өвчтөн олдсонгүй."}
+                  {!isFirstPage ? "Энэ хуудсанд өвчтөн олдсонгүй." : "Өвчтөн олдсонгүй."}
                 </TableCell>
               </TableRow>
             )}
@@ -394,14 +452,14 @@ export default function PatientsPage({ loaderData }: Route.ComponentProps) {
         </Table>
       </div>
 
-      {/* --- Pagination Controls (adapting to missing total count) --- */}
+      {/* --- Pagination Controls (cursor-based) --- */}
       {/* Show controls only if there are patients on the current page OR if we are not on the first page */}
-      {(patientsOnPage.length > 0 || offset > 0) && (
+      {(patientsOnPage.length > 0 || !isFirstPage) && (
         <div className="flex flex-col sm:flex-row items-center justify-between mt-6 gap-4">
           <div className="text-sm text-muted-foreground">
             {/* Show range for the current page */}
             {patientsOnPage.length > 0
-              ? `${displayStartIndex + 1}-${displayEndIndex}-г харуулж байна`
+              ? `${pageInfo?.total ?? patientsOnPage.length}-аас ${patientsOnPage.length}-г харуулж байна`
               : "Үр дүн байхгүй"}
           </div>
           <div className="flex items-center gap-2">
@@ -423,23 +481,19 @@ export default function PatientsPage({ loaderData }: Route.ComponentProps) {
                 ))}
               </SelectContent>
             </Select>
-            {/* Display only the current page number */}
-            <span className="text-sm mx-2">
-              Хуудас {currentPage}
-            </span>
             <Button
               variant="outline"
               size="sm"
-              onClick={() => handlePageChange(currentPage - 1)}
-              disabled={!hasPreviousPage} // Disable if offset is 0
+              onClick={() => handlePageChange(pageInfo?.prev_cursor)}
+              disabled={!hasPreviousPage} // Disable on the first page
             >
               Өмнөх
             </Button>
             <Button
               variant="outline"
               size="sm"
-              onClick={() => handlePageChange(currentPage + 1)}
-              disabled={!hasNextPage} // Disable if last fetch returned less than limit items
+              onClick={() => handlePageChange(pageInfo?.next_cursor)}
+              disabled={!hasNextPage} // Disable on the last page
             >
               Дараах
             </Button>
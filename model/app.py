@@ -132,10 +132,11 @@ def predict():
 
 @app.route("/", methods=["GET"])
 def home():
+    loaded = model is not None and bool(model_features) and label_encoder is not None
     status = "Model, features, and label encoder loaded successfully."
-    if model is None or not model_features or label_encoder is None:
+    if not loaded:
         status = "Model or related artifacts failed to load. Check logs."
-    return jsonify({"message": "Flask backend for Disease Prediction is running!", "model_status": status})
+    return jsonify({"message": "Flask backend for Disease Prediction is running!", "model_status": status, "model_loaded": loaded})
 
 
 if __name__ == "__main__":